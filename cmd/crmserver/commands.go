@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app"
+	"github.com/rixtrayker/medical-rep/internal/platform/buildinfo"
+	"github.com/rixtrayker/medical-rep/internal/platform/database"
+)
+
+const usage = `Usage: crmserver [command]
+
+Commands:
+  serve               Run the HTTP server (default)
+  migrate up          Apply all pending database migrations
+  migrate down [-steps N]
+                      Roll back the last N migrations (default 1)
+  config validate     Load and validate the configuration
+  version             Print the application version
+`
+
+// run dispatches to the subcommand named by args[0] and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	var err error
+	switch name {
+	case "serve":
+		err = serve()
+	case "migrate":
+		err = migrateCommand(args)
+	case "config":
+		err = configCommand(args, stdout)
+	case "version":
+		err = versionCommand(stdout)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", name, usage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// serve builds the application and runs it until shutdown
+func serve() error {
+	application, err := app.New()
+	if err != nil {
+		return fmt.Errorf("failed to create application: %w", err)
+	}
+	return application.Run()
+}
+
+// migrateCommand runs database migrations in the given direction
+func migrateCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate requires a direction: up or down")
+	}
+
+	if err := configs.Load(); err != nil {
+		return err
+	}
+	cfg := configs.Get().Database
+
+	switch args[0] {
+	case "up":
+		return database.MigrateUp(cfg)
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return database.MigrateDown(cfg, *steps)
+	default:
+		return fmt.Errorf("unknown migrate direction %q", args[0])
+	}
+}
+
+// configCommand runs configuration subcommands
+func configCommand(args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] != "validate" {
+		return fmt.Errorf("config requires a subcommand: validate")
+	}
+
+	if err := configs.Load(); err != nil {
+		// List every invalid field on its own line so they can all be fixed in one pass
+		var invalid *configs.ValidationError
+		if errors.As(err, &invalid) {
+			for _, field := range invalid.Fields {
+				fmt.Fprintf(stdout, "%s: %s\n", field.Field, field.Reason)
+			}
+			return fmt.Errorf("configuration is invalid: %d error(s)", len(invalid.Fields))
+		}
+		return err
+	}
+
+	cfg := configs.Get()
+	fmt.Fprintf(stdout, "Configuration is valid (environment: %s)\n", cfg.App.Environment)
+	return nil
+}
+
+// versionCommand prints the configured application name and version with the build metadata
+func versionCommand(stdout io.Writer) error {
+	if err := configs.Load(); err != nil {
+		return err
+	}
+
+	cfg := configs.Get()
+	info := buildinfo.Get(cfg.App.Name, cfg.App.Version)
+	fmt.Fprintf(stdout, "%s %s (commit %s, built %s, %s)\n",
+		info.Name, info.Version, info.GitCommit, info.BuildDate, info.GoVersion)
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// inConfigDir runs the test from a directory whose configs/config.yaml holds yaml
+func inConfigDir(t *testing.T, yaml string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "configs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "configs", "config.yaml"), []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+}
+
+// runCommand runs the dispatcher with args and returns the exit code and output
+func runCommand(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestConfigValidate(t *testing.T) {
+	inConfigDir(t, "app:\n  environment: staging\nauth:\n  jwt_secret: 0123456789abcdef0123456789abcdef\n")
+
+	code, stdout, stderr := runCommand("config", "validate")
+	if code != 0 {
+		t.Fatalf("exit code = %d, want 0: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "Configuration is valid (environment: staging)") {
+		t.Errorf("stdout = %q", stdout)
+	}
+}
+
+func TestConfigValidateInvalid(t *testing.T) {
+	inConfigDir(t, "http:\n  port: 0\nauth:\n  jwt_secret: short\n")
+
+	code, stdout, stderr := runCommand("config", "validate")
+	if code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+	for _, field := range []string{"http.port", "auth.jwt_secret"} {
+		if !strings.Contains(stdout, field+": ") {
+			t.Errorf("stdout %q does not list %s", stdout, field)
+		}
+	}
+	if !strings.Contains(stderr, "configuration is invalid: 2 error(s)") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	code, _, stderr := runCommand("deploy")
+	if code != 2 {
+		t.Fatalf("exit code = %d, want 2", code)
+	}
+	if !strings.Contains(stderr, `unknown command "deploy"`) || !strings.Contains(stderr, "Usage:") {
+		t.Errorf("stderr = %q, want the error and usage", stderr)
+	}
+
+	if code, _, _ := runCommand("config", "show"); code != 1 {
+		t.Errorf("config show exit code = %d, want 1", code)
+	}
+	if code, _, _ := runCommand("migrate"); code != 1 {
+		t.Errorf("migrate without a direction exit code = %d, want 1", code)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	inConfigDir(t, "app:\n  name: crm\n  version: 2.1.0\nauth:\n  jwt_secret: 0123456789abcdef0123456789abcdef\n")
+
+	code, stdout, _ := runCommand("version")
+	if code != 0 || !strings.HasPrefix(stdout, "crm 2.1.0 (commit ") {
+		t.Fatalf("version = %d, %q", code, stdout)
+	}
+}
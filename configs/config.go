@@ -1,102 +1,393 @@
 package configs
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"log/slog"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/knadh/koanf/v2"
-	// "github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/maps"
 	"github.com/knadh/koanf/parsers/yaml"
-	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/providers/structs"
+	"github.com/knadh/koanf/v2"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	App      AppConfig      `koanf:"app"`
-	HTTP     HTTPConfig     `koanf:"http"`
-	Database DatabaseConfig `koanf:"database"`
-	Redis    RedisConfig    `koanf:"redis"`
-	Auth     AuthConfig     `koanf:"auth"`
-	Logging  LoggingConfig  `koanf:"logging"`
-	Health   HealthConfig   `koanf:"health"`
+	App        AppConfig                `koanf:"app"`
+	HTTP       HTTPConfig               `koanf:"http"`
+	HTTPClient HTTPClientConfig         `koanf:"http_client"`
+	Database   DatabaseConfig           `koanf:"database"`
+	Redis      RedisConfig              `koanf:"redis"`
+	Auth       AuthConfig               `koanf:"auth"`
+	Session    SessionConfig            `koanf:"session"`
+	APIKeys    APIKeyConfig             `koanf:"api_keys"`
+	Logging    LoggingConfig            `koanf:"logging"`
+	Health     HealthConfig             `koanf:"health"`
+	Metrics    MetricsConfig            `koanf:"metrics"`
+	Tracing    TracingConfig            `koanf:"tracing"`
+	Versions   []VersionConfig          `koanf:"versions"`
+	Startup    StartupConfig            `koanf:"startup"`
+	Webhooks   WebhookConfig            `koanf:"webhooks"`
+	Scheduler  SchedulerConfig          `koanf:"scheduler"`
+	Cache      CacheConfig              `koanf:"cache"`
+	Tenancy    TenancyConfig            `koanf:"tenancy"`
+	Features   map[string]FeatureConfig `koanf:"features"`
+	Audit      AuditConfig              `koanf:"audit"`
+	Sequence   SequenceConfig           `koanf:"sequence"`
 }
 
 type AppConfig struct {
-	Name        string        `koanf:"name"`
-	Version     string        `koanf:"version"`
-	Environment string        `koanf:"environment"`
-	Debug       bool          `koanf:"debug"`
-	Shutdown    ShutdownConfig `koanf:"shutdown"`
+	Name        string            `koanf:"name"`
+	Version     string            `koanf:"version"`
+	Environment string            `koanf:"environment"`
+	Debug       bool              `koanf:"debug"`
+	Shutdown    ShutdownConfig    `koanf:"shutdown"`
+	Maintenance MaintenanceConfig `koanf:"maintenance"`
+	PanicBudget PanicBudgetConfig `koanf:"panic_budget"`
+}
+
+// PanicBudgetConfig sets how many recovered panics are tolerated within a window
+type PanicBudgetConfig struct {
+	Enabled   bool          `koanf:"enabled"`
+	MaxPanics int           `koanf:"max_panics"`
+	Window    time.Duration `koanf:"window"`
+	Shutdown  bool          `koanf:"shutdown"`
+}
+
+type MaintenanceConfig struct {
+	Enabled    bool          `koanf:"enabled"`
+	Message    string        `koanf:"message"`
+	RetryAfter time.Duration `koanf:"retry_after"`
 }
 
 type ShutdownConfig struct {
-	Timeout time.Duration `koanf:"timeout"`
+	Timeout        time.Duration `koanf:"timeout"`
+	UpgradeTimeout time.Duration `koanf:"upgrade_timeout"`
+	DrainDelay     time.Duration `koanf:"drain_delay"`
+	PreStopDelay   time.Duration `koanf:"pre_stop_delay"`
+}
+
+type StartupConfig struct {
+	Attempts   int           `koanf:"attempts"`
+	Backoff    time.Duration `koanf:"backoff"`
+	MaxBackoff time.Duration `koanf:"max_backoff"`
+	Timeout    time.Duration `koanf:"timeout"`
+}
+
+type AuditConfig struct {
+	Enabled bool          `koanf:"enabled"`
+	Log     LoggingConfig `koanf:"log"`
+	Table   string        `koanf:"table"`
+}
+
+// SequenceConfig sets when the daily reference number sequences roll over
+type SequenceConfig struct {
+	Timezone string `koanf:"timezone"`
+}
+
+type FeatureConfig struct {
+	Enabled    bool `koanf:"enabled"`
+	Percentage int  `koanf:"percentage"`
+}
+
+type TenancyConfig struct {
+	Enabled    bool     `koanf:"enabled"`
+	Header     string   `koanf:"header"`
+	BaseDomain string   `koanf:"base_domain"`
+	Registry   string   `koanf:"registry"`
+	Tenants    []string `koanf:"tenants"`
+}
+
+type CacheConfig struct {
+	TTL time.Duration `koanf:"ttl"`
+}
+
+type SchedulerConfig struct {
+	Enabled bool          `koanf:"enabled"`
+	LockTTL time.Duration `koanf:"lock_ttl"`
+}
+
+type HTTPClientConfig struct {
+	Timeout             time.Duration `koanf:"timeout"`
+	DialTimeout         time.Duration `koanf:"dial_timeout"`
+	MaxIdleConns        int           `koanf:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `koanf:"max_idle_conns_per_host"`
+	IdleConnTimeout     time.Duration `koanf:"idle_conn_timeout"`
+	MaxRetries          int           `koanf:"max_retries"`
+	RetryBackoff        time.Duration `koanf:"retry_backoff"`
+	RetryMaxBackoff     time.Duration `koanf:"retry_max_backoff"`
+}
+
+type WebhookConfig struct {
+	Enabled      bool          `koanf:"enabled"`
+	Endpoints    []string      `koanf:"endpoints"`
+	Secret       string        `koanf:"secret"`
+	Timeout      time.Duration `koanf:"timeout"`
+	MaxAttempts  int           `koanf:"max_attempts"`
+	Backoff      time.Duration `koanf:"backoff"`
+	MaxBackoff   time.Duration `koanf:"max_backoff"`
+	PollInterval time.Duration `koanf:"poll_interval"`
 }
 
 type HTTPConfig struct {
-	Port            int           `koanf:"port"`
-	Host            string        `koanf:"host"`
-	ReadTimeout     time.Duration `koanf:"read_timeout"`
-	WriteTimeout    time.Duration `koanf:"write_timeout"`
-	IdleTimeout     time.Duration `koanf:"idle_timeout"`
-	MaxHeaderBytes  int           `koanf:"max_header_bytes"`
-	TLS             TLSConfig     `koanf:"tls"`
-	CORS            CORSConfig    `koanf:"cors"`
-	RateLimit       RateLimitConfig `koanf:"rate_limit"`
+	Port                  int                `koanf:"port"`
+	Host                  string             `koanf:"host"`
+	Network               string             `koanf:"network"`
+	UnixSocket            string             `koanf:"unix_socket"`
+	UnixSocketMode        string             `koanf:"unix_socket_mode"`
+	ReadTimeout           time.Duration      `koanf:"read_timeout"`
+	ReadHeaderTimeout     time.Duration      `koanf:"read_header_timeout"`
+	ServerTiming          bool               `koanf:"server_timing"`
+	JSONPrecheck          JSONPrecheckConfig `koanf:"json_precheck"`
+	RequestID             RequestIDConfig    `koanf:"request_id"`
+	Middleware            MiddlewareConfig   `koanf:"middleware"`
+	AccessLog             AccessLogConfig    `koanf:"access_log"`
+	Pagination            PaginationConfig   `koanf:"pagination"`
+	ExposeStackTraces     bool               `koanf:"expose_stack_traces"`
+	HSTS                  HSTSConfig         `koanf:"hsts"`
+	Admin                 AdminConfig        `koanf:"admin"`
+	BodyLog               BodyLogConfig      `koanf:"body_log"`
+	WriteTimeout          time.Duration      `koanf:"write_timeout"`
+	IdleTimeout           time.Duration      `koanf:"idle_timeout"`
+	RequestTimeout        time.Duration      `koanf:"request_timeout"`
+	SlowRequestThreshold  time.Duration      `koanf:"slow_request_threshold"`
+	MaxHeaderBytes        int                `koanf:"max_header_bytes"`
+	MaxBodyBytes          int64              `koanf:"max_body_bytes"`
+	MaxConcurrentRequests int                `koanf:"max_concurrent_requests"`
+	ConcurrencyWait       time.Duration      `koanf:"concurrency_wait"`
+	TLS                   TLSConfig          `koanf:"tls"`
+	CORS                  CORSConfig         `koanf:"cors"`
+	RateLimit             RateLimitConfig    `koanf:"rate_limit"`
+	TrustedProxies        []string           `koanf:"trusted_proxies"`
+	Idempotency           IdempotencyConfig  `koanf:"idempotency"`
+	Compression           CompressionConfig  `koanf:"compression"`
+	ETag                  ETagConfig         `koanf:"etag"`
+	ZeroDowntime          bool               `koanf:"zero_downtime"`
+}
+
+type ETagConfig struct {
+	Enabled      bool   `koanf:"enabled"`
+	MaxSize      int    `koanf:"max_size"`
+	CacheControl string `koanf:"cache_control"`
+}
+
+type IdempotencyConfig struct {
+	Enabled bool          `koanf:"enabled"`
+	TTL     time.Duration `koanf:"ttl"`
+}
+
+type CompressionConfig struct {
+	Enabled      bool     `koanf:"enabled"`
+	Level        int      `koanf:"level"`
+	MinSize      int      `koanf:"min_size"`
+	ContentTypes []string `koanf:"content_types"`
 }
 
 type TLSConfig struct {
-	Enabled  bool   `koanf:"enabled"`
-	CertFile string `koanf:"cert_file"`
-	KeyFile  string `koanf:"key_file"`
+	Enabled      bool     `koanf:"enabled"`
+	CertFile     string   `koanf:"cert_file"`
+	KeyFile      string   `koanf:"key_file"`
+	MinVersion   string   `koanf:"min_version"`
+	CipherSuites []string `koanf:"cipher_suites"`
+	ClientCAFile string   `koanf:"client_ca_file"`
+	ClientAuth   string   `koanf:"client_auth"`
+}
+
+// MiddlewareConfig switches optional router middleware on or off. Compression and
+// Server-Timing have their own settings. Order lists every name in MiddlewareNames, in
+// the sequence the router applies them; the first name sees the request first. It may also
+// list "auth", followed only by names in AfterAuthMiddlewareNames.
+type MiddlewareConfig struct {
+	RequestID bool     `koanf:"request_id"`
+	RealIP    bool     `koanf:"real_ip"`
+	AccessLog bool     `koanf:"access_log"`
+	Heartbeat bool     `koanf:"heartbeat"`
+	CORS      bool     `koanf:"cors"`
+	Order     []string `koanf:"order"`
+}
+
+// MiddlewareNames are the global router middleware, in the default order. Authentication is
+// not among them: it is applied per route group inside /api, after all of these unless the
+// order lists "auth" ahead of some of AfterAuthMiddlewareNames.
+var MiddlewareNames = []string{
+	"request_id",
+	"hsts",
+	"real_ip",
+	"stats",
+	"server_timing",
+	"tracing",
+	"access_log",
+	"slow_requests",
+	"recoverer",
+	"heartbeat",
+	"metrics",
+	"concurrency_limit",
+	"timeout",
+	"body_limit",
+	"compression",
+	"body_log",
+	"cors",
+	"rate_limit",
+}
+
+// AfterAuthMiddlewareNames are the middleware that may follow "auth" in the order. Listed
+// after it, they move from the router to the authenticated routes, where they run after
+// authentication and no longer apply to unauthenticated routes.
+var AfterAuthMiddlewareNames = []string{"rate_limit"}
+
+// AccessLogConfig sets the request details written to the access log. Values of the
+// parameters and headers listed under Redact are masked, in the slow request log as well.
+type AccessLogConfig struct {
+	Query   bool         `koanf:"query"`
+	Headers []string     `koanf:"headers"`
+	Redact  RedactConfig `koanf:"redact"`
+}
+
+type RedactConfig struct {
+	QueryParams []string `koanf:"query_params"`
+	Headers     []string `koanf:"headers"`
+}
+
+// HSTSConfig enforces HTTPS. The header is only sent on responses to HTTPS requests.
+type HSTSConfig struct {
+	Enabled           bool          `koanf:"enabled"`
+	MaxAge            time.Duration `koanf:"max_age"`
+	IncludeSubDomains bool          `koanf:"include_subdomains"`
+	Preload           bool          `koanf:"preload"`
+	Redirect          bool          `koanf:"redirect"`
+}
+
+// AdminConfig moves the metrics, debug and admin routes to a separate internal listener
+type AdminConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	Host    string `koanf:"host"`
+	Port    int    `koanf:"port"`
+}
+
+// BodyLogConfig enables logging request and response bodies of selected routes for debugging
+type BodyLogConfig struct {
+	Enabled      bool     `koanf:"enabled"`
+	Routes       []string `koanf:"routes"`
+	MaxBytes     int      `koanf:"max_bytes"`
+	RedactFields []string `koanf:"redact_fields"`
+}
+
+// PaginationConfig sets the page size of list endpoints
+type PaginationConfig struct {
+	DefaultLimit int `koanf:"default_limit"`
+	MaxLimit     int `koanf:"max_limit"`
+}
+
+type RequestIDConfig struct {
+	Header string `koanf:"header"`
+	Trust  bool   `koanf:"trust"`
+}
+
+type JSONPrecheckConfig struct {
+	Enabled  bool `koanf:"enabled"`
+	Validate bool `koanf:"validate"`
 }
 
 type CORSConfig struct {
-	AllowedOrigins []string `koanf:"allowed_origins"`
-	AllowedMethods []string `koanf:"allowed_methods"`
-	AllowedHeaders []string `koanf:"allowed_headers"`
+	AllowedOrigins   []string      `koanf:"allowed_origins"`
+	AllowedMethods   []string      `koanf:"allowed_methods"`
+	AllowedHeaders   []string      `koanf:"allowed_headers"`
+	AllowCredentials bool          `koanf:"allow_credentials"`
+	MaxAge           time.Duration `koanf:"max_age"`
 }
 
 type RateLimitConfig struct {
 	Enabled bool    `koanf:"enabled"`
 	Rate    float64 `koanf:"rate"`
 	Burst   int     `koanf:"burst"`
+	Store   string  `koanf:"store"`
 }
 
 type DatabaseConfig struct {
-	Driver          string        `koanf:"driver"`
-	Host            string        `koanf:"host"`
-	Port            int           `koanf:"port"`
-	Database        string        `koanf:"database"`
-	Username        string        `koanf:"username"`
-	Password        string        `koanf:"password"`
-	SSLMode         string        `koanf:"ssl_mode"`
-	MaxOpenConns    int           `koanf:"max_open_conns"`
-	MaxIdleConns    int           `koanf:"max_idle_conns"`
-	ConnMaxLifetime time.Duration `koanf:"conn_max_lifetime"`
-	MigrationsPath  string        `koanf:"migrations_path"`
+	Driver             string                  `koanf:"driver"`
+	Host               string                  `koanf:"host"`
+	Port               int                     `koanf:"port"`
+	Database           string                  `koanf:"database"`
+	Username           string                  `koanf:"username"`
+	Password           string                  `koanf:"password"`
+	SSLMode            string                  `koanf:"ssl_mode"`
+	MaxOpenConns       int                     `koanf:"max_open_conns"`
+	MaxIdleConns       int                     `koanf:"max_idle_conns"`
+	ConnMaxLifetime    time.Duration           `koanf:"conn_max_lifetime"`
+	MigrationsPath     string                  `koanf:"migrations_path"`
+	QueryTimeout       time.Duration           `koanf:"query_timeout"`
+	SlowQueryThreshold time.Duration           `koanf:"slow_query_threshold"`
+	WarmupConns        int                     `koanf:"warmup_conns"`
+	Reconnect          DatabaseReconnectConfig `koanf:"reconnect"`
+}
+
+// DatabaseReconnectConfig controls the supervisor that reopens the pool after connection loss
+type DatabaseReconnectConfig struct {
+	Enabled          bool          `koanf:"enabled"`
+	Interval         time.Duration `koanf:"interval"`
+	FailureThreshold int           `koanf:"failure_threshold"`
+	Backoff          time.Duration `koanf:"backoff"`
+	MaxBackoff       time.Duration `koanf:"max_backoff"`
 }
 
 type RedisConfig struct {
-	Host         string        `koanf:"host"`
-	Port         int           `koanf:"port"`
-	Password     string        `koanf:"password"`
-	Database     int           `koanf:"database"`
-	PoolSize     int           `koanf:"pool_size"`
-	DialTimeout  time.Duration `koanf:"dial_timeout"`
-	ReadTimeout  time.Duration `koanf:"read_timeout"`
-	WriteTimeout time.Duration `koanf:"write_timeout"`
+	Host         string         `koanf:"host"`
+	Port         int            `koanf:"port"`
+	Username     string         `koanf:"username"`
+	Password     string         `koanf:"password"`
+	Database     int            `koanf:"database"`
+	PoolSize     int            `koanf:"pool_size"`
+	DialTimeout  time.Duration  `koanf:"dial_timeout"`
+	ReadTimeout  time.Duration  `koanf:"read_timeout"`
+	WriteTimeout time.Duration  `koanf:"write_timeout"`
+	TLS          RedisTLSConfig `koanf:"tls"`
+}
+
+// RedisTLSConfig enables TLS to Redis. CAFile verifies servers whose certificate is not
+// signed by a system-trusted CA.
+type RedisTLSConfig struct {
+	Enabled            bool   `koanf:"enabled"`
+	CAFile             string `koanf:"ca_file"`
+	ServerName         string `koanf:"server_name"`
+	InsecureSkipVerify bool   `koanf:"insecure_skip_verify"`
 }
 
+// MinJWTSecretLength is the shortest HS256 secret accepted: the 256 bits of the hash output
+const MinJWTSecretLength = 32
+
 type AuthConfig struct {
-	JWTSecret     string        `koanf:"jwt_secret"`
-	JWTExpiration time.Duration `koanf:"jwt_expiration"`
-	BCryptCost    int           `koanf:"bcrypt_cost"`
+	JWTAlgorithm      string        `koanf:"jwt_algorithm"`
+	JWTSecret         string        `koanf:"jwt_secret"`
+	JWTPublicKeyFile  string        `koanf:"jwt_public_key_file"`
+	JWTPrivateKeyFile string        `koanf:"jwt_private_key_file"`
+	JWTExpiration     time.Duration `koanf:"jwt_expiration"`
+	RefreshExpiration time.Duration `koanf:"refresh_expiration"`
+	BCryptCost        int           `koanf:"bcrypt_cost"`
+}
+
+type SessionConfig struct {
+	CookieName string        `koanf:"cookie_name"`
+	IdleTTL    time.Duration `koanf:"idle_ttl"`
+	Secure     bool          `koanf:"secure"`
+	SameSite   string        `koanf:"same_site"`
+}
+
+// APIKeyConfig sets how integration partners authenticate with long-lived API keys
+type APIKeyConfig struct {
+	Header           string        `koanf:"header"`
+	BCryptCost       int           `koanf:"bcrypt_cost"`
+	LastUsedInterval time.Duration `koanf:"last_used_interval"`
 }
 
 type LoggingConfig struct {
@@ -110,60 +401,166 @@ type LoggingConfig struct {
 }
 
 type HealthConfig struct {
-	Enabled         bool          `koanf:"enabled"`
-	CheckInterval   time.Duration `koanf:"check_interval"`
-	Timeout         time.Duration `koanf:"timeout"`
-	DatabaseCheck   bool          `koanf:"database_check"`
-	RedisCheck      bool          `koanf:"redis_check"`
-	ExternalChecks  []string      `koanf:"external_checks"`
+	Enabled            bool          `koanf:"enabled"`
+	CheckInterval      time.Duration `koanf:"check_interval"`
+	Timeout            time.Duration `koanf:"timeout"`
+	DatabaseCheck      bool          `koanf:"database_check"`
+	RedisCheck         bool          `koanf:"redis_check"`
+	DiskCheck          bool          `koanf:"disk_check"`
+	DiskPath           string        `koanf:"disk_path"`
+	DiskMinFreeBytes   uint64        `koanf:"disk_min_free_bytes"`
+	SelfCheck          bool          `koanf:"self_check"`
+	FailureWindow      time.Duration `koanf:"failure_window"`
+	ExternalChecks     []string      `koanf:"external_checks"`
+	PingCacheTTL       time.Duration `koanf:"ping_cache_ttl"`
+	CriticalChecks     []string      `koanf:"critical_checks"`
+	FailureLogInterval time.Duration `koanf:"failure_log_interval"`
+	Breaker            BreakerConfig `koanf:"breaker"`
+	BatchMode          bool          `koanf:"batch_mode"`
+	ImmediateCheck     bool          `koanf:"immediate_check"`
+	MigrationCheck     bool          `koanf:"migration_check"`
+}
+
+// BreakerConfig sets when external health checks stop calling a failing dependency
+type BreakerConfig struct {
+	Threshold int           `koanf:"threshold"`
+	Cooldown  time.Duration `koanf:"cooldown"`
+}
+
+type MetricsConfig struct {
+	Enabled      bool          `koanf:"enabled"`
+	Path         string        `koanf:"path"`
+	PProfEnabled bool          `koanf:"pprof_enabled"`
+	Sampler      SamplerConfig `koanf:"sampler"`
+}
+
+// SamplerConfig sets how often runtime and connection pool stats are recorded between scrapes
+type SamplerConfig struct {
+	Interval time.Duration `koanf:"interval"`
+	Log      bool          `koanf:"log"`
+}
+
+// VersionConfig describes an API version. Dates use the YYYY-MM-DD format.
+type VersionConfig struct {
+	Name        string `koanf:"name"`
+	Deprecation string `koanf:"deprecation"`
+	Sunset      string `koanf:"sunset"`
+}
+
+// Deprecated reports whether the version has a deprecation date
+func (v VersionConfig) Deprecated() bool {
+	return v.Deprecation != ""
+}
+
+// DeprecationTime parses the deprecation date
+func (v VersionConfig) DeprecationTime() (time.Time, error) {
+	return time.Parse(time.DateOnly, v.Deprecation)
+}
+
+// SunsetTime parses the sunset date
+func (v VersionConfig) SunsetTime() (time.Time, error) {
+	return time.Parse(time.DateOnly, v.Sunset)
+}
+
+type TracingConfig struct {
+	Enabled    bool    `koanf:"enabled"`
+	Endpoint   string  `koanf:"endpoint"`
+	Insecure   bool    `koanf:"insecure"`
+	SampleRate float64 `koanf:"sample_rate"`
 }
 
 var (
-	k *koanf.Koanf
 	C *Config
+
+	// SilentMissingFiles suppresses even the debug log for absent config files, for
+	// container images configured purely through environment variables
+	SilentMissingFiles bool
 )
 
+// envPrefix is stripped from environment variable names before mapping them to config keys
+const envPrefix = "MEDICAL_REP_"
+
+// LoadOptions controls where LoadFrom reads configuration from
+type LoadOptions struct {
+	// BaseFile is the base YAML file; empty skips it
+	BaseFile string
+	// EnvFile is the environment-specific YAML file; empty derives
+	// configs/config.<app.environment>.yaml from the base file's directory
+	EnvFile string
+	// Env holds MEDICAL_REP_* variables; nil reads the process environment
+	Env map[string]string
+}
+
 // Load initializes and loads configuration from multiple sources
 func Load() error {
-	k = koanf.New(".")
-	
+	cfg, err := LoadFrom(LoadOptions{BaseFile: "configs/config.yaml"})
+	if err != nil {
+		return err
+	}
+	C = cfg
+	return nil
+}
+
+// LoadFrom loads defaults, the base file, the environment-specific file and
+// environment variables, in increasing order of precedence, and returns the
+// validated result without touching the global configuration
+func LoadFrom(opts LoadOptions) (*Config, error) {
+	k := koanf.New(".")
+
 	// 1. Load default values
-	if err := loadDefaults(); err != nil {
-		return fmt.Errorf("failed to load defaults: %w", err)
+	if err := loadDefaults(k); err != nil {
+		return nil, fmt.Errorf("failed to load defaults: %w", err)
 	}
 
 	// 2. Load base configuration file
-	if err := loadConfigFile("configs/config.yaml"); err != nil {
-		log.Printf("Warning: Could not load base config file: %v", err)
+	if opts.BaseFile != "" {
+		if err := loadConfigFile(k, opts.BaseFile); err != nil {
+			reportFileError("base", opts.BaseFile, err)
+		}
 	}
 
-	// 3. Load environment-specific configuration
-	env := k.String("app.environment")
-	envConfigFile := fmt.Sprintf("configs/config.%s.yaml", env)
-	if err := loadConfigFile(envConfigFile); err != nil {
-		log.Printf("Warning: Could not load environment config file %s: %v", envConfigFile, err)
+	// 3. Load environment-specific configuration. The environment may be
+	// selected by an env var, so it is resolved before the file is read
+	envVars := opts.Env
+	if envVars == nil {
+		envVars = processEnv()
+	}
+	envConfigFile := opts.EnvFile
+	if envConfigFile == "" {
+		environment := k.String("app.environment")
+		if v, ok := envVars[envPrefix+"APP_ENVIRONMENT"]; ok {
+			environment = v
+		}
+		dir := "configs"
+		if opts.BaseFile != "" {
+			dir = filepath.Dir(opts.BaseFile)
+		}
+		envConfigFile = filepath.Join(dir, fmt.Sprintf("config.%s.yaml", environment))
+	}
+	if err := loadConfigFile(k, envConfigFile); err != nil {
+		reportFileError("environment", envConfigFile, err)
 	}
 
 	// 4. Load environment variables
-	if err := loadEnvVars(); err != nil {
-		return fmt.Errorf("failed to load environment variables: %w", err)
+	if err := loadEnvVars(k, envVars); err != nil {
+		return nil, fmt.Errorf("failed to load environment variables: %w", err)
 	}
 
 	// 5. Unmarshal into config struct
-	C = &Config{}
-	if err := k.Unmarshal("", C); err != nil {
-		return fmt.Errorf("failed to unmarshal config: %w", err)
+	cfg := &Config{}
+	if err := k.Unmarshal("", cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
 	// 6. Validate configuration
-	if err := validate(); err != nil {
-		return fmt.Errorf("config validation failed: %w", err)
+	if err := validate(cfg); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
-	return nil
+	return cfg, nil
 }
 
-func loadDefaults() error {
+func loadDefaults(k *koanf.Koanf) error {
 	defaults := Config{
 		App: AppConfig{
 			Name:        "medical-rep-api",
@@ -171,42 +568,135 @@ func loadDefaults() error {
 			Environment: "development",
 			Debug:       true,
 			Shutdown: ShutdownConfig{
-				Timeout: 30 * time.Second,
+				Timeout:        30 * time.Second,
+				UpgradeTimeout: 2 * time.Minute,
+				DrainDelay:     5 * time.Second,
+			},
+			Maintenance: MaintenanceConfig{
+				Enabled:    false,
+				Message:    "The service is undergoing maintenance, please try again later",
+				RetryAfter: 5 * time.Minute,
+			},
+			PanicBudget: PanicBudgetConfig{
+				MaxPanics: 20,
+				Window:    time.Minute,
+				Shutdown:  true,
 			},
 		},
 		HTTP: HTTPConfig{
-			Port:           8080,
-			Host:           "0.0.0.0",
-			ReadTimeout:    15 * time.Second,
-			WriteTimeout:   15 * time.Second,
-			IdleTimeout:    60 * time.Second,
-			MaxHeaderBytes: 1 << 20, // 1MB
+			Port:                 8080,
+			Host:                 "0.0.0.0",
+			Network:              "tcp",
+			UnixSocketMode:       "0660",
+			ReadTimeout:          15 * time.Second,
+			ReadHeaderTimeout:    5 * time.Second,
+			WriteTimeout:         15 * time.Second,
+			IdleTimeout:          60 * time.Second,
+			RequestTimeout:       60 * time.Second,
+			SlowRequestThreshold: time.Second,
+			MaxHeaderBytes:       1 << 20,  // 1MB
+			MaxBodyBytes:         10 << 20, // 10MB
+			ConcurrencyWait:      100 * time.Millisecond,
+			TrustedProxies:       []string{},
+			ZeroDowntime:         true,
+			ServerTiming:         true,
+			JSONPrecheck: JSONPrecheckConfig{
+				Enabled:  true,
+				Validate: true,
+			},
+			RequestID: RequestIDConfig{
+				Header: "X-Request-ID",
+			},
+			HSTS: HSTSConfig{
+				MaxAge: 365 * 24 * time.Hour,
+			},
+			AccessLog: AccessLogConfig{
+				Query:   true,
+				Headers: []string{"User-Agent", "Referer"},
+				Redact: RedactConfig{
+					QueryParams: []string{"token", "access_token", "refresh_token", "id_token", "api_key", "apikey", "key", "password", "secret", "client_secret", "signature", "sig", "code"},
+					Headers:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key", "X-Auth-Token", "X-CSRF-Token"},
+				},
+			},
+			BodyLog: BodyLogConfig{
+				MaxBytes:     4096,
+				RedactFields: []string{"password", "token", "access_token", "refresh_token", "secret", "api_key", "key", "authorization", "ssn", "national_id", "date_of_birth", "dob", "phone", "email", "address", "diagnosis", "notes"},
+			},
+			Admin: AdminConfig{
+				Host: "127.0.0.1",
+				Port: 9090,
+			},
+			Pagination: PaginationConfig{
+				DefaultLimit: 20,
+				MaxLimit:     100,
+			},
+			Middleware: MiddlewareConfig{
+				RequestID: true,
+				RealIP:    true,
+				AccessLog: true,
+				Heartbeat: true,
+				CORS:      true,
+				Order:     slices.Clone(MiddlewareNames),
+			},
+			Idempotency: IdempotencyConfig{
+				Enabled: true,
+				TTL:     24 * time.Hour,
+			},
+			ETag: ETagConfig{
+				Enabled:      true,
+				MaxSize:      1 << 20, // 1MB
+				CacheControl: "private, no-cache",
+			},
+			Compression: CompressionConfig{
+				Enabled: true,
+				Level:   5,
+				MinSize: 1024,
+				ContentTypes: []string{
+					"application/json",
+					"application/javascript",
+					"application/xml",
+					"image/svg+xml",
+					"text/*",
+				},
+			},
 			TLS: TLSConfig{
-				Enabled: false,
+				Enabled:    false,
+				MinVersion: "1.2",
 			},
 			CORS: CORSConfig{
 				AllowedOrigins: []string{"*"},
 				AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 				AllowedHeaders: []string{"*"},
+				MaxAge:         5 * time.Minute,
 			},
 			RateLimit: RateLimitConfig{
 				Enabled: false,
 				Rate:    100,
 				Burst:   200,
+				Store:   "memory",
 			},
 		},
 		Database: DatabaseConfig{
-			Driver:          "postgres",
-			Host:            "localhost",
-			Port:            5432,
-			Database:        "medical_rep",
-			Username:        "postgres",
-			Password:        "password",
-			SSLMode:         "disable",
-			MaxOpenConns:    25,
-			MaxIdleConns:    5,
-			ConnMaxLifetime: 5 * time.Minute,
-			MigrationsPath:  "migrations",
+			Driver:             "postgres",
+			Host:               "localhost",
+			Port:               5432,
+			Database:           "medical_rep",
+			Username:           "postgres",
+			Password:           "password",
+			SSLMode:            "disable",
+			MaxOpenConns:       25,
+			MaxIdleConns:       5,
+			ConnMaxLifetime:    5 * time.Minute,
+			MigrationsPath:     "migrations",
+			QueryTimeout:       30 * time.Second,
+			SlowQueryThreshold: 500 * time.Millisecond,
+			Reconnect: DatabaseReconnectConfig{
+				Enabled:          true,
+				Interval:         10 * time.Second,
+				FailureThreshold: 3,
+				Backoff:          time.Second,
+				MaxBackoff:       30 * time.Second,
+			},
 		},
 		Redis: RedisConfig{
 			Host:         "localhost",
@@ -218,8 +708,20 @@ func loadDefaults() error {
 			WriteTimeout: 3 * time.Second,
 		},
 		Auth: AuthConfig{
-			JWTExpiration: 24 * time.Hour,
-			BCryptCost:    12,
+			JWTAlgorithm:      "HS256",
+			JWTExpiration:     24 * time.Hour,
+			RefreshExpiration: 7 * 24 * time.Hour,
+			BCryptCost:        12,
+		},
+		Session: SessionConfig{
+			CookieName: "medical_rep_session",
+			IdleTTL:    30 * time.Minute,
+			SameSite:   "lax",
+		},
+		APIKeys: APIKeyConfig{
+			Header:           "X-API-Key",
+			BCryptCost:       10,
+			LastUsedInterval: time.Minute,
 		},
 		Logging: LoggingConfig{
 			Level:      "info",
@@ -231,59 +733,580 @@ func loadDefaults() error {
 			Compress:   true,
 		},
 		Health: HealthConfig{
-			Enabled:        true,
-			CheckInterval:  30 * time.Second,
-			Timeout:        5 * time.Second,
-			DatabaseCheck:  true,
-			RedisCheck:     true,
-			ExternalChecks: []string{},
+			Enabled:            true,
+			CheckInterval:      30 * time.Second,
+			Timeout:            5 * time.Second,
+			DatabaseCheck:      true,
+			RedisCheck:         true,
+			DiskCheck:          false,
+			DiskPath:           "/",
+			DiskMinFreeBytes:   100 << 20, // 100MB
+			SelfCheck:          false,
+			FailureWindow:      15 * time.Minute,
+			ExternalChecks:     []string{},
+			PingCacheTTL:       2 * time.Second,
+			CriticalChecks:     []string{"database", "redis", "disk", "http_check"},
+			FailureLogInterval: 10 * time.Minute,
+			ImmediateCheck:     true,
+			MigrationCheck:     true,
+			Breaker: BreakerConfig{
+				Threshold: 5,
+				Cooldown:  time.Minute,
+			},
+		},
+		Metrics: MetricsConfig{
+			Enabled:      true,
+			Path:         "/metrics",
+			PProfEnabled: false,
+			Sampler: SamplerConfig{
+				Interval: 5 * time.Second,
+			},
+		},
+		Tracing: TracingConfig{
+			Enabled:    false,
+			Endpoint:   "localhost:4318",
+			Insecure:   true,
+			SampleRate: 1.0,
+		},
+		Versions: []VersionConfig{
+			{Name: "v1"},
+		},
+		Startup: StartupConfig{
+			Attempts:   10,
+			Backoff:    500 * time.Millisecond,
+			MaxBackoff: 10 * time.Second,
+			Timeout:    time.Minute,
+		},
+		HTTPClient: HTTPClientConfig{
+			Timeout:             30 * time.Second,
+			DialTimeout:         5 * time.Second,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+			MaxRetries:          2,
+			RetryBackoff:        200 * time.Millisecond,
+			RetryMaxBackoff:     2 * time.Second,
+		},
+		Scheduler: SchedulerConfig{
+			Enabled: true,
+			LockTTL: 30 * time.Second,
+		},
+		Cache: CacheConfig{
+			TTL: 5 * time.Minute,
+		},
+		Sequence: SequenceConfig{
+			Timezone: "UTC",
+		},
+		Audit: AuditConfig{
+			Enabled: true,
+			Log: LoggingConfig{
+				Level:  "info",
+				Format: "json",
+				Output: "stdout",
+			},
+		},
+		Tenancy: TenancyConfig{
+			Enabled:  false,
+			Header:   "X-Tenant-ID",
+			Registry: "static",
+			Tenants:  []string{},
+		},
+		Webhooks: WebhookConfig{
+			Enabled:      false,
+			Endpoints:    []string{},
+			Timeout:      10 * time.Second,
+			MaxAttempts:  8,
+			Backoff:      30 * time.Second,
+			MaxBackoff:   time.Hour,
+			PollInterval: 5 * time.Second,
 		},
 	}
 
 	return k.Load(structs.Provider(defaults, "koanf"), nil)
 }
 
-func loadConfigFile(path string) error {
+func loadConfigFile(k *koanf.Koanf, path string) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return err
 	}
 	return k.Load(file.Provider(path), yaml.Parser())
 }
 
-func loadEnvVars() error {
-	return k.Load(env.Provider("", ".", func(s string) string {
-		// Convert MEDICAL_REP_APP_NAME to app.name
-		s = strings.TrimPrefix(s, "MEDICAL_REP_")
-		return strings.ToLower(strings.ReplaceAll(s, "_", "."))
-	}), nil)
+// reportFileError logs a config file that could not be loaded. Optional files are often
+// absent, so that is only logged at debug level; unreadable or malformed files are warnings
+func reportFileError(kind, path string, err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		if !SilentMissingFiles {
+			slog.Debug("Config file not found, skipping", "kind", kind, "path", path)
+		}
+		return
+	}
+	slog.Warn("Could not load config file", "kind", kind, "path", path, "error", err)
+}
+
+// processEnv returns the MEDICAL_REP_* variables of the current process
+func processEnv() map[string]string {
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, ok := strings.Cut(kv, "=")
+		if ok && strings.HasPrefix(key, envPrefix) {
+			vars[key] = value
+		}
+	}
+	return vars
+}
+
+// loadEnvVars applies the MEDICAL_REP_* variables over the keys loaded so far. Names are matched
+// against the known keys first, so MEDICAL_REP_AUTH_JWT_SECRET sets auth.jwt_secret; names that
+// match no known key fall back to treating every underscore as a nesting separator.
+func loadEnvVars(k *koanf.Koanf, vars map[string]string) error {
+	known := make(map[string]string, len(k.Keys()))
+	for _, key := range k.Keys() {
+		known[envName(key)] = key
+	}
+
+	values := make(map[string]interface{}, len(vars))
+	for name, value := range vars {
+		if !strings.HasPrefix(name, envPrefix) {
+			continue
+		}
+		name = strings.TrimPrefix(name, envPrefix)
+		key, ok := known[name]
+		if !ok {
+			// Convert MEDICAL_REP_APP_NAME to app.name
+			key = strings.ToLower(strings.ReplaceAll(name, "_", "."))
+		}
+		values[key] = value
+	}
+	return k.Load(envMap(values), nil)
+}
+
+// envName returns the environment variable name of a config key without the prefix,
+// e.g. AUTH_JWT_SECRET for auth.jwt_secret
+func envName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// envMap is a koanf provider over already-mapped environment variables
+type envMap map[string]interface{}
+
+// ReadBytes is not supported by envMap
+func (e envMap) ReadBytes() ([]byte, error) {
+	return nil, errors.New("envMap provider does not support this method")
+}
+
+// Read returns the variables as a nested map
+func (e envMap) Read() (map[string]interface{}, error) {
+	return maps.Unflatten(maps.Copy(e), "."), nil
 }
 
-func validate() error {
+func validate(c *Config) error {
+	var errs ValidationError
+
 	// Validate required fields
-	if C.App.Name == "" {
-		return fmt.Errorf("app.name is required")
+	if c.App.Name == "" {
+		errs.add("app.name", "is required")
+	}
+
+	if c.App.Shutdown.UpgradeTimeout <= 0 {
+		errs.add("app.shutdown.upgrade_timeout", "must be positive")
+	}
+
+	if c.Metrics.Sampler.Interval < 0 {
+		errs.add("metrics.sampler.interval", "must not be negative")
+	}
+
+	if _, err := time.LoadLocation(c.Sequence.Timezone); err != nil {
+		errs.add("sequence.timezone", "must be an IANA time zone such as Africa/Cairo: %v", err)
+	}
+
+	if c.App.PanicBudget.Enabled {
+		if c.App.PanicBudget.MaxPanics <= 0 {
+			errs.add("app.panic_budget.max_panics", "must be positive")
+		}
+		if c.App.PanicBudget.Window <= 0 {
+			errs.add("app.panic_budget.window", "must be positive")
+		}
+	}
+
+	if c.App.Shutdown.PreStopDelay < 0 {
+		errs.add("app.shutdown.pre_stop_delay", "must not be negative")
+	}
+
+	if c.App.Maintenance.RetryAfter <= 0 {
+		errs.add("app.maintenance.retry_after", "must be positive")
+	}
+
+	if c.HTTP.Port <= 0 || c.HTTP.Port > 65535 {
+		errs.add("http.port", "must be between 1 and 65535")
+	}
+
+	if c.HTTP.Admin.Enabled {
+		if c.HTTP.Admin.Port <= 0 || c.HTTP.Admin.Port > 65535 {
+			errs.add("http.admin.port", "must be between 1 and 65535")
+		} else if c.HTTP.Network == "tcp" && c.HTTP.Admin.Port == c.HTTP.Port {
+			errs.add("http.admin.port", "must differ from http.port")
+		}
+	}
+
+	if c.HTTP.MaxConcurrentRequests < 0 {
+		errs.add("http.max_concurrent_requests", "must not be negative")
+	}
+	if c.HTTP.ConcurrencyWait < 0 {
+		errs.add("http.concurrency_wait", "must not be negative")
+	}
+
+	if c.HTTP.BodyLog.Enabled {
+		if c.App.Environment == "production" {
+			errs.add("http.body_log.enabled", "must not be set in production")
+		}
+		if c.HTTP.BodyLog.MaxBytes <= 0 {
+			errs.add("http.body_log.max_bytes", "must be positive")
+		}
+	}
+
+	if c.HTTP.HSTS.Enabled && c.HTTP.HSTS.MaxAge <= 0 {
+		errs.add("http.hsts.max_age", "must be positive when http.hsts.enabled is set")
+	}
+
+	if c.HTTP.Pagination.DefaultLimit < 1 || c.HTTP.Pagination.MaxLimit < c.HTTP.Pagination.DefaultLimit {
+		errs.add("http.pagination.default_limit", "must be positive and not above http.pagination.max_limit")
+	}
+
+	if h := c.HTTP.RequestID.Header; h == "" || strings.ContainsAny(h, " \t\r\n:") {
+		errs.add("http.request_id.header", "must be a valid header name")
+	}
+
+	switch c.HTTP.Network {
+	case "tcp":
+	case "unix":
+		if c.HTTP.UnixSocket == "" {
+			errs.add("http.unix_socket", "is required when http.network is unix")
+		}
+		if _, err := c.HTTP.ParseUnixSocketMode(); err != nil {
+			errs.addErr(err)
+		}
+	default:
+		errs.add("http.network", "must be one of tcp, unix")
+	}
+
+	if c.Database.Driver == "" {
+		errs.add("database.driver", "is required")
+	}
+	if r := c.Database.Reconnect; r.Enabled {
+		if r.Interval <= 0 || r.FailureThreshold < 1 {
+			errs.add("database.reconnect.interval", "must be positive and database.reconnect.failure_threshold at least 1")
+		}
+		if r.Backoff <= 0 || r.MaxBackoff < r.Backoff {
+			errs.add("database.reconnect.backoff", "must be positive and not exceed database.reconnect.max_backoff")
+		}
+	}
+	if c.Database.WarmupConns < 0 {
+		errs.add("database.warmup_conns", "must not be negative")
+	}
+
+	if c.Database.QueryTimeout < 0 || c.Database.SlowQueryThreshold < 0 {
+		errs.add("database.query_timeout", "and database.slow_query_threshold must not be negative")
+	}
+
+	if c.Redis.TLS.Enabled && c.IsProduction() {
+		if c.Redis.TLS.CAFile == "" {
+			errs.add("redis.tls.ca_file", "is required when redis.tls.enabled is set in production")
+		}
+		if c.Redis.TLS.InsecureSkipVerify {
+			errs.add("redis.tls.insecure_skip_verify", "must not be set in production")
+		}
+	}
+
+	switch c.Auth.JWTAlgorithm {
+	case "HS256":
+		// An empty HMAC key is accepted by the JWT library, so anyone could mint tokens
+		if len(c.Auth.JWTSecret) < MinJWTSecretLength {
+			errs.add("auth.jwt_secret", "must be at least %d bytes for HS256", MinJWTSecretLength)
+		}
+	case "RS256":
+		if c.Auth.JWTPublicKeyFile == "" {
+			errs.add("auth.jwt_public_key_file", "is required when auth.jwt_algorithm is RS256")
+		}
+	default:
+		errs.add("auth.jwt_algorithm", "must be one of HS256, RS256")
+	}
+
+	if c.Session.CookieName == "" {
+		errs.add("session.cookie_name", "is required")
+	}
+	if c.Session.IdleTTL <= 0 {
+		errs.add("session.idle_ttl", "must be positive")
+	}
+	if _, err := c.Session.ParseSameSite(); err != nil {
+		errs.addErr(err)
+	}
+
+	if h := c.APIKeys.Header; h == "" || strings.ContainsAny(h, " \t\r\n:") {
+		errs.add("api_keys.header", "must be a valid header name")
+	}
+	if c.APIKeys.BCryptCost < 4 || c.APIKeys.BCryptCost > 31 {
+		errs.add("api_keys.bcrypt_cost", "must be between 4 and 31")
+	}
+	if c.APIKeys.LastUsedInterval < 0 {
+		errs.add("api_keys.last_used_interval", "must not be negative")
+	}
+
+	// Validate rate limit configuration
+	if c.HTTP.RateLimit.Enabled {
+		if c.HTTP.RateLimit.Rate <= 0 || c.HTTP.RateLimit.Burst <= 0 {
+			errs.add("http.rate_limit.rate", "and http.rate_limit.burst must be positive")
+		}
+		if c.HTTP.RateLimit.Store != "memory" && c.HTTP.RateLimit.Store != "redis" {
+			errs.add("http.rate_limit.store", "must be one of memory, redis")
+		}
 	}
 
-	if C.HTTP.Port <= 0 || C.HTTP.Port > 65535 {
-		return fmt.Errorf("http.port must be between 1 and 65535")
+	if err := c.HTTP.CORS.Validate(); err != nil {
+		errs.addErr(err)
 	}
 
-	if C.Database.Driver == "" {
-		return fmt.Errorf("database.driver is required")
+	for _, proxy := range c.HTTP.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(proxy); err != nil {
+			errs.add("http.trusted_proxies", "contains invalid address or CIDR %q", proxy)
+		}
 	}
 
-	if C.Auth.JWTSecret == "" && C.App.Environment == "production" {
-		return fmt.Errorf("auth.jwt_secret is required in production")
+	if c.HTTP.Idempotency.Enabled && c.HTTP.Idempotency.TTL <= 0 {
+		errs.add("http.idempotency.ttl", "must be positive when idempotency is enabled")
+	}
+
+	if c.HTTP.Compression.Enabled {
+		if c.HTTP.Compression.Level < 1 || c.HTTP.Compression.Level > 9 {
+			errs.add("http.compression.level", "must be between 1 and 9")
+		}
+		if c.HTTP.Compression.MinSize < 0 {
+			errs.add("http.compression.min_size", "must not be negative")
+		}
+	}
+
+	if c.HTTP.ETag.Enabled && c.HTTP.ETag.MaxSize <= 0 {
+		errs.add("http.etag.max_size", "must be positive when etags are enabled")
+	}
+
+	if c.HTTP.SlowRequestThreshold < 0 {
+		errs.add("http.slow_request_threshold", "must not be negative")
+	}
+
+	if c.Health.FailureLogInterval < 0 {
+		errs.add("health.failure_log_interval", "must not be negative")
+	}
+
+	if c.Health.Breaker.Threshold < 0 {
+		errs.add("health.breaker.threshold", "must not be negative")
+	}
+
+	if c.Health.Breaker.Threshold > 0 && c.Health.Breaker.Cooldown <= 0 {
+		errs.add("health.breaker.cooldown", "must be positive when health.breaker.threshold is set")
+	}
+
+	if c.Health.PingCacheTTL < 0 {
+		errs.add("health.ping_cache_ttl", "must not be negative")
+	}
+
+	if c.HTTP.ReadHeaderTimeout <= 0 {
+		errs.add("http.read_header_timeout", "must be positive")
+	}
+
+	if c.HTTP.RequestTimeout <= 0 {
+		errs.add("http.request_timeout", "must be positive")
+	}
+
+	if c.HTTP.MaxBodyBytes <= 0 {
+		errs.add("http.max_body_bytes", "must be positive")
+	}
+
+	if c.Health.FailureWindow <= 0 {
+		errs.add("health.failure_window", "must be positive")
+	}
+
+	// Validate disk health check configuration
+	if c.Health.DiskCheck && c.Health.DiskPath == "" {
+		errs.add("health.disk_path", "is required when health.disk_check is enabled")
+	}
+
+	// Validate middleware order; every middleware is listed once so none is dropped by accident
+	order := make(map[string]int, len(c.HTTP.Middleware.Order))
+	for i, name := range c.HTTP.Middleware.Order {
+		if name != "auth" && !slices.Contains(MiddlewareNames, name) {
+			errs.add("http.middleware.order", "contains unknown middleware %q", name)
+			continue
+		}
+		if _, ok := order[name]; ok {
+			errs.add("http.middleware.order", "contains duplicate middleware %q", name)
+			continue
+		}
+		order[name] = i
+	}
+	for _, name := range MiddlewareNames {
+		if _, ok := order[name]; !ok {
+			errs.add("http.middleware.order", "is missing middleware %q", name)
+		}
+	}
+	// Authentication runs per route group, so only middleware that can move there may follow it
+	if auth, ok := order["auth"]; ok {
+		for _, name := range c.HTTP.Middleware.Order[auth+1:] {
+			if name != "auth" && !slices.Contains(AfterAuthMiddlewareNames, name) {
+				errs.add("http.middleware.order", "can only list %s after auth, not %q", strings.Join(AfterAuthMiddlewareNames, ", "), name)
+			}
+		}
+	}
+	// HSTS and the HTTPS redirect read the proxy's address, which realIP replaces
+	if hsts, ok := order["hsts"]; ok {
+		if realIP, ok := order["real_ip"]; ok && hsts > realIP {
+			errs.add("http.middleware.order", "must list hsts before real_ip")
+		}
+	}
+
+	// The self check requests the heartbeat route
+	if c.Health.SelfCheck && !c.HTTP.Middleware.Heartbeat {
+		errs.add("health.self_check", "requires http.middleware.heartbeat")
+	}
+
+	// Validate tracing configuration
+	if c.Tracing.Enabled {
+		if c.Tracing.Endpoint == "" {
+			errs.add("tracing.endpoint", "is required when tracing is enabled")
+		}
+		if c.Tracing.SampleRate < 0 || c.Tracing.SampleRate > 1 {
+			errs.add("tracing.sample_rate", "must be between 0 and 1")
+		}
+	}
+
+	// Validate startup retries
+	if c.Startup.Attempts < 1 {
+		errs.add("startup.attempts", "must be at least 1")
+	}
+	if c.Startup.Backoff <= 0 || c.Startup.MaxBackoff < c.Startup.Backoff {
+		errs.add("startup.backoff", "must be positive and not exceed startup.max_backoff")
+	}
+	if c.Startup.Timeout <= 0 {
+		errs.add("startup.timeout", "must be positive")
+	}
+
+	if c.HTTPClient.Timeout <= 0 || c.HTTPClient.DialTimeout <= 0 {
+		errs.add("http_client.timeout", "and http_client.dial_timeout must be positive")
+	}
+	if c.HTTPClient.MaxRetries < 0 {
+		errs.add("http_client.max_retries", "must not be negative")
+	}
+	if c.HTTPClient.RetryBackoff <= 0 || c.HTTPClient.RetryMaxBackoff < c.HTTPClient.RetryBackoff {
+		errs.add("http_client.retry_backoff", "must be positive and not exceed http_client.retry_max_backoff")
+	}
+
+	if c.Scheduler.Enabled && c.Scheduler.LockTTL < 3*time.Second {
+		errs.add("scheduler.lock_ttl", "must be at least 3s")
+	}
+
+	if c.Cache.TTL <= 0 {
+		errs.add("cache.ttl", "must be positive")
+	}
+
+	for name, feature := range c.Features {
+		if feature.Percentage < 0 || feature.Percentage > 100 {
+			errs.add("features."+name+".percentage", "must be between 0 and 100")
+		}
+	}
+
+	if c.Tenancy.Enabled {
+		if c.Tenancy.Header == "" {
+			errs.add("tenancy.header", "is required when tenancy is enabled")
+		}
+		switch c.Tenancy.Registry {
+		case "static":
+			if len(c.Tenancy.Tenants) == 0 {
+				errs.add("tenancy.tenants", "must not be empty with the static registry")
+			}
+		case "redis":
+		default:
+			errs.add("tenancy.registry", "must be one of static, redis")
+		}
+	}
+
+	if c.Webhooks.Enabled {
+		if len(c.Webhooks.Endpoints) == 0 {
+			errs.add("webhooks.endpoints", "is required when webhooks are enabled")
+		}
+		for _, endpoint := range c.Webhooks.Endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs.add("webhooks.endpoints", "contains invalid URL %q", endpoint)
+			}
+		}
+		if c.Webhooks.Secret == "" {
+			errs.add("webhooks.secret", "is required when webhooks are enabled")
+		}
+		if c.Webhooks.Timeout <= 0 || c.Webhooks.PollInterval <= 0 {
+			errs.add("webhooks.timeout", "and webhooks.poll_interval must be positive")
+		}
+		if c.Webhooks.MaxAttempts < 1 {
+			errs.add("webhooks.max_attempts", "must be at least 1")
+		}
+		if c.Webhooks.Backoff <= 0 || c.Webhooks.MaxBackoff < c.Webhooks.Backoff {
+			errs.add("webhooks.backoff", "must be positive and not exceed webhooks.max_backoff")
+		}
+	}
+
+	// Validate API versions
+	seen := make(map[string]bool)
+	for _, v := range c.Versions {
+		if v.Name == "" {
+			errs.add("versions.name", "is required")
+			continue
+		}
+		if seen[v.Name] {
+			errs.add("versions", "contains duplicate version %s", v.Name)
+			continue
+		}
+		seen[v.Name] = true
+
+		field := "versions." + v.Name
+		if v.Sunset != "" && v.Deprecation == "" {
+			errs.add(field+".deprecation", "is required when sunset is set")
+		}
+		if v.Deprecation == "" {
+			continue
+		}
+		deprecation, err := v.DeprecationTime()
+		if err != nil {
+			errs.add(field+".deprecation", "must be a YYYY-MM-DD date: %v", err)
+			continue
+		}
+		if v.Sunset != "" {
+			sunset, err := v.SunsetTime()
+			if err != nil {
+				errs.add(field+".sunset", "must be a YYYY-MM-DD date: %v", err)
+			} else if !sunset.After(deprecation) {
+				errs.add(field+".sunset", "must be after its deprecation date")
+			}
+		}
 	}
 
 	// Validate TLS configuration
-	if C.HTTP.TLS.Enabled {
-		if C.HTTP.TLS.CertFile == "" || C.HTTP.TLS.KeyFile == "" {
-			return fmt.Errorf("tls.cert_file and tls.key_file are required when TLS is enabled")
+	if c.HTTP.TLS.Enabled {
+		if c.HTTP.TLS.CertFile == "" || c.HTTP.TLS.KeyFile == "" {
+			errs.add("tls.cert_file", "and tls.key_file are required when TLS is enabled")
+		}
+		if _, err := c.HTTP.TLS.ParseMinVersion(); err != nil {
+			errs.addErr(err)
+		}
+		if _, err := c.HTTP.TLS.ParseCipherSuites(); err != nil {
+			errs.addErr(err)
+		}
+		if _, err := c.HTTP.TLS.ParseClientAuth(); err != nil {
+			errs.addErr(err)
 		}
 	}
 
-	return nil
+	return errs.err()
 }
 
 // Get returns the global configuration instance
@@ -296,24 +1319,32 @@ func Get() *Config {
 
 // GetConnectionString returns the database connection string
 func (c *Config) GetConnectionString() string {
-	switch c.Database.Driver {
+	return c.Database.ConnectionString()
+}
+
+// ConnectionString returns the driver specific connection string
+func (d DatabaseConfig) ConnectionString() string {
+	switch d.Driver {
 	case "postgres":
 		return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-			c.Database.Host,
-			c.Database.Port,
-			c.Database.Username,
-			c.Database.Password,
-			c.Database.Database,
-			c.Database.SSLMode,
+			d.Host,
+			d.Port,
+			d.Username,
+			d.Password,
+			d.Database,
+			d.SSLMode,
 		)
 	case "mysql":
 		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
-			c.Database.Username,
-			c.Database.Password,
-			c.Database.Host,
-			c.Database.Port,
-			c.Database.Database,
+			d.Username,
+			d.Password,
+			d.Host,
+			d.Port,
+			d.Database,
 		)
+	case "sqlite3":
+		// SQLite is only used by tests; database names the file or a file: URI
+		return d.Database
 	default:
 		return ""
 	}
@@ -332,4 +1363,45 @@ func (c *Config) IsProduction() bool {
 // IsDevelopment returns true if running in development environment
 func (c *Config) IsDevelopment() bool {
 	return c.App.Environment == "development"
-}
\ No newline at end of file
+}
+
+// redactedValue replaces secrets in a redacted config
+const redactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the config with passwords and signing secrets masked, safe to log
+func (c *Config) Redacted() *Config {
+	r := *c
+	redact := func(s *string) {
+		if *s != "" {
+			*s = redactedValue
+		}
+	}
+	redact(&r.Database.Password)
+	redact(&r.Redis.Password)
+	redact(&r.Auth.JWTSecret)
+	redact(&r.Webhooks.Secret)
+	return &r
+}
+
+// ListenAddress returns the network and address the server listens on: a TCP host:port,
+// or the socket path when listening on a Unix socket
+func (h HTTPConfig) ListenAddress() (string, string) {
+	if h.Network == "unix" {
+		return "unix", h.UnixSocket
+	}
+	return "tcp", fmt.Sprintf("%s:%d", h.Host, h.Port)
+}
+
+// Address returns the host:port the admin listener binds to
+func (a AdminConfig) Address() string {
+	return fmt.Sprintf("%s:%d", a.Host, a.Port)
+}
+
+// ParseUnixSocketMode returns the permissions for the Unix socket file, given in octal
+func (h HTTPConfig) ParseUnixSocketMode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(h.UnixSocketMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("http.unix_socket_mode must be octal permissions such as 0660 (got %q)", h.UnixSocketMode)
+	}
+	return os.FileMode(mode), nil
+}
@@ -0,0 +1,519 @@
+package configs
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSecret is an HS256 secret long enough to pass validation
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// loadYAML loads the defaults overlaid with a base file holding yaml, ignoring the process environment
+func loadYAML(t *testing.T, yaml string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadFrom(LoadOptions{BaseFile: path, Env: map[string]string{}})
+}
+
+// validYAML returns a minimal valid configuration followed by extra
+func validYAML(extra string) string {
+	return "auth:\n  jwt_secret: " + testSecret + "\n" + extra
+}
+
+// assertInvalid fails unless err is a validation error mentioning field
+func assertInvalid(t *testing.T, err error, field string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected a validation error for %s", field)
+	}
+	if !strings.Contains(err.Error(), field) {
+		t.Fatalf("error %q does not mention %s", err, field)
+	}
+}
+
+func TestValidateJWTSecretLength(t *testing.T) {
+	for _, secret := range []string{"", "short", testSecret[:MinJWTSecretLength-1]} {
+		_, err := loadYAML(t, "auth:\n  jwt_secret: \""+secret+"\"\n")
+		assertInvalid(t, err, "auth.jwt_secret")
+	}
+
+	cfg, err := loadYAML(t, validYAML(""))
+	if err != nil {
+		t.Fatalf("valid secret rejected: %v", err)
+	}
+	if cfg.Auth.JWTSecret != testSecret {
+		t.Errorf("JWTSecret = %q, want %q", cfg.Auth.JWTSecret, testSecret)
+	}
+}
+
+func TestJWTSecretFromEnvOnly(t *testing.T) {
+	secret := testSecret + "more"
+	env := map[string]string{envPrefix + "AUTH_JWT_SECRET": secret}
+
+	// No config files at all, as in an image configured through the environment
+	cfg, err := LoadFrom(LoadOptions{BaseFile: filepath.Join(t.TempDir(), "config.yaml"), Env: env})
+	if err != nil {
+		t.Fatalf("secret from env rejected: %v", err)
+	}
+	if cfg.Auth.JWTSecret != secret {
+		t.Errorf("JWTSecret = %q, want %q", cfg.Auth.JWTSecret, secret)
+	}
+
+	// The shipped production file carries no secret, so it must come from the environment
+	cfg, err = LoadFrom(LoadOptions{BaseFile: filepath.Join(t.TempDir(), "config.yaml"), EnvFile: "config.prod.yaml", Env: env})
+	if err != nil {
+		t.Fatalf("production file with secret from env rejected: %v", err)
+	}
+	if cfg.App.Environment != "production" || cfg.Auth.JWTSecret != secret {
+		t.Errorf("environment = %s, JWTSecret = %q, want the production file and %q", cfg.App.Environment, cfg.Auth.JWTSecret, secret)
+	}
+}
+
+func TestValidateJWTAlgorithm(t *testing.T) {
+	_, err := loadYAML(t, "auth:\n  jwt_algorithm: RS256\n")
+	assertInvalid(t, err, "auth.jwt_public_key_file")
+
+	// The HMAC secret is not needed for RS256
+	cfg, err := loadYAML(t, "auth:\n  jwt_algorithm: RS256\n  jwt_public_key_file: /etc/jwt.pub\n")
+	if err != nil {
+		t.Fatalf("RS256 with a public key rejected: %v", err)
+	}
+	if cfg.Auth.JWTAlgorithm != "RS256" {
+		t.Errorf("JWTAlgorithm = %q, want RS256", cfg.Auth.JWTAlgorithm)
+	}
+
+	_, err = loadYAML(t, validYAML("  jwt_algorithm: none\n"))
+	assertInvalid(t, err, "auth.jwt_algorithm")
+}
+
+func TestValidateVersions(t *testing.T) {
+	tests := map[string]string{
+		"versions.v1.deprecation": "versions:\n  - name: v1\n    sunset: \"2026-07-01\"\n",
+		"versions.v2.sunset":      "versions:\n  - name: v2\n    deprecation: \"2026-07-01\"\n    sunset: \"2026-01-01\"\n",
+		"duplicate version v1":    "versions:\n  - name: v1\n  - name: v1\n",
+	}
+	for field, yaml := range tests {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, field)
+	}
+}
+
+func TestValidateReadHeaderTimeout(t *testing.T) {
+	cfg, err := loadYAML(t, validYAML(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.ReadHeaderTimeout != 5*time.Second {
+		t.Errorf("default read header timeout = %s, want 5s", cfg.HTTP.ReadHeaderTimeout)
+	}
+
+	for _, timeout := range []string{"0s", "-1s"} {
+		_, err := loadYAML(t, validYAML("http:\n  read_header_timeout: "+timeout+"\n"))
+		assertInvalid(t, err, "http.read_header_timeout")
+	}
+}
+
+func TestValidateHealthFailureLogInterval(t *testing.T) {
+	_, err := loadYAML(t, validYAML("health:\n  failure_log_interval: -1s\n"))
+	assertInvalid(t, err, "health.failure_log_interval")
+
+	// Zero disables the reminders
+	if _, err := loadYAML(t, validYAML("health:\n  failure_log_interval: 0s\n")); err != nil {
+		t.Errorf("zero interval rejected: %v", err)
+	}
+}
+
+func TestValidateNetwork(t *testing.T) {
+	tests := map[string]string{
+		"http.network":          "http:\n  network: udp\n",
+		"http.unix_socket":      "http:\n  network: unix\n",
+		"http.unix_socket_mode": "http:\n  network: unix\n  unix_socket: /run/app.sock\n  unix_socket_mode: \"rw\"\n",
+	}
+	for field, yaml := range tests {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, field)
+	}
+
+	cfg, err := loadYAML(t, validYAML("http:\n  network: unix\n  unix_socket: /run/app.sock\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network, addr := cfg.HTTP.ListenAddress(); network != "unix" || addr != "/run/app.sock" {
+		t.Errorf("ListenAddress = %s %s, want unix /run/app.sock", network, addr)
+	}
+	if mode, err := cfg.HTTP.ParseUnixSocketMode(); err != nil || mode != 0o660 {
+		t.Errorf("default socket mode = %v, %v, want 0660", mode, err)
+	}
+
+	cfg, err = loadYAML(t, validYAML(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network, addr := cfg.HTTP.ListenAddress(); network != "tcp" || addr != "0.0.0.0:8080" {
+		t.Errorf("default ListenAddress = %s %s, want tcp 0.0.0.0:8080", network, addr)
+	}
+}
+
+func TestValidateRequestIDHeader(t *testing.T) {
+	for _, header := range []string{"\"\"", "\"X Request\"", "\"X-Request-ID:\""} {
+		_, err := loadYAML(t, validYAML("http:\n  request_id:\n    header: "+header+"\n"))
+		assertInvalid(t, err, "http.request_id.header")
+	}
+
+	cfg, err := loadYAML(t, validYAML(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.RequestID.Header != "X-Request-ID" || cfg.HTTP.RequestID.Trust {
+		t.Errorf("default request ID = %+v, want an untrusted X-Request-ID", cfg.HTTP.RequestID)
+	}
+}
+
+func TestValidateHealthBreaker(t *testing.T) {
+	tests := map[string]string{
+		"health.breaker.threshold": "health:\n  breaker:\n    threshold: -1\n",
+		"health.breaker.cooldown":  "health:\n  breaker:\n    threshold: 3\n    cooldown: 0s\n",
+	}
+	for field, yaml := range tests {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, field)
+	}
+
+	// A zero threshold disables the breaker, so no cooldown is needed
+	if _, err := loadYAML(t, validYAML("health:\n  breaker:\n    threshold: 0\n    cooldown: 0s\n")); err != nil {
+		t.Errorf("disabled breaker rejected: %v", err)
+	}
+}
+
+func TestValidateSelfCheckNeedsHeartbeat(t *testing.T) {
+	_, err := loadYAML(t, validYAML("http:\n  middleware:\n    heartbeat: false\nhealth:\n  self_check: true\n"))
+	assertInvalid(t, err, "health.self_check")
+
+	cfg, err := loadYAML(t, validYAML("http:\n  middleware:\n    heartbeat: false\nhealth:\n  self_check: false\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mw := cfg.HTTP.Middleware
+	if !mw.CORS || !mw.RequestID || !mw.RealIP || !mw.AccessLog || mw.Heartbeat {
+		t.Errorf("middleware = %+v, want only the heartbeat disabled", mw)
+	}
+}
+
+func TestValidateRedisTLS(t *testing.T) {
+	const production = "app:\n  environment: production\n"
+	tests := map[string]string{
+		"redis.tls.ca_file":              production + "redis:\n  tls:\n    enabled: true\n",
+		"redis.tls.insecure_skip_verify": production + "redis:\n  tls:\n    enabled: true\n    ca_file: /etc/redis/ca.pem\n    insecure_skip_verify: true\n",
+	}
+	for field, yaml := range tests {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, field)
+	}
+
+	// Outside production the system roots are enough
+	cfg, err := loadYAML(t, validYAML("redis:\n  username: svc-reps\n  tls:\n    enabled: true\n"))
+	if err != nil {
+		t.Fatalf("TLS without a CA rejected outside production: %v", err)
+	}
+	if cfg.Redis.Username != "svc-reps" {
+		t.Errorf("Username = %q, want svc-reps", cfg.Redis.Username)
+	}
+}
+
+func TestValidatePagination(t *testing.T) {
+	for _, yaml := range []string{
+		"http:\n  pagination:\n    default_limit: 0\n",
+		"http:\n  pagination:\n    default_limit: 50\n    max_limit: 10\n",
+	} {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, "http.pagination.default_limit")
+	}
+}
+
+func TestValidateHSTSMaxAge(t *testing.T) {
+	_, err := loadYAML(t, validYAML("http:\n  hsts:\n    enabled: true\n    max_age: 0s\n"))
+	assertInvalid(t, err, "http.hsts.max_age")
+}
+
+func TestValidateUpgradeTimeout(t *testing.T) {
+	_, err := loadYAML(t, validYAML("app:\n  shutdown:\n    upgrade_timeout: 0s\n"))
+	assertInvalid(t, err, "app.shutdown.upgrade_timeout")
+}
+
+func TestValidateDatabaseReconnect(t *testing.T) {
+	tests := map[string]string{
+		"database.reconnect.interval": "database:\n  reconnect:\n    failure_threshold: 0\n",
+		"database.reconnect.backoff":  "database:\n  reconnect:\n    backoff: 10s\n    max_backoff: 1s\n",
+	}
+	for field, yaml := range tests {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, field)
+	}
+
+	// Disabled reconnects are not validated
+	if _, err := loadYAML(t, validYAML("database:\n  reconnect:\n    enabled: false\n    failure_threshold: 0\n")); err != nil {
+		t.Errorf("disabled reconnect rejected: %v", err)
+	}
+}
+
+func TestValidateAdminListener(t *testing.T) {
+	for _, yaml := range []string{
+		"http:\n  admin:\n    enabled: true\n    port: 0\n",
+		"http:\n  port: 8080\n  admin:\n    enabled: true\n    port: 8080\n",
+	} {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, "http.admin.port")
+	}
+}
+
+func TestValidatePreStopDelay(t *testing.T) {
+	_, err := loadYAML(t, validYAML("app:\n  shutdown:\n    pre_stop_delay: -1s\n"))
+	assertInvalid(t, err, "app.shutdown.pre_stop_delay")
+}
+
+func TestValidateAPIKeys(t *testing.T) {
+	tests := map[string]string{
+		"api_keys.header":             "api_keys:\n  header: \"X-API Key\"\n",
+		"api_keys.bcrypt_cost":        "api_keys:\n  bcrypt_cost: 3\n",
+		"api_keys.last_used_interval": "api_keys:\n  last_used_interval: -1s\n",
+	}
+	for field, yaml := range tests {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, field)
+	}
+}
+
+func TestValidateMetricsSampler(t *testing.T) {
+	_, err := loadYAML(t, validYAML("metrics:\n  sampler:\n    interval: -1s\n"))
+	assertInvalid(t, err, "metrics.sampler.interval")
+}
+
+func TestValidateConcurrencyLimit(t *testing.T) {
+	tests := map[string]string{
+		"http.max_concurrent_requests": "http:\n  max_concurrent_requests: -1\n",
+		"http.concurrency_wait":        "http:\n  concurrency_wait: -1s\n",
+	}
+	for field, yaml := range tests {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, field)
+	}
+}
+
+func TestValidateBodyLog(t *testing.T) {
+	tests := map[string]string{
+		"http.body_log.enabled":   "app:\n  environment: production\nhttp:\n  body_log:\n    enabled: true\n",
+		"http.body_log.max_bytes": "http:\n  body_log:\n    enabled: true\n    max_bytes: 0\n",
+	}
+	for field, yaml := range tests {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, field)
+	}
+}
+
+func TestValidatePanicBudget(t *testing.T) {
+	tests := map[string]string{
+		"app.panic_budget.max_panics": "app:\n  panic_budget:\n    enabled: true\n    max_panics: 0\n",
+		"app.panic_budget.window":     "app:\n  panic_budget:\n    enabled: true\n    window: 0s\n",
+	}
+	for field, yaml := range tests {
+		_, err := loadYAML(t, validYAML(yaml))
+		assertInvalid(t, err, field)
+	}
+}
+
+func TestValidateSequenceTimezone(t *testing.T) {
+	_, err := loadYAML(t, validYAML("sequence:\n  timezone: Mars/Olympus\n"))
+	assertInvalid(t, err, "sequence.timezone")
+}
+
+func TestValidateMiddlewareOrder(t *testing.T) {
+	// without returns the default order without name
+	without := func(name string) []string {
+		return slices.DeleteFunc(slices.Clone(MiddlewareNames), func(n string) bool { return n == name })
+	}
+	swapped := slices.Clone(MiddlewareNames)
+	hsts, realIP := slices.Index(swapped, "hsts"), slices.Index(swapped, "real_ip")
+	swapped[hsts], swapped[realIP] = swapped[realIP], swapped[hsts]
+
+	tests := map[string][]string{
+		`unknown middleware "gzip"`:      append(slices.Clone(MiddlewareNames), "gzip"),
+		`duplicate middleware "cors"`:    append(slices.Clone(MiddlewareNames), "cors"),
+		`missing middleware "recoverer"`: without("recoverer"),
+		`duplicate middleware "auth"`:    append(slices.Clone(MiddlewareNames), "auth", "auth"),
+		`not "cors"`:                     slices.Insert(slices.Clone(MiddlewareNames), slices.Index(MiddlewareNames, "cors"), "auth"),
+		"must list hsts before real_ip":  swapped,
+	}
+	for want, order := range tests {
+		_, err := loadYAML(t, validYAML("http:\n  middleware:\n    order: ["+strings.Join(order, ", ")+"]\n"))
+		assertInvalid(t, err, "http.middleware.order")
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+
+	// auth is optional, and may come last or ahead of rate limiting
+	rateLimit := slices.Index(MiddlewareNames, "rate_limit")
+	for _, order := range [][]string{
+		append(slices.Clone(MiddlewareNames), "auth"),
+		slices.Insert(slices.Clone(MiddlewareNames), rateLimit, "auth"),
+	} {
+		if _, err := loadYAML(t, validYAML("http:\n  middleware:\n    order: ["+strings.Join(order, ", ")+"]\n")); err != nil {
+			t.Errorf("order %v rejected: %v", order, err)
+		}
+	}
+}
+
+func TestLoadFromPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	overlay := filepath.Join(dir, "config.staging.yaml")
+	writeFile(t, base, validYAML("app:\n  name: base\n  version: 1.1.0\n  environment: staging\nhttp:\n  port: 9000\n"))
+	writeFile(t, overlay, "app:\n  name: staging\n  version: 1.2.0\n")
+
+	// The environment file is derived from app.environment next to the base file
+	cfg, err := LoadFrom(LoadOptions{BaseFile: base, Env: map[string]string{
+		envPrefix + "APP_NAME":                 "from-env",
+		envPrefix + "DATABASE_MAX_OPEN_CONNS":  "7",
+		envPrefix + "HTTP_READ_HEADER_TIMEOUT": "3s",
+		envPrefix + "HTTP_RATE_LIMIT_BURST":    "11",
+		"OTHER_APP_VERSION":                    "ignored",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		field, got, want string
+	}{
+		{"app.name (env var over env file)", cfg.App.Name, "from-env"},
+		{"app.version (env file over base file)", cfg.App.Version, "1.2.0"},
+		{"http.port (base file over default)", strconv.Itoa(cfg.HTTP.Port), "9000"},
+		{"http.host (default)", cfg.HTTP.Host, "0.0.0.0"},
+		{"database.max_open_conns (env var with underscores)", strconv.Itoa(cfg.Database.MaxOpenConns), "7"},
+		{"http.read_header_timeout (env var with underscores)", cfg.HTTP.ReadHeaderTimeout.String(), "3s"},
+		{"http.rate_limit.burst (env var with underscores)", strconv.Itoa(cfg.HTTP.RateLimit.Burst), "11"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadFromEnvironmentSelectsEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	writeFile(t, base, validYAML(""))
+	writeFile(t, filepath.Join(dir, "config.production.yaml"), "app:\n  debug: false\n")
+
+	cfg, err := LoadFrom(LoadOptions{BaseFile: base, Env: map[string]string{envPrefix + "APP_ENVIRONMENT": "production"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.App.Environment != "production" || cfg.App.Debug {
+		t.Errorf("environment = %s, debug = %v, want the production file applied", cfg.App.Environment, cfg.App.Debug)
+	}
+
+	// An explicit EnvFile wins over the derived one, and missing files are skipped
+	cfg, err = LoadFrom(LoadOptions{BaseFile: base, EnvFile: filepath.Join(dir, "missing.yaml"),
+		Env: map[string]string{envPrefix + "APP_ENVIRONMENT": "production"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.App.Debug {
+		t.Error("the production file was read despite an explicit EnvFile")
+	}
+}
+
+// writeFile writes content to path, failing the test on error
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// captureLogs sends the default slog logger to a buffer at debug level for the rest of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestMissingConfigFilesAreNotWarnings(t *testing.T) {
+	logs := captureLogs(t)
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "secrets.yaml")
+	writeFile(t, envFile, validYAML(""))
+	opts := LoadOptions{BaseFile: filepath.Join(dir, "config.yaml"), EnvFile: envFile, Env: map[string]string{}}
+
+	cfg, err := LoadFrom(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Port != 8080 {
+		t.Errorf("port = %d, want the default", cfg.HTTP.Port)
+	}
+	if strings.Contains(logs.String(), "level=WARN") {
+		t.Errorf("a missing file logged a warning:\n%s", logs)
+	}
+	if !strings.Contains(logs.String(), "level=DEBUG msg=\"Config file not found, skipping\" kind=base") {
+		t.Errorf("want a debug entry for the missing base file:\n%s", logs)
+	}
+
+	SilentMissingFiles = true
+	t.Cleanup(func() { SilentMissingFiles = false })
+	logs.Reset()
+	if _, err := LoadFrom(opts); err != nil {
+		t.Fatal(err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("SilentMissingFiles still logged:\n%s", logs)
+	}
+}
+
+func TestMalformedConfigFileIsWarned(t *testing.T) {
+	logs := captureLogs(t)
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	writeFile(t, base, validYAML(""))
+	writeFile(t, filepath.Join(dir, "config.development.yaml"), "http: [unterminated\n")
+
+	if _, err := LoadFrom(LoadOptions{BaseFile: base, Env: map[string]string{}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logs.String(), "level=WARN msg=\"Could not load config file\" kind=environment") {
+		t.Errorf("malformed file was not warned about:\n%s", logs)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg, err := loadYAML(t, validYAML("database:\n  password: db-pass\nredis:\n  password: \"\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := cfg.Redacted()
+	if r.Database.Password != redactedValue || r.Auth.JWTSecret != redactedValue {
+		t.Errorf("secrets not redacted: %q, %q", r.Database.Password, r.Auth.JWTSecret)
+	}
+	if r.Redis.Password != "" {
+		t.Errorf("empty redis password redacted to %q, want it left empty", r.Redis.Password)
+	}
+	if cfg.Database.Password != "db-pass" || cfg.Auth.JWTSecret != testSecret {
+		t.Error("Redacted modified the original config")
+	}
+}
@@ -0,0 +1,64 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate checks the allowed origins. Each entry is "*", an exact origin such as
+// https://app.example.com, or a subdomain wildcard such as https://*.example.com
+// (the scheme may be omitted to allow any). Credentials cannot be combined with "*"
+func (c CORSConfig) Validate() error {
+	if c.MaxAge < 0 {
+		return fmt.Errorf("http.cors.max_age must not be negative")
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("http.cors.allowed_origins must not contain \"*\" when http.cors.allow_credentials is enabled")
+			}
+			continue
+		}
+
+		scheme, host := splitOrigin(origin)
+		rest, wildcard := strings.CutPrefix(host, "*.")
+		if rest == "" || strings.Contains(rest, "*") || (!wildcard && scheme == "") {
+			return fmt.Errorf("http.cors.allowed_origins entry %q must be \"*\", an origin or a *.domain wildcard", origin)
+		}
+	}
+	return nil
+}
+
+// AllowsOrigin reports whether the request origin matches one of the allowed origins
+func (c CORSConfig) AllowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	scheme, host := splitOrigin(origin)
+
+	for _, allowed := range c.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		allowedScheme, allowedHost := splitOrigin(allowed)
+		suffix, ok := strings.CutPrefix(allowedHost, "*")
+		if !ok || (allowedScheme != "" && allowedScheme != scheme) {
+			continue
+		}
+		// The wildcard stands for one or more whole labels, so the apex domain
+		// and look-alikes such as evilexample.com do not match
+		if label, found := strings.CutSuffix(host, suffix); found && label != "" && !strings.ContainsAny(label, "/:") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitOrigin splits an origin into its scheme (empty when absent) and host[:port]
+func splitOrigin(origin string) (string, string) {
+	if scheme, host, ok := strings.Cut(origin, "://"); ok {
+		return scheme, host
+	}
+	return "", origin
+}
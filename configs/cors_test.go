@@ -0,0 +1,72 @@
+package configs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateCORS(t *testing.T) {
+	tests := []struct {
+		name    string
+		cors    CORSConfig
+		wantErr bool
+	}{
+		{"wildcard without credentials", CORSConfig{AllowedOrigins: []string{"*"}}, false},
+		{"wildcard with credentials", CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, true},
+		{"allowlist with credentials", CORSConfig{AllowedOrigins: []string{"https://crm.example.com", "https://*.example.com", "*.example.org"}, AllowCredentials: true}, false},
+		{"origin without scheme", CORSConfig{AllowedOrigins: []string{"crm.example.com"}}, true},
+		{"wildcard in the middle", CORSConfig{AllowedOrigins: []string{"https://crm.*.example.com"}}, true},
+		{"bare subdomain wildcard", CORSConfig{AllowedOrigins: []string{"https://*."}}, true},
+		{"negative max age", CORSConfig{MaxAge: -time.Second}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cors.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsCredentialedWildcard(t *testing.T) {
+	_, err := loadYAML(t, validYAML("http:\n  cors:\n    allowed_origins: [\"*\"]\n    allow_credentials: true\n"))
+	assertInvalid(t, err, "http.cors.allowed_origins")
+
+	cfg, err := loadYAML(t, validYAML("http:\n  cors:\n    allowed_origins: [\"https://*.example.com\"]\n    allow_credentials: true\n    max_age: 10m\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.HTTP.CORS.AllowCredentials || cfg.HTTP.CORS.MaxAge != 10*time.Minute {
+		t.Errorf("cors = %+v, want credentials and a 10m max age", cfg.HTTP.CORS)
+	}
+}
+
+func TestAllowsOrigin(t *testing.T) {
+	cors := CORSConfig{AllowedOrigins: []string{"https://crm.example.com", "https://*.example.com", "*.example.org"}}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://crm.example.com", true},
+		{"HTTPS://CRM.Example.com", true},
+		{"https://reps.example.com", true},
+		{"https://eu.reps.example.com", true},
+		{"https://example.com", false},
+		{"https://evilexample.com", false},
+		{"https://example.com.evil.net", false},
+		{"http://reps.example.com", false},
+		{"http://reps.example.org", true},
+		{"https://reps.example.org:8443", false},
+		{"https://other.net", false},
+	}
+	for _, tt := range tests {
+		if got := cors.AllowsOrigin(tt.origin); got != tt.want {
+			t.Errorf("AllowsOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+
+	if !(CORSConfig{AllowedOrigins: []string{"*"}}).AllowsOrigin("https://anything.test") {
+		t.Error("\"*\" did not allow every origin")
+	}
+}
@@ -0,0 +1,26 @@
+package configs
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// sameSiteModes maps the accepted session.same_site strings to cookie SameSite modes
+var sameSiteModes = map[string]http.SameSite{
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+// ParseSameSite returns the configured SameSite mode for the session cookie, defaulting to Lax
+func (s SessionConfig) ParseSameSite() (http.SameSite, error) {
+	if s.SameSite == "" {
+		return http.SameSiteLaxMode, nil
+	}
+
+	mode, ok := sameSiteModes[s.SameSite]
+	if !ok {
+		return 0, fmt.Errorf("session.same_site must be one of lax, strict, none (got %q)", s.SameSite)
+	}
+	return mode, nil
+}
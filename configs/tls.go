@@ -0,0 +1,77 @@
+package configs
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// tlsVersions maps the accepted tls.min_version strings to crypto/tls constants
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsClientAuthModes maps the accepted tls.client_auth strings to crypto/tls client auth types
+var tlsClientAuthModes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// ParseMinVersion returns the configured minimum TLS version, defaulting to TLS 1.2
+func (t TLSConfig) ParseMinVersion() (uint16, error) {
+	if t.MinVersion == "" {
+		return tls.VersionTLS12, nil
+	}
+
+	version, ok := tlsVersions[t.MinVersion]
+	if !ok {
+		return 0, fmt.Errorf("tls.min_version must be one of 1.2, 1.3 (got %q)", t.MinVersion)
+	}
+	return version, nil
+}
+
+// ParseCipherSuites resolves the configured cipher suite names to their IDs.
+// It returns nil when none are configured. Suites Go considers insecure are rejected.
+func (t TLSConfig) ParseCipherSuites() ([]uint16, error) {
+	if len(t.CipherSuites) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(t.CipherSuites))
+	for _, name := range t.CipherSuites {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("tls.cipher_suites contains unknown or insecure suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ParseClientAuth returns the configured client certificate policy.
+// When unset it requires a verified client certificate if a client CA is configured, otherwise none.
+func (t TLSConfig) ParseClientAuth() (tls.ClientAuthType, error) {
+	if t.ClientAuth == "" {
+		if t.ClientCAFile != "" {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	}
+
+	mode, ok := tlsClientAuthModes[t.ClientAuth]
+	if !ok {
+		return 0, fmt.Errorf("tls.client_auth must be one of none, request, require, verify_if_given, require_and_verify (got %q)", t.ClientAuth)
+	}
+	if (mode == tls.VerifyClientCertIfGiven || mode == tls.RequireAndVerifyClientCert) && t.ClientCAFile == "" {
+		return 0, fmt.Errorf("tls.client_ca_file is required when tls.client_auth is %s", t.ClientAuth)
+	}
+	return mode, nil
+}
@@ -0,0 +1,87 @@
+package configs
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestParseMinVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    uint16
+		wantErr bool
+	}{
+		{"", tls.VersionTLS12, false},
+		{"1.2", tls.VersionTLS12, false},
+		{"1.3", tls.VersionTLS13, false},
+		{"1.1", 0, true},
+		{"TLS1.3", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := TLSConfig{MinVersion: tt.version}.ParseMinVersion()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMinVersion(%q) err = %v, wantErr %v", tt.version, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMinVersion(%q) = %x, want %x", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	ids, err := TLSConfig{}.ParseCipherSuites()
+	if err != nil || ids != nil {
+		t.Fatalf("ParseCipherSuites() = %v, %v, want nil for no suites", ids, err)
+	}
+
+	ids, err = TLSConfig{CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}}.ParseCipherSuites()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 {
+		t.Errorf("ParseCipherSuites() = %v, want the AES-128-GCM suite", ids)
+	}
+
+	for _, name := range []string{"TLS_RSA_WITH_RC4_128_SHA", "NOT_A_SUITE"} {
+		if _, err := (TLSConfig{CipherSuites: []string{name}}).ParseCipherSuites(); err == nil {
+			t.Errorf("suite %s accepted", name)
+		}
+	}
+}
+
+func TestValidateTLSVersion(t *testing.T) {
+	const tlsYAML = "http:\n  tls:\n    enabled: true\n    cert_file: server.crt\n    key_file: server.key\n"
+
+	if _, err := loadYAML(t, validYAML(tlsYAML+"    min_version: \"1.3\"\n")); err != nil {
+		t.Fatalf("TLS 1.3 rejected: %v", err)
+	}
+
+	_, err := loadYAML(t, validYAML(tlsYAML+"    min_version: \"1.0\"\n"))
+	assertInvalid(t, err, "tls.min_version")
+}
+
+func TestParseClientAuth(t *testing.T) {
+	tests := []struct {
+		cfg     TLSConfig
+		want    tls.ClientAuthType
+		wantErr bool
+	}{
+		{TLSConfig{}, tls.NoClientCert, false},
+		{TLSConfig{ClientCAFile: "ca.crt"}, tls.RequireAndVerifyClientCert, false},
+		{TLSConfig{ClientCAFile: "ca.crt", ClientAuth: "verify_if_given"}, tls.VerifyClientCertIfGiven, false},
+		{TLSConfig{ClientAuth: "request"}, tls.RequestClientCert, false},
+		{TLSConfig{ClientAuth: "require_and_verify"}, 0, true},
+		{TLSConfig{ClientAuth: "always"}, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.cfg.ParseClientAuth()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseClientAuth(%+v) err = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseClientAuth(%+v) = %v, want %v", tt.cfg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is a configuration value that failed validation
+type FieldError struct {
+	// Field is the dotted path of the offending key, such as http.port
+	Field  string
+	Reason string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Reason
+}
+
+// ValidationError lists every configuration value that failed validation, so they can all
+// be fixed in one pass. Match it with errors.As.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// add records a failure of field; reason is a format string for args
+func (e *ValidationError) add(field, reason string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Reason: fmt.Sprintf(reason, args...)})
+}
+
+// addErr records an error from a config parsing helper. Their messages start with the field
+// path, like every validation message.
+func (e *ValidationError) addErr(err error) {
+	field, reason, _ := strings.Cut(err.Error(), " ")
+	e.Fields = append(e.Fields, FieldError{Field: field, Reason: reason})
+}
+
+// err returns e when any field failed, or nil
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
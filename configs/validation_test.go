@@ -0,0 +1,64 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestValidateReportsEveryFailure(t *testing.T) {
+	_, err := loadYAML(t, `auth:
+  jwt_secret: short
+http:
+  port: 0
+  network: udp
+  pagination:
+    default_limit: 0
+database:
+  driver: ""
+health:
+  breaker:
+    threshold: -1
+`)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("error = %v, want a *ValidationError", err)
+	}
+
+	var fields []string
+	for _, f := range invalid.Fields {
+		if f.Reason == "" {
+			t.Errorf("%s has no reason", f.Field)
+		}
+		fields = append(fields, f.Field)
+	}
+	for _, want := range []string{
+		"auth.jwt_secret", "http.port", "http.network", "http.pagination.default_limit",
+		"database.driver", "health.breaker.threshold",
+	} {
+		if !slices.Contains(fields, want) {
+			t.Errorf("fields = %v, missing %s", fields, want)
+		}
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	var e ValidationError
+	if e.err() != nil {
+		t.Fatal("an empty ValidationError is an error")
+	}
+
+	e.add("http.port", "must be between %d and %d", 1, 65535)
+	e.addErr(fmt.Errorf("http.unix_socket_mode must be octal permissions"))
+	want := []FieldError{
+		{Field: "http.port", Reason: "must be between 1 and 65535"},
+		{Field: "http.unix_socket_mode", Reason: "must be octal permissions"},
+	}
+	if !slices.Equal(e.Fields, want) {
+		t.Errorf("Fields = %+v, want %+v", e.Fields, want)
+	}
+	if got := e.err().Error(); got != "http.port must be between 1 and 65535; http.unix_socket_mode must be octal permissions" {
+		t.Errorf("Error() = %q, want both failures joined", got)
+	}
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/apiversion"
+	"github.com/rixtrayker/medical-rep/internal/platform/metrics"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// accessLog logs every request once it completes. The route field is the matched chi pattern,
+// the same label used by the request metrics, so log lines can be grouped per handler; the
+// version field is the API version of the matched route, if any. The query and the headers
+// configured under http.access_log are included with sensitive values masked.
+func (a *App) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r, version := apiversion.Track(r)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		fields := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", metrics.RouteLabel(r),
+			"version", version(),
+			"status", status,
+			"bytes_written", ww.BytesWritten(),
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr,
+			"request_id", requestid.FromContext(r.Context()),
+		}
+		if a.config.HTTP.AccessLog.Query && r.URL.RawQuery != "" {
+			fields = append(fields, "query", a.redactor.query(r.URL))
+		}
+		if headers := a.redactor.loggedHeaders(r.Header); headers != nil {
+			fields = append(fields, "headers", headers)
+		}
+		a.logger.Info("Request", fields...)
+	})
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/metrics"
+)
+
+func TestAccessLogAndMetricsUseRoutePattern(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	log, logs := logtest.New(t)
+	a.logger = log
+	a.redactor = newRedactor(a.config.HTTP.AccessLog)
+	m := metrics.New()
+
+	r := chi.NewRouter()
+	r.Use(a.accessLog, m.Middleware)
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/reps/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	for _, path := range []string{"/api/v1/reps/17", "/api/v1/reps/42", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logs.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("%d access log entries, want 3", len(entries))
+	}
+	for i, want := range []string{"/api/v1/reps/{id}", "/api/v1/reps/{id}", "unmatched"} {
+		if entries[i]["route"] != want {
+			t.Errorf("%s logged with route %v, want %s", entries[i]["path"], entries[i]["route"], want)
+		}
+	}
+	if entries[0]["path"] != "/api/v1/reps/17" {
+		t.Errorf("path = %v, want the concrete path next to the route", entries[0]["path"])
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `path="/api/v1/reps/{id}",status="200"`) || !strings.Contains(body, `path="unmatched",status="404"`) {
+		t.Errorf("metrics are not labelled by route pattern:\n%s", body)
+	}
+	if strings.Contains(body, "/api/v1/reps/17") {
+		t.Error("concrete ID used as a metric label")
+	}
+}
+
+func TestAccessLogAndMetricsIncludeAPIVersion(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	log, logs := logtest.New(t)
+	a.logger = log
+	a.redactor = newRedactor(a.config.HTTP.AccessLog)
+	m := metrics.New()
+
+	r := chi.NewRouter()
+	r.Use(a.accessLog, m.Middleware)
+	for _, v := range []string{"v1", "v2"} {
+		a.mountVersion(r, v, func(r chi.Router) {
+			r.Get("/reps", func(w http.ResponseWriter, r *http.Request) {})
+		})
+	}
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {})
+	for _, path := range []string{"/v1/reps", "/v2/reps", "/health"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logs.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("%d access log entries, want 3", len(entries))
+	}
+	for i, want := range []string{"v1", "v2", ""} {
+		if entries[i]["version"] != want {
+			t.Errorf("%s logged with version %v, want %q", entries[i]["path"], entries[i]["version"], want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`path="/v1/reps",status="200",version="v1"`,
+		`path="/v2/reps",status="200",version="v2"`,
+		`path="/health",status="200",version="none"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s:\n%s", want, body)
+		}
+	}
+}
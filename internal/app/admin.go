@@ -0,0 +1,59 @@
+package app
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/rixtrayker/medical-rep/internal/app/auth"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// mountAdminRoutes mounts the operator routes: metrics, the debug endpoints when enabled and /admin
+func (a *App) mountAdminRoutes(r chi.Router) {
+	// Metrics endpoint
+	if a.metrics != nil {
+		r.Handle(a.config.Metrics.Path, a.metrics.Handler())
+	}
+
+	// Profiling and runtime stats endpoints, only when explicitly enabled
+	if a.config.App.Debug || a.config.Metrics.PProfEnabled {
+		r.Group(func(r chi.Router) {
+			r.Use(a.auth.Middleware)
+			r.Get("/debug/metrics", a.debugMetricsHandler)
+			r.Get("/debug/log-level", a.getLogLevelHandler)
+			r.Put("/debug/log-level", a.setLogLevelHandler)
+			r.Mount("/debug", middleware.Profiler())
+		})
+	}
+
+	// Admin routes; handlers of server-rendered pages read the session with session.FromContext
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(a.auth.Middleware, auth.RequireRole("admin"), a.audited)
+		r.Use(a.sessions.Middleware(a.logger))
+		r.Get("/maintenance", a.getMaintenanceHandler)
+		r.Post("/maintenance", a.setMaintenanceHandler)
+		r.Get("/flags", a.listFlagsHandler)
+		r.Put("/flags/{name}", a.setFlagHandler)
+		r.Delete("/flags/{name}", a.deleteFlagHandler)
+		r.Post("/upgrade", a.upgradeHandler)
+		r.Post("/api-keys", a.createAPIKeyHandler)
+		r.Get("/api-keys/{id}", a.getAPIKeyHandler)
+		r.Delete("/api-keys/{id}", a.revokeAPIKeyHandler)
+	})
+}
+
+// newAdminRouter builds the router of the admin listener. It only serves the operator routes,
+// with the request ID, access log and panic recovery of the public router but none of its
+// client-facing middleware (CORS, rate limiting, compression).
+func (a *App) newAdminRouter() chi.Router {
+	r := chi.NewRouter()
+	r.NotFound(notFoundHandler)
+	r.MethodNotAllowed(methodNotAllowedHandler(r))
+
+	r.Use(requestid.Middleware(a.config.HTTP.RequestID.Header, a.config.HTTP.RequestID.Trust))
+	r.Use(a.accessLog)
+	r.Use(a.recoverer)
+
+	a.mountAdminRoutes(r)
+	return r
+}
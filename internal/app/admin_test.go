@@ -0,0 +1,163 @@
+package app
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+func TestPprofRoutes(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		a, _ := newRoutedApp(t, testConfig(t, "app:\n  debug: false\n"))
+		rec := serveAs(a.router, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil), bearer(t, a))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404 with pprof disabled", rec.Code)
+		}
+	})
+
+	for name, yaml := range map[string]string{
+		"debug mode": "app:\n  debug: true\n",
+		"pprof flag": "metrics:\n  pprof_enabled: true\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			a, _ := newRoutedApp(t, testConfig(t, yaml))
+
+			rec := serveAs(a.router, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil), bearer(t, a))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+
+			rec = serveAs(a.router, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil), "")
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status without a token = %d, want 401", rec.Code)
+			}
+		})
+	}
+}
+
+func TestLogLevelEndpoint(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "app:\n  debug: true\n"))
+	log, logs := logtest.New(t)
+	a.logger = log
+	if err := a.logger.SetLevel("info"); err != nil {
+		t.Fatal(err)
+	}
+	token := bearer(t, a)
+
+	setLevel := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/debug/log-level", strings.NewReader(body))
+		return serveAs(a.router, req, token)
+	}
+
+	rec := serveAs(a.router, httptest.NewRequest(http.MethodGet, "/debug/log-level", nil), token)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"level":"info"`) {
+		t.Fatalf("GET = %d %s, want info", rec.Code, rec.Body)
+	}
+
+	if rec := setLevel(`{"level":"debug"}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT debug = %d %s", rec.Code, rec.Body)
+	}
+	a.logger.Debug("after raising")
+	if _, ok := logs.Find("after raising"); !ok {
+		t.Error("debug entry suppressed after switching to debug")
+	}
+	if entry, ok := logs.Find("Log level changed"); !ok || entry["from"] != "info" || entry["to"] != "debug" {
+		t.Errorf("level change logged as %v, want info to debug", entry)
+	}
+
+	if rec := setLevel(`{"level":"warn"}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT warn = %d %s", rec.Code, rec.Body)
+	}
+	a.logger.Debug("after lowering")
+	if _, ok := logs.Find("after lowering"); ok {
+		t.Error("debug entry emitted after switching to warn")
+	}
+
+	for body, code := range map[string]int{
+		`{"level":"verbose"}`: http.StatusBadRequest,
+		`{}`:                  http.StatusBadRequest,
+		`not json`:            http.StatusBadRequest,
+	} {
+		if rec := setLevel(body); rec.Code != code {
+			t.Errorf("PUT %s = %d, want %d", body, rec.Code, code)
+		}
+	}
+	if a.logger.LevelName() != "warn" {
+		t.Errorf("level = %s after rejected changes, want warn", a.logger.LevelName())
+	}
+
+	rec = serveAs(a.router, httptest.NewRequest(http.MethodPut, "/debug/log-level", strings.NewReader(`{"level":"debug"}`)), "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("PUT without a token = %d, want 401", rec.Code)
+	}
+}
+
+func TestAdminListenerServesOperatorRoutes(t *testing.T) {
+	cfg := runConfig(t, "app:\n  debug: true\nhttp:\n  admin:\n    enabled: true\n    host: 127.0.0.1\n    port: 9090\n")
+	// Validation requires a fixed port; 0 picks a free one
+	cfg.HTTP.Admin.Port = 0
+	a, _ := newRoutedApp(t, cfg)
+	log, logs := logtest.New(t)
+	a.logger = log
+
+	public, done := runApp(t, a)
+	var admin net.Listener
+	select {
+	case admin = <-a.upgrader.(*testUpgrader).listeners:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the admin listener was not opened")
+	}
+
+	fetch := func(ln net.Listener, path, token string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, "http://"+ln.Addr().String()+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	admins, reps := bearer(t, a, "admin"), bearer(t, a)
+	tests := []struct {
+		path         string
+		token        string
+		public, admn int
+	}{
+		{"/metrics", "", http.StatusNotFound, http.StatusOK},
+		{"/debug/log-level", reps, http.StatusNotFound, http.StatusOK},
+		{"/admin/flags", admins, http.StatusNotFound, http.StatusOK},
+		{"/liveness", "", http.StatusOK, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if got := fetch(public, tt.path, tt.token); got != tt.public {
+			t.Errorf("public %s = %d, want %d", tt.path, got, tt.public)
+		}
+		if got := fetch(admin, tt.path, tt.token); got != tt.admn {
+			t.Errorf("admin %s = %d, want %d", tt.path, got, tt.admn)
+		}
+	}
+
+	a.server.Close()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	if _, err := net.DialTimeout("tcp", admin.Addr().String(), time.Second); err == nil {
+		t.Error("the admin listener is still accepting after shutdown")
+	}
+	if _, ok := logs.Find("Admin server shutdown error"); ok {
+		t.Error("the admin server did not shut down gracefully")
+	}
+}
@@ -0,0 +1,246 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+// prefix marks the keys issued by this service so they are recognizable in configs and scanners
+const prefix = "mrk_"
+
+const (
+	keyPrefix      = "apikey:"
+	lastUsedSuffix = ":last_used"
+)
+
+var (
+	// ErrInvalidKey is returned for malformed, unknown or mismatching keys
+	ErrInvalidKey = errors.New("invalid api key")
+	// ErrRevoked is returned when authenticating with a revoked key
+	ErrRevoked = errors.New("api key revoked")
+	// ErrNotFound is returned when a key ID does not exist
+	ErrNotFound = errors.New("api key not found")
+)
+
+// Key describes an API key. The secret itself is never stored, only its bcrypt hash.
+type Key struct {
+	ID         string     `json:"id"`
+	Owner      string     `json:"owner"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// Revoked reports whether the key has been revoked
+func (k *Key) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// HasScope reports whether the key was granted scope
+func (k *Key) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// record is the stored form of a key
+type record struct {
+	Key
+	Hash string `json:"hash"`
+}
+
+// Manager issues, verifies and revokes API keys stored in Redis. Last use is recorded under a
+// separate key, at most once per interval per key, so busy keys do not write on every request.
+type Manager struct {
+	client           *redis.Client
+	header           string
+	cost             int
+	lastUsedInterval time.Duration
+	now              func() time.Time
+
+	mu       sync.Mutex
+	lastUsed map[string]time.Time
+}
+
+// NewManager creates an API key manager; cfg is expected to have passed configs validation
+func NewManager(cfg configs.APIKeyConfig, client *redis.Client) *Manager {
+	return &Manager{
+		client:           client,
+		header:           cfg.Header,
+		cost:             cfg.BCryptCost,
+		lastUsedInterval: cfg.LastUsedInterval,
+		now:              time.Now,
+		lastUsed:         make(map[string]time.Time),
+	}
+}
+
+// Generate creates a key for owner with the given scopes and returns it in full. The returned
+// string is the only copy of the secret: it cannot be recovered later.
+func (m *Manager) Generate(ctx context.Context, owner string, scopes []string) (string, *Key, error) {
+	id, err := randomString(8, hex.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), m.cost)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to hash api key: %w", err)
+	}
+
+	rec := record{
+		Key: Key{
+			ID:        id,
+			Owner:     owner,
+			Scopes:    scopes,
+			CreatedAt: m.now().UTC(),
+		},
+		Hash: string(hash),
+	}
+	if err := m.save(ctx, &rec); err != nil {
+		return "", nil, err
+	}
+
+	return prefix + id + "." + secret, &rec.Key, nil
+}
+
+// Authenticate verifies a full key and returns its metadata, recording the use
+func (m *Manager) Authenticate(ctx context.Context, raw string) (*Key, error) {
+	id, secret, ok := parse(raw)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	rec, err := m.load(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(rec.Hash), []byte(secret)); err != nil {
+		return nil, ErrInvalidKey
+	}
+	if rec.Revoked() {
+		return nil, ErrRevoked
+	}
+
+	m.touch(ctx, id)
+	return &rec.Key, nil
+}
+
+// Get returns the metadata of the key with the given ID, including when it was last used
+func (m *Manager) Get(ctx context.Context, id string) (*Key, error) {
+	rec, err := m.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := m.client.Get(ctx, keyPrefix+id+lastUsedSuffix)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		return nil, fmt.Errorf("failed to load api key last use: %w", err)
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		lastUsed := time.Unix(unix, 0).UTC()
+		rec.LastUsedAt = &lastUsed
+	}
+	return &rec.Key, nil
+}
+
+// Revoke permanently disables the key with the given ID. The record is kept for auditing.
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	rec, err := m.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rec.Revoked() {
+		return nil
+	}
+
+	now := m.now().UTC()
+	rec.RevokedAt = &now
+	return m.save(ctx, rec)
+}
+
+// touch records the key's last use unless it was recorded less than an interval ago.
+// Failures are ignored: last use is informational and must not fail the request.
+func (m *Manager) touch(ctx context.Context, id string) {
+	now := m.now()
+
+	m.mu.Lock()
+	if last, ok := m.lastUsed[id]; ok && now.Sub(last) < m.lastUsedInterval {
+		m.mu.Unlock()
+		return
+	}
+	m.lastUsed[id] = now
+	m.mu.Unlock()
+
+	m.client.Set(ctx, keyPrefix+id+lastUsedSuffix, strconv.FormatInt(now.Unix(), 10), 0)
+}
+
+func (m *Manager) load(ctx context.Context, id string) (*record, error) {
+	value, err := m.client.Get(ctx, keyPrefix+id)
+	if errors.Is(err, redis.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load api key: %w", err)
+	}
+
+	var rec record
+	if err := json.Unmarshal([]byte(value), &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode api key: %w", err)
+	}
+	return &rec, nil
+}
+
+func (m *Manager) save(ctx context.Context, rec *record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode api key: %w", err)
+	}
+	if err := m.client.Set(ctx, keyPrefix+rec.ID, data, 0); err != nil {
+		return fmt.Errorf("failed to store api key: %w", err)
+	}
+	return nil
+}
+
+// parse splits a full key into its ID and secret
+func parse(raw string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(raw, prefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok := strings.Cut(rest, ".")
+	if !ok || id == "" || secret == "" || strings.ContainsAny(id, ":") {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+// randomString returns n random bytes in the given encoding
+func randomString(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return encode(b), nil
+}
@@ -0,0 +1,167 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+// newTestManager returns a manager backed by miniredis, hashing with the cheapest bcrypt cost
+func newTestManager(t *testing.T) (*Manager, *miniredis.Miniredis) {
+	t.Helper()
+	client, server := redistest.New(t)
+	m := NewManager(configs.APIKeyConfig{Header: "X-API-Key", BCryptCost: 4, LastUsedInterval: time.Minute}, client)
+	return m, server
+}
+
+func TestGenerateAndAuthenticate(t *testing.T) {
+	m, server := newTestManager(t)
+	ctx := context.Background()
+
+	raw, key, err := m.Generate(ctx, "acme", []string{"visits:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw, prefix+key.ID+".") {
+		t.Fatalf("key = %q, want %s<id>.<secret>", raw, prefix)
+	}
+	if key.Owner != "acme" || !key.HasScope("visits:read") || key.CreatedAt.IsZero() || key.Revoked() {
+		t.Errorf("metadata = %+v, want an active key for acme with its scopes", key)
+	}
+
+	// Only the hash of the secret is stored
+	stored, err := server.Get(keyPrefix + key.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, secret, _ := strings.Cut(raw, "."); strings.Contains(stored, secret) {
+		t.Errorf("stored record %s contains the secret", stored)
+	}
+
+	got, err := m.Authenticate(ctx, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != key.ID || got.Owner != "acme" || !got.HasScope("visits:read") {
+		t.Errorf("Authenticate() = %+v, want the generated key", got)
+	}
+}
+
+func TestAuthenticateRejectsInvalidKeys(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+
+	raw, key, err := m.Generate(ctx, "acme", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"empty":          "",
+		"no prefix":      strings.TrimPrefix(raw, prefix),
+		"no secret":      prefix + key.ID + ".",
+		"unknown id":     prefix + "0000000000000000.secret",
+		"wrong secret":   prefix + key.ID + ".wrong",
+		"colon in id":    prefix + key.ID + ":last_used.secret",
+		"no separator":   prefix + key.ID,
+		"other key form": "Bearer " + raw,
+	}
+	for name, raw := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := m.Authenticate(ctx, raw); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Authenticate(%q) = %v, want ErrInvalidKey", raw, err)
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+
+	raw, key, err := m.Generate(ctx, "acme", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Revoke(ctx, key.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Authenticate(ctx, raw); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Authenticate() = %v, want ErrRevoked", err)
+	}
+	// The record is kept, and revoking again keeps the first revocation time
+	revoked, err := m.Get(ctx, key.ID)
+	if err != nil || !revoked.Revoked() {
+		t.Fatalf("Get() = %+v, %v, want the revoked key", revoked, err)
+	}
+	if err := m.Revoke(ctx, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := m.Get(ctx, key.ID); !again.RevokedAt.Equal(*revoked.RevokedAt) {
+		t.Errorf("revoked at %v after revoking again, want %v", again.RevokedAt, revoked.RevokedAt)
+	}
+
+	if err := m.Revoke(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Revoke(missing) = %v, want ErrNotFound", err)
+	}
+	if _, err := m.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLastUsedTracking(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	m.now = func() time.Time { return now }
+
+	raw, key, err := m.Generate(ctx, "acme", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Get(ctx, key.ID); got.LastUsedAt != nil {
+		t.Fatalf("last used = %v before any use, want none", got.LastUsedAt)
+	}
+
+	lastUsed := func() time.Time {
+		t.Helper()
+		got, err := m.Get(ctx, key.ID)
+		if err != nil || got.LastUsedAt == nil {
+			t.Fatalf("Get() = %+v, %v, want a last use", got, err)
+		}
+		return *got.LastUsedAt
+	}
+
+	if _, err := m.Authenticate(ctx, raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastUsed(); !got.Equal(now) {
+		t.Errorf("last used = %v, want %v", got, now)
+	}
+
+	// Uses within the interval are not written again
+	first := now
+	now = now.Add(30 * time.Second)
+	if _, err := m.Authenticate(ctx, raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastUsed(); !got.Equal(first) {
+		t.Errorf("last used = %v within the interval, want %v", got, first)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := m.Authenticate(ctx, raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastUsed(); !got.Equal(now) {
+		t.Errorf("last used = %v after the interval, want %v", got, now)
+	}
+}
@@ -0,0 +1,70 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+type contextKey struct{}
+
+// Middleware rejects requests without a valid, unrevoked key in the configured header and
+// stores the key, with its scopes, in the request context
+func (m *Manager) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw := r.Header.Get(m.header)
+		if raw == "" {
+			respond.Error(w, http.StatusUnauthorized, "unauthorized", "missing api key")
+			return
+		}
+
+		key, err := m.Authenticate(r.Context(), raw)
+		switch {
+		case errors.Is(err, ErrRevoked):
+			respond.Error(w, http.StatusUnauthorized, "unauthorized", "api key revoked")
+			return
+		case errors.Is(err, ErrInvalidKey):
+			respond.Error(w, http.StatusUnauthorized, "unauthorized", "invalid api key")
+			return
+		case err != nil:
+			respond.Error(w, http.StatusServiceUnavailable, "unavailable", "authentication unavailable")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), key)))
+	})
+}
+
+// RequireScope allows requests whose API key has at least one of the scopes; it must run after Middleware
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := FromContext(r.Context())
+			if !ok {
+				respond.Error(w, http.StatusUnauthorized, "unauthorized", "missing api key")
+				return
+			}
+
+			for _, scope := range scopes {
+				if key.HasScope(scope) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			respond.Error(w, http.StatusForbidden, "forbidden", "insufficient scope")
+		})
+	}
+}
+
+// NewContext returns a copy of ctx carrying the key
+func NewContext(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext returns the key stored by the middleware, if any
+func FromContext(ctx context.Context) (*Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(*Key)
+	return key, ok
+}
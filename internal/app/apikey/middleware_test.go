@@ -0,0 +1,99 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveKey serves a GET request through handler with raw in the API key header, if not empty
+func serveKey(handler http.Handler, raw string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if raw != "" {
+		req.Header.Set("X-API-Key", raw)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewarePropagatesScopes(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+
+	reader, _, err := m.Generate(ctx, "acme", []string{"visits:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, _, err := m.Generate(ctx, "globex", []string{"visits:read", "visits:write"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var owner string
+	handler := m.Middleware(RequireScope("visits:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := FromContext(r.Context())
+		if !ok {
+			t.Fatal("no key in the request context")
+		}
+		owner = key.Owner
+	})))
+
+	if rec := serveKey(handler, writer); rec.Code != http.StatusOK || owner != "globex" {
+		t.Errorf("key with the scope = %d for %q, want 200 for globex", rec.Code, owner)
+	}
+	if rec := serveKey(handler, reader); rec.Code != http.StatusForbidden {
+		t.Errorf("key without the scope = %d, want 403", rec.Code)
+	}
+}
+
+func TestMiddlewareRejectsRequests(t *testing.T) {
+	m, server := newTestManager(t)
+	ctx := context.Background()
+
+	revoked, key, err := m.Generate(ctx, "acme", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Revoke(ctx, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the handler was called for a rejected request")
+	}))
+
+	tests := []struct {
+		name    string
+		raw     string
+		code    int
+		message string
+	}{
+		{"missing", "", http.StatusUnauthorized, "missing api key"},
+		{"invalid", "mrk_0000.secret", http.StatusUnauthorized, "invalid api key"},
+		{"revoked", revoked, http.StatusUnauthorized, "api key revoked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveKey(handler, tt.raw)
+			if rec.Code != tt.code || !strings.Contains(rec.Body.String(), tt.message) {
+				t.Errorf("response = %d %s, want %d %q", rec.Code, rec.Body, tt.code, tt.message)
+			}
+		})
+	}
+
+	// Redis being down is not the client's fault
+	server.SetError("LOADING")
+	defer server.SetError("")
+	if rec := serveKey(handler, revoked); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("response with Redis down = %d, want 503", rec.Code)
+	}
+}
+
+func TestRequireScopeWithoutKey(t *testing.T) {
+	handler := RequireScope("visits:read")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	if rec := serveKey(handler, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("response = %d, want 401 without a key in the context", rec.Code)
+	}
+}
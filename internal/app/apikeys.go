@@ -0,0 +1,96 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rixtrayker/medical-rep/internal/app/apikey"
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// authenticate accepts an API key from integration partners, sent in the configured header, or
+// otherwise a user's Bearer JWT. Without Redis only JWTs are accepted.
+func (a *App) authenticate(next http.Handler) http.Handler {
+	withJWT := a.auth.Middleware(next)
+	if a.redis == nil {
+		return withJWT
+	}
+	withKey := a.apiKeys.Middleware(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(a.config.APIKeys.Header) != "" {
+			withKey.ServeHTTP(w, r)
+			return
+		}
+		withJWT.ServeHTTP(w, r)
+	})
+}
+
+// createAPIKeyRequest is the body of POST /admin/api-keys
+type createAPIKeyRequest struct {
+	Owner  string   `json:"owner"`
+	Scopes []string `json:"scopes"`
+}
+
+// createAPIKeyHandler issues a key for an integration partner. The full key is only returned here.
+func (a *App) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "bad_request", "invalid request body")
+		return
+	}
+	if req.Owner == "" {
+		respond.Error(w, http.StatusBadRequest, "bad_request", "owner is required")
+		return
+	}
+
+	key, meta, err := a.apiKeys.Generate(r.Context(), req.Owner, req.Scopes)
+	if err != nil {
+		a.logger.Error("Failed to create API key", "owner", req.Owner, "error", err)
+		respond.Error(w, http.StatusServiceUnavailable, "unavailable", "failed to create api key")
+		return
+	}
+
+	a.logger.Warn("API key created", "id", meta.ID, "owner", meta.Owner, "scopes", meta.Scopes)
+	respond.JSON(w, http.StatusCreated, map[string]interface{}{
+		"key":     key,
+		"api_key": meta,
+	})
+}
+
+// getAPIKeyHandler reports a key's metadata, including when it was last used
+func (a *App) getAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	key, err := a.apiKeys.Get(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, apikey.ErrNotFound) {
+		respond.Error(w, http.StatusNotFound, "not_found", "api key not found")
+		return
+	}
+	if err != nil {
+		a.logger.Error("Failed to load API key", "id", chi.URLParam(r, "id"), "error", err)
+		respond.Error(w, http.StatusServiceUnavailable, "unavailable", "failed to load api key")
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, key)
+}
+
+// revokeAPIKeyHandler permanently disables a key
+func (a *App) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	err := a.apiKeys.Revoke(r.Context(), id)
+	if errors.Is(err, apikey.ErrNotFound) {
+		respond.Error(w, http.StatusNotFound, "not_found", "api key not found")
+		return
+	}
+	if err != nil {
+		a.logger.Error("Failed to revoke API key", "id", id, "error", err)
+		respond.Error(w, http.StatusServiceUnavailable, "unavailable", "failed to revoke api key")
+		return
+	}
+
+	a.logger.Warn("API key revoked", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/internal/app/apikey"
+	"github.com/rixtrayker/medical-rep/internal/app/auth"
+)
+
+func TestAPIKeyAdminEndpoints(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "api_keys:\n  bcrypt_cost: 4\n"))
+	token := bearer(t, a, "admin")
+
+	rec := serveAs(a.router, httptest.NewRequest(http.MethodPost, "/admin/api-keys", strings.NewReader(`{"owner":"acme","scopes":["visits:read"]}`)), token)
+	var created struct {
+		Key    string     `json:"key"`
+		APIKey apikey.Key `json:"api_key"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || rec.Code != http.StatusCreated || created.Key == "" {
+		t.Fatalf("POST = %d %s, want the created key", rec.Code, rec.Body)
+	}
+	id := created.APIKey.ID
+
+	rec = serveAs(a.router, httptest.NewRequest(http.MethodGet, "/admin/api-keys/"+id, nil), token)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "hash") || !strings.Contains(rec.Body.String(), `"owner":"acme"`) {
+		t.Errorf("GET = %d %s, want the key metadata without its hash", rec.Code, rec.Body)
+	}
+
+	rec = serveAs(a.router, httptest.NewRequest(http.MethodDelete, "/admin/api-keys/"+id, nil), token)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE = %d, want 204", rec.Code)
+	}
+	rec = serveAs(a.router, httptest.NewRequest(http.MethodGet, "/admin/api-keys/"+id, nil), token)
+	if !strings.Contains(rec.Body.String(), "revoked_at") {
+		t.Errorf("GET after DELETE = %s, want the revocation time", rec.Body)
+	}
+
+	for _, tt := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodPost, "/admin/api-keys", `{"scopes":["visits:read"]}`, http.StatusBadRequest},
+		{http.MethodPost, "/admin/api-keys", `not json`, http.StatusBadRequest},
+		{http.MethodGet, "/admin/api-keys/missing", "", http.StatusNotFound},
+		{http.MethodDelete, "/admin/api-keys/missing", "", http.StatusNotFound},
+	} {
+		rec := serveAs(a.router, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)), token)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+
+	rec = serveAs(a.router, httptest.NewRequest(http.MethodPost, "/admin/api-keys", strings.NewReader(`{"owner":"acme"}`)), bearer(t, a))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("non-admin POST = %d, want 403", rec.Code)
+	}
+}
+
+func TestAuthenticateAcceptsAPIKeysAndJWTs(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "api_keys:\n  bcrypt_cost: 4\n"))
+	raw, _, err := a.apiKeys.Generate(context.Background(), "acme", []string{"visits:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var caller string
+	handler := a.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, ok := apikey.FromContext(r.Context()); ok {
+			caller = "key:" + key.Owner
+		}
+		if claims, ok := auth.FromContext(r.Context()); ok {
+			caller = "user:" + claims.UserID
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/visits", nil)
+	req.Header.Set("X-API-Key", raw)
+	if rec := serveAs(handler, req, ""); rec.Code != http.StatusOK || caller != "key:acme" {
+		t.Errorf("API key = %d as %q, want 200 as the key owner", rec.Code, caller)
+	}
+
+	if rec := serveAs(handler, httptest.NewRequest(http.MethodGet, "/api/v1/visits", nil), bearer(t, a)); rec.Code != http.StatusOK || caller != "user:user-1" {
+		t.Errorf("JWT = %d as %q, want 200 as the user", rec.Code, caller)
+	}
+
+	// A key in the header is checked even when a valid JWT is sent too
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/visits", nil)
+	req.Header.Set("X-API-Key", "mrk_0000.wrong")
+	if rec := serveAs(handler, req, bearer(t, a)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("invalid API key with a JWT = %d, want 401", rec.Code)
+	}
+
+	if rec := serveAs(handler, httptest.NewRequest(http.MethodGet, "/api/v1/visits", nil), ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous = %d, want 401", rec.Code)
+	}
+}
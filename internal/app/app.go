@@ -2,37 +2,90 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	gosundheit "github.com/AppsFlyer/go-sundheit"
 	"github.com/AppsFlyer/go-sundheit/checks"
-	"github.com/cloudflare/tableflip"
+	healthhttp "github.com/AppsFlyer/go-sundheit/http"
 
 	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/apikey"
+	"github.com/rixtrayker/medical-rep/internal/app/audit"
+	"github.com/rixtrayker/medical-rep/internal/app/auth"
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+	"github.com/rixtrayker/medical-rep/internal/platform/buildinfo"
+	"github.com/rixtrayker/medical-rep/internal/platform/cache"
 	"github.com/rixtrayker/medical-rep/internal/platform/database"
+	"github.com/rixtrayker/medical-rep/internal/platform/flags"
+	"github.com/rixtrayker/medical-rep/internal/platform/httpclient"
+	"github.com/rixtrayker/medical-rep/internal/platform/idempotency"
 	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/metrics"
+	"github.com/rixtrayker/medical-rep/internal/platform/ratelimit"
 	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+	"github.com/rixtrayker/medical-rep/internal/platform/scheduler"
+	"github.com/rixtrayker/medical-rep/internal/platform/sequence"
+	"github.com/rixtrayker/medical-rep/internal/platform/session"
+	"github.com/rixtrayker/medical-rep/internal/platform/tenant"
+	"github.com/rixtrayker/medical-rep/internal/platform/tracing"
+	"github.com/rixtrayker/medical-rep/internal/platform/webhook"
 )
 
 // App represents the main application
 type App struct {
-	config     *configs.Config
-	logger     *logger.Logger
-	router     *chi.Mux
-	server     *http.Server
-	health     gosundheit.Health
-	db         *database.DB
-	redis      *redis.Client
-	upgrader   *tableflip.Upgrader
+	config      *configs.Config
+	applied     *configs.Config // config with the reloaded values in effect; nil until the first reload
+	logger      *logger.Logger
+	router      *chi.Mux
+	server      *http.Server
+	adminRouter chi.Router
+	adminServer *http.Server
+	health      gosundheit.Health
+	history     *checkHistory
+	db          *database.DB
+	redis       *redis.Client
+	upgrader    Upgrader
+	auth        *auth.Authenticator
+	sessions    *session.Manager
+	apiKeys     *apikey.Manager
+	tenants     *tenant.Resolver
+	flags       *flags.Store
+	audit       *audit.Logger
+	webhooks    *webhook.Dispatcher
+	httpClient  *httpclient.Client
+	scheduler   *scheduler.Scheduler
+	maintenance *maintenanceMode
+	pinger      *pinger
+	metrics     *metrics.Metrics
+	sampler     *metrics.Sampler
+	migrations  *migrationCheck
+	panics      *panicBudget
+	tracing     func(context.Context) error
+	certs       *certReloader
+	stats       *serverStats
+	proxies     trustedProxies
+	redactor    *redactor
+	afterAuth   []func(http.Handler) http.Handler
+	hooks       shutdownHooks
+	cors        atomic.Pointer[cors.Cors]
+	limiter     *reloadableLimiter
+	draining    atomic.Bool
+	upgrading   atomic.Bool
 }
 
 // Dependencies holds all application dependencies
@@ -59,37 +112,173 @@ func New() (*App, error) {
 		return nil, fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
+	respond.SetLogger(logger.Logger)
+
+	// Initialize tracing before any instrumented client is created
+	var tracingShutdown func(context.Context) error
+	if cfg.Tracing.Enabled {
+		tracingShutdown, err = tracing.Setup(context.Background(), cfg.Tracing, cfg.App.Name, cfg.App.Version)
+		if err != nil {
+			logger.Error("Failed to initialize tracing", "error", err)
+			return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+		}
+	}
+
+	// Dependencies may still be starting (e.g. under Docker Compose), so connect with retries
+	startupCtx, cancelStartup := context.WithTimeout(context.Background(), cfg.Startup.Timeout)
+	defer cancelStartup()
+
 	// Initialize database
-	db, err := database.New(cfg.Database)
+	db, err := connectWithRetry(startupCtx, logger, cfg.Startup, "database", func(ctx context.Context) (*database.DB, error) {
+		return database.New(ctx, cfg.Database, logger)
+	})
 	if err != nil {
 		logger.Error("Failed to initialize database", "error", err)
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
+	// Pre-fill the connection pool so the first requests don't each open a connection
+	if n := cfg.Database.WarmupConns; n > 0 {
+		warmed, err := db.Warmup(startupCtx, n)
+		if err != nil {
+			logger.Warn("Database pool warmup incomplete", "requested", n, "warmed", warmed, "error", err)
+		} else {
+			logger.Info("Database pool warmed", "connections", warmed)
+		}
+	}
+
+	// Reopen the pool if the database goes away, e.g. after a failover
+	if cfg.Database.Reconnect.Enabled {
+		db.Supervise(cfg.Database.Reconnect)
+	}
+
 	// Initialize Redis
-	redisClient, err := redis.New(cfg.Redis)
+	redisClient, err := connectWithRetry(startupCtx, logger, cfg.Startup, "redis", func(ctx context.Context) (*redis.Client, error) {
+		return redis.New(ctx, cfg.Redis, logger)
+	})
 	if err != nil {
 		logger.Error("Failed to initialize Redis", "error", err)
 		return nil, fmt.Errorf("failed to initialize Redis: %w", err)
 	}
 
-	// Initialize tableflip for zero-downtime deployments
-	upgrader, err := tableflip.New(tableflip.Options{})
+	// Initialize tableflip for zero-downtime deployments, falling back to a plain listener
+	upgrader := newUpgrader(cfg.HTTP, logger)
+
+	// Initialize JWT authenticator
+	authenticator, err := auth.New(cfg.Auth, redisClient)
 	if err != nil {
-		logger.Error("Failed to create tableflip upgrader", "error", err)
-		return nil, fmt.Errorf("failed to create tableflip upgrader: %w", err)
+		logger.Error("Failed to initialize authenticator", "error", err)
+		return nil, fmt.Errorf("failed to initialize authenticator: %w", err)
 	}
 
-	// Initialize health checker
-	health := gosundheit.New()
+	// Initialize the session store used by server-rendered admin pages
+	sessions, err := session.NewManager(cfg.Session, redisClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sessions: %w", err)
+	}
+
+	// Initialize health checker, recording per-check history for /health/details
+	history := newCheckHistory(cfg.Health.FailureWindow)
+	var health gosundheit.Health
+	if cfg.Health.BatchMode {
+		health = newBatchHealth(cfg.Health.CheckInterval, cfg.Health.Timeout, history)
+	} else {
+		health = gosundheit.New(gosundheit.WithCheckListeners(history))
+	}
 
 	app := &App{
-		config:   cfg,
-		logger:   logger,
-		db:       db,
-		redis:    redisClient,
-		health:   health,
-		upgrader: upgrader,
+		config:     cfg,
+		logger:     logger,
+		db:         db,
+		redis:      redisClient,
+		health:     health,
+		history:    history,
+		upgrader:   upgrader,
+		auth:       authenticator,
+		sessions:   sessions,
+		apiKeys:    apikey.NewManager(cfg.APIKeys, redisClient),
+		httpClient: httpclient.New(cfg.HTTPClient),
+		tracing:    tracingShutdown,
+		stats:      newServerStats(),
+	}
+	app.maintenance = newMaintenanceMode(cfg.App.Maintenance, redisClient, logger)
+
+	// Watch for handlers panicking repeatedly
+	if pb := cfg.App.PanicBudget; pb.Enabled {
+		app.panics = newPanicBudget(pb.MaxPanics, pb.Window)
+	}
+
+	// Stay not-ready until the schema reaches the migrations shipped with this binary
+	if db != nil && cfg.Health.MigrationCheck {
+		app.migrations = newMigrationCheck(db, cfg.Database.MigrationsPath, logger)
+	}
+
+	// Dependencies reported by /api/v1/ping
+	app.pinger = newPinger(cfg.Health.PingCacheTTL, cfg.Health.Timeout)
+	if db != nil {
+		app.pinger.add("database", db.Ping)
+	}
+	if redisClient != nil {
+		app.pinger.add("redis", redisClient.Ping)
+	}
+
+	// Resolve the tenant of API requests from the subdomain or tenant header
+	if cfg.Tenancy.Enabled {
+		// Only proxies listed in http.trusted_proxies may name the tenant in its header
+		app.tenants, err = tenant.NewResolver(cfg.Tenancy, redisClient, logger, func(r *http.Request) bool {
+			return app.proxies.fromTrustedProxy(r)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize tenancy: %w", err)
+		}
+	}
+
+	// Audit trail of authenticated mutating requests, written apart from the application log
+	if cfg.Audit.Enabled {
+		app.audit, err = audit.New(cfg.Audit, db, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize audit log: %w", err)
+		}
+	}
+
+	// Feature flags: config defaults with Redis overrides, available through flags.Enabled
+	app.flags = flags.New(cfg.Features, redisClient, flagSubject, logger)
+	flags.SetDefault(app.flags)
+
+	// Read-through cache, available through cache.GetOrLoad
+	if redisClient != nil {
+		cache.SetDefault(cache.New(cfg.Cache, redisClient, logger))
+	}
+
+	// Daily reference number sequences, available through sequence.Next
+	location, err := time.LoadLocation(cfg.Sequence.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sequence timezone: %w", err)
+	}
+	sequence.SetDefault(sequence.New(redisClient, location))
+
+	// Initialize Prometheus metrics
+	if cfg.Metrics.Enabled {
+		app.metrics = metrics.New()
+		if cfg.Metrics.Sampler.Interval > 0 {
+			app.sampler = app.newSampler()
+			app.OnShutdown("metrics sampler", app.sampler.Stop)
+		}
+	}
+
+	// Initialize the job scheduler, available through scheduler.Register; jobs only run on the
+	// instance holding the leader lock
+	if cfg.Scheduler.Enabled {
+		app.scheduler = scheduler.New(cfg.Scheduler, redisClient, logger)
+		scheduler.SetDefault(app.scheduler)
+		app.OnShutdown("scheduler", app.scheduler.Stop)
+	}
+
+	// Initialize webhook dispatcher, available through webhook.Send; its retry worker starts with Run
+	if cfg.Webhooks.Enabled {
+		app.webhooks = webhook.New(cfg.Webhooks, redisClient, logger)
+		webhook.SetDefault(app.webhooks)
+		app.OnShutdown("webhooks", app.webhooks.Stop)
 	}
 
 	// Setup router and server
@@ -113,47 +302,102 @@ func New() (*App, error) {
 func (a *App) setupRouter() error {
 	a.router = chi.NewRouter()
 
-	// Basic middleware
-	a.router.Use(middleware.RequestID)
-	a.router.Use(middleware.RealIP)
-	a.router.Use(middleware.Logger)
-	a.router.Use(middleware.Recoverer)
-	a.router.Use(middleware.Heartbeat("/ping"))
+	proxies, err := parseTrustedProxies(a.config.HTTP.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	a.proxies = proxies
+	a.redactor = newRedactor(a.config.HTTP.AccessLog)
 
-	// Timeout middleware
-	a.router.Use(middleware.Timeout(60 * time.Second))
+	// JSON 404 and 405 responses; mounted subrouters inherit them
+	a.router.NotFound(notFoundHandler)
+	a.router.MethodNotAllowed(methodNotAllowedHandler(a.router))
 
-	// CORS middleware
-	a.router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   a.config.HTTP.CORS.AllowedOrigins,
-		AllowedMethods:   a.config.HTTP.CORS.AllowedMethods,
-		AllowedHeaders:   a.config.HTTP.CORS.AllowedHeaders,
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
+	middlewares, err := a.middlewares()
+	if err != nil {
+		return err
+	}
+	// Apply in the configured order; configs validation guarantees every name is known. Names
+	// listed after "auth" run on the authenticated routes, after authentication.
+	order := a.config.HTTP.Middleware.Order
+	auth := slices.Index(order, "auth")
+	for i, name := range order {
+		m := middlewares[name]
+		switch {
+		case m == nil:
+		case auth >= 0 && i > auth:
+			a.afterAuth = append(a.afterAuth, m)
+		default:
+			a.router.Use(m)
+		}
+	}
 
-	// Rate limiting (if enabled)
-	if a.config.HTTP.RateLimit.Enabled {
-		// TODO: Implement rate limiting middleware
-		a.logger.Info("Rate limiting is enabled but not implemented yet")
+	// Metrics, debug and admin routes, on the public listener unless the admin listener is enabled
+	if a.config.HTTP.Admin.Enabled {
+		a.adminRouter = a.newAdminRouter()
+	} else {
+		a.mountAdminRoutes(a.router)
 	}
 
 	// Health check routes
-	a.router.Mount("/health", a.health.Handler())
+	a.router.Get("/health", healthhttp.HandleHealthJSON(a.health))
+	a.router.Get("/health/details", a.healthDetailsHandler)
 	a.router.Get("/healthz", a.healthzHandler)
 	a.router.Get("/readiness", a.readinessHandler)
 	a.router.Get("/liveness", a.livenessHandler)
 
+	// Build metadata
+	a.router.Get("/version", a.versionHandler)
+
 	// API routes
 	a.router.Route("/api", func(r chi.Router) {
-		r.Route("/v1", func(r chi.Router) {
-			// TODO: Add API routes here
+		// Encode respond.JSON bodies as MessagePack for clients that ask for it
+		r.Use(respond.Negotiate)
+
+		// Answer 503 during maintenance; health, metrics and admin routes stay live
+		r.Use(a.maintenance.Middleware)
+
+		// Reject requests that do not name a known tenant
+		if a.tenants != nil {
+			r.Use(a.tenants.Middleware)
+		}
+
+		// Reject non-JSON and malformed bodies before they reach handlers
+		if a.config.HTTP.JSONPrecheck.Enabled {
+			r.Use(jsonPrecheck(a.config.HTTP.JSONPrecheck))
+		}
+
+		// Conditional GETs for cacheable responses
+		if a.config.HTTP.ETag.Enabled {
+			r.Use(newETagger(a.config.HTTP.ETag).Middleware)
+		}
+
+		// Replay responses for retried unsafe requests carrying an Idempotency-Key
+		if a.config.HTTP.Idempotency.Enabled && a.redis != nil {
+			r.Use(idempotency.Middleware(a.redis, a.config.HTTP.Idempotency.TTL, a.logger))
+		}
+
+		a.mountVersion(r, "v1", func(r chi.Router) {
 			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(`{"message": "Medical Rep API v1", "status": "ok"}`))
 			})
+			r.Get("/ping", a.pingHandler)
+
+			// Token lifecycle routes
+			r.Route("/auth", func(r chi.Router) {
+				r.Post("/refresh", a.auth.RefreshHandler)
+				r.With(a.auth.Middleware).With(a.afterAuth...).With(a.audited).Post("/logout", a.auth.LogoutHandler)
+			})
+
+			// Authenticated routes, for users with a JWT and partners with an API key
+			r.Group(func(r chi.Router) {
+				r.Use(a.authenticate)
+				r.Use(a.afterAuth...)
+				r.Use(a.audited)
+				// TODO: Add API routes here
+			})
 		})
 	})
 
@@ -168,17 +412,98 @@ func (a *App) setupRouter() error {
 	return nil
 }
 
+// logStartupSummary logs the effective operational settings in one line so a deployment can be
+// verified at a glance. It reads the redacted config so no secret can end up in the log.
+func (a *App) logStartupSummary(listenAddr net.Addr) {
+	cfg := a.config.Redacted()
+	a.logger.Info("Starting server",
+		"network", listenAddr.Network(),
+		"addr", listenAddr.String(),
+		"environment", cfg.App.Environment,
+		"version", cfg.App.Version,
+		"tls", cfg.HTTP.TLS.Enabled,
+		"database_driver", cfg.Database.Driver,
+		"database_host", net.JoinHostPort(cfg.Database.Host, strconv.Itoa(cfg.Database.Port)),
+		"redis_host", net.JoinHostPort(cfg.Redis.Host, strconv.Itoa(cfg.Redis.Port)),
+		"rate_limit", cfg.HTTP.RateLimit.Enabled,
+		"health", cfg.Health.Enabled,
+		"metrics", cfg.Metrics.Enabled,
+		"tracing", cfg.Tracing.Enabled,
+		"zero_downtime", cfg.HTTP.ZeroDowntime,
+	)
+}
+
+// audited records authenticated mutating requests in the audit log; it must follow auth.Middleware
+func (a *App) audited(next http.Handler) http.Handler {
+	if a.audit == nil {
+		return next
+	}
+	return a.audit.Middleware(next)
+}
+
+// newRateLimiter creates the limiter backend selected by the rate limit store
+func (a *App) newRateLimiter(cfg configs.RateLimitConfig) (ratelimit.Limiter, error) {
+	switch cfg.Store {
+	case "redis":
+		if a.redis == nil {
+			return nil, fmt.Errorf("redis rate limit store requires a redis client")
+		}
+		return ratelimit.NewRedisLimiter(a.redis, cfg.Rate, cfg.Burst), nil
+	default:
+		return ratelimit.NewMemoryLimiter(cfg.Rate, cfg.Burst), nil
+	}
+}
+
+// connState feeds connection state changes to the debug stats and, when enabled, Prometheus
+func (a *App) connState(conn net.Conn, state http.ConnState) {
+	a.stats.ConnState(conn, state)
+	if a.metrics != nil {
+		a.metrics.ConnState(conn, state)
+	}
+}
+
 // setupServer configures the HTTP server
 func (a *App) setupServer() error {
 	addr := fmt.Sprintf("%s:%d", a.config.HTTP.Host, a.config.HTTP.Port)
 
 	a.server = &http.Server{
-		Addr:           addr,
-		Handler:        a.router,
-		ReadTimeout:    a.config.HTTP.ReadTimeout,
-		WriteTimeout:   a.config.HTTP.WriteTimeout,
-		IdleTimeout:    a.config.HTTP.IdleTimeout,
-		MaxHeaderBytes: a.config.HTTP.MaxHeaderBytes,
+		Addr:              addr,
+		Handler:           a.router,
+		ReadTimeout:       a.config.HTTP.ReadTimeout,
+		ReadHeaderTimeout: a.config.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      a.config.HTTP.WriteTimeout,
+		IdleTimeout:       a.config.HTTP.IdleTimeout,
+		MaxHeaderBytes:    a.config.HTTP.MaxHeaderBytes,
+		ConnState:         a.connState,
+	}
+
+	// Certificates are served through the reloader so rotations apply without a restart
+	if a.config.HTTP.TLS.Enabled {
+		certs, err := newCertReloader(a.config.HTTP.TLS.CertFile, a.config.HTTP.TLS.KeyFile, a.logger)
+		if err != nil {
+			return fmt.Errorf("failed to setup TLS: %w", err)
+		}
+		a.certs = certs
+
+		tlsConfig, err := newTLSConfig(a.config.HTTP.TLS)
+		if err != nil {
+			return fmt.Errorf("failed to setup TLS: %w", err)
+		}
+		tlsConfig.GetCertificate = certs.GetCertificate
+		a.server.TLSConfig = tlsConfig
+	}
+
+	// The admin listener is internal only, so it is served over plain HTTP
+	if a.adminRouter != nil {
+		a.adminServer = &http.Server{
+			Addr:              a.config.HTTP.Admin.Address(),
+			Handler:           a.adminRouter,
+			ReadTimeout:       a.config.HTTP.ReadTimeout,
+			ReadHeaderTimeout: a.config.HTTP.ReadHeaderTimeout,
+			WriteTimeout:      a.config.HTTP.WriteTimeout,
+			IdleTimeout:       a.config.HTTP.IdleTimeout,
+			MaxHeaderBytes:    a.config.HTTP.MaxHeaderBytes,
+		}
 	}
 
 	return nil
@@ -192,14 +517,17 @@ func (a *App) setupHealthChecks() error {
 
 	// Database health check
 	if a.config.Health.DatabaseCheck && a.db != nil {
-		dbCheck := checks.NewCustomCheck("database", func(ctx context.Context) (interface{}, error) {
-			if err := a.db.Ping(ctx); err != nil {
-				return nil, fmt.Errorf("database ping failed: %w", err)
-			}
-			return map[string]string{"status": "healthy"}, nil
-		})
+		dbCheck := &checks.CustomCheck{
+			CheckName: "database",
+			CheckFunc: checkWithTimeout("database", a.config.Health.Timeout, func(ctx context.Context) (interface{}, error) {
+				if err := a.db.Ping(ctx); err != nil {
+					return nil, fmt.Errorf("database ping failed: %w", err)
+				}
+				return map[string]string{"status": "healthy"}, nil
+			}),
+		}
 
-		if err := a.health.RegisterCheck(dbCheck,
+		if err := a.registerDependencyCheck(dbCheck,
 			gosundheit.InitialDelay(2*time.Second),
 			gosundheit.ExecutionPeriod(a.config.Health.CheckInterval),
 		); err != nil {
@@ -209,14 +537,17 @@ func (a *App) setupHealthChecks() error {
 
 	// Redis health check
 	if a.config.Health.RedisCheck && a.redis != nil {
-		redisCheck := checks.NewCustomCheck("redis", func(ctx context.Context) (interface{}, error) {
-			if err := a.redis.Ping(ctx); err != nil {
-				return nil, fmt.Errorf("redis ping failed: %w", err)
-			}
-			return map[string]string{"status": "healthy"}, nil
-		})
+		redisCheck := &checks.CustomCheck{
+			CheckName: "redis",
+			CheckFunc: checkWithTimeout("redis", a.config.Health.Timeout, func(ctx context.Context) (interface{}, error) {
+				if err := a.redis.Ping(ctx); err != nil {
+					return nil, fmt.Errorf("redis ping failed: %w", err)
+				}
+				return map[string]string{"status": "healthy"}, nil
+			}),
+		}
 
-		if err := a.health.RegisterCheck(redisCheck,
+		if err := a.registerDependencyCheck(redisCheck,
 			gosundheit.InitialDelay(2*time.Second),
 			gosundheit.ExecutionPeriod(a.config.Health.CheckInterval),
 		); err != nil {
@@ -224,18 +555,47 @@ func (a *App) setupHealthChecks() error {
 		}
 	}
 
+	// Disk space health check
+	if a.config.Health.DiskCheck {
+		diskCheck := newDiskCheck(a.config.Health.DiskPath, a.config.Health.DiskMinFreeBytes, syscall.Statfs)
+
+		if err := a.registerDependencyCheck(diskCheck,
+			gosundheit.InitialDelay(2*time.Second),
+			gosundheit.ExecutionPeriod(a.config.Health.CheckInterval),
+		); err != nil {
+			return fmt.Errorf("failed to register disk health check: %w", err)
+		}
+	}
+
+	// Self HTTP check against our own heartbeat endpoint
+	if a.config.Health.SelfCheck {
+		selfCheck, err := a.newSelfCheck()
+		if err != nil {
+			return fmt.Errorf("failed to create self HTTP health check: %w", err)
+		}
+
+		if err := a.registerCheck(selfCheck,
+			gosundheit.InitialDelay(2*time.Second),
+			gosundheit.ExecutionPeriod(a.config.Health.CheckInterval),
+		); err != nil {
+			return fmt.Errorf("failed to register self HTTP health check: %w", err)
+		}
+	}
+
 	// External service health checks
 	for _, url := range a.config.Health.ExternalChecks {
 		httpCheck, err := checks.NewHTTPCheck(checks.HTTPCheckConfig{
 			CheckName: fmt.Sprintf("http_%s", url),
 			Timeout:   a.config.Health.Timeout,
 			URL:       url,
+			Client:    a.httpClient.StandardClient(),
 		})
 		if err != nil {
 			return fmt.Errorf("failed to create HTTP health check for %s: %w", url, err)
 		}
 
-		if err := a.health.RegisterCheck(httpCheck,
+		breaker := a.config.Health.Breaker
+		if err := a.registerCheck(withBreaker(httpCheck, a.logger, breaker.Threshold, breaker.Cooldown),
 			gosundheit.InitialDelay(5*time.Second),
 			gosundheit.ExecutionPeriod(a.config.Health.CheckInterval),
 		); err != nil {
@@ -246,35 +606,105 @@ func (a *App) setupHealthChecks() error {
 	return nil
 }
 
+// newSelfCheck creates an HTTP check that requests /ping through the server's own listener
+func (a *App) newSelfCheck() (gosundheit.Check, error) {
+	host := a.config.HTTP.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+
+	scheme := "http"
+	transport := &http.Transport{}
+	if a.config.HTTP.TLS.Enabled {
+		// The certificate is issued for the public name, not the loopback address we dial
+		scheme = "https"
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	if network, socket := a.config.HTTP.ListenAddress(); network == "unix" {
+		// The URL host is only used for the Host header; every request goes to the socket
+		host = "localhost"
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, socket)
+		}
+	}
+	client := &http.Client{Transport: transport}
+
+	return checks.NewHTTPCheck(checks.HTTPCheckConfig{
+		CheckName: "http_check",
+		Timeout:   a.config.Health.Timeout,
+		URL:       fmt.Sprintf("%s://%s/ping", scheme, net.JoinHostPort(host, strconv.Itoa(a.config.HTTP.Port))),
+		Client:    client,
+	})
+}
+
 // healthzHandler provides a simple health check endpoint for Kubernetes
 func (a *App) healthzHandler(w http.ResponseWriter, r *http.Request) {
 	results, healthy := a.health.Results()
-	
-	w.Header().Set("Content-Type", "application/json")
-	
-	if !healthy {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		w.Write([]byte(`{"status": "unhealthy"}`))
-	} else {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status": "healthy"}`))
+
+	// Only failing critical checks make the app unhealthy; other failures degrade it
+	var critical, degraded []string
+	for name, result := range results {
+		if result.IsHealthy() {
+			continue
+		}
+		if a.isCriticalCheck(name) {
+			critical = append(critical, name)
+		} else {
+			degraded = append(degraded, name)
+		}
 	}
-	
+	sort.Strings(critical)
+	sort.Strings(degraded)
+
+	switch {
+	case len(critical) > 0:
+		respond.JSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status":  "unhealthy",
+			"failing": append(critical, degraded...),
+		})
+	case len(degraded) > 0:
+		respond.JSON(w, http.StatusOK, map[string]interface{}{
+			"status":  "degraded",
+			"failing": degraded,
+		})
+	default:
+		respond.JSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+	}
+
 	a.logger.Debug("Health check", "results", results, "healthy", healthy)
 }
 
-// readinessHandler checks if the application is ready to serve traffic
+// isCriticalCheck reports whether a failing check makes the app unhealthy rather than degraded
+func (a *App) isCriticalCheck(name string) bool {
+	return slices.Contains(a.config.Health.CriticalChecks, name)
+}
+
+// readinessHandler checks if the application is ready to serve traffic.
+// It reads the cached health check results so frequent probes don't ping dependencies on every call.
 func (a *App) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	// Report not-ready while draining or upgrading so load balancers stop routing to us
+	if draining, upgrading := a.draining.Load(), a.upgrading.Load(); draining || upgrading {
+		respond.JSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"ready":     false,
+			"draining":  draining,
+			"upgrading": upgrading,
+		})
+		return
+	}
+
 	// Check critical dependencies
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	ready := true
 	checks := make(map[string]string)
+	results, _ := a.health.Results()
 
 	// Check database
 	if a.db != nil {
-		if err := a.db.Ping(ctx); err != nil {
+		// The supervisor marks the database unhealthy while it reopens the pool
+		if !a.db.IsHealthy() || !dependencyHealthy(ctx, results, "database", a.db.Ping) {
 			ready = false
 			checks["database"] = "unhealthy"
 		} else {
@@ -282,9 +712,18 @@ func (a *App) readinessHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Check the schema is migrated
+	if a.migrations != nil {
+		status, ok := a.migrations.status(ctx)
+		if !ok {
+			ready = false
+		}
+		checks["migrations"] = status
+	}
+
 	// Check Redis
 	if a.redis != nil {
-		if err := a.redis.Ping(ctx); err != nil {
+		if !dependencyHealthy(ctx, results, "redis", a.redis.Ping) {
 			ready = false
 			checks["redis"] = "unhealthy"
 		} else {
@@ -292,25 +731,63 @@ func (a *App) readinessHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	
-	if !ready {
-		w.WriteHeader(http.StatusServiceUnavailable)
-	} else {
-		w.WriteHeader(http.StatusOK)
-	}
-
 	response := map[string]interface{}{
 		"ready":  ready,
 		"checks": checks,
 	}
 
-	// Simple JSON encoding
-	if ready {
-		w.Write([]byte(`{"ready": true}`))
-	} else {
-		w.Write([]byte(`{"ready": false}`))
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	respond.JSON(w, status, response)
+}
+
+// dependencyHealthy reports a dependency's health from its cached check result,
+// falling back to a live ping when the check has not produced a result yet
+func dependencyHealthy(ctx context.Context, results map[string]gosundheit.Result, name string, ping func(context.Context) error) bool {
+	if result, ok := results[name]; ok && !errors.Is(result.Error, gosundheit.ErrNotRunYet) {
+		return result.IsHealthy()
+	}
+	return ping(ctx) == nil
+}
+
+// debugMetricsHandler reports live runtime and request statistics
+func (a *App) debugMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, a.stats.Snapshot())
+}
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// getLogLevelHandler reports the running logger's level
+func (a *App) getLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, map[string]string{"level": a.logger.LevelName()})
+}
+
+// setLogLevelHandler changes the running logger's level until the next restart or reload
+func (a *App) setLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	var req logLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Level == "" {
+		respond.Error(w, http.StatusBadRequest, "invalid_request", "level is required")
+		return
+	}
+
+	previous := a.logger.LevelName()
+	if err := a.logger.SetLevel(req.Level); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid_level", err.Error())
+		return
 	}
+
+	a.logger.Info("Log level changed", "from", previous, "to", a.logger.LevelName())
+	respond.JSON(w, http.StatusOK, map[string]string{"level": a.logger.LevelName()})
+}
+
+// versionHandler reports the running build
+func (a *App) versionHandler(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, buildinfo.Get(a.config.App.Name, a.config.App.Version))
 }
 
 // livenessHandler checks if the application is alive
@@ -323,72 +800,199 @@ func (a *App) livenessHandler(w http.ResponseWriter, r *http.Request) {
 // Run starts the application
 func (a *App) Run() error {
 	// Listen on the upgradeable socket
-	addr := fmt.Sprintf("%s:%d", a.config.HTTP.Host, a.config.HTTP.Port)
-	ln, err := a.upgrader.Listen("tcp", addr)
+	ln, err := listen(a.upgrader, a.config.HTTP)
 	if err != nil {
-		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		return err
 	}
+	ln = a.stats.countConnections(ln)
 
-	a.logger.Info("Starting server", 
-		"addr", addr,
-		"environment", a.config.App.Environment,
-		"version", a.config.App.Version,
-	)
+	// Open the admin listener before serving either, so a failure leaves nothing running.
+	// It shares the upgrader so it is handed over on upgrades as well.
+	var adminLn net.Listener
+	if a.adminServer != nil {
+		adminLn, err = a.upgrader.Listen("tcp", a.adminServer.Addr)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("failed to listen on admin address: %w", err)
+		}
+	}
+
+	a.logStartupSummary(ln.Addr())
+
+	// Watch certificate files for rotation
+	if a.certs != nil {
+		if err := a.certs.Watch(); err != nil {
+			ln.Close()
+			if adminLn != nil {
+				adminLn.Close()
+			}
+			return err
+		}
+	}
+
+	// Start periodic jobs
+	if a.scheduler != nil {
+		a.scheduler.Start()
+	}
+
+	// Record runtime and pool stats between scrapes
+	if a.sampler != nil {
+		a.sampler.Start()
+	}
 
-	// Start the server in a goroutine
-	errChan := make(chan error, 1)
+	// Retry queued webhook deliveries
+	if a.webhooks != nil {
+		a.webhooks.Start()
+	}
+
+	// Start the servers in goroutines
+	errChan := make(chan error, 2)
 	go func() {
 		if a.config.HTTP.TLS.Enabled {
-			errChan <- a.server.ServeTLS(ln, a.config.HTTP.TLS.CertFile, a.config.HTTP.TLS.KeyFile)
+			errChan <- a.server.ServeTLS(ln, "", "")
 		} else {
 			errChan <- a.server.Serve(ln)
 		}
 	}()
 
+	if adminLn != nil {
+		a.logger.Info("Admin server listening", "address", adminLn.Addr().String())
+
+		go func() {
+			errChan <- a.adminServer.Serve(adminLn)
+		}()
+	}
+
 	// Tell tableflip that initialization is complete
 	if err := a.upgrader.Ready(); err != nil {
-		return fmt.Errorf("failed to signal ready: %w", err)
+		return errors.Join(fmt.Errorf("failed to signal ready: %w", err), a.Shutdown())
 	}
 
-	// Wait for shutdown signal or server error
+	// Wait for shutdown signal or server error; SIGHUP reloads configuration
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	defer signal.Stop(hupChan)
 
-	select {
-	case err := <-errChan:
-		if err != http.ErrServerClosed {
-			return fmt.Errorf("server error: %w", err)
+	for {
+		select {
+		case err := <-errChan:
+			// One server failing takes the other down with it
+			if err != http.ErrServerClosed {
+				return errors.Join(fmt.Errorf("server error: %w", err), a.Shutdown())
+			}
+			return a.Shutdown()
+		case sig := <-sigChan:
+			a.logger.Info("Received shutdown signal", "signal", sig.String())
+			a.preStop()
+			return a.Shutdown()
+		case <-a.upgrader.Exit():
+			// The new process is already serving, so in-flight requests can take longer to finish
+			a.logger.Info("Received upgrade signal")
+			return a.shutdown(a.config.App.Shutdown.UpgradeTimeout)
+		case <-a.panicShutdown():
+			// Exit with an error so the orchestrator restarts us instead of routing to a broken process
+			a.logger.Error("Shutting down after exceeding the panic budget")
+			if err := a.Shutdown(); err != nil {
+				return err
+			}
+			return errPanicBudgetExceeded
+		case <-hupChan:
+			a.reload()
 		}
-	case sig := <-sigChan:
-		a.logger.Info("Received shutdown signal", "signal", sig.String())
-	case <-a.upgrader.Exit():
-		a.logger.Info("Received upgrade signal")
 	}
+}
+
+// panicShutdown fires once the panic budget is exceeded, if exceeding it should stop the process
+func (a *App) panicShutdown() <-chan struct{} {
+	if !a.config.App.PanicBudget.Shutdown {
+		return nil
+	}
+	return a.panics.Exceeded()
+}
+
+// startDraining marks the application as draining so readiness reports not-ready
+func (a *App) startDraining() {
+	a.draining.Store(true)
+}
 
-	return a.Shutdown()
+// preStop keeps serving with readiness failing for the pre-stop delay, so Kubernetes removes the
+// pod from its endpoints before the drain and the server shutdown begin
+func (a *App) preStop() {
+	delay := a.config.App.Shutdown.PreStopDelay
+	if delay <= 0 {
+		return
+	}
+
+	a.startDraining()
+	a.logger.Info("Waiting before shutdown", "pre_stop_delay", delay, "open_connections", a.stats.Connections())
+	time.Sleep(delay)
 }
 
-// Shutdown gracefully shuts down the application
+// Shutdown gracefully shuts down the application within the configured shutdown timeout
 func (a *App) Shutdown() error {
-	a.logger.Info("Shutting down application...")
+	return a.shutdown(a.config.App.Shutdown.Timeout)
+}
+
+// shutdown gracefully shuts down the application, giving in-flight work up to timeout
+func (a *App) shutdown(timeout time.Duration) error {
+	a.logger.Info("Shutting down application...", "timeout", timeout)
+
+	// Fail readiness and give load balancers time to deregister us before closing connections
+	a.startDraining()
+	if delay := a.config.App.Shutdown.DrainDelay; delay > 0 {
+		a.logger.Info("Draining connections", "delay", delay, "open_connections", a.stats.Connections())
+		time.Sleep(delay)
+	}
 
 	// Create shutdown context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), a.config.App.Shutdown.Timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	// Shutdown HTTP server
+	// Shutdown HTTP server. Dependencies below are only released once this returns,
+	// so handlers that finish within the timeout never see a closed DB or Redis client.
 	if err := a.server.Shutdown(ctx); err != nil {
 		a.logger.Error("Server shutdown error", "error", err)
+
+		// Timed out: drop the remaining connections and report what was abandoned
+		if abandoned := a.stats.Active(); abandoned > 0 {
+			a.logger.Warn("Abandoning in-flight requests", "count", abandoned)
+		}
+		if open := a.stats.Connections(); open > 0 {
+			a.logger.Warn("Closing open connections", "count", open)
+		}
+		if err := a.server.Close(); err != nil {
+			a.logger.Error("Server close error", "error", err)
+		}
+	}
+
+	// Admin routes stay reachable until the public traffic has drained
+	if a.adminServer != nil {
+		if err := a.adminServer.Shutdown(ctx); err != nil {
+			a.logger.Error("Admin server shutdown error", "error", err)
+			if err := a.adminServer.Close(); err != nil {
+				a.logger.Error("Admin server close error", "error", err)
+			}
+		}
 	}
 
+	// Stop certificate watcher
+	if a.certs != nil {
+		a.certs.Close()
+	}
+
+	// Run registered shutdown hooks while dependencies are still open
+	a.runShutdownHooks()
+
 	// Stop health checker
 	if a.health != nil {
 		a.health.DeregisterAll()
 	}
 
-	// Close database connections
+	// Close database connections once in-flight queries finish, within what is left of the timeout
 	if a.db != nil {
-		if err := a.db.Close(); err != nil {
+		if err := a.db.CloseWithTimeout(ctx); err != nil {
 			a.logger.Error("Database close error", "error", err)
 		}
 	}
@@ -400,6 +1004,13 @@ func (a *App) Shutdown() error {
 		}
 	}
 
+	// Flush pending spans
+	if a.tracing != nil {
+		if err := a.tracing(ctx); err != nil {
+			a.logger.Error("Tracing shutdown error", "error", err)
+		}
+	}
+
 	// Stop upgrader
 	if a.upgrader != nil {
 		a.upgrader.Stop()
@@ -0,0 +1,662 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/apikey"
+	"github.com/rixtrayker/medical-rep/internal/app/auth"
+	"github.com/rixtrayker/medical-rep/internal/platform/buildinfo"
+	"github.com/rixtrayker/medical-rep/internal/platform/database/dbtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/flags"
+	"github.com/rixtrayker/medical-rep/internal/platform/httpclient"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/metrics"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+	"github.com/rixtrayker/medical-rep/internal/platform/session"
+)
+
+// testSecret is an HS256 secret long enough to pass config validation
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// testConfig loads the default configuration with a valid auth section, overlaid with yaml
+func testConfig(t *testing.T, yaml string) *configs.Config {
+	t.Helper()
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	overlay := filepath.Join(dir, "config.test.yaml")
+	if err := os.WriteFile(base, []byte("auth:\n  jwt_secret: "+testSecret+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overlay, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := configs.LoadFrom(configs.LoadOptions{BaseFile: base, EnvFile: overlay, Env: map[string]string{}})
+	if err != nil {
+		t.Fatalf("failed to load test config: %v", err)
+	}
+	return cfg
+}
+
+// newTestApp returns an app with the given config, a discarded log and no dependencies
+func newTestApp(t *testing.T, cfg *configs.Config) *App {
+	t.Helper()
+	return &App{
+		config: cfg,
+		logger: logtest.Discard(t),
+		health: &stubHealth{},
+		stats:  newServerStats(),
+	}
+}
+
+// newRoutedApp returns a test app whose Redis-backed dependencies use miniredis, with the router set up
+func newRoutedApp(t *testing.T, cfg *configs.Config) (*App, *miniredis.Miniredis) {
+	t.Helper()
+	a := newTestApp(t, cfg)
+	client, server := redistest.New(t)
+	a.redis = client
+
+	var err error
+	if a.auth, err = auth.New(cfg.Auth, client); err != nil {
+		t.Fatal(err)
+	}
+	if a.sessions, err = session.NewManager(cfg.Session, client); err != nil {
+		t.Fatal(err)
+	}
+	a.apiKeys = apikey.NewManager(cfg.APIKeys, client)
+	a.maintenance = newMaintenanceMode(cfg.App.Maintenance, client, a.logger)
+	a.flags = flags.New(cfg.Features, client, flagSubject, a.logger)
+	a.pinger = newPinger(cfg.Health.PingCacheTTL, cfg.Health.Timeout)
+	a.httpClient = httpclient.New(cfg.HTTPClient)
+	if cfg.Metrics.Enabled {
+		a.metrics = metrics.New()
+	}
+
+	if err := a.setupRouter(); err != nil {
+		t.Fatalf("failed to set up router: %v", err)
+	}
+	return a, server
+}
+
+// bearer returns an access token for user-1 with roles
+func bearer(t *testing.T, a *App, roles ...string) string {
+	t.Helper()
+	token, err := a.auth.IssueToken("user-1", roles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// serveAs serves req through handler, authenticated with token when it is not empty
+func serveAs(handler http.Handler, req *http.Request, token string) *httptest.ResponseRecorder {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// get serves a GET request for path and returns the response
+func get(handler http.Handler, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+// stubHealth is a gosundheit.Health reporting fixed results
+type stubHealth struct {
+	results map[string]gosundheit.Result
+}
+
+func (h *stubHealth) RegisterCheck(gosundheit.Check, ...gosundheit.CheckOption) error { return nil }
+func (h *stubHealth) Deregister(string)                                               {}
+func (h *stubHealth) DeregisterAll()                                                  {}
+
+func (h *stubHealth) Results() (map[string]gosundheit.Result, bool) {
+	healthy := true
+	for _, result := range h.results {
+		healthy = healthy && result.IsHealthy()
+	}
+	return h.results, healthy
+}
+
+func (h *stubHealth) IsHealthy() bool {
+	_, healthy := h.Results()
+	return healthy
+}
+
+func TestReadinessUsesCachedResults(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	client, server := redistest.New(t)
+	a.redis = client
+	a.health = &stubHealth{results: map[string]gosundheit.Result{
+		"redis": {Error: errors.New("redis ping failed")},
+	}}
+
+	commands := server.CommandCount()
+	rec := get(http.HandlerFunc(a.readinessHandler), "/readiness")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 from the cached unhealthy result", rec.Code)
+	}
+	if n := server.CommandCount() - commands; n != 0 {
+		t.Fatalf("readiness issued %d Redis commands despite a cached result", n)
+	}
+
+	// A cached healthy result is trusted even when Redis is gone
+	a.health = &stubHealth{results: map[string]gosundheit.Result{"redis": {}}}
+	server.Close()
+	if rec := get(http.HandlerFunc(a.readinessHandler), "/readiness"); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 from the cached healthy result", rec.Code)
+	}
+}
+
+func TestReadinessPingsWithoutCachedResult(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	client, server := redistest.New(t)
+	a.redis = client
+	a.health = &stubHealth{results: map[string]gosundheit.Result{
+		"redis": {Error: gosundheit.ErrNotRunYet},
+	}}
+
+	commands := server.CommandCount()
+	if rec := get(http.HandlerFunc(a.readinessHandler), "/readiness"); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if server.CommandCount() == commands {
+		t.Fatal("readiness did not ping Redis before the check first ran")
+	}
+
+	server.Close()
+	if rec := get(http.HandlerFunc(a.readinessHandler), "/readiness"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 when the live ping fails", rec.Code)
+	}
+}
+
+func TestReadinessReportsChecks(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	a.redis, _ = redistest.New(t)
+	a.health = &stubHealth{results: map[string]gosundheit.Result{
+		"redis": {Error: errors.New("redis ping failed")},
+	}}
+
+	rec := get(http.HandlerFunc(a.readinessHandler), "/readiness")
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var body struct {
+		Ready  bool              `json:"ready"`
+		Checks map[string]string `json:"checks"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body, err)
+	}
+	if body.Ready {
+		t.Error("ready = true with Redis unhealthy")
+	}
+	if body.Checks["redis"] != "unhealthy" {
+		t.Errorf("checks = %v, want redis unhealthy", body.Checks)
+	}
+}
+
+func TestHealthzDegradesOnNonCriticalFailures(t *testing.T) {
+	failing := gosundheit.Result{Error: errors.New("check failed")}
+	tests := []struct {
+		name       string
+		results    map[string]gosundheit.Result
+		wantStatus int
+		wantBody   string
+	}{
+		{"all healthy", map[string]gosundheit.Result{"database": {}, "reports_api": {}},
+			http.StatusOK, `{"status":"healthy"}`},
+		{"non-critical failing", map[string]gosundheit.Result{"database": {}, "reports_api": failing, "maps_api": failing},
+			http.StatusOK, `{"failing":["maps_api","reports_api"],"status":"degraded"}`},
+		{"critical failing", map[string]gosundheit.Result{"database": failing, "reports_api": failing},
+			http.StatusServiceUnavailable, `{"failing":["database","reports_api"],"status":"unhealthy"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t, testConfig(t, "health:\n  critical_checks: [database]\n"))
+			a.health = &stubHealth{results: tt.results}
+
+			rec := get(http.HandlerFunc(a.healthzHandler), "/healthz")
+			if rec.Code != tt.wantStatus || strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Errorf("healthz = %d %s, want %d %s", rec.Code, rec.Body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestDrainingFailsReadinessOnly(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+
+	if rec := get(http.HandlerFunc(a.readinessHandler), "/readiness"); rec.Code != http.StatusOK {
+		t.Fatalf("readiness before draining = %d, want 200", rec.Code)
+	}
+
+	a.startDraining()
+	rec := get(http.HandlerFunc(a.readinessHandler), "/readiness")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readiness while draining = %d, want 503", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"draining":true`) {
+		t.Errorf("body = %s, want draining reported", rec.Body)
+	}
+	if rec := get(http.HandlerFunc(a.livenessHandler), "/liveness"); rec.Code != http.StatusOK {
+		t.Fatalf("liveness while draining = %d, want 200", rec.Code)
+	}
+}
+
+func TestShutdownWaitsDrainDelay(t *testing.T) {
+	a := newTestApp(t, testConfig(t, "app:\n  shutdown:\n    drain_delay: 50ms\n"))
+	a.server = &http.Server{}
+
+	start := time.Now()
+	if err := a.shutdown(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("shutdown took %s, want at least the 50ms drain delay", elapsed)
+	}
+	if !a.draining.Load() {
+		t.Error("app is not draining after shutdown")
+	}
+}
+
+// testUpgrader is a plainUpgrader handing out every listener it opens
+type testUpgrader struct {
+	*plainUpgrader
+	listeners chan net.Listener
+}
+
+func newTestUpgrader() *testUpgrader {
+	return &testUpgrader{plainUpgrader: newPlainUpgrader(), listeners: make(chan net.Listener, 2)}
+}
+
+func (u *testUpgrader) Listen(network, addr string) (net.Listener, error) {
+	ln, err := u.plainUpgrader.Listen(network, addr)
+	if err == nil {
+		u.listeners <- ln
+	}
+	return ln, err
+}
+
+// runApp starts a.Run on a loopback port and returns its public listener and the channel
+// receiving Run's result
+func runApp(t *testing.T, a *App) (net.Listener, <-chan error) {
+	t.Helper()
+	upgrader := newTestUpgrader()
+	a.upgrader = upgrader
+	if err := a.setupServer(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- a.Run() }()
+
+	select {
+	case ln := <-upgrader.listeners:
+		return ln, done
+	case err := <-done:
+		t.Fatalf("Run returned before listening: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not listen")
+	}
+	return nil, nil
+}
+
+// waitRun returns the result of Run, failing the test if it does not return in time
+func waitRun(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+		return nil
+	}
+}
+
+// runConfig returns the configuration for tests calling Run, overlaid with yaml: a random
+// loopback port, no drain delay and no zero-downtime upgrades
+func runConfig(t *testing.T, yaml string) *configs.Config {
+	t.Helper()
+	cfg := testConfig(t, yaml)
+	cfg.App.Shutdown.DrainDelay = 0
+	cfg.HTTP.Host = "127.0.0.1"
+	// Validation requires a fixed port; 0 picks a free one
+	cfg.HTTP.Port = 0
+	cfg.HTTP.ZeroDowntime = false
+	return cfg
+}
+
+func TestRunStopsCleanlyOnServerClose(t *testing.T) {
+	a, _ := newRoutedApp(t, runConfig(t, ""))
+	ln, done := runApp(t, a)
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/liveness")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("liveness = %d, want 200", resp.StatusCode)
+	}
+
+	// http.ErrServerClosed is the normal end of Serve, not a failure
+	a.server.Close()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("Run = %v, want nil after the server was closed", err)
+	}
+}
+
+func TestRunLogsStartupSummaryOnce(t *testing.T) {
+	cfg := runConfig(t, "database:\n  host: db.internal\n  password: db-pass-123\nredis:\n  host: cache.internal\n  password: redis-pass-456\nhttp:\n  rate_limit:\n    enabled: true\n")
+	a, _ := newRoutedApp(t, cfg)
+	log, logs := logtest.New(t)
+	a.logger = log
+
+	ln, done := runApp(t, a)
+	a.server.Close()
+	if err := waitRun(t, done); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := logs.Count("Starting server"); n != 1 {
+		t.Fatalf("startup summary logged %d times, want once", n)
+	}
+	entry, _ := logs.Find("Starting server")
+	want := map[string]any{
+		"addr":            ln.Addr().String(),
+		"environment":     "development",
+		"tls":             false,
+		"database_driver": "postgres",
+		"database_host":   "db.internal:5432",
+		"redis_host":      "cache.internal:6379",
+		"rate_limit":      true,
+		"health":          cfg.Health.Enabled,
+	}
+	for field, value := range want {
+		if entry[field] != value {
+			t.Errorf("%s = %v, want %v", field, entry[field], value)
+		}
+	}
+
+	all, err := json.Marshal(logs.Entries())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"db-pass-123", "redis-pass-456", testSecret} {
+		if strings.Contains(string(all), secret) {
+			t.Errorf("logs contain the secret %s", secret)
+		}
+	}
+}
+
+func TestRunReportsServerErrors(t *testing.T) {
+	a, _ := newRoutedApp(t, runConfig(t, ""))
+	ln, done := runApp(t, a)
+
+	ln.Close()
+	err := waitRun(t, done)
+	if err == nil || !strings.Contains(err.Error(), "server error") {
+		t.Fatalf("Run = %v, want the server error", err)
+	}
+}
+
+// serveApp serves a's public server on a loopback listener until the test ends
+func serveApp(t *testing.T, a *App) string {
+	t.Helper()
+	if err := a.setupServer(); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go a.server.Serve(a.stats.countConnections(ln))
+	t.Cleanup(func() { a.server.Close() })
+	return "http://" + ln.Addr().String()
+}
+
+func TestShutdownClosesDependenciesAfterDraining(t *testing.T) {
+	a, _ := newRoutedApp(t, runConfig(t, ""))
+	a.db = dbtest.NewPing(t)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	pingErrs := make(chan error, 2)
+	a.router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		pingErrs <- a.db.Ping(r.Context())
+		pingErrs <- a.redis.Ping(r.Context())
+	})
+	url := serveApp(t, a)
+
+	go func() {
+		if resp, err := http.Get(url + "/slow"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- a.shutdown(5 * time.Second) }()
+
+	// Wait until the server has stopped accepting, so the handler finishes mid-shutdown
+	addr := strings.TrimPrefix(url, "http://")
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	close(release)
+	for _, name := range []string{"database", "redis"} {
+		if err := <-pingErrs; err != nil {
+			t.Errorf("%s was closed while a request was in flight: %v", name, err)
+		}
+	}
+
+	if err := <-shutdownErr; err != nil {
+		t.Fatal(err)
+	}
+	if err := a.db.Ping(context.Background()); err == nil {
+		t.Error("database is still open after shutdown")
+	}
+}
+
+func TestShutdownLogsAbandonedRequests(t *testing.T) {
+	a, _ := newRoutedApp(t, runConfig(t, ""))
+	log, logs := logtest.New(t)
+	a.logger = log
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	a.router.Get("/stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	url := serveApp(t, a)
+
+	go func() {
+		if resp, err := http.Get(url + "/stuck"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	if err := a.shutdown(50 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := logs.Find("Abandoning in-flight requests")
+	if !ok {
+		t.Fatal("abandoned requests were not logged")
+	}
+	if entry["count"] != float64(1) {
+		t.Errorf("count = %v, want 1", entry["count"])
+	}
+}
+
+func TestPreStopFailsReadinessWhileServing(t *testing.T) {
+	a, _ := newRoutedApp(t, runConfig(t, "app:\n  shutdown:\n    pre_stop_delay: 500ms\n"))
+	log, logs := logtest.New(t)
+	a.logger = log
+
+	// Keep a stray SIGTERM from killing the test binary before Run subscribes to it
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, syscall.SIGTERM)
+	defer signal.Stop(term)
+
+	ln, done := runApp(t, a)
+	url := "http://" + ln.Addr().String()
+	// A connection dialed but never used would hold up the shutdown for 5 seconds
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	resp, err := client.Get(url + "/readiness")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("readiness before SIGTERM = %d, want 200", resp.StatusCode)
+	}
+
+	// Run subscribes after it starts listening, so signal until the pre-stop delay begins
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.Count("Waiting before shutdown") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the pre-stop delay did not begin after SIGTERM")
+		}
+		if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	signaled := time.Now()
+
+	// Readiness fails while the server keeps serving requests
+	for path, want := range map[string]int{"/readiness": http.StatusServiceUnavailable, "/version": http.StatusOK} {
+		resp, err := client.Get(url + path)
+		if err != nil {
+			t.Fatalf("%s during the pre-stop delay failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s during the pre-stop delay = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("Run returned %v after SIGTERM", err)
+	}
+	if elapsed := time.Since(signaled); elapsed < 400*time.Millisecond {
+		t.Errorf("Run returned %s after the pre-stop delay began, want at least 400ms", elapsed)
+	}
+}
+
+func TestRequestIDFromConfig(t *testing.T) {
+	tests := []struct {
+		name, yaml string
+		adopted    bool
+	}{
+		{"untrusted by default", "http:\n  request_id:\n    header: X-Edge-ID\n", false},
+		{"trusted header adopted", "http:\n  request_id:\n    header: X-Edge-ID\n    trust: true\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newRoutedApp(t, testConfig(t, tt.yaml))
+			req := httptest.NewRequest(http.MethodGet, "/liveness", nil)
+			req.Header.Set("X-Edge-ID", "edge-42")
+			rec := httptest.NewRecorder()
+			a.router.ServeHTTP(rec, req)
+
+			id := rec.Header().Get("X-Edge-ID")
+			if id == "" {
+				t.Fatal("no request ID echoed in the configured header")
+			}
+			if adopted := id == "edge-42"; adopted != tt.adopted {
+				t.Errorf("echoed %q, adopted = %v, want %v", id, adopted, tt.adopted)
+			}
+		})
+	}
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "app:\n  name: crm\n  version: 2.1.0\n"))
+	commit := buildinfo.GitCommit
+	buildinfo.GitCommit = "abc1234"
+	t.Cleanup(func() { buildinfo.GitCommit = commit })
+
+	rec := get(a.router, "/version")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var info buildinfo.Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "crm" || info.Version != "2.1.0" || info.GitCommit != "abc1234" {
+		t.Errorf("version = %+v, want crm 2.1.0 at commit abc1234", info)
+	}
+}
+
+func TestSmoke(t *testing.T) {
+	a, _ := newRoutedApp(t, runConfig(t, ""))
+	url := serveApp(t, a)
+
+	for _, path := range []string{"/ping", "/healthz", "/api/v1/", "/liveness", "/readiness", "/version"} {
+		resp, err := http.Get(url + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestSelfCheck(t *testing.T) {
+	a, _ := newRoutedApp(t, runConfig(t, ""))
+	url := serveApp(t, a)
+
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(url, "http://"))
+	a.config.HTTP.Port, _ = strconv.Atoi(port)
+	a.config.HTTP.Host = "0.0.0.0"
+	check, err := a.newSelfCheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := check.Execute(context.Background()); err != nil {
+		t.Fatalf("self check against a serving app failed: %v", err)
+	}
+
+	a.server.Close()
+	if _, err := check.Execute(context.Background()); err == nil {
+		t.Fatal("self check passed with the server stopped")
+	}
+}
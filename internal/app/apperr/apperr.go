@@ -0,0 +1,118 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// Error kinds. Match them with errors.Is; StatusCode maps them to HTTP statuses.
+var (
+	ErrInvalid      = errors.New("invalid")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInternal     = errors.New("internal")
+)
+
+// kinds maps each kind to its status and error envelope code
+var kinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{ErrInvalid, http.StatusBadRequest, "invalid"},
+	{ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{ErrForbidden, http.StatusForbidden, "forbidden"},
+	{ErrNotFound, http.StatusNotFound, "not_found"},
+	{ErrConflict, http.StatusConflict, "conflict"},
+	{ErrInternal, http.StatusInternalServerError, "internal_error"},
+}
+
+// Error is a domain error of a given kind with a message safe to show to clients
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+// New returns an error of kind with a client-facing message
+func New(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap returns an error of kind with a client-facing message, keeping err as the cause for logs
+func Wrap(err error, kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Is matches the error's kind, so errors.Is(err, apperr.ErrNotFound) works through wrapping
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP status for err's kind, or 500 for errors of no known kind
+func StatusCode(err error) int {
+	status, _ := classify(err)
+	return status
+}
+
+// classify returns the status and envelope code for err
+func classify(err error) (int, string) {
+	for _, k := range kinds {
+		if errors.Is(err, k.kind) {
+			return k.status, k.code
+		}
+	}
+	return http.StatusInternalServerError, "internal_error"
+}
+
+// Write renders err through the JSON error envelope with its mapped status.
+// Server errors are logged and their details are not sent to the client.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := classify(err)
+
+	if status >= http.StatusInternalServerError {
+		respond.Logger().Error("Request failed",
+			"error", err,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"request_id", requestid.FromContext(r.Context()),
+		)
+		respond.Error(w, status, code, http.StatusText(status))
+		return
+	}
+
+	message := http.StatusText(status)
+	var appErr *Error
+	if errors.As(err, &appErr) && appErr.Message != "" {
+		message = appErr.Message
+	}
+	respond.Error(w, status, code, message)
+}
+
+// HandlerFunc is an HTTP handler that returns an error instead of writing it
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Handle adapts fn to http.HandlerFunc, rendering any returned error with Write
+func Handle(fn HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			Write(w, r, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"invalid", New(ErrInvalid, "territory is required"), http.StatusBadRequest},
+		{"unauthorized", New(ErrUnauthorized, "sign in"), http.StatusUnauthorized},
+		{"forbidden", New(ErrForbidden, "not your territory"), http.StatusForbidden},
+		{"not found", New(ErrNotFound, "doctor not found"), http.StatusNotFound},
+		{"conflict", New(ErrConflict, "visit already logged"), http.StatusConflict},
+		{"internal", New(ErrInternal, "boom"), http.StatusInternalServerError},
+		{"wrapped by fmt", fmt.Errorf("load visit: %w", New(ErrNotFound, "visit not found")), http.StatusNotFound},
+		{"bare kind", ErrConflict, http.StatusConflict},
+		{"unknown", errors.New("driver: bad connection"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusCode(tt.err); got != tt.want {
+				t.Errorf("StatusCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapKeepsCause(t *testing.T) {
+	cause := errors.New("duplicate key value violates unique constraint")
+	err := Wrap(cause, ErrConflict, "visit already logged")
+
+	if !errors.Is(err, ErrConflict) || !errors.Is(err, cause) {
+		t.Error("wrapped error does not match both its kind and its cause")
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Error("wrapped error matches another kind")
+	}
+	if err.Error() != "visit already logged: "+cause.Error() {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+// serveError renders the error returned by a handler and decodes the envelope
+func serveError(t *testing.T, err error) (int, respond.ErrorDetail) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handle(func(w http.ResponseWriter, r *http.Request) error {
+		return err
+	}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/visits/1", nil))
+
+	var body respond.ErrorBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body, err)
+	}
+	return rec.Code, body.Error
+}
+
+func TestHandleRendersClientErrors(t *testing.T) {
+	status, detail := serveError(t, Wrap(errors.New("sql: no rows"), ErrNotFound, "visit not found"))
+	if status != http.StatusNotFound || detail.Code != "not_found" || detail.Message != "visit not found" {
+		t.Errorf("got %d %+v, want 404 not_found with the message", status, detail)
+	}
+
+	status, detail = serveError(t, ErrForbidden)
+	if status != http.StatusForbidden || detail.Message != "Forbidden" {
+		t.Errorf("got %d %+v, want 403 with the status text", status, detail)
+	}
+}
+
+func TestHandleHidesServerErrors(t *testing.T) {
+	log, logs := logtest.New(t)
+	respond.SetLogger(log.Logger)
+	t.Cleanup(func() { respond.SetLogger(nil) })
+
+	status, detail := serveError(t, errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+	if status != http.StatusInternalServerError || detail.Code != "internal_error" || detail.Message != "Internal Server Error" {
+		t.Errorf("got %d %+v, want a generic 500", status, detail)
+	}
+
+	entry, ok := logs.Find("Request failed")
+	if !ok {
+		t.Fatal("the server error was not logged")
+	}
+	if entry["error"] != "dial tcp 10.0.0.5:5432: connection refused" || entry["path"] != "/visits/1" {
+		t.Errorf("log entry = %v, want the cause and path", entry)
+	}
+}
+
+func TestHandleWritesNothingOnSuccess(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handle(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}).ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/visits/1", nil))
+
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("got %d %q, want the handler's 204", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/apikey"
+	"github.com/rixtrayker/medical-rep/internal/app/auth"
+	"github.com/rixtrayker/medical-rep/internal/platform/database"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+	"github.com/rixtrayker/medical-rep/internal/platform/tenant"
+)
+
+// writeTimeout bounds storing an entry in the database after the response has been sent
+const writeTimeout = 5 * time.Second
+
+// columns are the audit table columns, in Entry field order
+var columns = []string{"occurred_at", "user_id", "method", "path", "route", "status", "request_id", "tenant", "remote_addr"}
+
+// Entry records one authenticated mutating request
+type Entry struct {
+	Time       time.Time
+	UserID     string
+	Method     string
+	Path       string
+	Route      string
+	Status     int
+	RequestID  string
+	Tenant     string
+	RemoteAddr string
+}
+
+// Logger writes audit entries to its own log, separate from the application log so it can
+// have its own output and retention, and optionally to a database table
+type Logger struct {
+	out   *logger.Logger
+	db    *database.DB
+	table string
+	log   *logger.Logger
+}
+
+// New creates an audit logger. Entries are also inserted into cfg.Table when it is set;
+// failures there are reported on the application logger log.
+func New(cfg configs.AuditConfig, db *database.DB, log *logger.Logger) (*Logger, error) {
+	out, err := logger.New(cfg.Log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+	if cfg.Table != "" && db == nil {
+		return nil, fmt.Errorf("audit table %q requires a database", cfg.Table)
+	}
+
+	return &Logger{out: out, db: db, table: cfg.Table, log: log}, nil
+}
+
+// Middleware audits POST, PUT, PATCH and DELETE requests carrying auth claims or an API key.
+// It must run after authentication; anonymous and read-only requests are not audited.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := actor(r.Context())
+		if !ok || !mutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := ""
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+
+		l.Record(r.Context(), Entry{
+			Time:       time.Now().UTC(),
+			UserID:     actor,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Route:      route,
+			Status:     status,
+			RequestID:  requestid.FromContext(r.Context()),
+			Tenant:     tenant.FromContext(r.Context()),
+			RemoteAddr: r.RemoteAddr,
+		})
+	})
+}
+
+// actor identifies the authenticated caller: the JWT user, or "apikey:<id>" for an API key
+func actor(ctx context.Context) (string, bool) {
+	if claims, ok := auth.FromContext(ctx); ok {
+		return claims.UserID, true
+	}
+	if key, ok := apikey.FromContext(ctx); ok {
+		return "apikey:" + key.ID, true
+	}
+	return "", false
+}
+
+// Record writes an entry to the audit log and, when configured, the audit table
+func (l *Logger) Record(ctx context.Context, e Entry) {
+	l.out.Info("Audit",
+		"time", e.Time,
+		"user_id", e.UserID,
+		"method", e.Method,
+		"path", e.Path,
+		"route", e.Route,
+		"status", e.Status,
+		"request_id", e.RequestID,
+		"tenant", e.Tenant,
+		"remote_addr", e.RemoteAddr,
+	)
+
+	if l.table == "" {
+		return
+	}
+
+	// The response is already sent, so the write must not be cut short by the client leaving
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+
+	row := []any{e.Time, e.UserID, e.Method, e.Path, e.Route, e.Status, e.RequestID, e.Tenant, e.RemoteAddr}
+	if _, err := l.db.BulkInsert(ctx, l.table, columns, [][]any{row}); err != nil {
+		l.log.Error("Failed to store audit entry",
+			"user_id", e.UserID, "method", e.Method, "path", e.Path, "request_id", e.RequestID, "error", err)
+	}
+}
+
+// mutating reports whether the method changes state
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
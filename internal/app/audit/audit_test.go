@@ -0,0 +1,161 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/apikey"
+	"github.com/rixtrayker/medical-rep/internal/app/auth"
+	"github.com/rixtrayker/medical-rep/internal/platform/database"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// auditedRouter returns a router auditing /visits routes, with the audit and application logs
+func auditedRouter(t *testing.T, db *database.DB, table string) (http.Handler, *logtest.Recorder, *logtest.Recorder) {
+	t.Helper()
+	out, entries := logtest.New(t)
+	log, appLogs := logtest.New(t)
+	l := &Logger{out: out, db: db, table: table, log: log}
+
+	r := chi.NewRouter()
+	r.Use(requestid.Middleware("", false), l.Middleware)
+	r.Get("/visits", func(w http.ResponseWriter, r *http.Request) {})
+	r.Post("/visits", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) })
+	r.Delete("/visits/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	return r, entries, appLogs
+}
+
+// as returns a request authenticated as claims, or as key when claims is nil
+func as(method, path string, claims *auth.Claims, key *apikey.Key) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	ctx := req.Context()
+	if claims != nil {
+		ctx = auth.NewContext(ctx, claims)
+	}
+	if key != nil {
+		ctx = apikey.NewContext(ctx, key)
+	}
+	return req.WithContext(ctx)
+}
+
+func TestMiddlewareAuditsAuthenticatedMutations(t *testing.T) {
+	handler, entries, _ := auditedRouter(t, nil, "")
+	user := &auth.Claims{UserID: "user-1"}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, as(http.MethodPost, "/visits", user, nil))
+
+	if entries.Count("Audit") != 1 {
+		t.Fatalf("%d audit entries, want 1", entries.Count("Audit"))
+	}
+	entry, _ := entries.Find("Audit")
+	want := map[string]any{
+		"user_id":    "user-1",
+		"method":     "POST",
+		"path":       "/visits",
+		"route":      "/visits",
+		"status":     float64(http.StatusCreated),
+		"request_id": rec.Header().Get(requestid.Header),
+	}
+	for field, value := range want {
+		if entry[field] != value {
+			t.Errorf("%s = %v, want %v", field, entry[field], value)
+		}
+	}
+	if ts, _ := entry["time"].(string); ts == "" {
+		t.Error("entry has no timestamp")
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), as(http.MethodDelete, "/visits/42", nil, &apikey.Key{ID: "key-7"}))
+	entry = entries.Entries()[1]
+	if entry["user_id"] != "apikey:key-7" || entry["route"] != "/visits/{id}" || entry["status"] != float64(http.StatusOK) {
+		t.Errorf("API key entry = %v", entry)
+	}
+}
+
+func TestMiddlewareSkipsReadsAndAnonymousRequests(t *testing.T) {
+	handler, entries, _ := auditedRouter(t, nil, "")
+
+	handler.ServeHTTP(httptest.NewRecorder(), as(http.MethodGet, "/visits", &auth.Claims{UserID: "user-1"}, nil))
+	handler.ServeHTTP(httptest.NewRecorder(), as(http.MethodPost, "/visits", nil, nil))
+
+	if n := len(entries.Entries()); n != 0 {
+		t.Errorf("%d audit entries for a GET and an anonymous POST, want none", n)
+	}
+}
+
+func TestRecordStoresEntriesInTable(t *testing.T) {
+	db, err := database.New(context.Background(), configs.DatabaseConfig{
+		Driver:       "sqlite3",
+		Database:     filepath.Join(t.TempDir(), "audit.db"),
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}, logtest.Discard(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if _, err := db.Exec(ctx, `CREATE TABLE audit_log (occurred_at TIMESTAMP, user_id TEXT, method TEXT,
+		path TEXT, route TEXT, status INTEGER, request_id TEXT, tenant TEXT, remote_addr TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+
+	handler, _, appLogs := auditedRouter(t, db, "audit_log")
+	handler.ServeHTTP(httptest.NewRecorder(), as(http.MethodPost, "/visits", &auth.Claims{UserID: "user-1"}, nil))
+
+	var user, method string
+	var status int
+	if err := db.QueryRow(ctx, "SELECT user_id, method, status FROM audit_log").Scan(&user, &method, &status); err != nil {
+		t.Fatal(err)
+	}
+	if user != "user-1" || method != "POST" || status != http.StatusCreated {
+		t.Errorf("stored row = %s %s %d", user, method, status)
+	}
+
+	// A failed insert is reported on the application log
+	if _, err := db.Exec(ctx, "DROP TABLE audit_log"); err != nil {
+		t.Fatal(err)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), as(http.MethodPost, "/visits", &auth.Claims{UserID: "user-1"}, nil))
+	if _, ok := appLogs.Find("Failed to store audit entry"); !ok {
+		t.Error("failed audit insert was not logged")
+	}
+}
+
+func TestNewWritesToItsOwnOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	appLog, appLogs := logtest.New(t)
+	l, err := New(configs.AuditConfig{Log: configs.LoggingConfig{Level: "info", Format: "json", Output: path}}, nil, appLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Record(context.Background(), Entry{Time: time.Now(), UserID: "user-1", Method: "PUT", Path: "/reps/1", Status: 200})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"user_id":"user-1"`) {
+		t.Errorf("audit file = %s, want the entry", data)
+	}
+	if len(appLogs.Entries()) != 0 {
+		t.Error("audit entry written to the application log")
+	}
+
+	if _, err := New(configs.AuditConfig{Table: "audit_log"}, nil, appLog); err == nil {
+		t.Error("audit table accepted without a database")
+	}
+}
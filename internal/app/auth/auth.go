@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// Token types carried in the "typ" claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+type contextKey struct{}
+
+// Claims holds the JWT claims carried by authenticated requests
+type Claims struct {
+	UserID string   `json:"user_id"`
+	Roles  []string `json:"roles"`
+	Type   string   `json:"typ"`
+	Family string   `json:"fam,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Store is the key/value store backing refresh tokens and revocations
+type Store interface {
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	Del(ctx context.Context, keys ...string) (int64, error)
+	Exists(ctx context.Context, keys ...string) (int64, error)
+	RunScript(ctx context.Context, script *goredis.Script, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// Authenticator issues and verifies JWT access and refresh tokens
+type Authenticator struct {
+	method            jwt.SigningMethod
+	signKey           interface{}
+	verifyKey         interface{}
+	expiration        time.Duration
+	refreshExpiration time.Duration
+	store             Store
+	now               func() time.Time
+}
+
+// New creates an authenticator from the auth configuration, loading RSA keys for RS256
+func New(cfg configs.AuthConfig, store Store) (*Authenticator, error) {
+	a := &Authenticator{
+		expiration:        cfg.JWTExpiration,
+		refreshExpiration: cfg.RefreshExpiration,
+		store:             store,
+		now:               time.Now,
+	}
+
+	switch cfg.JWTAlgorithm {
+	case "", "HS256":
+		if len(cfg.JWTSecret) < configs.MinJWTSecretLength {
+			return nil, fmt.Errorf("JWT secret must be at least %d bytes for HS256", configs.MinJWTSecretLength)
+		}
+		a.method = jwt.SigningMethodHS256
+		a.signKey = []byte(cfg.JWTSecret)
+		a.verifyKey = []byte(cfg.JWTSecret)
+	case "RS256":
+		a.method = jwt.SigningMethodRS256
+
+		publicPEM, err := os.ReadFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT public key: %w", err)
+		}
+		if a.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM); err != nil {
+			return nil, fmt.Errorf("failed to parse JWT public key: %w", err)
+		}
+
+		// Verification-only services are not given the private key
+		if cfg.JWTPrivateKeyFile != "" {
+			privatePEM, err := os.ReadFile(cfg.JWTPrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read JWT private key: %w", err)
+			}
+			if a.signKey, err = jwt.ParseRSAPrivateKeyFromPEM(privatePEM); err != nil {
+				return nil, fmt.Errorf("failed to parse JWT private key: %w", err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", cfg.JWTAlgorithm)
+	}
+
+	return a, nil
+}
+
+// IssueToken signs an access token for the given user and roles
+func (a *Authenticator) IssueToken(userID string, roles []string) (string, error) {
+	token, _, err := a.sign(userID, roles, TokenTypeAccess, "", a.expiration)
+	return token, err
+}
+
+// ParseToken verifies an access token's signature and expiry and returns its claims
+func (a *Authenticator) ParseToken(tokenString string) (*Claims, error) {
+	return a.parse(tokenString, TokenTypeAccess)
+}
+
+// sign builds and signs a token of the given type with a fresh token ID
+func (a *Authenticator) sign(userID string, roles []string, typ, family string, ttl time.Duration) (string, *Claims, error) {
+	if a.signKey == nil {
+		return "", nil, errors.New("no JWT signing key configured")
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := a.now()
+	claims := &Claims{
+		UserID: userID,
+		Roles:  roles,
+		Type:   typ,
+		Family: family,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(a.method, claims).SignedString(a.signKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return token, claims, nil
+}
+
+// parse verifies the token and checks it is of the expected type
+func (a *Authenticator) parse(tokenString, typ string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims,
+		func(*jwt.Token) (interface{}, error) { return a.verifyKey, nil },
+		jwt.WithValidMethods([]string{a.method.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(a.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	if claims.Type != typ {
+		return nil, fmt.Errorf("invalid token: expected %s token, got %q", typ, claims.Type)
+	}
+	return claims, nil
+}
+
+// Middleware rejects requests without a valid, unrevoked Bearer token and stores the claims in the context
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString, err := bearerToken(r)
+		if err != nil {
+			unauthorized(w, err.Error())
+			return
+		}
+
+		claims, err := a.ParseToken(tokenString)
+		if err != nil {
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				unauthorized(w, "token expired")
+			} else {
+				unauthorized(w, "invalid token")
+			}
+			return
+		}
+
+		revoked, err := a.IsRevoked(r.Context(), claims)
+		if err != nil {
+			respond.Error(w, http.StatusServiceUnavailable, "unavailable", "authentication unavailable")
+			return
+		}
+		if revoked {
+			unauthorized(w, "token revoked")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+	})
+}
+
+// NewContext returns a copy of ctx carrying the claims
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// FromContext returns the claims stored by the middleware, if any
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+// bearerToken extracts the token from the Authorization header
+func bearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", errors.New("missing authorization header")
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", errors.New("malformed authorization header")
+	}
+	return token, nil
+}
+
+// newID returns a random token identifier
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// unauthorized writes a JSON 401 response
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	respond.Error(w, http.StatusUnauthorized, "unauthorized", message)
+}
+
+// forbidden writes a JSON 403 response
+func forbidden(w http.ResponseWriter, message string) {
+	respond.Error(w, http.StatusForbidden, "forbidden", message)
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newTestAuthenticator returns an HS256 authenticator backed by miniredis with a controllable clock
+func newTestAuthenticator(t *testing.T) (*Authenticator, *time.Time) {
+	t.Helper()
+	client, _ := redistest.New(t)
+	a, err := New(configs.AuthConfig{
+		JWTAlgorithm:      "HS256",
+		JWTSecret:         testSecret,
+		JWTExpiration:     15 * time.Minute,
+		RefreshExpiration: 24 * time.Hour,
+	}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	a.now = func() time.Time { return now }
+	return a, &now
+}
+
+// serve runs req through the middleware and returns the response and the claims the handler saw
+func serve(a *Authenticator, req *http.Request) (*httptest.ResponseRecorder, *Claims) {
+	var seen *Claims
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = FromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, seen
+}
+
+// withToken returns a GET request carrying token as a Bearer credential
+func withToken(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// assertUnauthorized fails unless rec is a 401 whose body mentions message
+func assertUnauthorized(t *testing.T, rec *httptest.ResponseRecorder, message string) {
+	t.Helper()
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rec.Code)
+	}
+	if rec.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("missing WWW-Authenticate header")
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"code":"unauthorized"`) || !strings.Contains(body, message) {
+		t.Errorf("body = %s, want the unauthorized envelope with %q", body, message)
+	}
+}
+
+func TestMiddlewareValidToken(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	token, err := a.IssueToken("user-1", []string{"rep"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, claims := serve(a, withToken(token))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if claims == nil || claims.UserID != "user-1" || !claims.HasAnyRole("rep") {
+		t.Fatalf("claims = %+v, want user-1 with role rep", claims)
+	}
+}
+
+func TestMiddlewareExpiredToken(t *testing.T) {
+	a, now := newTestAuthenticator(t)
+	token, err := a.IssueToken("user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	*now = now.Add(16 * time.Minute)
+	rec, claims := serve(a, withToken(token))
+	assertUnauthorized(t, rec, "token expired")
+	if claims != nil {
+		t.Error("handler ran for an expired token")
+	}
+}
+
+func TestMiddlewareTamperedSignature(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	token, err := a.IssueToken("user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip the first signature character so the token no longer verifies
+	dot := strings.LastIndex(token, ".")
+	flipped := byte('A')
+	if token[dot+1] == 'A' {
+		flipped = 'B'
+	}
+	tampered := token[:dot+1] + string(flipped) + token[dot+2:]
+
+	rec, _ := serve(a, withToken(tampered))
+	assertUnauthorized(t, rec, "invalid token")
+}
+
+func TestMiddlewareWrongSecret(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	other, err := New(configs.AuthConfig{JWTSecret: strings.Repeat("x", 32), JWTExpiration: time.Minute}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := other.IssueToken("user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, _ := serve(a, withToken(token))
+	assertUnauthorized(t, rec, "invalid token")
+}
+
+func TestMiddlewareMissingHeader(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+
+	rec, _ := serve(a, httptest.NewRequest(http.MethodGet, "/", nil))
+	assertUnauthorized(t, rec, "missing authorization header")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	rec, _ = serve(a, req)
+	assertUnauthorized(t, rec, "malformed authorization header")
+}
+
+func TestNewRejectsShortSecret(t *testing.T) {
+	for _, secret := range []string{"", "short", testSecret[:configs.MinJWTSecretLength-1]} {
+		if _, err := New(configs.AuthConfig{JWTSecret: secret}, nil); err == nil {
+			t.Errorf("secret of %d bytes accepted", len(secret))
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// Redis key prefixes for refresh token state
+const (
+	refreshKeyPrefix = "auth:refresh:"
+	familyKeyPrefix  = "auth:refresh_family:"
+	revokedKeyPrefix = "auth:revoked:"
+)
+
+var (
+	// ErrRefreshTokenReused is returned when an already-rotated refresh token is presented again.
+	// The whole token family is revoked so a stolen token cannot be used alongside the legitimate one.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+
+	// ErrRefreshTokenRevoked is returned when the refresh token's family has been revoked
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+
+	// ErrTokenStoreUnavailable wraps failures of the store backing refresh tokens. The presented
+	// token is left untouched, so the client can retry with it.
+	ErrTokenStoreUnavailable = errors.New("token store unavailable")
+)
+
+// Results of rotateScript
+const (
+	rotateDone    = 1
+	rotateRevoked = 0
+	rotateReused  = -1
+)
+
+// rotateScript consumes the presented refresh token and records its successor in one step, so a
+// failure can neither burn the old token without issuing a new one nor leave both usable.
+// A token that was already consumed revokes its whole family.
+//
+// KEYS[1] presented token, KEYS[2] family, KEYS[3] successor; ARGV[1] family ID, ARGV[2] TTL in ms
+var rotateScript = goredis.NewScript(`
+if redis.call('DEL', KEYS[1]) == 0 then
+	redis.call('DEL', KEYS[2])
+	return -1
+end
+if redis.call('EXISTS', KEYS[2]) == 0 then
+	return 0
+end
+redis.call('SET', KEYS[3], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+// TokenPair is an access token with its matching refresh token
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// IssueRefreshToken signs a refresh token starting a new rotation family and records it in the store
+func (a *Authenticator) IssueRefreshToken(ctx context.Context, userID string, roles []string) (string, error) {
+	family, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.store.Set(ctx, familyKeyPrefix+family, userID, a.refreshExpiration); err != nil {
+		return "", fmt.Errorf("failed to store refresh token family: %w", err)
+	}
+
+	return a.issueRefreshToken(ctx, userID, roles, family)
+}
+
+// issueRefreshToken signs a refresh token within an existing family and records its ID
+func (a *Authenticator) issueRefreshToken(ctx context.Context, userID string, roles []string, family string) (string, error) {
+	token, claims, err := a.sign(userID, roles, TokenTypeRefresh, family, a.refreshExpiration)
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.store.Set(ctx, refreshKeyPrefix+claims.ID, family, a.refreshExpiration); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// Refresh validates a refresh token, consumes it and returns a new access/refresh pair.
+// Both new tokens are signed before the presented one is consumed.
+func (a *Authenticator) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := a.parse(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := a.IssueToken(claims.UserID, claims.Roles)
+	if err != nil {
+		return nil, err
+	}
+
+	newRefreshToken, newClaims, err := a.sign(claims.UserID, claims.Roles, TokenTypeRefresh, claims.Family, a.refreshExpiration)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{refreshKeyPrefix + claims.ID, familyKeyPrefix + claims.Family, refreshKeyPrefix + newClaims.ID}
+	result, err := a.store.RunScript(ctx, rotateScript, keys, claims.Family, a.refreshExpiration.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to rotate refresh token: %w", ErrTokenStoreUnavailable, err)
+	}
+	switch result {
+	case int64(rotateDone):
+	case int64(rotateReused):
+		return nil, ErrRefreshTokenReused
+	case int64(rotateRevoked):
+		return nil, ErrRefreshTokenRevoked
+	default:
+		return nil, fmt.Errorf("unexpected refresh token rotation result %v", result)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(a.expiration.Seconds()),
+	}, nil
+}
+
+// Revoke adds the access token to the revocation list until it would have expired anyway
+func (a *Authenticator) Revoke(ctx context.Context, claims *Claims) error {
+	ttl := claims.ExpiresAt.Time.Sub(a.now())
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := a.store.Set(ctx, revokedKeyPrefix+claims.ID, 1, ttl); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// RevokeRefreshToken revokes the whole rotation family of a refresh token owned by userID
+func (a *Authenticator) RevokeRefreshToken(ctx context.Context, userID, refreshToken string) error {
+	claims, err := a.parse(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return err
+	}
+	if claims.UserID != userID {
+		return errors.New("refresh token belongs to another user")
+	}
+
+	if _, err := a.store.Del(ctx, familyKeyPrefix+claims.Family, refreshKeyPrefix+claims.ID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether the access token has been revoked
+func (a *Authenticator) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	n, err := a.store.Exists(ctx, revokedKeyPrefix+claims.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return n > 0, nil
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshHandler rotates a refresh token and returns a new token pair
+func (a *Authenticator) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		respond.Error(w, http.StatusBadRequest, "bad_request", "refresh_token is required")
+		return
+	}
+
+	pair, err := a.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRefreshTokenReused), errors.Is(err, ErrRefreshTokenRevoked):
+			unauthorized(w, err.Error())
+		case errors.Is(err, ErrTokenStoreUnavailable):
+			respond.Error(w, http.StatusServiceUnavailable, "unavailable", "failed to refresh token")
+		default:
+			unauthorized(w, "invalid refresh token")
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respond.JSON(w, http.StatusOK, pair)
+}
+
+// LogoutHandler revokes the caller's access token and, if supplied, its refresh token family
+func (a *Authenticator) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := FromContext(r.Context())
+	if !ok {
+		unauthorized(w, "authentication required")
+		return
+	}
+
+	if err := a.Revoke(r.Context(), claims); err != nil {
+		respond.Error(w, http.StatusServiceUnavailable, "unavailable", "failed to revoke token")
+		return
+	}
+
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err == nil && req.RefreshToken != "" {
+		if err := a.RevokeRefreshToken(r.Context(), claims.UserID, req.RefreshToken); err != nil {
+			respond.Error(w, http.StatusBadRequest, "bad_request", "failed to revoke refresh token")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+func TestRefreshRotation(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	ctx := context.Background()
+
+	refreshToken, err := a.IssueRefreshToken(ctx, "user-1", []string{"rep"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair, err := a.Refresh(ctx, refreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if pair.RefreshToken == refreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+	claims, err := a.ParseToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("new access token is invalid: %v", err)
+	}
+	if claims.UserID != "user-1" || !claims.HasAnyRole("rep") {
+		t.Errorf("claims = %+v, want user-1 with role rep", claims)
+	}
+
+	// The rotated token keeps working
+	if _, err := a.Refresh(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("rotated refresh token rejected: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	ctx := context.Background()
+
+	first, err := a.IssueRefreshToken(ctx, "user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := a.Refresh(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Refresh(ctx, first); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reusing a rotated token: err = %v, want ErrRefreshTokenReused", err)
+	}
+
+	// The legitimate holder's newer token dies with the family
+	if _, err := a.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenRevoked) {
+		t.Fatalf("token from a reused family: err = %v, want ErrRefreshTokenRevoked", err)
+	}
+}
+
+func TestRefreshFailureKeepsToken(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	client, server := redistest.New(t)
+	a.store = client
+	ctx := context.Background()
+
+	refreshToken, err := a.IssueRefreshToken(ctx, "user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing fails, as with a verify-only RS256 key
+	signKey := a.signKey
+	a.signKey = nil
+	if _, err := a.Refresh(ctx, refreshToken); err == nil {
+		t.Fatal("refresh succeeded without a signing key")
+	}
+	a.signKey = signKey
+
+	// The store is down
+	server.SetError("LOADING Redis is loading the dataset in memory")
+	if _, err := a.Refresh(ctx, refreshToken); !errors.Is(err, ErrTokenStoreUnavailable) {
+		t.Fatalf("err = %v with the store down, want ErrTokenStoreUnavailable", err)
+	}
+	rec := httptest.NewRecorder()
+	a.RefreshHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(`{"refresh_token":"`+refreshToken+`"}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status with the store down = %d, want 503", rec.Code)
+	}
+	server.SetError("")
+
+	// Neither failure consumed the token
+	if _, err := a.Refresh(ctx, refreshToken); err != nil {
+		t.Fatalf("refresh after the failures: %v, want the token still valid", err)
+	}
+}
+
+func TestRefreshRejectsAccessToken(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	token, err := a.IssueToken("user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Refresh(context.Background(), token); err == nil {
+		t.Fatal("an access token was accepted as a refresh token")
+	}
+}
+
+func TestRevokedAccessTokenRejected(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	token, err := a.IssueToken("user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := a.ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec, _ := serve(a, withToken(token)); rec.Code != http.StatusOK {
+		t.Fatalf("status before revocation = %d, want 200", rec.Code)
+	}
+
+	if err := a.Revoke(context.Background(), claims); err != nil {
+		t.Fatal(err)
+	}
+	rec, _ := serve(a, withToken(token))
+	assertUnauthorized(t, rec, "token revoked")
+}
+
+func TestRefreshHandler(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	refreshToken, err := a.IssueRefreshToken(context.Background(), "user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		a.RefreshHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"refresh_token":"` + refreshToken + `"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Error("token response is cacheable")
+	}
+	var pair TokenPair
+	if err := json.Unmarshal(rec.Body.Bytes(), &pair); err != nil {
+		t.Fatal(err)
+	}
+	if pair.AccessToken == "" || pair.RefreshToken == "" || pair.TokenType != "Bearer" {
+		t.Errorf("pair = %+v, want both tokens of type Bearer", pair)
+	}
+
+	assertUnauthorized(t, post(`{"refresh_token":"`+refreshToken+`"}`), ErrRefreshTokenReused.Error())
+
+	if rec := post(`{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing token status = %d, want 400", rec.Code)
+	}
+}
+
+func TestLogoutHandler(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	ctx := context.Background()
+
+	accessToken, err := a.IssueToken("user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshToken, err := a.IssueRefreshToken(ctx, "user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", strings.NewReader(`{"refresh_token":"`+refreshToken+`"}`))
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	rec := httptest.NewRecorder()
+	a.Middleware(http.HandlerFunc(a.LogoutHandler)).ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204: %s", rec.Code, rec.Body)
+	}
+
+	rec, _ = serve(a, withToken(accessToken))
+	assertUnauthorized(t, rec, "token revoked")
+	if _, err := a.Refresh(ctx, refreshToken); err == nil {
+		t.Fatal("refresh token still works after logout")
+	}
+}
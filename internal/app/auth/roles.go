@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+)
+
+// RequireRole allows the request through if the caller holds at least one of roles.
+// It must run after the JWT middleware; requests without claims get 401.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := FromContext(r.Context())
+			if !ok {
+				unauthorized(w, "authentication required")
+				return
+			}
+
+			if !claims.HasAnyRole(roles...) {
+				forbidden(w, "insufficient role")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasAnyRole reports whether the claims include at least one of roles
+func (c *Claims) HasAnyRole(roles ...string) bool {
+	for _, role := range roles {
+		if slices.Contains(c.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequireRole(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(a.Middleware)
+		r.With(RequireRole("admin", "manager")).Get("/reports", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	// Without the JWT middleware there are never claims
+	r.With(RequireRole("admin")).Get("/unguarded", func(w http.ResponseWriter, r *http.Request) {})
+
+	token := func(roles ...string) string {
+		t.Helper()
+		token, err := a.IssueToken("user-1", roles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		want  int
+	}{
+		{"one of the roles", "/reports", token("rep", "manager"), http.StatusOK},
+		{"wrong role", "/reports", token("rep"), http.StatusForbidden},
+		{"no roles", "/reports", token(), http.StatusForbidden},
+		{"no token", "/reports", "", http.StatusUnauthorized},
+		{"no claims", "/unguarded", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+// writeRSAKeys generates a key pair and writes both halves as PEM files, returning their paths
+func writeRSAKeys(t *testing.T) (publicFile, privateFile string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	publicFile = filepath.Join(dir, "jwt.pub")
+	privateFile = filepath.Join(dir, "jwt.key")
+	write := func(path, blockType string, der []byte) {
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(publicFile, "PUBLIC KEY", publicDER)
+	write(privateFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	return publicFile, privateFile
+}
+
+func TestRS256IssueAndVerify(t *testing.T) {
+	publicFile, privateFile := writeRSAKeys(t)
+	client, _ := redistest.New(t)
+
+	issuer, err := New(configs.AuthConfig{
+		JWTAlgorithm:      "RS256",
+		JWTPublicKeyFile:  publicFile,
+		JWTPrivateKeyFile: privateFile,
+		JWTExpiration:     time.Minute,
+	}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.IssueToken("user-1", []string{"rep"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another service only holds the public key
+	verifier, err := New(configs.AuthConfig{JWTAlgorithm: "RS256", JWTPublicKeyFile: publicFile}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, claims := serve(verifier, withToken(token))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if claims == nil || claims.UserID != "user-1" {
+		t.Fatalf("claims = %+v, want user-1", claims)
+	}
+
+	if _, err := verifier.IssueToken("user-1", nil); err == nil {
+		t.Error("a verification-only authenticator issued a token")
+	}
+}
+
+func TestRS256RejectsHS256Token(t *testing.T) {
+	publicFile, _ := writeRSAKeys(t)
+	hs256, _ := newTestAuthenticator(t)
+	rs256, err := New(configs.AuthConfig{JWTAlgorithm: "RS256", JWTPublicKeyFile: publicFile}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := hs256.IssueToken("user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs256.ParseToken(token); err == nil {
+		t.Fatal("an HS256 token was accepted with RS256 configured")
+	}
+}
+
+func TestRS256MissingKeyFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.pub")
+	if _, err := New(configs.AuthConfig{JWTAlgorithm: "RS256", JWTPublicKeyFile: missing}, nil); err == nil {
+		t.Fatal("a missing public key file was accepted")
+	}
+}
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+)
+
+// errInvalidCheck is returned when registering a nil or unnamed check
+var errInvalidCheck = errors.New("check must not be nil and must have a name")
+
+// batchInitialDelay gives the checks registered during startup time to be added before the first sweep
+const batchInitialDelay = 2 * time.Second
+
+// batchHealth is a gosundheit.Health that runs all registered checks together on one ticker
+// instead of one timer per check, so every sweep reports the checks at the same point in time.
+// Per-check options such as gosundheit.ExecutionPeriod are ignored: every check runs each
+// interval, bounded by timeout.
+type batchHealth struct {
+	interval time.Duration
+	timeout  time.Duration
+	listener gosundheit.CheckListener
+
+	mu      sync.RWMutex
+	checks  map[string]gosundheit.Check
+	results map[string]gosundheit.Result
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// newBatchHealth starts sweeping every interval; DeregisterAll stops it
+func newBatchHealth(interval, timeout time.Duration, listener gosundheit.CheckListener) *batchHealth {
+	h := &batchHealth{
+		interval: interval,
+		timeout:  timeout,
+		listener: listener,
+		checks:   make(map[string]gosundheit.Check),
+		results:  make(map[string]gosundheit.Result),
+		stop:     make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+func (h *batchHealth) run() {
+	select {
+	case <-h.stop:
+		return
+	case now := <-time.After(batchInitialDelay):
+		h.sweep(now)
+	}
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.stop:
+			return
+		case now := <-ticker.C:
+			h.sweep(now)
+		}
+	}
+}
+
+// sweep runs every check concurrently and records all results with the sweep's start time
+func (h *batchHealth) sweep(now time.Time) {
+	h.mu.RLock()
+	checks := maps.Clone(h.checks)
+	h.mu.RUnlock()
+
+	type outcome struct {
+		details  interface{}
+		err      error
+		duration time.Duration
+	}
+	outcomes := make(map[string]outcome, len(checks))
+	var (
+		wg         sync.WaitGroup
+		outcomesMu sync.Mutex
+	)
+	for name, check := range checks {
+		h.listener.OnCheckStarted(name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := check.Execute(ctx)
+
+			outcomesMu.Lock()
+			outcomes[name] = outcome{details: details, err: err, duration: time.Since(start)}
+			outcomesMu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	completed := make(map[string]gosundheit.Result, len(outcomes))
+	for name, o := range outcomes {
+		// Deregistered while the sweep ran
+		if _, ok := h.checks[name]; !ok {
+			continue
+		}
+		result := nextResult(h.results[name], o.details, o.err, o.duration, now)
+		h.results[name] = result
+		completed[name] = result
+	}
+	h.mu.Unlock()
+
+	for name, result := range completed {
+		h.listener.OnCheckCompleted(name, result)
+	}
+}
+
+// nextResult builds a result the way gosundheit does, carrying the failure streak over from prev
+func nextResult(prev gosundheit.Result, details interface{}, err error, duration time.Duration, now time.Time) gosundheit.Result {
+	result := gosundheit.Result{Details: details, Timestamp: now, Duration: duration}
+	if err == nil {
+		return result
+	}
+
+	result.Error = checkError{Message: err.Error()}
+	result.ContiguousFailures = prev.ContiguousFailures + 1
+	result.TimeOfFirstFailure = prev.TimeOfFirstFailure
+	if prev.IsHealthy() || result.TimeOfFirstFailure == nil {
+		result.TimeOfFirstFailure = &now
+	}
+	return result
+}
+
+// checkError serializes to JSON like gosundheit's errors, which plain errors do not
+type checkError struct {
+	Message string `json:"message,omitempty"`
+}
+
+func (e checkError) Error() string {
+	return e.Message
+}
+
+// RegisterCheck implements gosundheit.Health. The check reports as not run yet until the next sweep.
+func (h *batchHealth) RegisterCheck(check gosundheit.Check, _ ...gosundheit.CheckOption) error {
+	if check == nil || check.Name() == "" {
+		return errInvalidCheck
+	}
+
+	now := time.Now()
+	result := gosundheit.Result{
+		Details:            gosundheit.ErrNotRunYet.Error(),
+		Error:              gosundheit.ErrNotRunYet,
+		Timestamp:          now,
+		ContiguousFailures: 1,
+		TimeOfFirstFailure: &now,
+	}
+
+	h.mu.Lock()
+	h.checks[check.Name()] = check
+	h.results[check.Name()] = result
+	h.mu.Unlock()
+
+	h.listener.OnCheckRegistered(check.Name(), result)
+	return nil
+}
+
+// prime records the result of a run made outside the sweeps for a check that was just registered
+func (h *batchHealth) prime(name string, details interface{}, err error, duration time.Duration, now time.Time) {
+	h.mu.Lock()
+	if _, ok := h.checks[name]; !ok {
+		h.mu.Unlock()
+		return
+	}
+	result := nextResult(gosundheit.Result{}, details, err, duration, now)
+	h.results[name] = result
+	h.mu.Unlock()
+
+	h.listener.OnCheckCompleted(name, result)
+}
+
+// Deregister implements gosundheit.Health
+func (h *batchHealth) Deregister(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.checks, name)
+	delete(h.results, name)
+}
+
+// DeregisterAll implements gosundheit.Health and stops the sweeps
+func (h *batchHealth) DeregisterAll() {
+	h.stopOnce.Do(func() { close(h.stop) })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clear(h.checks)
+	clear(h.results)
+}
+
+// Results implements gosundheit.Health
+func (h *batchHealth) Results() (map[string]gosundheit.Result, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	results := maps.Clone(h.results)
+	for _, result := range results {
+		if !result.IsHealthy() {
+			return results, false
+		}
+	}
+	return results, true
+}
+
+// IsHealthy implements gosundheit.Health
+func (h *batchHealth) IsHealthy() bool {
+	_, healthy := h.Results()
+	return healthy
+}
@@ -0,0 +1,213 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+	"github.com/AppsFlyer/go-sundheit/checks"
+)
+
+// recordingListener records the checks each listener callback was called for
+type recordingListener struct {
+	mu        sync.Mutex
+	started   []string
+	completed map[string]gosundheit.Result
+}
+
+func newRecordingListener() *recordingListener {
+	return &recordingListener{completed: make(map[string]gosundheit.Result)}
+}
+
+func (l *recordingListener) OnCheckRegistered(string, gosundheit.Result) {}
+
+func (l *recordingListener) OnCheckStarted(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.started = append(l.started, name)
+}
+
+func (l *recordingListener) OnCheckCompleted(name string, result gosundheit.Result) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.completed[name] = result
+}
+
+// newBatch returns a batch health that is stopped when the test ends
+func newBatch(t *testing.T, interval, timeout time.Duration, listener gosundheit.CheckListener) *batchHealth {
+	t.Helper()
+	h := newBatchHealth(interval, timeout, listener)
+	t.Cleanup(h.DeregisterAll)
+	return h
+}
+
+// timedCheck records when it last ran and sleeps for delay
+type timedCheck struct {
+	name  string
+	delay time.Duration
+	err   error
+	runs  atomic.Int32
+	last  atomic.Pointer[time.Time]
+}
+
+func (c *timedCheck) Name() string { return c.name }
+
+func (c *timedCheck) Execute(ctx context.Context) (interface{}, error) {
+	now := time.Now()
+	c.last.Store(&now)
+	c.runs.Add(1)
+	select {
+	case <-time.After(c.delay):
+		return c.name, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestBatchHealthRunsChecksInOneSweep(t *testing.T) {
+	listener := newRecordingListener()
+	h := newBatch(t, time.Hour, time.Second, listener)
+	all := []*timedCheck{
+		{name: "database", delay: 100 * time.Millisecond},
+		{name: "redis", delay: 100 * time.Millisecond},
+		{name: "disk", delay: 100 * time.Millisecond, err: errors.New("disk full")},
+	}
+	for _, c := range all {
+		if err := h.RegisterCheck(c, gosundheit.ExecutionPeriod(time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if results, healthy := h.Results(); healthy || len(results) != 3 {
+		t.Fatalf("results before the first sweep = %v, healthy %v; want 3 not run yet", results, healthy)
+	}
+
+	sweepTime := time.Now()
+	h.sweep(sweepTime)
+	if elapsed := time.Since(sweepTime); elapsed > 250*time.Millisecond {
+		t.Errorf("sweep took %s, want the checks run concurrently", elapsed)
+	}
+
+	results, healthy := h.Results()
+	if healthy {
+		t.Error("healthy with the disk check failing")
+	}
+	for _, c := range all {
+		result := results[c.name]
+		if !result.Timestamp.Equal(sweepTime) {
+			t.Errorf("%s timestamp = %s, want the sweep time", c.name, result.Timestamp)
+		}
+		if result.IsHealthy() != (c.err == nil) {
+			t.Errorf("%s healthy = %v, want %v", c.name, result.IsHealthy(), c.err == nil)
+		}
+		if c.runs.Load() != 1 {
+			t.Errorf("%s ran %d times, want once", c.name, c.runs.Load())
+		}
+	}
+	if len(listener.started) != 3 || len(listener.completed) != 3 {
+		t.Errorf("listener saw %d started and %d completed, want 3 each", len(listener.started), len(listener.completed))
+	}
+}
+
+func TestBatchHealthTracksFailureStreaks(t *testing.T) {
+	h := newBatch(t, time.Hour, time.Second, newRecordingListener())
+	check := &timedCheck{name: "redis", err: errors.New("connection refused")}
+	if err := h.RegisterCheck(check); err != nil {
+		t.Fatal(err)
+	}
+
+	// Like gosundheit, a check that has not run yet counts as the first failure
+	registered := time.Now()
+	first := registered.Add(time.Second)
+	h.sweep(first)
+	h.sweep(first.Add(time.Minute))
+	results, _ := h.Results()
+	result := results["redis"]
+	if result.ContiguousFailures != 3 || result.TimeOfFirstFailure == nil || result.TimeOfFirstFailure.After(registered) {
+		t.Errorf("result = %+v, want 3 failures since registration", result)
+	}
+	if result.Error == nil || result.Error.Error() != "connection refused" {
+		t.Errorf("error = %v, want the check's error", result.Error)
+	}
+
+	check.err = nil
+	h.sweep(first.Add(2 * time.Minute))
+	if !h.IsHealthy() {
+		t.Error("not healthy after the check recovered")
+	}
+}
+
+func TestBatchHealthAppliesTimeout(t *testing.T) {
+	h := newBatch(t, time.Hour, 20*time.Millisecond, newRecordingListener())
+	if err := h.RegisterCheck(&timedCheck{name: "upstream", delay: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+
+	h.sweep(time.Now())
+	results, _ := h.Results()
+	if err := results["upstream"].Error; err == nil || err.Error() != context.DeadlineExceeded.Error() {
+		t.Errorf("error = %v, want the timeout", err)
+	}
+}
+
+func TestBatchHealthRegistration(t *testing.T) {
+	h := newBatch(t, time.Hour, time.Second, newRecordingListener())
+	if err := h.RegisterCheck(nil); !errors.Is(err, errInvalidCheck) {
+		t.Errorf("RegisterCheck(nil) = %v, want errInvalidCheck", err)
+	}
+	if err := h.RegisterCheck(&checks.CustomCheck{}); !errors.Is(err, errInvalidCheck) {
+		t.Errorf("RegisterCheck(unnamed) = %v, want errInvalidCheck", err)
+	}
+
+	check := &timedCheck{name: "disk"}
+	if err := h.RegisterCheck(check); err != nil {
+		t.Fatal(err)
+	}
+	h.Deregister("disk")
+	h.sweep(time.Now())
+	if results, _ := h.Results(); len(results) != 0 || check.runs.Load() != 0 {
+		t.Errorf("deregistered check ran %d times with results %v", check.runs.Load(), results)
+	}
+}
+
+func TestBatchHealthSweepsOnSharedTicker(t *testing.T) {
+	h := newBatch(t, 100*time.Millisecond, time.Second, newRecordingListener())
+	all := []*timedCheck{{name: "database"}, {name: "redis"}, {name: "disk"}}
+	for _, c := range all {
+		if err := h.RegisterCheck(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(batchInitialDelay + 2*time.Second)
+	for !h.IsHealthy() {
+		if time.Now().After(deadline) {
+			t.Fatal("no sweep ran")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Every check of a sweep reports the same time and ran within the same window
+	results, _ := h.Results()
+	stamp := results["database"].Timestamp
+	var earliest, latest time.Time
+	for i, c := range all {
+		if !results[c.name].Timestamp.Equal(stamp) {
+			t.Errorf("%s timestamp = %s, want %s like the other checks", c.name, results[c.name].Timestamp, stamp)
+		}
+		ran := *c.last.Load()
+		if i == 0 || ran.Before(earliest) {
+			earliest = ran
+		}
+		if i == 0 || ran.After(latest) {
+			latest = ran
+		}
+	}
+	if spread := latest.Sub(earliest); spread > 50*time.Millisecond {
+		t.Errorf("checks ran %s apart, want them in one sweep", spread)
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// readBody answers 413 when reading the body hits the limit, otherwise echoes its length
+func readBody(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Write([]byte(strings.Repeat("x", len(body))))
+}
+
+func TestBodyLimit(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(bodyLimit(10))
+	r.Post("/small", readBody)
+	r.With(bodyLimit(100)).Post("/upload", readBody)
+
+	post := func(path string, size int, chunked bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(strings.Repeat("a", size)))
+		if chunked {
+			// An unknown length is only caught while reading
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"under the limit", "/small", 10, false, http.StatusOK},
+		{"declared over the limit", "/small", 11, false, http.StatusRequestEntityTooLarge},
+		{"declared over the limit, under the override", "/upload", 50, false, http.StatusOK},
+		{"streamed over the limit", "/small", 11, true, http.StatusRequestEntityTooLarge},
+		{"streamed under the override", "/upload", 50, true, http.StatusOK},
+		{"over the override", "/upload", 101, true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := post(tt.path, tt.size, tt.chunked)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && rec.Body.Len() != tt.size {
+				t.Errorf("handler read %d bytes, want %d", rec.Body.Len(), tt.size)
+			}
+		})
+	}
+}
+
+func TestBodyLimitOnAPIRoutes(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "http:\n  max_body_bytes: 64\n"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh",
+		strings.NewReader(`{"refresh_token":"`+strings.Repeat("a", 100)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := serveAs(a.router, req, "")
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"request_too_large"`) {
+		t.Errorf("body = %s, want the request_too_large envelope", rec.Body)
+	}
+}
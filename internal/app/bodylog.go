@@ -0,0 +1,128 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// bodyLogger logs request and response bodies of allowlisted routes for debugging integrations.
+// Only JSON bodies are logged, with the values of sensitive fields masked at any depth; other
+// bodies, and JSON cut off by the size cap, are reported by size only so nothing unredacted leaks.
+type bodyLogger struct {
+	app      *App
+	routes   []string
+	maxBytes int
+	redact   map[string]bool
+}
+
+func newBodyLogger(a *App, cfg configs.BodyLogConfig) *bodyLogger {
+	redact := make(map[string]bool, len(cfg.RedactFields))
+	for _, field := range cfg.RedactFields {
+		redact[strings.ToLower(field)] = true
+	}
+	return &bodyLogger{app: a, routes: cfg.Routes, maxBytes: cfg.MaxBytes, redact: redact}
+}
+
+// Middleware buffers up to the size cap of the request body, restoring it so the handler still
+// reads the whole body, and captures the same amount of the response
+func (l *bodyLogger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.allowed(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var reqBody []byte
+		if r.Body != nil && r.Body != http.NoBody {
+			// One byte past the cap tells a body that fits apart from a truncated one
+			reqBody, _ = io.ReadAll(io.LimitReader(r.Body, int64(l.maxBytes)+1))
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+		}
+
+		capture := &capWriter{max: l.maxBytes + 1}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(capture)
+		next.ServeHTTP(ww, r)
+
+		l.app.logger.Info("Request bodies",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", ww.Status(),
+			"request_body", l.body(r.Header.Get("Content-Type"), reqBody),
+			"response_body", l.body(ww.Header().Get("Content-Type"), capture.buf.Bytes()),
+			"request_id", requestid.FromContext(r.Context()),
+		)
+	})
+}
+
+// allowed reports whether path falls under one of the allowlisted route prefixes
+func (l *bodyLogger) allowed(path string) bool {
+	for _, route := range l.routes {
+		if path == route || strings.HasPrefix(path, strings.TrimSuffix(route, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// body returns the loggable form of a captured body
+func (l *bodyLogger) body(contentType string, body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	if !isJSONContentType(contentType) {
+		return map[string]interface{}{"omitted": "not JSON", "content_type": contentType, "bytes": len(body)}
+	}
+	if len(body) > l.maxBytes {
+		return map[string]interface{}{"omitted": "larger than the size cap", "max_bytes": l.maxBytes}
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return map[string]interface{}{"omitted": "invalid JSON", "bytes": len(body)}
+	}
+	return l.redactValue(value)
+}
+
+// redactValue masks the values of sensitive keys in decoded JSON
+func (l *bodyLogger) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if l.redact[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = l.redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = l.redactValue(item)
+		}
+	}
+	return value
+}
+
+// capWriter keeps the first max bytes written to it and discards the rest
+type capWriter struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (c *capWriter) Write(p []byte) (int, error) {
+	if room := c.max - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+func TestBodyLoggerRedactsBodies(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	log, logs := logtest.New(t)
+	a.logger = log
+
+	var received string
+	l := newBodyLogger(a, configs.BodyLogConfig{Routes: []string{"/api/v1/visits"}, MaxBytes: 64, RedactFields: []string{"Password", "notes"}})
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		respond.JSON(w, http.StatusCreated, map[string]interface{}{"id": "visit-1", "visits": []map[string]string{{"notes": "fever"}}})
+	}))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        map[string]interface{}
+	}{
+		{"json", "application/json", `{"user":"rep-1","password":"hunter2"}`, map[string]interface{}{"user": "rep-1", "password": redacted}},
+		{"over the cap", "application/json", `{"notes":"` + strings.Repeat("x", 100) + `"}`, map[string]interface{}{"omitted": "larger than the size cap", "max_bytes": float64(64)}},
+		{"not json", "text/plain", "password=hunter2", map[string]interface{}{"omitted": "not JSON", "content_type": "text/plain", "bytes": float64(16)}},
+		{"invalid json", "application/json", `{"password":`, map[string]interface{}{"omitted": "invalid JSON", "bytes": float64(12)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/visits", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			// The handler reads the whole body, even past the size cap
+			if received != tt.body {
+				t.Errorf("handler received %q, want %q", received, tt.body)
+			}
+
+			entries := logs.Entries()
+			entry := entries[len(entries)-1]
+			if entry.Message() != "Request bodies" || entry["status"] != float64(http.StatusCreated) {
+				t.Fatalf("entry = %v, want the bodies logged", entry)
+			}
+			if got, _ := entry["request_body"].(map[string]interface{}); !equalJSON(got, tt.want) {
+				t.Errorf("request_body = %v, want %v", got, tt.want)
+			}
+			response, _ := entry["response_body"].(map[string]interface{})
+			visits, _ := response["visits"].([]interface{})
+			if len(visits) != 1 || visits[0].(map[string]interface{})["notes"] != redacted {
+				t.Errorf("response_body = %v, want the nested notes redacted", response)
+			}
+		})
+	}
+}
+
+// equalJSON reports whether two decoded JSON objects hold the same scalar values
+func equalJSON(got, want map[string]interface{}) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for key, value := range want {
+		if got[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBodyLoggerOnlyLogsAllowlistedRoutes(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	log, logs := logtest.New(t)
+	a.logger = log
+
+	l := newBodyLogger(a, configs.BodyLogConfig{Routes: []string{"/api/v1/visits"}, MaxBytes: 64})
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for path, want := range map[string]bool{
+		"/api/v1/visits":   true,
+		"/api/v1/visits/1": true,
+		"/api/v1/visitsX":  false,
+		"/api/v1/reps":     false,
+	} {
+		before := logs.Count("Request bodies")
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if logged := logs.Count("Request bodies") > before; logged != want {
+			t.Errorf("%s logged = %v, want %v", path, logged, want)
+		}
+	}
+}
+
+func TestBodyLogRequiresDebugMode(t *testing.T) {
+	for debug, want := range map[bool]int{true: 1, false: 0} {
+		cfg := testConfig(t, "http:\n  body_log:\n    enabled: true\n    routes: [\"/version\"]\n")
+		cfg.App.Debug = debug
+		a, _ := newRoutedApp(t, cfg)
+		log, logs := logtest.New(t)
+		a.logger = log
+
+		get(a.router, "/version")
+		if n := logs.Count("Request bodies"); n != want {
+			t.Errorf("debug %v: logged %d bodies, want %d", debug, n, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+// errCircuitOpen is reported instead of running a check while its breaker is open
+var errCircuitOpen = errors.New("circuit open")
+
+// breakerCheck stops calling a dependency that keeps failing. After threshold consecutive
+// failures the check reports unhealthy immediately for the cooldown; the first run after the
+// cooldown probes the dependency again, closing the breaker on success and reopening it on failure.
+type breakerCheck struct {
+	gosundheit.Check
+	log       *logger.Logger
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// withBreaker wraps check in a circuit breaker. A threshold of zero returns check unchanged.
+func withBreaker(check gosundheit.Check, log *logger.Logger, threshold int, cooldown time.Duration) gosundheit.Check {
+	if threshold <= 0 {
+		return check
+	}
+	return &breakerCheck{Check: check, log: log, threshold: threshold, cooldown: cooldown}
+}
+
+// Execute implements gosundheit.Check
+func (c *breakerCheck) Execute(ctx context.Context) (interface{}, error) {
+	if err := c.open(time.Now()); err != nil {
+		return nil, err
+	}
+
+	details, err := c.Check.Execute(ctx)
+	c.observe(time.Now(), err)
+	return details, err
+}
+
+// open returns errCircuitOpen while the cooldown is running
+func (c *breakerCheck) open(now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Before(c.openUntil) {
+		return fmt.Errorf("%w after %d consecutive failures, retrying in %s",
+			errCircuitOpen, c.failures, c.openUntil.Sub(now).Round(time.Second))
+	}
+	return nil
+}
+
+func (c *breakerCheck) observe(now time.Time, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		if !c.openUntil.IsZero() {
+			c.log.Info("Health check circuit closed", "check", c.Name())
+		}
+		c.failures = 0
+		c.openUntil = time.Time{}
+		return
+	}
+
+	c.failures++
+	if c.failures >= c.threshold {
+		// A failed probe after the cooldown reopens the breaker for another cooldown
+		if c.openUntil.IsZero() {
+			c.log.Warn("Health check circuit opened",
+				"check", c.Name(),
+				"consecutive_failures", c.failures,
+				"cooldown", c.cooldown,
+			)
+		}
+		c.openUntil = now.Add(c.cooldown)
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AppsFlyer/go-sundheit/checks"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+func TestBreakerOpensAndProbesAfterCooldown(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer target.Close()
+
+	httpCheck, err := checks.NewHTTPCheck(checks.HTTPCheckConfig{CheckName: "http_target", URL: target.URL, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, logs := logtest.New(t)
+	const cooldown = 100 * time.Millisecond
+	check := withBreaker(httpCheck, log, 3, cooldown)
+
+	ctx := context.Background()
+	run := func() error {
+		t.Helper()
+		_, err := check.Execute(ctx)
+		return err
+	}
+
+	for i := range 3 {
+		if err := run(); err == nil || errors.Is(err, errCircuitOpen) {
+			t.Fatalf("run %d = %v, want the target's failure", i+1, err)
+		}
+	}
+	if logs.Count("Health check circuit opened") != 1 {
+		t.Error("opening the circuit was not logged")
+	}
+
+	// Open: failing immediately without calling the target
+	for range 5 {
+		if err := run(); !errors.Is(err, errCircuitOpen) {
+			t.Fatalf("run while open = %v, want errCircuitOpen", err)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("target called %d times, want 3 with the circuit open", n)
+	}
+
+	// A failed probe after the cooldown reopens it
+	time.Sleep(cooldown)
+	if err := run(); err == nil || errors.Is(err, errCircuitOpen) {
+		t.Fatalf("probe = %v, want the target's failure", err)
+	}
+	if err := run(); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("run after a failed probe = %v, want errCircuitOpen", err)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("target called %d times, want one probe after the cooldown", n)
+	}
+	if logs.Count("Health check circuit opened") != 1 {
+		t.Error("reopening after a failed probe was logged as a new opening")
+	}
+
+	// A successful probe closes it
+	healthy.Store(true)
+	time.Sleep(cooldown)
+	for i := range 2 {
+		if err := run(); err != nil {
+			t.Fatalf("run %d after recovery = %v", i+1, err)
+		}
+	}
+	if n := calls.Load(); n != 6 {
+		t.Errorf("target called %d times, want every run to reach it once closed", n)
+	}
+	if logs.Count("Health check circuit closed") != 1 {
+		t.Error("closing the circuit was not logged")
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	check := &checks.CustomCheck{CheckName: "target"}
+	if got := withBreaker(check, logtest.Discard(t), 0, time.Minute); got != check {
+		t.Errorf("withBreaker with a zero threshold = %T, want the check unchanged", got)
+	}
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"crypto/tls"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+// certReloader serves the current TLS keypair and swaps it in place when the files on disk change,
+// so rotated certificates are picked up without a restart
+type certReloader struct {
+	certFile string
+	keyFile  string
+	logger   *logger.Logger
+	cert     atomic.Value // *tls.Certificate
+	done     chan struct{}
+}
+
+// newCertReloader loads the initial keypair; it fails if the files cannot be parsed
+func newCertReloader(certFile, keyFile string, logger *logger.Logger) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads the keypair. On failure the previously loaded certificate stays in use.
+func (r *certReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load().(*tls.Certificate), nil
+}
+
+// Watch reloads the keypair when the certificate files change.
+// The parent directories are watched so symlink swaps (as done for Kubernetes secrets) are seen too.
+func (r *certReloader) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create certificate watcher: %w", err)
+	}
+
+	dirs := map[string]bool{
+		filepath.Dir(r.certFile): true,
+		filepath.Dir(r.keyFile):  true,
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Has(fsnotify.Chmod) {
+					continue
+				}
+				r.reload("file change")
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				r.logger.Error("Certificate watcher error", "error", err)
+			case <-r.done:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// reload reloads the keypair and logs the outcome
+func (r *certReloader) reload(reason string) {
+	if err := r.Reload(); err != nil {
+		r.logger.Error("TLS certificate reload failed, keeping previous certificate",
+			"reason", reason,
+			"error", err,
+		)
+		return
+	}
+	r.logger.Info("TLS certificate reloaded", "reason", reason)
+}
+
+// Close stops watching for changes
+func (r *certReloader) Close() {
+	close(r.done)
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// servedCert returns the DER bytes of the certificate r currently serves
+func servedCert(t *testing.T, r *certReloader) []byte {
+	t.Helper()
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert.Certificate[0]
+}
+
+func TestCertReloaderSwapsCertificate(t *testing.T) {
+	dir := t.TempDir()
+	first := newTestCert(t, "localhost", nil, false)
+	certFile, keyFile := first.write(t, dir)
+
+	log, logs := logtest.New(t)
+	r, err := newCertReloader(certFile, keyFile, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if !bytes.Equal(servedCert(t, r), first.der) {
+		t.Fatal("initial certificate is not served")
+	}
+
+	second := newTestCert(t, "localhost", nil, false)
+	second.write(t, dir)
+	deadline := time.Now().Add(5 * time.Second)
+	for !bytes.Equal(servedCert(t, r), second.der) {
+		if time.Now().After(deadline) {
+			t.Fatal("rotated certificate was not picked up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A broken file keeps the last good certificate in use
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for logs.Count("TLS certificate reload failed, keeping previous certificate") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("failed reload was not logged")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !bytes.Equal(servedCert(t, r), second.der) {
+		t.Fatal("a failed reload replaced the served certificate")
+	}
+}
+
+func TestNewCertReloaderRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := newTestCert(t, "localhost", nil, false).write(t, dir)
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newCertReloader(certFile, keyFile, logtest.Discard(t)); err == nil {
+		t.Fatal("an invalid key was accepted")
+	}
+}
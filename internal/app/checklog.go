@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+// loggedCheck logs a check's failures without flooding the log while a dependency stays down:
+// the first failure, a reminder with the consecutive failure count at most once per interval,
+// and the recovery
+type loggedCheck struct {
+	gosundheit.Check
+	log      *logger.Logger
+	interval time.Duration
+
+	mu          sync.Mutex
+	failures    int
+	failingFrom time.Time
+	lastLogged  time.Time
+}
+
+// logCheckFailures wraps check with de-duplicated failure logging. A zero interval logs only
+// the first failure and the recovery.
+func logCheckFailures(check gosundheit.Check, log *logger.Logger, interval time.Duration) gosundheit.Check {
+	return &loggedCheck{Check: check, log: log, interval: interval}
+}
+
+// registerCheck registers check with de-duplicated failure logging
+func (a *App) registerCheck(check gosundheit.Check, opts ...gosundheit.CheckOption) error {
+	return a.health.RegisterCheck(logCheckFailures(check, a.logger, a.config.Health.FailureLogInterval), opts...)
+}
+
+// registerDependencyCheck registers the check of a dependency New has already connected to.
+// With health.immediate_check it runs the check once synchronously first, so Results reflects
+// the dependency right away instead of reporting it as not run yet during the initial delay.
+func (a *App) registerDependencyCheck(check gosundheit.Check, opts ...gosundheit.CheckOption) error {
+	if !a.config.Health.ImmediateCheck {
+		return a.registerCheck(check, opts...)
+	}
+
+	logged := logCheckFailures(check, a.logger, a.config.Health.FailureLogInterval)
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Health.Timeout)
+	start := time.Now()
+	details, err := logged.Execute(ctx)
+	cancel()
+
+	if batch, ok := a.health.(*batchHealth); ok {
+		if err := batch.RegisterCheck(logged); err != nil {
+			return err
+		}
+		batch.prime(logged.Name(), details, err, time.Since(start), start)
+		return nil
+	}
+	// gosundheit has no way to seed a result, so the first run only decides the initial status
+	return a.health.RegisterCheck(logged, append(opts, gosundheit.InitiallyPassing(err == nil))...)
+}
+
+// Execute implements gosundheit.Check
+func (c *loggedCheck) Execute(ctx context.Context) (interface{}, error) {
+	details, err := c.Check.Execute(ctx)
+	c.observe(time.Now(), err)
+	return details, err
+}
+
+func (c *loggedCheck) observe(now time.Time, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		if c.failures > 0 {
+			c.log.Info("Health check recovered",
+				"check", c.Name(),
+				"consecutive_failures", c.failures,
+				"failing_for", now.Sub(c.failingFrom),
+			)
+		}
+		c.failures = 0
+		return
+	}
+
+	c.failures++
+	switch {
+	case c.failures == 1:
+		c.failingFrom, c.lastLogged = now, now
+		c.log.Error("Health check failed", "check", c.Name(), "error", err)
+	case c.interval > 0 && now.Sub(c.lastLogged) >= c.interval:
+		c.lastLogged = now
+		c.log.Error("Health check still failing",
+			"check", c.Name(),
+			"consecutive_failures", c.failures,
+			"failing_for", now.Sub(c.failingFrom),
+			"error", err,
+		)
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+	"github.com/AppsFlyer/go-sundheit/checks"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/database/dbtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+func TestLoggedCheckDeduplicatesFailures(t *testing.T) {
+	log, logs := logtest.New(t)
+	check := logCheckFailures(&checks.CustomCheck{CheckName: "database"}, log, 5*time.Minute).(*loggedCheck)
+
+	// Ten failures a minute apart: the first is logged, then a reminder after five minutes
+	start := time.Unix(1_700_000_000, 0)
+	down := errors.New("connection refused")
+	for i := range 10 {
+		check.observe(start.Add(time.Duration(i)*time.Minute), down)
+	}
+
+	if n := logs.Count("Health check failed"); n != 1 {
+		t.Errorf("logged %d first failures, want 1", n)
+	}
+	if n := logs.Count("Health check still failing"); n != 1 {
+		t.Fatalf("logged %d reminders, want 1", n)
+	}
+	reminder, _ := logs.Find("Health check still failing")
+	if reminder["check"] != "database" || reminder["consecutive_failures"] != float64(6) {
+		t.Errorf("reminder = %v, want the database check at 6 consecutive failures", reminder)
+	}
+
+	check.observe(start.Add(10*time.Minute), nil)
+	recovered, ok := logs.Find("Health check recovered")
+	if !ok {
+		t.Fatal("the recovery was not logged")
+	}
+	if recovered["consecutive_failures"] != float64(10) {
+		t.Errorf("recovery = %v, want 10 consecutive failures", recovered)
+	}
+
+	// Passing checks log nothing, and a new failure is logged as a first failure again
+	check.observe(start.Add(11*time.Minute), nil)
+	check.observe(start.Add(12*time.Minute), down)
+	if n := logs.Count("Health check recovered"); n != 1 {
+		t.Errorf("logged %d recoveries, want 1", n)
+	}
+	if n := logs.Count("Health check failed"); n != 2 {
+		t.Errorf("logged %d first failures after a new outage, want 2", n)
+	}
+}
+
+func TestLoggedCheckWithoutReminders(t *testing.T) {
+	log, logs := logtest.New(t)
+	check := logCheckFailures(&checks.CustomCheck{CheckName: "redis"}, log, 0).(*loggedCheck)
+
+	start := time.Unix(1_700_000_000, 0)
+	for i := range 10 {
+		check.observe(start.Add(time.Duration(i)*time.Hour), errors.New("down"))
+	}
+	if n := len(logs.Entries()); n != 1 {
+		t.Errorf("logged %d entries with a zero interval, want only the first failure", n)
+	}
+}
+
+func TestLoggedCheckExecute(t *testing.T) {
+	log, logs := logtest.New(t)
+	check := logCheckFailures(&checks.CustomCheck{
+		CheckName: "upstream",
+		CheckFunc: func(context.Context) (interface{}, error) { return "details", errors.New("timeout") },
+	}, log, time.Minute)
+
+	if check.Name() != "upstream" {
+		t.Errorf("Name = %q, want upstream", check.Name())
+	}
+	details, err := check.Execute(context.Background())
+	if details != "details" || err == nil || err.Error() != "timeout" {
+		t.Errorf("Execute = %v, %v, want the wrapped check's result", details, err)
+	}
+	if entry, ok := logs.Find("Health check failed"); !ok || entry["check"] != "upstream" {
+		t.Errorf("failure log = %v, want one for upstream", entry)
+	}
+}
+
+func TestDependencyChecksRunOnRegistration(t *testing.T) {
+	tests := []struct {
+		name        string
+		yaml        string
+		redisDown   bool
+		wantHealthy bool
+	}{
+		{"immediate", "", false, true},
+		{"immediate in batch mode", "health:\n  batch_mode: true\n", false, true},
+		{"immediate with a dependency down", "", true, false},
+		{"immediate in batch mode with a dependency down", "health:\n  batch_mode: true\n", true, false},
+		// Without the immediate run the checks are not run yet until their initial delay
+		{"deferred", "health:\n  immediate_check: false\n", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t, tt.yaml)
+			cfg.Health.DiskCheck = false
+			cfg.Health.SelfCheck = false
+
+			a := newTestApp(t, cfg)
+			a.db = dbtest.NewPing(t)
+			client, server := redistest.New(t)
+			a.redis = client
+			if tt.redisDown {
+				server.Close()
+			}
+
+			history := newCheckHistory(cfg.Health.FailureWindow)
+			if cfg.Health.BatchMode {
+				a.health = newBatchHealth(cfg.Health.CheckInterval, cfg.Health.Timeout, history)
+			} else {
+				a.health = gosundheit.New(gosundheit.WithCheckListeners(history))
+			}
+			t.Cleanup(a.health.DeregisterAll)
+
+			if err := a.setupHealthChecks(); err != nil {
+				t.Fatal(err)
+			}
+
+			results, healthy := a.health.Results()
+			if healthy != tt.wantHealthy {
+				t.Errorf("healthy = %v, want %v: %v", healthy, tt.wantHealthy, results)
+			}
+			if db := results["database"]; cfg.Health.ImmediateCheck && db.Error != nil {
+				t.Errorf("database = %v, want passing", db)
+			}
+			if redis := results["redis"]; tt.redisDown && redis.Error == nil {
+				t.Errorf("redis = %v, want failing", redis)
+			}
+			if cfg.Health.BatchMode && results["database"].Timestamp.IsZero() {
+				t.Error("the batch result of the first run has no timestamp")
+			}
+		})
+	}
+}
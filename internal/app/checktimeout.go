@@ -0,0 +1,24 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// checkWithTimeout bounds a health check by timeout. A check that runs out of time reports
+// a timeout detail and an error wrapping context.DeadlineExceeded.
+func checkWithTimeout(name string, timeout time.Duration, check func(ctx context.Context) (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		details, err := check(ctx)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return map[string]string{"status": "timeout", "timeout": timeout.String()},
+				fmt.Errorf("%s check timed out after %s: %w", name, timeout, context.DeadlineExceeded)
+		}
+		return details, err
+	}
+}
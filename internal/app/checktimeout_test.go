@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckWithTimeout(t *testing.T) {
+	hang := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	healthy := func(context.Context) (interface{}, error) {
+		return map[string]string{"status": "healthy"}, nil
+	}
+	refused := errors.New("connection refused")
+	failing := func(context.Context) (interface{}, error) { return nil, refused }
+
+	start := time.Now()
+	details, err := checkWithTimeout("database", 20*time.Millisecond, hang)(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("hanging check took %s, want it cut off at the timeout", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || err.Error() != "database check timed out after 20ms: context deadline exceeded" {
+		t.Errorf("err = %v, want a database timeout", err)
+	}
+	if d, _ := details.(map[string]string); d["status"] != "timeout" || d["timeout"] != "20ms" {
+		t.Errorf("details = %v, want a timeout status", details)
+	}
+
+	details, err = checkWithTimeout("redis", time.Second, healthy)(context.Background())
+	if err != nil || details.(map[string]string)["status"] != "healthy" {
+		t.Errorf("healthy check = %v, %v", details, err)
+	}
+
+	if _, err := checkWithTimeout("redis", time.Second, failing)(context.Background()); err != refused {
+		t.Errorf("err = %v, want the check's own error", err)
+	}
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// compressor gzips responses whose content type is allowed and whose body reaches the minimum size
+type compressor struct {
+	minSize int
+	types   map[string]bool
+	pool    sync.Pool
+}
+
+// newCompressor creates the compressor; the level is checked in configs.validate
+func newCompressor(cfg configs.CompressionConfig) *compressor {
+	c := &compressor{
+		minSize: cfg.MinSize,
+		types:   make(map[string]bool, len(cfg.ContentTypes)),
+	}
+	for _, t := range cfg.ContentTypes {
+		c.types[strings.ToLower(t)] = true
+	}
+	c.pool.New = func() interface{} {
+		gz, _ := gzip.NewWriterLevel(nil, cfg.Level)
+		return gz
+	}
+	return c
+}
+
+// Middleware compresses eligible responses when the client accepts gzip
+func (c *compressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		if !acceptsGzip(r) || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, c: c, status: http.StatusOK}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// allowed reports whether a Content-Type is in the allowlist, supporting "type/*" entries
+func (c *compressor) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if c.types[mediaType] {
+		return true
+	}
+	if i := strings.IndexByte(mediaType, '/'); i > 0 {
+		return c.types[mediaType[:i]+"/*"]
+	}
+	return false
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip with a non-zero quality
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.TrimSpace(coding)
+		if !strings.EqualFold(coding, "gzip") && coding != "*" {
+			continue
+		}
+		q, found := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q=")
+		if !found {
+			return true
+		}
+		quality, err := strconv.ParseFloat(q, 64)
+		return err == nil && quality > 0
+	}
+	return false
+}
+
+// compressWriter buffers the start of the body until it knows whether to compress
+type compressWriter struct {
+	http.ResponseWriter
+	c       *compressor
+	status  int
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	decided bool
+	wrote   bool
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wrote {
+		return
+	}
+	cw.wrote = true
+	cw.status = status
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.wrote = true
+	if cw.decided {
+		if cw.gz != nil {
+			return cw.gz.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf.Write(p)
+	if cw.buf.Len() >= cw.c.minSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide sends the headers and buffered bytes, compressing when large enough and eligible
+func (cw *compressWriter) decide(largeEnough bool) error {
+	cw.decided = true
+	h := cw.Header()
+
+	if h.Get("Content-Type") == "" && cw.buf.Len() > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf.Bytes()))
+	}
+
+	compress := largeEnough &&
+		h.Get("Content-Encoding") == "" &&
+		cw.status != http.StatusNoContent &&
+		cw.status != http.StatusNotModified &&
+		cw.c.allowed(h.Get("Content-Type"))
+
+	if compress {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		// The compressed bytes differ from the ones a strong ETag was computed over
+		if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			h.Set("ETag", "W/"+etag)
+		}
+		cw.gz = cw.c.pool.Get().(*gzip.Writer)
+		cw.gz.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.gz != nil {
+		_, err = cw.gz.Write(cw.buf.Bytes())
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf.Bytes())
+	}
+	cw.buf.Reset()
+	return err
+}
+
+// Flush commits to a decision so streamed responses are not held back by the size threshold
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.decide(true)
+	}
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// close writes anything still buffered and finishes the gzip stream
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if !cw.wrote {
+			return
+		}
+		cw.decide(false)
+	}
+	if cw.gz != nil {
+		cw.gz.Close()
+		cw.c.pool.Put(cw.gz)
+		cw.gz = nil
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// testCompressor compresses JSON and text of at least 1 KiB
+func testCompressor() *compressor {
+	return newCompressor(configs.CompressionConfig{
+		Enabled:      true,
+		Level:        5,
+		MinSize:      1024,
+		ContentTypes: []string{"application/json", "text/*"},
+	})
+}
+
+// serving returns a handler writing body with contentType and any extra headers
+func serving(contentType, body string, headers map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		io.WriteString(w, body)
+	})
+}
+
+// compressed serves a request accepting encoding through the compressor
+func compressed(handler http.Handler, encoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/reps", nil)
+	if encoding != "" {
+		req.Header.Set("Accept-Encoding", encoding)
+	}
+	rec := httptest.NewRecorder()
+	testCompressor().Middleware(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCompressorGzipsLargeJSON(t *testing.T) {
+	body := `{"reps":[` + strings.Repeat(`{"name":"rep"},`, 200) + `{}]}`
+	rec := compressed(serving("application/json", body, nil), "br, gzip")
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", rec.Header().Get("Content-Encoding"))
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", rec.Header().Get("Vary"))
+	}
+	if rec.Body.Len() >= len(body) {
+		t.Errorf("compressed body is %d bytes, want fewer than %d", rec.Body.Len(), len(body))
+	}
+
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != body {
+		t.Error("decompressed body differs from the original")
+	}
+}
+
+func TestCompressorLeavesResponsesAlone(t *testing.T) {
+	large := strings.Repeat("a", 2048)
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		headers     map[string]string
+		encoding    string
+	}{
+		{"below threshold", "application/json", `{"ok":true}`, nil, "gzip"},
+		{"not accepted", "application/json", large, nil, ""},
+		{"refused by quality", "application/json", large, nil, "gzip;q=0"},
+		{"type not allowed", "image/png", large, nil, "gzip"},
+		{"already encoded", "application/json", large, map[string]string{"Content-Encoding": "br"}, "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := compressed(serving(tt.contentType, tt.body, tt.headers), tt.encoding)
+			if rec.Header().Get("Content-Encoding") == "gzip" {
+				t.Fatal("response was gzipped")
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("body was altered: %q", rec.Body.String())
+			}
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCompressorWeakensStrongETag(t *testing.T) {
+	rec := compressed(serving("text/plain", strings.Repeat("a", 2048), map[string]string{"ETag": `"v1"`}), "gzip")
+	if rec.Header().Get("ETag") != `W/"v1"` {
+		t.Errorf("ETag = %q, want it weakened", rec.Header().Get("ETag"))
+	}
+}
+
+func TestCompressorKeepsStatus(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, strings.Repeat(" ", 2048))
+	})
+	rec := compressed(handler, "gzip")
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("got %d with encoding %q, want a gzipped 201", rec.Code, rec.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":                 false,
+		"gzip":             true,
+		"GZIP":             true,
+		"deflate, gzip":    true,
+		"gzip;q=0":         false,
+		"gzip; q=0.5":      true,
+		"*":                true,
+		"br":               false,
+		"identity, br;q=1": false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", header)
+		if got := acceptsGzip(req); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
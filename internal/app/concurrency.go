@@ -0,0 +1,74 @@
+package app
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// concurrencyLimiter bounds the number of requests served at once so a traffic spike cannot
+// exhaust database connections and memory. Requests over the limit wait up to wait for a slot
+// and are then rejected with 503 and Retry-After.
+type concurrencyLimiter struct {
+	slots chan struct{}
+	wait  time.Duration
+	// exempt paths are never limited so probes keep answering under load
+	exempt []string
+}
+
+func newConcurrencyLimiter(limit int, wait time.Duration, metricsPath string) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		slots:  make(chan struct{}, limit),
+		wait:   wait,
+		exempt: []string{"/ping", "/health", "/healthz", "/readiness", "/liveness", metricsPath},
+	}
+}
+
+// Middleware serves the request once it holds a slot
+func (l *concurrencyLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slices.Contains(l.exempt, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !l.acquire(r) {
+			w.Header().Set("Retry-After", "1")
+			respond.Error(w, http.StatusServiceUnavailable, "overloaded",
+				"the server is handling too many requests, please retry shortly")
+			return
+		}
+		defer l.release()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acquire takes a slot, waiting up to the queue timeout while the client is still connected
+func (l *concurrencyLimiter) acquire(r *http.Request) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if l.wait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
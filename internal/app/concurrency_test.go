@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// blockedHandler returns a handler that signals started and then blocks until release is closed
+func blockedHandler() (http.Handler, chan struct{}, chan struct{}) {
+	started, release := make(chan struct{}, 16), make(chan struct{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}), started, release
+}
+
+func TestConcurrencyLimiterRejectsOverflow(t *testing.T) {
+	handler, started, release := blockedHandler()
+	limited := newConcurrencyLimiter(2, 0, "/metrics").Middleware(handler)
+
+	done := make(chan int, 2)
+	for range 2 {
+		go func() { done <- get(limited, "/api/v1/visits").Code }()
+		<-started
+	}
+
+	rec := get(limited, "/api/v1/visits")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("overflow = %d with Retry-After %q, want 503 with Retry-After 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if code := errorCode(t, rec); code != "overloaded" {
+		t.Errorf("code = %q, want overloaded", code)
+	}
+
+	// Probes are never limited
+	for _, path := range []string{"/readiness", "/metrics"} {
+		go get(limited, path)
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Errorf("%s was limited", path)
+		}
+	}
+
+	// Once capacity frees the next request is served
+	close(release)
+	for range 2 {
+		if code := <-done; code != http.StatusOK {
+			t.Errorf("limited request = %d, want 200", code)
+		}
+	}
+	if rec := get(limited, "/api/v1/visits"); rec.Code != http.StatusOK {
+		t.Errorf("request after capacity freed = %d, want 200", rec.Code)
+	}
+}
+
+func TestConcurrencyLimiterQueues(t *testing.T) {
+	handler, started, release := blockedHandler()
+	limited := newConcurrencyLimiter(1, time.Second, "/metrics").Middleware(handler)
+
+	first := make(chan int, 1)
+	go func() { first <- get(limited, "/api/v1/visits").Code }()
+	<-started
+
+	// The second request waits for the slot instead of failing
+	second := make(chan int, 1)
+	go func() { second <- get(limited, "/api/v1/visits").Code }()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("first = %d, want 200", code)
+	}
+	if code := <-second; code != http.StatusOK {
+		t.Errorf("queued = %d, want 200 once the slot freed", code)
+	}
+}
+
+func TestConcurrencyLimiterGivesUpWaiting(t *testing.T) {
+	handler, started, release := blockedHandler()
+	defer close(release)
+	limited := newConcurrencyLimiter(1, 50*time.Millisecond, "/metrics").Middleware(handler)
+
+	go get(limited, "/api/v1/visits")
+	<-started
+
+	start := time.Now()
+	if rec := get(limited, "/api/v1/visits"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503 after the wait", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("rejected after %s, want the 50ms wait first", elapsed)
+	}
+
+	// A client that goes away stops waiting right away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	start = time.Now()
+	limited.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/visits", nil).WithContext(ctx))
+	if rec.Code != http.StatusServiceUnavailable || time.Since(start) >= 50*time.Millisecond {
+		t.Errorf("cancelled request = %d after %s, want 503 without waiting", rec.Code, time.Since(start))
+	}
+}
+
+func TestConcurrencyLimitFromConfig(t *testing.T) {
+	for limit, want := range map[int]int{0: http.StatusOK, 1: http.StatusServiceUnavailable} {
+		cfg := testConfig(t, "http:\n  concurrency_wait: 0s\n")
+		cfg.HTTP.MaxConcurrentRequests = limit
+		a, _ := newRoutedApp(t, cfg)
+		handler, started, release := blockedHandler()
+		a.router.Method(http.MethodGet, "/slow", handler)
+
+		go get(a.router, "/slow")
+		<-started
+		if rec := get(a.router, "/version"); rec.Code != want {
+			t.Errorf("limit %d: request beside a slow one = %d, want %d", limit, rec.Code, want)
+		}
+		close(release)
+	}
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+	"github.com/AppsFlyer/go-sundheit/checks"
+)
+
+// statfsFunc reports filesystem statistics for a path (syscall.Statfs outside tests)
+type statfsFunc func(path string, buf *syscall.Statfs_t) error
+
+// newDiskCheck creates a health check that fails when free space on path drops below minFree bytes
+func newDiskCheck(path string, minFree uint64, statfs statfsFunc) gosundheit.Check {
+	return &checks.CustomCheck{
+		CheckName: "disk",
+		CheckFunc: func(ctx context.Context) (interface{}, error) {
+			var stat syscall.Statfs_t
+			if err := statfs(path, &stat); err != nil {
+				return nil, fmt.Errorf("statfs %s failed: %w", path, err)
+			}
+
+			free := stat.Bavail * uint64(stat.Bsize)
+			total := stat.Blocks * uint64(stat.Bsize)
+			details := map[string]interface{}{
+				"path":        path,
+				"free_bytes":  free,
+				"total_bytes": total,
+			}
+
+			if free < minFree {
+				return details, fmt.Errorf("free disk space %d bytes is below threshold %d bytes", free, minFree)
+			}
+			return details, nil
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+)
+
+// fakeStatfs reports a filesystem of 4 KiB blocks with the given free and total block counts
+func fakeStatfs(free, total uint64) statfsFunc {
+	return func(path string, buf *syscall.Statfs_t) error {
+		buf.Bsize = 4096
+		buf.Bavail = free
+		buf.Blocks = total
+		return nil
+	}
+}
+
+func TestDiskCheck(t *testing.T) {
+	const minFree = 100 * 4096
+
+	tests := []struct {
+		name    string
+		statfs  statfsFunc
+		wantErr bool
+	}{
+		{"enough space", fakeStatfs(1000, 2000), false},
+		{"exactly the threshold", fakeStatfs(100, 2000), false},
+		{"low space", fakeStatfs(99, 2000), true},
+		{"statfs error", func(string, *syscall.Statfs_t) error { return errors.New("no such file") }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := newDiskCheck("/var/log", minFree, tt.statfs)
+			if check.Name() != "disk" {
+				t.Errorf("name = %q, want disk", check.Name())
+			}
+
+			details, err := check.Execute(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.name == "statfs error" {
+				return
+			}
+
+			d := details.(map[string]interface{})
+			if d["free_bytes"] == nil || d["total_bytes"] != uint64(2000*4096) {
+				t.Errorf("details = %v, want free and total bytes", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// etagger adds strong ETags to buffered GET responses and answers matching conditional requests with 304
+type etagger struct {
+	maxSize      int
+	cacheControl string
+}
+
+func newETagger(cfg configs.ETagConfig) *etagger {
+	return &etagger{maxSize: cfg.MaxSize, cacheControl: cfg.CacheControl}
+}
+
+// Middleware buffers successful GET responses up to maxSize. Larger or flushed responses are
+// streamed unchanged, as are responses whose handler already set an ETag.
+func (e *etagger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ew := &etagWriter{ResponseWriter: w, maxSize: e.maxSize, status: http.StatusOK}
+		next.ServeHTTP(ew, r)
+		if ew.streaming {
+			return
+		}
+
+		h := w.Header()
+		if ew.status != http.StatusOK || h.Get("ETag") != "" {
+			ew.flushBuffer()
+			return
+		}
+
+		sum := sha256.Sum256(ew.buf.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		h.Set("ETag", etag)
+		if h.Get("Cache-Control") == "" && e.cacheControl != "" {
+			h.Set("Cache-Control", e.cacheControl)
+		}
+
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			h.Del("Content-Type")
+			h.Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		ew.flushBuffer()
+	})
+}
+
+// etagMatches applies the weak comparison If-None-Match requires
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagWriter holds the response until it completes, or streams it once it outgrows maxSize
+type etagWriter struct {
+	http.ResponseWriter
+	maxSize     int
+	status      int
+	buf         bytes.Buffer
+	wroteHeader bool
+	streaming   bool
+}
+
+func (ew *etagWriter) WriteHeader(status int) {
+	if ew.streaming || ew.wroteHeader {
+		return
+	}
+	ew.wroteHeader = true
+	ew.status = status
+}
+
+func (ew *etagWriter) Write(p []byte) (int, error) {
+	ew.wroteHeader = true
+	if ew.streaming {
+		return ew.ResponseWriter.Write(p)
+	}
+	if ew.buf.Len()+len(p) > ew.maxSize {
+		ew.stream()
+		return ew.ResponseWriter.Write(p)
+	}
+	return ew.buf.Write(p)
+}
+
+// Flush gives up on the ETag so streamed responses are delivered immediately
+func (ew *etagWriter) Flush() {
+	if !ew.streaming {
+		ew.stream()
+	}
+	if f, ok := ew.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (ew *etagWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
+
+// stream switches to pass-through, writing the status and anything buffered so far
+func (ew *etagWriter) stream() {
+	ew.streaming = true
+	ew.flushBuffer()
+}
+
+// flushBuffer writes the held status and body
+func (ew *etagWriter) flushBuffer() {
+	ew.ResponseWriter.WriteHeader(ew.status)
+	if ew.buf.Len() > 0 {
+		ew.ResponseWriter.Write(ew.buf.Bytes())
+		ew.buf.Reset()
+	}
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// products is a cacheable reference list handler
+var products = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, `[{"id":1,"name":"Amoxicillin"}]`)
+})
+
+// conditional serves a request through an etagger allowing 64-byte bodies, sending
+// If-None-Match when etag is not empty
+func conditional(handler http.Handler, method, etag string) *httptest.ResponseRecorder {
+	e := newETagger(configs.ETagConfig{Enabled: true, MaxSize: 64, CacheControl: "private, no-cache"})
+	req := httptest.NewRequest(method, "/api/v1/products", nil)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	rec := httptest.NewRecorder()
+	e.Middleware(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestETagConditionalRequest(t *testing.T) {
+	first := conditional(products, http.MethodGet, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("first request = %d with ETag %q, want 200 with a strong ETag", first.Code, etag)
+	}
+	if first.Body.String() != `[{"id":1,"name":"Amoxicillin"}]` {
+		t.Errorf("body = %q, want it unchanged", first.Body)
+	}
+	if got := first.Header().Get("Cache-Control"); got != "private, no-cache" {
+		t.Errorf("Cache-Control = %q, want the configured policy", got)
+	}
+
+	for _, header := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		rec := conditional(products, http.MethodGet, header)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s = %d with %d bytes, want an empty 304", header, rec.Code, rec.Body.Len())
+		}
+		if rec.Header().Get("ETag") != etag {
+			t.Errorf("304 ETag = %q, want %q", rec.Header().Get("ETag"), etag)
+		}
+	}
+
+	if rec := conditional(products, http.MethodGet, `"stale"`); rec.Code != http.StatusOK {
+		t.Errorf("stale If-None-Match = %d, want 200", rec.Code)
+	}
+}
+
+func TestETagLeavesResponsesAlone(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		handler http.HandlerFunc
+	}{
+		{"not a GET", http.MethodPost, products},
+		{"error status", http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "missing")
+		}},
+		{"over the size limit", http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, strings.Repeat("p", 65))
+		}},
+		{"flushed", http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "event: visit\n")
+			w.(http.Flusher).Flush()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := httptest.NewRecorder()
+			tt.handler.ServeHTTP(want, httptest.NewRequest(tt.method, "/", nil))
+
+			rec := conditional(tt.handler, tt.method, "*")
+			if rec.Header().Get("ETag") != "" {
+				t.Errorf("ETag = %q, want none", rec.Header().Get("ETag"))
+			}
+			if rec.Code != want.Code || rec.Body.String() != want.Body.String() {
+				t.Errorf("got %d %q, want %d %q", rec.Code, rec.Body, want.Code, want.Body)
+			}
+		})
+	}
+}
+
+func TestETagKeepsHandlerETag(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v42"`)
+		io.WriteString(w, "territories")
+	})
+
+	rec := conditional(handler, http.MethodGet, `"v42"`)
+	if rec.Header().Get("ETag") != `"v42"` || rec.Code != http.StatusOK || rec.Body.String() != "territories" {
+		t.Errorf("got %d %q with ETag %q, want the handler's response untouched", rec.Code, rec.Body, rec.Header().Get("ETag"))
+	}
+}
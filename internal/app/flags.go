@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rixtrayker/medical-rep/internal/app/auth"
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+	"github.com/rixtrayker/medical-rep/internal/platform/flags"
+	"github.com/rixtrayker/medical-rep/internal/platform/tenant"
+)
+
+// flagSubject keys percentage rollouts on the authenticated user, falling back to the tenant
+func flagSubject(ctx context.Context) string {
+	if claims, ok := auth.FromContext(ctx); ok && claims.UserID != "" {
+		return "user:" + claims.UserID
+	}
+	if id := tenant.FromContext(ctx); id != "" {
+		return "tenant:" + id
+	}
+	return ""
+}
+
+// listFlagsHandler reports the effective state of every configured flag
+func (a *App) listFlagsHandler(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, a.flags.List(r.Context()))
+}
+
+// setFlagHandler overrides a flag for every instance
+func (a *App) setFlagHandler(w http.ResponseWriter, r *http.Request) {
+	if a.redis == nil {
+		respond.Error(w, http.StatusServiceUnavailable, "unavailable", "flag overrides require redis")
+		return
+	}
+
+	var state flags.State
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+		respond.Error(w, http.StatusBadRequest, "bad_request", "invalid request body")
+		return
+	}
+	if state.Percentage < 0 || state.Percentage > 100 {
+		respond.Error(w, http.StatusBadRequest, "bad_request", "percentage must be between 0 and 100")
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	if err := a.flags.SetOverride(r.Context(), name, state); err != nil {
+		a.logger.Error("Failed to store feature flag override", "flag", name, "error", err)
+		respond.Error(w, http.StatusServiceUnavailable, "unavailable", "failed to store flag override")
+		return
+	}
+
+	a.logger.Warn("Feature flag overridden", "flag", name, "enabled", state.Enabled, "percentage", state.Percentage)
+	respond.JSON(w, http.StatusOK, a.flags.Get(r.Context(), name))
+}
+
+// deleteFlagHandler removes a flag's override so its config default applies again
+func (a *App) deleteFlagHandler(w http.ResponseWriter, r *http.Request) {
+	if a.redis == nil {
+		respond.Error(w, http.StatusServiceUnavailable, "unavailable", "flag overrides require redis")
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	if err := a.flags.DeleteOverride(r.Context(), name); err != nil {
+		a.logger.Error("Failed to delete feature flag override", "flag", name, "error", err)
+		respond.Error(w, http.StatusServiceUnavailable, "unavailable", "failed to delete flag override")
+		return
+	}
+
+	a.logger.Warn("Feature flag override removed", "flag", name)
+	respond.JSON(w, http.StatusOK, a.flags.Get(r.Context(), name))
+}
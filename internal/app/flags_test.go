@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/internal/app/auth"
+	"github.com/rixtrayker/medical-rep/internal/platform/flags"
+	"github.com/rixtrayker/medical-rep/internal/platform/tenant"
+)
+
+func TestFlagAdminEndpoints(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "features:\n  visit_map:\n    enabled: false\n"))
+	token := bearer(t, a, "admin")
+	ctx := context.Background()
+
+	flag := func(rec *httptest.ResponseRecorder) flags.Flag {
+		t.Helper()
+		var f flags.Flag
+		if err := json.Unmarshal(rec.Body.Bytes(), &f); err != nil {
+			t.Fatalf("invalid body %q: %v", rec.Body, err)
+		}
+		return f
+	}
+
+	rec := serveAs(a.router, httptest.NewRequest(http.MethodPut, "/admin/flags/visit_map", strings.NewReader(`{"enabled":true}`)), token)
+	if rec.Code != http.StatusOK || flag(rec).Source != "override" || !a.flags.Enabled(ctx, "visit_map") {
+		t.Fatalf("PUT = %d %s, want the override applied", rec.Code, rec.Body)
+	}
+
+	rec = serveAs(a.router, httptest.NewRequest(http.MethodGet, "/admin/flags", nil), token)
+	var list []flags.Flag
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 1 || !list[0].State.Enabled {
+		t.Errorf("GET = %s, want the overridden flag", rec.Body)
+	}
+
+	rec = serveAs(a.router, httptest.NewRequest(http.MethodDelete, "/admin/flags/visit_map", nil), token)
+	if rec.Code != http.StatusOK || flag(rec).Source != "config" || a.flags.Enabled(ctx, "visit_map") {
+		t.Errorf("DELETE = %d %s, want the config default back", rec.Code, rec.Body)
+	}
+
+	for _, body := range []string{`{"enabled":true,"percentage":101}`, `not json`} {
+		rec := serveAs(a.router, httptest.NewRequest(http.MethodPut, "/admin/flags/visit_map", strings.NewReader(body)), token)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s = %d, want 400", body, rec.Code)
+		}
+	}
+
+	rec = serveAs(a.router, httptest.NewRequest(http.MethodPut, "/admin/flags/visit_map", strings.NewReader(`{"enabled":true}`)), bearer(t, a))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("non-admin PUT = %d, want 403", rec.Code)
+	}
+}
+
+func TestFlagSubject(t *testing.T) {
+	ctx := context.Background()
+	if got := flagSubject(ctx); got != "" {
+		t.Errorf("anonymous subject = %q", got)
+	}
+
+	ctx = tenant.NewContext(ctx, "pfizer")
+	if got := flagSubject(ctx); got != "tenant:pfizer" {
+		t.Errorf("tenant subject = %q", got)
+	}
+
+	ctx = auth.NewContext(ctx, &auth.Claims{UserID: "user-1"})
+	if got := flagSubject(ctx); got != "user:user-1" {
+		t.Errorf("user subject = %q, want the user over the tenant", got)
+	}
+}
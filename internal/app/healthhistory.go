@@ -0,0 +1,164 @@
+package app
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// checkHistory is a gosundheit.CheckListener keeping per-check state that the library's
+// results don't carry: last success, last error, last status change and recent failures
+type checkHistory struct {
+	mu     sync.Mutex
+	window time.Duration
+	checks map[string]*checkRecord
+	now    func() time.Time
+}
+
+type checkRecord struct {
+	healthy     bool
+	lastChange  time.Time
+	lastSuccess time.Time
+	lastError   string
+	lastErrorAt time.Time
+	failures    []time.Time
+}
+
+// newCheckHistory counts failures over the trailing window
+func newCheckHistory(window time.Duration) *checkHistory {
+	return &checkHistory{
+		window: window,
+		checks: make(map[string]*checkRecord),
+		now:    time.Now,
+	}
+}
+
+// OnCheckRegistered implements gosundheit.CheckListener
+func (h *checkHistory) OnCheckRegistered(name string, _ gosundheit.Result) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.checks[name]; !ok {
+		h.checks[name] = &checkRecord{}
+	}
+}
+
+// OnCheckStarted implements gosundheit.CheckListener
+func (h *checkHistory) OnCheckStarted(string) {}
+
+// OnCheckCompleted implements gosundheit.CheckListener
+func (h *checkHistory) OnCheckCompleted(name string, result gosundheit.Result) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rec, ok := h.checks[name]
+	if !ok {
+		rec = &checkRecord{}
+		h.checks[name] = rec
+	}
+
+	now := h.now()
+	healthy := result.IsHealthy()
+	if rec.lastChange.IsZero() || healthy != rec.healthy {
+		rec.lastChange = now
+	}
+	rec.healthy = healthy
+
+	if healthy {
+		rec.lastSuccess = now
+	} else {
+		rec.lastError = result.Error.Error()
+		rec.lastErrorAt = now
+		rec.failures = append(rec.failures, now)
+	}
+	rec.failures = h.prune(rec.failures, now)
+}
+
+// prune drops failures older than the window
+func (h *checkHistory) prune(failures []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-h.window)
+	i := 0
+	for i < len(failures) && failures[i].Before(cutoff) {
+		i++
+	}
+	return failures[i:]
+}
+
+// checkDetails is the /health/details entry for a single check
+type checkDetails struct {
+	Status             string      `json:"status"`
+	Details            interface{} `json:"details,omitempty"`
+	Error              string      `json:"error,omitempty"`
+	LastChecked        *time.Time  `json:"last_checked,omitempty"`
+	LatencyMS          float64     `json:"latency_ms"`
+	ContiguousFailures int64       `json:"contiguous_failures"`
+	RecentFailures     int         `json:"recent_failures"`
+	LastChange         *time.Time  `json:"last_change,omitempty"`
+	LastSuccess        *time.Time  `json:"last_success,omitempty"`
+	LastError          string      `json:"last_error,omitempty"`
+	LastErrorAt        *time.Time  `json:"last_error_at,omitempty"`
+}
+
+// details merges gosundheit's latest results with the recorded history
+func (h *checkHistory) details(results map[string]gosundheit.Result) map[string]checkDetails {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	out := make(map[string]checkDetails, len(results))
+	for name, result := range results {
+		d := checkDetails{
+			Status:             "healthy",
+			Details:            result.Details,
+			LatencyMS:          float64(result.Duration) / float64(time.Millisecond),
+			ContiguousFailures: result.ContiguousFailures,
+			LastChecked:        timePtr(result.Timestamp),
+		}
+		if result.Error != nil {
+			d.Status = "unhealthy"
+			if result.Error == gosundheit.ErrNotRunYet {
+				d.Status = "pending"
+			}
+			d.Error = result.Error.Error()
+		}
+
+		if rec, ok := h.checks[name]; ok {
+			rec.failures = h.prune(rec.failures, now)
+			d.RecentFailures = len(rec.failures)
+			d.LastChange = timePtr(rec.lastChange)
+			d.LastSuccess = timePtr(rec.lastSuccess)
+			d.LastError = rec.lastError
+			d.LastErrorAt = timePtr(rec.lastErrorAt)
+		}
+
+		out[name] = d
+	}
+	return out
+}
+
+// timePtr returns nil for the zero time so it is omitted from JSON
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// healthDetailsHandler reports every registered check with its latency, errors and recent failures
+func (a *App) healthDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	results, healthy := a.health.Results()
+
+	status := http.StatusOK
+	if !healthy {
+		status = http.StatusServiceUnavailable
+	}
+
+	respond.JSON(w, status, map[string]interface{}{
+		"healthy":        healthy,
+		"failure_window": a.history.window.String(),
+		"checks":         a.history.details(results),
+	})
+}
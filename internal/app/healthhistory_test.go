@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+	"github.com/AppsFlyer/go-sundheit/checks"
+)
+
+// healthDetailsBody is the /health/details response
+type healthDetailsBody struct {
+	Healthy bool                    `json:"healthy"`
+	Checks  map[string]checkDetails `json:"checks"`
+}
+
+func TestHealthDetailsReportsFlappingCheck(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	a.history = newCheckHistory(time.Minute)
+	health := gosundheit.New(gosundheit.WithCheckListeners(a.history))
+	t.Cleanup(health.DeregisterAll)
+	a.health = health
+
+	// Even runs fail until the fifth, then the check stays healthy
+	var runs atomic.Int32
+	flapping := &checks.CustomCheck{
+		CheckName: "flapping",
+		CheckFunc: func(context.Context) (interface{}, error) {
+			n := runs.Add(1)
+			if n%2 == 0 && n < 5 {
+				return nil, fmt.Errorf("flap %d", n)
+			}
+			return "ok", nil
+		},
+	}
+	if err := health.RegisterCheck(flapping, gosundheit.ExecutionPeriod(time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	var body healthDetailsBody
+	deadline := time.Now().Add(5 * time.Second)
+	for runs.Load() < 6 {
+		if time.Now().After(deadline) {
+			t.Fatalf("check ran %d times, want at least 6", runs.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	rec := get(http.HandlerFunc(a.healthDetailsHandler), "/health/details")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 once the check recovered", rec.Code)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	check, ok := body.Checks["flapping"]
+	if !ok {
+		t.Fatalf("checks = %v, want flapping", body.Checks)
+	}
+	if check.Status != "healthy" || check.RecentFailures != 2 || check.ContiguousFailures != 0 {
+		t.Errorf("check = %+v, want healthy with 2 recent failures", check)
+	}
+	if check.LastError != "flap 4" || check.LastErrorAt == nil || check.LastSuccess == nil {
+		t.Errorf("check = %+v, want the last error flap 4 and both timestamps", check)
+	}
+	if check.LastSuccess != nil && check.LastErrorAt != nil && !check.LastSuccess.After(*check.LastErrorAt) {
+		t.Errorf("last success %s is not after the last error %s", check.LastSuccess, check.LastErrorAt)
+	}
+}
+
+func TestCheckHistoryPrunesFailuresOutsideWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := newCheckHistory(10 * time.Minute)
+	history.now = func() time.Time { return now }
+
+	failed := gosundheit.Result{Error: errors.New("down")}
+	history.OnCheckRegistered("db", gosundheit.Result{})
+	history.OnCheckCompleted("db", failed)
+	now = now.Add(8 * time.Minute)
+	history.OnCheckCompleted("db", failed)
+	now = now.Add(time.Minute)
+	history.OnCheckCompleted("db", gosundheit.Result{})
+
+	results := map[string]gosundheit.Result{"db": {Timestamp: now}}
+	if got := history.details(results)["db"]; got.RecentFailures != 2 || got.Status != "healthy" {
+		t.Fatalf("details = %+v, want 2 recent failures", got)
+	}
+
+	// The first failure leaves the window
+	now = now.Add(2 * time.Minute)
+	got := history.details(results)["db"]
+	if got.RecentFailures != 1 {
+		t.Errorf("recent failures = %d, want 1 after the window moved on", got.RecentFailures)
+	}
+	if got.LastChange == nil || !got.LastChange.Equal(time.Date(2024, 1, 1, 12, 9, 0, 0, time.UTC)) {
+		t.Errorf("last change = %v, want the recovery at 12:09", got.LastChange)
+	}
+}
+
+func TestHealthDetailsUnhealthy(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	a.history = newCheckHistory(time.Minute)
+	a.health = &stubHealth{results: map[string]gosundheit.Result{
+		"redis": {Error: errors.New("connection refused"), ContiguousFailures: 3},
+		"db":    {Error: gosundheit.ErrNotRunYet},
+	}}
+
+	rec := get(http.HandlerFunc(a.healthDetailsHandler), "/health/details")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	var body healthDetailsBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if redis := body.Checks["redis"]; redis.Status != "unhealthy" || redis.Error != "connection refused" || redis.ContiguousFailures != 3 {
+		t.Errorf("redis = %+v, want unhealthy with its error", redis)
+	}
+	if db := body.Checks["db"]; db.Status != "pending" {
+		t.Errorf("db status = %q, want pending before the first run", db.Status)
+	}
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"sync"
+)
+
+// shutdownHook is a named cleanup function run during Shutdown
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// shutdownHooks holds hooks registered with OnShutdown
+type shutdownHooks struct {
+	mu    sync.Mutex
+	hooks []shutdownHook
+}
+
+// OnShutdown registers a hook run during Shutdown, after the HTTP server has stopped and
+// before database and Redis connections are closed. Hooks run in reverse registration order,
+// each with its own shutdown timeout; a failing hook is logged and does not stop the rest.
+func (a *App) OnShutdown(name string, fn func(ctx context.Context) error) {
+	a.hooks.mu.Lock()
+	defer a.hooks.mu.Unlock()
+	a.hooks.hooks = append(a.hooks.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// runShutdownHooks runs the registered hooks in LIFO order
+func (a *App) runShutdownHooks() {
+	a.hooks.mu.Lock()
+	hooks := make([]shutdownHook, len(a.hooks.hooks))
+	copy(hooks, a.hooks.hooks)
+	a.hooks.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+
+		ctx, cancel := context.WithTimeout(context.Background(), a.config.App.Shutdown.Timeout)
+		err := hook.fn(ctx)
+		cancel()
+
+		if err != nil {
+			a.logger.Error("Shutdown hook failed", "hook", hook.name, "error", err)
+			continue
+		}
+		a.logger.Debug("Shutdown hook completed", "hook", hook.name)
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+func TestShutdownHooksRunInReverse(t *testing.T) {
+	a := newTestApp(t, testConfig(t, "app:\n  shutdown:\n    timeout: 2s\n"))
+	log, logs := logtest.New(t)
+	a.logger = log
+
+	var ran []string
+	a.OnShutdown("workers", func(ctx context.Context) error {
+		ran = append(ran, "workers")
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > 2*time.Second {
+			t.Error("hook context is not bounded by the shutdown timeout")
+		}
+		return nil
+	})
+	a.OnShutdown("buffers", func(ctx context.Context) error {
+		ran = append(ran, "buffers")
+		return errors.New("flush failed")
+	})
+
+	a.runShutdownHooks()
+
+	if !slices.Equal(ran, []string{"buffers", "workers"}) {
+		t.Fatalf("hooks ran as %v, want buffers then workers", ran)
+	}
+	entry, ok := logs.Find("Shutdown hook failed")
+	if !ok || entry["hook"] != "buffers" || entry["error"] != "flush failed" {
+		t.Errorf("failure log = %v, want the buffers hook error", entry)
+	}
+}
+
+func TestShutdownRunsHooks(t *testing.T) {
+	a := newTestApp(t, runConfig(t, ""))
+	a.server = &http.Server{}
+
+	ran := false
+	a.OnShutdown("worker", func(context.Context) error {
+		ran = true
+		return nil
+	})
+	if err := a.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Error("Shutdown did not run the hook")
+	}
+}
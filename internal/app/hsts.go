@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// httpsEnforcer sets Strict-Transport-Security on responses served over HTTPS and, when
+// configured, redirects plain HTTP requests to HTTPS. Behind TLS termination a request counts
+// as HTTPS when a trusted proxy says so in X-Forwarded-Proto. It must run before realIP, which
+// replaces the peer address the proxy check relies on.
+type httpsEnforcer struct {
+	cfg     configs.HSTSConfig
+	proxies trustedProxies
+	header  string
+	// exempt paths are never redirected: probes and scrapers talk plain HTTP to the pod
+	exempt []string
+}
+
+func newHTTPSEnforcer(cfg configs.HSTSConfig, proxies trustedProxies, metricsPath string) *httpsEnforcer {
+	header := fmt.Sprintf("max-age=%d", int64(cfg.MaxAge.Seconds()))
+	if cfg.IncludeSubDomains {
+		header += "; includeSubDomains"
+	}
+	if cfg.Preload {
+		header += "; preload"
+	}
+
+	return &httpsEnforcer{
+		cfg:     cfg,
+		proxies: proxies,
+		header:  header,
+		exempt:  []string{"/ping", "/health", "/healthz", "/readiness", "/liveness", metricsPath},
+	}
+}
+
+// Middleware applies the HSTS header and the redirect
+func (e *httpsEnforcer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e.secure(r) {
+			w.Header().Set("Strict-Transport-Security", e.header)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if e.cfg.Redirect && !e.isExempt(r.URL.Path) {
+			// 308 keeps the method and body of non-GET requests
+			status := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				status = http.StatusPermanentRedirect
+			}
+			http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), status)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// secure reports whether the client connected over HTTPS, directly or through a trusted proxy
+func (e *httpsEnforcer) secure(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+	if !e.proxies.contains(peer) {
+		return false
+	}
+	// The first entry was set by the proxy closest to the client
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
+
+func (e *httpsEnforcer) isExempt(path string) bool {
+	for _, p := range e.exempt {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+func TestHTTPSEnforcer(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := configs.HSTSConfig{Enabled: true, MaxAge: 365 * 24 * time.Hour, IncludeSubDomains: true, Redirect: true}
+	handler := newHTTPSEnforcer(cfg, proxies, "/metrics").Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		peer     string
+		tls      bool
+		proto    string
+		status   int
+		hsts     bool
+		location string
+	}{
+		{"direct TLS", http.MethodGet, "/api/v1/reps", "203.0.113.7:1234", true, "", http.StatusOK, true, ""},
+		{"trusted proxy terminating TLS", http.MethodGet, "/api/v1/reps", "10.0.0.5:1234", false, "https", http.StatusOK, true, ""},
+		{"first proxy hop wins", http.MethodGet, "/api/v1/reps", "10.0.0.5:1234", false, "HTTPS, http", http.StatusOK, true, ""},
+		{"untrusted peer claiming https", http.MethodGet, "/api/v1/reps?page=2", "203.0.113.7:1234", false, "https", http.StatusMovedPermanently, false, "https://reps.example.com/api/v1/reps?page=2"},
+		{"plain GET redirected", http.MethodGet, "/api/v1/reps", "10.0.0.5:1234", false, "http", http.StatusMovedPermanently, false, "https://reps.example.com/api/v1/reps"},
+		{"plain POST keeps its method", http.MethodPost, "/api/v1/visits", "10.0.0.5:1234", false, "", http.StatusPermanentRedirect, false, "https://reps.example.com/api/v1/visits"},
+		{"probe exempt", http.MethodGet, "/healthz", "10.0.0.5:1234", false, "", http.StatusOK, false, ""},
+		{"scrape exempt", http.MethodGet, "/metrics", "10.0.0.5:1234", false, "", http.StatusOK, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://reps.example.com"+tt.target, nil)
+			req.RemoteAddr = tt.peer
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			hsts := rec.Header().Get("Strict-Transport-Security")
+			if tt.hsts && hsts != "max-age=31536000; includeSubDomains" {
+				t.Errorf("Strict-Transport-Security = %q, want max-age=31536000; includeSubDomains", hsts)
+			}
+			if !tt.hsts && hsts != "" {
+				t.Errorf("Strict-Transport-Security = %q on a plain HTTP response", hsts)
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+		})
+	}
+}
+
+func TestHTTPSEnforcerWithoutRedirect(t *testing.T) {
+	cfg := configs.HSTSConfig{Enabled: true, MaxAge: time.Hour, Preload: true}
+	handler := newHTTPSEnforcer(cfg, nil, "/metrics").Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/reps", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Strict-Transport-Security") != "" {
+		t.Errorf("plain request = %d with HSTS %q, want it served without HSTS", rec.Code, rec.Header().Get("Strict-Transport-Security"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reps", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=3600; preload" {
+		t.Errorf("Strict-Transport-Security = %q, want max-age=3600; preload", got)
+	}
+}
+
+func TestHSTSBehindTrustedProxy(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "http:\n  trusted_proxies: [\"10.0.0.0/8\"]\n  hsts:\n    enabled: true\n    redirect: true\n"))
+
+	// realIP replaces the proxy's address, so HSTS has to see the request first
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	req.RemoteAddr = "10.0.0.5:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Strict-Transport-Security") == "" {
+		t.Errorf("proxied HTTPS request = %d with HSTS %q, want 200 with HSTS", rec.Code, rec.Header().Get("Strict-Transport-Security"))
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// modulePath is the canonical import path prefix of this module
+const modulePath = "github.com/rixtrayker/medical-rep"
+
+// TestCanonicalImports catches imports of this module under another path. Compiling only
+// checks the files of the current platform and build tags, so every Go file is parsed.
+func TestCanonicalImports(t *testing.T) {
+	root := filepath.Join("..", "..")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "vendor" || strings.HasPrefix(d.Name(), ".")) && path != root {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, imp := range file.Imports {
+			importPath, _ := strconv.Unquote(imp.Path.Value)
+			if strings.HasPrefix(importPath, "medical-rep/") {
+				t.Errorf("%s imports %q, want %s/%s", path, importPath, modulePath, strings.TrimPrefix(importPath, "medical-rep/"))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// jsonPrecheck rejects API requests whose body is not JSON before they reach a handler:
+// 415 when a body is sent with another Content-Type, 413 past the body limit, and, when
+// validation is on, 400 for malformed JSON. A validated body is buffered and handed on
+// unread, so handlers decode it as usual.
+func jsonPrecheck(cfg configs.JSONPrecheckConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !hasBody(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !isJSONContentType(r.Header.Get("Content-Type")) {
+				respond.Error(w, http.StatusUnsupportedMediaType, "unsupported_media_type",
+					"request body must be sent as application/json")
+				return
+			}
+
+			if !cfg.Validate {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// The router-wide body limit bounds how much is buffered here
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					respond.Error(w, http.StatusRequestEntityTooLarge, "request_too_large",
+						"request body exceeds "+strconv.FormatInt(maxErr.Limit, 10)+" bytes")
+					return
+				}
+				respond.Error(w, http.StatusBadRequest, "bad_request", "failed to read request body")
+				return
+			}
+			if !json.Valid(body) {
+				respond.Error(w, http.StatusBadRequest, "malformed_json", "request body is not valid JSON")
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// hasBody reports whether the request carries a body
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && (r.ContentLength > 0 || r.ContentLength == -1)
+}
+
+// isJSONContentType accepts application/json and structured +json types such as
+// application/merge-patch+json
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || (strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
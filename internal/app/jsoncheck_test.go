@@ -0,0 +1,96 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// echoBody writes back the request body it reads
+func echoBody(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Write(body)
+}
+
+func TestJSONPrecheck(t *testing.T) {
+	handler := bodyLimit(64)(jsonPrecheck(configs.JSONPrecheckConfig{Enabled: true, Validate: true})(http.HandlerFunc(echoBody)))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    string
+	}{
+		{"valid JSON", "application/json", `{"name":"Alice"}`, http.StatusOK, ""},
+		{"JSON with charset", "application/json; charset=utf-8", `[1,2]`, http.StatusOK, ""},
+		{"structured JSON type", "application/merge-patch+json", `{"name":null}`, http.StatusOK, ""},
+		{"form body", "application/x-www-form-urlencoded", "name=Alice", http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"missing content type", "", `{"name":"Alice"}`, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"malformed JSON", "application/json", `{"name":`, http.StatusBadRequest, "malformed_json"},
+		{"too large", "application/json", `"` + strings.Repeat("a", 100) + `"`, http.StatusRequestEntityTooLarge, "request_too_large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/reps", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if tt.wantCode == "" {
+				if rec.Body.String() != tt.body {
+					t.Errorf("handler read %q, want the untouched body", rec.Body)
+				}
+			} else if !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %s", rec.Body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestJSONPrecheckPassesBodilessAndUnvalidatedRequests(t *testing.T) {
+	reached := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached = true; echoBody(w, r) })
+
+	handler := jsonPrecheck(configs.JSONPrecheckConfig{Enabled: true, Validate: true})(next)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/reps/1", nil))
+	if !reached {
+		t.Error("a request without a body was rejected")
+	}
+
+	// Without validation only the content type is checked
+	handler = jsonPrecheck(configs.JSONPrecheckConfig{Enabled: true})(next)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/reps", strings.NewReader(`{"name":`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"name":` {
+		t.Errorf("unvalidated request = %d %q, want it handed on", rec.Code, rec.Body)
+	}
+}
+
+func TestJSONPrecheckOnlyCoversAPI(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "app:\n  debug: true\n"))
+	token := bearer(t, a)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/reps", strings.NewReader("name=Alice"))
+	req.Header.Set("Content-Type", "text/plain")
+	if rec := serveAs(a.router, req, token); rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("API request = %d, want 415", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/debug/log-level", strings.NewReader(`{"level":"info"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	if rec := serveAs(a.router, req, token); rec.Code != http.StatusOK {
+		t.Errorf("non-API request = %d, want the precheck skipped", rec.Code)
+	}
+}
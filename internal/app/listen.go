@@ -0,0 +1,66 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// listen opens the server's listener through upg on the configured TCP address or Unix socket.
+// A Unix socket left behind by a process that is gone is removed first, and the new socket
+// file gets the configured permissions.
+func listen(upg Upgrader, cfg configs.HTTPConfig) (net.Listener, error) {
+	network, addr := cfg.ListenAddress()
+	if network == "unix" {
+		if err := removeStaleSocket(addr); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := upg.Listen(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s %s: %w", network, addr, err)
+	}
+
+	if network == "unix" {
+		mode, err := cfg.ParseUnixSocketMode()
+		if err == nil {
+			err = os.Chmod(addr, mode)
+		}
+		if err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("failed to set permissions on socket %s: %w", addr, err)
+		}
+	}
+	return ln, nil
+}
+
+// removeStaleSocket deletes a socket file nobody is accepting on. A live socket is kept: during
+// a zero-downtime upgrade the parent still serves it and the listener is inherited, and
+// otherwise Listen reports the conflict.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect socket %s: %w", path, err)
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+	return nil
+}
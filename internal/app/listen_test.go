@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// socketPath returns a path for a Unix socket in a short temporary directory, as socket paths
+// are limited to about 100 bytes
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "app.sock")
+}
+
+// unixConfig returns an HTTP config listening on the socket at path
+func unixConfig(path string) configs.HTTPConfig {
+	return configs.HTTPConfig{Network: "unix", UnixSocket: path, UnixSocketMode: "0600"}
+}
+
+func TestRunOnUnixSocket(t *testing.T) {
+	path := socketPath(t)
+	cfg := runConfig(t, "http:\n  network: unix\n  unix_socket: "+path+"\n  unix_socket_mode: \"0600\"\n")
+	a, _ := newRoutedApp(t, cfg)
+	_, done := runApp(t, a)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&fs.ModeSocket == 0 || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %s, want a socket with 0600", info.Mode())
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://localhost/liveness")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("liveness over the socket = %d, want 200", resp.StatusCode)
+	}
+
+	a.server.Close()
+	if err := waitRun(t, done); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListenRemovesStaleSocket(t *testing.T) {
+	path := socketPath(t)
+
+	// A process that died without cleaning up leaves its socket file behind
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("stale socket missing: %v", err)
+	}
+
+	ln, err := listen(newPlainUpgrader(), unixConfig(path))
+	if err != nil {
+		t.Fatalf("listen over a stale socket: %v", err)
+	}
+	ln.Close()
+}
+
+func TestListenKeepsLiveSocket(t *testing.T) {
+	path := socketPath(t)
+	live, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close()
+
+	if ln, err := listen(newPlainUpgrader(), unixConfig(path)); err == nil {
+		ln.Close()
+		t.Fatal("listen took over a socket another process is serving")
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("the live socket was removed: %v", err)
+	}
+	conn.Close()
+}
+
+func TestListenRefusesNonSocket(t *testing.T) {
+	path := socketPath(t)
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := listen(newPlainUpgrader(), unixConfig(path))
+	if err == nil || !strings.Contains(err.Error(), "is not a socket") {
+		t.Fatalf("listen = %v, want a not-a-socket error", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "data" {
+		t.Error("the regular file was modified")
+	}
+}
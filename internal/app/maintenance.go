@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+const (
+	// maintenanceKey holds the maintenance state shared by every instance
+	maintenanceKey = "maintenance:state"
+
+	// maintenanceRefresh bounds how stale an instance's view of the shared state can be
+	maintenanceRefresh = time.Second
+)
+
+// maintenanceState is the stored and reported maintenance state
+type maintenanceState struct {
+	Enabled    bool      `json:"enabled"`
+	Message    string    `json:"message,omitempty"`
+	RetryAfter int       `json:"retry_after_seconds,omitempty"`
+	Since      time.Time `json:"since,omitempty"`
+}
+
+// maintenanceMode answers whether API requests should get 503. The config flag forces it on;
+// otherwise the state toggled through the admin endpoint is read from Redis. Requests never wait
+// on Redis: a stale cached state is refreshed in the background, one read at a time, and the last
+// known state is kept while Redis is slow or unavailable.
+type maintenanceMode struct {
+	cfg    configs.MaintenanceConfig
+	client *redis.Client
+	log    *logger.Logger
+
+	mu         sync.Mutex
+	state      maintenanceState
+	checkedAt  time.Time
+	refreshing bool
+}
+
+func newMaintenanceMode(cfg configs.MaintenanceConfig, client *redis.Client, log *logger.Logger) *maintenanceMode {
+	return &maintenanceMode{cfg: cfg, client: client, log: log}
+}
+
+// current returns the effective maintenance state
+func (m *maintenanceMode) current(ctx context.Context) maintenanceState {
+	if m.cfg.Enabled {
+		return maintenanceState{
+			Enabled:    true,
+			Message:    m.cfg.Message,
+			RetryAfter: int(m.cfg.RetryAfter.Seconds()),
+		}
+	}
+	if m.client == nil {
+		return maintenanceState{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if time.Since(m.checkedAt) >= maintenanceRefresh && !m.refreshing {
+		m.refreshing = true
+		go m.refresh(context.WithoutCancel(ctx))
+	}
+	return m.state
+}
+
+// refresh reads the shared state from Redis; the client's timeout bounds the call
+func (m *maintenanceMode) refresh(ctx context.Context) {
+	started := time.Now()
+	data, err := m.client.Get(ctx, maintenanceKey)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refreshing = false
+	// A toggle through set while reading wins over the value read
+	if m.checkedAt.After(started) {
+		return
+	}
+	m.checkedAt = time.Now()
+
+	switch {
+	case errors.Is(err, redis.ErrNotFound):
+		m.state = maintenanceState{}
+	case err != nil:
+		m.log.Warn("Failed to read maintenance state, keeping last known state", "error", err)
+	default:
+		var state maintenanceState
+		if err := json.Unmarshal([]byte(data), &state); err != nil {
+			m.log.Warn("Ignoring malformed maintenance state", "error", err)
+			break
+		}
+		m.state = state
+	}
+}
+
+// set stores the shared state and updates the local cache
+func (m *maintenanceMode) set(ctx context.Context, state maintenanceState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := m.client.Set(ctx, maintenanceKey, data, 0); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.state, m.checkedAt = state, time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// Middleware answers 503 with Retry-After while maintenance is on. A state stored without a
+// retry delay falls back to the configured one.
+func (m *maintenanceMode) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := m.current(r.Context())
+		if !state.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		retryAfter := state.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = int(m.cfg.RetryAfter.Seconds())
+		}
+		if retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		}
+		respond.Error(w, http.StatusServiceUnavailable, "maintenance", state.Message)
+	})
+}
+
+// maintenanceRequest is the body accepted by POST /admin/maintenance
+type maintenanceRequest struct {
+	Enabled    bool   `json:"enabled"`
+	Message    string `json:"message"`
+	RetryAfter string `json:"retry_after"`
+}
+
+// getMaintenanceHandler reports the effective maintenance state
+func (a *App) getMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, a.maintenance.current(r.Context()))
+}
+
+// setMaintenanceHandler turns maintenance mode on or off for every instance
+func (a *App) setMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	if a.redis == nil {
+		respond.Error(w, http.StatusServiceUnavailable, "unavailable", "maintenance toggle requires redis")
+		return
+	}
+
+	var req maintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "bad_request", "invalid request body")
+		return
+	}
+
+	state := maintenanceState{Enabled: req.Enabled}
+	if req.Enabled {
+		retryAfter := a.config.App.Maintenance.RetryAfter
+		if req.RetryAfter != "" {
+			d, err := time.ParseDuration(req.RetryAfter)
+			if err != nil || d <= 0 {
+				respond.Error(w, http.StatusBadRequest, "bad_request", "retry_after must be a positive duration such as 10m")
+				return
+			}
+			retryAfter = d
+		}
+
+		state.Message = req.Message
+		if state.Message == "" {
+			state.Message = a.config.App.Maintenance.Message
+		}
+		state.RetryAfter = int(retryAfter.Seconds())
+		state.Since = time.Now().UTC()
+	}
+
+	if err := a.maintenance.set(r.Context(), state); err != nil {
+		a.logger.Error("Failed to store maintenance state", "error", err)
+		respond.Error(w, http.StatusServiceUnavailable, "unavailable", "failed to store maintenance state")
+		return
+	}
+
+	a.logger.Warn("Maintenance mode changed", "enabled", state.Enabled, "message", state.Message)
+	if a.config.App.Maintenance.Enabled && !state.Enabled {
+		a.logger.Warn("Maintenance stays on: app.maintenance.enabled is set in configuration")
+	}
+	respond.JSON(w, http.StatusOK, a.maintenance.current(r.Context()))
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+// setMaintenance posts body to the maintenance endpoint as an admin
+func setMaintenance(t *testing.T, a *App, body string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/admin/maintenance", strings.NewReader(body))
+	if rec := serveAs(a.router, req, bearer(t, a, "admin")); rec.Code != http.StatusOK {
+		t.Fatalf("POST /admin/maintenance = %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMaintenanceToggle(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, ""))
+
+	if rec := get(a.router, "/api/v1/"); rec.Code != http.StatusOK {
+		t.Fatalf("/api/v1/ = %d before maintenance, want 200", rec.Code)
+	}
+
+	setMaintenance(t, a, `{"enabled":true,"message":"Migrating visits","retry_after":"10m"}`)
+
+	rec := get(a.router, "/api/v1/")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("/api/v1/ = %d during maintenance, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "600" {
+		t.Errorf("Retry-After = %q, want 600", got)
+	}
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Code != "maintenance" || body.Error.Message != "Migrating visits" {
+		t.Errorf("body = %s, want the maintenance error and message", rec.Body)
+	}
+
+	for _, path := range []string{"/healthz", "/liveness", "/metrics"} {
+		if rec := get(a.router, path); rec.Code != http.StatusOK {
+			t.Errorf("%s = %d during maintenance, want 200", path, rec.Code)
+		}
+	}
+	rec = serveAs(a.router, httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil), bearer(t, a, "admin"))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"enabled":true`) {
+		t.Errorf("GET /admin/maintenance = %d %s, want it enabled", rec.Code, rec.Body)
+	}
+
+	setMaintenance(t, a, `{"enabled":false}`)
+	if rec := get(a.router, "/api/v1/"); rec.Code != http.StatusOK {
+		t.Errorf("/api/v1/ = %d after maintenance, want 200", rec.Code)
+	}
+}
+
+func TestMaintenanceToggleRequiresAdmin(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, ""))
+	body := `{"enabled":true}`
+
+	rec := serveAs(a.router, httptest.NewRequest(http.MethodPost, "/admin/maintenance", strings.NewReader(body)), "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("without a token = %d, want 401", rec.Code)
+	}
+	rec = serveAs(a.router, httptest.NewRequest(http.MethodPost, "/admin/maintenance", strings.NewReader(body)), bearer(t, a, "rep"))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("as a rep = %d, want 403", rec.Code)
+	}
+	if rec := get(a.router, "/api/v1/"); rec.Code != http.StatusOK {
+		t.Errorf("/api/v1/ = %d, want maintenance left off", rec.Code)
+	}
+}
+
+func TestMaintenanceRejectsInvalidRetryAfter(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, ""))
+	for _, body := range []string{`{"enabled":true,"retry_after":"soon"}`, `{"enabled":true,"retry_after":"-1m"}`, `not json`} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/maintenance", strings.NewReader(body))
+		if rec := serveAs(a.router, req, bearer(t, a, "admin")); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST %s = %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestMaintenanceIsSharedAcrossInstances(t *testing.T) {
+	a, server := newRoutedApp(t, testConfig(t, ""))
+	other := newMaintenanceMode(a.config.App.Maintenance, redistest.Connect(t, server), logtest.Discard(t))
+	handler := other.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	setMaintenance(t, a, `{"enabled":true}`)
+
+	// The other instance picks the state up from Redis in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec := get(handler, "/api/v1/")
+		if rec.Code == http.StatusServiceUnavailable {
+			if got := rec.Header().Get("Retry-After"); got != "300" {
+				t.Errorf("Retry-After = %q, want the configured 300", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the other instance never entered maintenance")
+		}
+		// Let the cached state go stale so the next request refreshes it
+		other.mu.Lock()
+		other.checkedAt = time.Time{}
+		other.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMaintenanceForcedByConfig(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "app:\n  maintenance:\n    enabled: true\n    retry_after: 1m\n"))
+
+	rec := get(a.router, "/api/v1/")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("/api/v1/ = %d with Retry-After %q, want 503 and 60", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	// Turning it off at runtime cannot override the config flag
+	setMaintenance(t, a, `{"enabled":false}`)
+	if rec := get(a.router, "/api/v1/"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/api/v1/ = %d, want maintenance kept on by config", rec.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/ratelimit"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+	"github.com/rixtrayker/medical-rep/internal/platform/tracing"
+)
+
+// middlewares builds the global router middleware by the names in configs.MiddlewareNames.
+// Middleware that is switched off in config maps to nil.
+func (a *App) middlewares() (map[string]func(http.Handler) http.Handler, error) {
+	cfg := a.config.HTTP
+	mw := cfg.Middleware
+	m := make(map[string]func(http.Handler) http.Handler)
+
+	// Basic middleware; operators can switch off the optional ones under http.middleware
+	if mw.RequestID {
+		m["request_id"] = requestid.Middleware(cfg.RequestID.Header, cfg.RequestID.Trust)
+	}
+	// HSTS and the HTTPS redirect read the proxy's address, so they run before realIP
+	if cfg.HSTS.Enabled {
+		m["hsts"] = newHTTPSEnforcer(cfg.HSTS, a.proxies, a.config.Metrics.Path).Middleware
+	}
+	if mw.RealIP {
+		m["real_ip"] = a.proxies.realIP
+	}
+	m["stats"] = a.stats.Middleware
+
+	// Report server time to clients
+	if cfg.ServerTiming {
+		m["server_timing"] = serverTiming
+	}
+
+	// Distributed tracing
+	if a.config.Tracing.Enabled {
+		m["tracing"] = tracing.Middleware
+	}
+
+	if mw.AccessLog {
+		m["access_log"] = a.accessLog
+	}
+
+	// Warn about latency outliers; a zero threshold disables it
+	if threshold := cfg.SlowRequestThreshold; threshold > 0 {
+		m["slow_requests"] = a.slowRequests(threshold)
+	}
+
+	m["recoverer"] = a.recoverer
+	if mw.Heartbeat {
+		m["heartbeat"] = middleware.Heartbeat("/ping")
+	}
+
+	// Request instrumentation
+	if a.metrics != nil {
+		m["metrics"] = a.metrics.Middleware
+	}
+
+	// Bound concurrent requests; a zero limit disables it
+	if limit := cfg.MaxConcurrentRequests; limit > 0 {
+		m["concurrency_limit"] = newConcurrencyLimiter(limit, cfg.ConcurrencyWait, a.config.Metrics.Path).Middleware
+	}
+
+	// Timeout middleware
+	m["timeout"] = a.requestTimeout(cfg.RequestTimeout)
+
+	// Request body limit; routes accepting large payloads can override it with bodyLimit
+	m["body_limit"] = bodyLimit(cfg.MaxBodyBytes)
+
+	if cfg.Compression.Enabled {
+		m["compression"] = newCompressor(cfg.Compression).Middleware
+	}
+
+	// Opt-in body logging for debugging integrations; never outside debug mode or in production
+	if bl := cfg.BodyLog; bl.Enabled && a.config.App.Debug && !a.config.IsProduction() {
+		m["body_log"] = newBodyLogger(a, bl).Middleware
+	}
+
+	// CORS middleware
+	a.cors.Store(newCORS(cfg.CORS))
+	if mw.CORS {
+		m["cors"] = a.corsMiddleware
+	}
+
+	// Rate limiting (if enabled)
+	if cfg.RateLimit.Enabled {
+		limiter, err := a.newRateLimiter(cfg.RateLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create rate limiter: %w", err)
+		}
+		a.limiter = newReloadableLimiter(limiter)
+		m["rate_limit"] = ratelimit.Middleware(a.limiter, a.logger)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+func TestOptionalMiddlewareCanBeDisabled(t *testing.T) {
+	const cors = "http:\n  cors:\n    allowed_origins: [\"https://crm.example.com\"]\n"
+	tests := []struct {
+		name, yaml    string
+		cors, reqID   bool
+		heartbeatCode int
+	}{
+		{"defaults", cors, true, true, http.StatusOK},
+		{"all disabled", cors + "  middleware:\n    cors: false\n    request_id: false\n    heartbeat: false\nhealth:\n  self_check: false\n", false, false, http.StatusNotFound},
+		{"only CORS disabled", cors + "  middleware:\n    cors: false\n", false, true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newRoutedApp(t, testConfig(t, tt.yaml))
+
+			req := httptest.NewRequest(http.MethodGet, "/liveness", nil)
+			req.Header.Set("Origin", "https://crm.example.com")
+			rec := httptest.NewRecorder()
+			a.router.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin") != ""; got != tt.cors {
+				t.Errorf("CORS headers present = %v, want %v", got, tt.cors)
+			}
+			if got := rec.Header().Get("X-Request-ID") != ""; got != tt.reqID {
+				t.Errorf("request ID header present = %v, want %v", got, tt.reqID)
+			}
+			if rec := get(a.router, "/ping"); rec.Code != tt.heartbeatCode {
+				t.Errorf("/ping = %d, want %d", rec.Code, tt.heartbeatCode)
+			}
+		})
+	}
+}
+
+// moveBefore returns the default middleware order with name moved right before other
+func moveBefore(name, other string) []string {
+	order := slices.DeleteFunc(slices.Clone(configs.MiddlewareNames), func(n string) bool { return n == name })
+	i := slices.Index(order, other)
+	return slices.Insert(order, i, name)
+}
+
+func TestMiddlewareOrderFromConfig(t *testing.T) {
+	const yaml = "http:\n  rate_limit:\n    enabled: true\n    rate: 1\n    burst: 1\n  cors:\n    allowed_origins: [\"https://crm.example.com\"]\n"
+	tests := []struct {
+		name  string
+		order []string
+		// CORS headers on rate-limited responses show whether cors ran before rate_limit
+		corsOnLimited bool
+		// The request ID in the access log shows whether request_id ran before access_log
+		loggedRequestID bool
+	}{
+		{"default", configs.MiddlewareNames, true, true},
+		{"rate limit before CORS", moveBefore("rate_limit", "cors"), false, true},
+		{"access log before request ID", moveBefore("access_log", "request_id"), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t, yaml)
+			cfg.HTTP.Middleware.Order = tt.order
+			a, _ := newRoutedApp(t, cfg)
+			log, logs := logtest.New(t)
+			a.logger = log
+
+			var limited *httptest.ResponseRecorder
+			for range 3 {
+				req := httptest.NewRequest(http.MethodGet, "/version", nil)
+				req.Header.Set("Origin", "https://crm.example.com")
+				rec := httptest.NewRecorder()
+				a.router.ServeHTTP(rec, req)
+				if rec.Code == http.StatusTooManyRequests {
+					limited = rec
+					break
+				}
+			}
+			if limited == nil {
+				t.Fatal("no request was rate limited")
+			}
+			if got := limited.Header().Get("Access-Control-Allow-Origin") != ""; got != tt.corsOnLimited {
+				t.Errorf("CORS headers on the 429 = %v, want %v", got, tt.corsOnLimited)
+			}
+
+			entry, ok := logs.Find("Request")
+			if !ok {
+				t.Fatal("the request was not logged")
+			}
+			if got := entry["request_id"] != ""; got != tt.loggedRequestID {
+				t.Errorf("request_id logged = %v (%v), want %v", got, entry["request_id"], tt.loggedRequestID)
+			}
+		})
+	}
+}
+
+func TestRateLimitAfterAuth(t *testing.T) {
+	const yaml = "http:\n  rate_limit:\n    enabled: true\n    rate: 0.001\n    burst: 1\n"
+	logout := func(a *App, token string) int {
+		return serveAs(a.router, httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil), token).Code
+	}
+
+	// By default the limiter runs first, so unauthenticated requests use up the budget
+	a, _ := newRoutedApp(t, testConfig(t, yaml))
+	if got := []int{logout(a, ""), logout(a, "")}; got[0] != http.StatusUnauthorized || got[1] != http.StatusTooManyRequests {
+		t.Errorf("default order: unauthenticated logouts = %v, want 401 then 429", got)
+	}
+
+	// With auth first, unauthenticated requests are rejected before reaching the limiter, which
+	// only applies to authenticated routes
+	cfg := testConfig(t, yaml)
+	cfg.HTTP.Middleware.Order = moveBefore("auth", "rate_limit")
+	a, _ = newRoutedApp(t, cfg)
+	for range 3 {
+		if code := logout(a, ""); code != http.StatusUnauthorized {
+			t.Fatalf("auth first: unauthenticated logout = %d, want 401", code)
+		}
+		if rec := get(a.router, "/version"); rec.Code != http.StatusOK {
+			t.Fatalf("auth first: /version = %d, want unauthenticated routes not rate limited", rec.Code)
+		}
+	}
+	if got := []int{logout(a, bearer(t, a)), logout(a, bearer(t, a))}; got[0] != http.StatusNoContent || got[1] != http.StatusTooManyRequests {
+		t.Errorf("auth first: authenticated logouts = %v, want 204 then 429", got)
+	}
+}
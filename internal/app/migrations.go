@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/database"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+// migrationCheck reports whether the database schema has reached the latest migration shipped
+// alongside the binary, so an instance booted before migrations finish stays out of rotation
+// instead of failing queries against missing tables. Once the schema is current it is not
+// queried again.
+type migrationCheck struct {
+	db       *database.DB
+	expected uint
+	applied  atomic.Bool
+}
+
+// newMigrationCheck reads the expected version from the migrations directory. It returns nil,
+// disabling the check, when the directory cannot be read or holds no migrations.
+func newMigrationCheck(db *database.DB, dir string, log *logger.Logger) *migrationCheck {
+	expected, err := database.LatestMigration(dir)
+	if err != nil {
+		log.Warn("Migration readiness check disabled", "error", err)
+		return nil
+	}
+	if expected == 0 {
+		return nil
+	}
+	return &migrationCheck{db: db, expected: expected}
+}
+
+// status returns "applied", "pending", "dirty" or "unknown" and whether the schema is current
+func (c *migrationCheck) status(ctx context.Context) (string, bool) {
+	if c.applied.Load() {
+		return "applied", true
+	}
+
+	version, dirty, err := c.db.MigrationVersion(ctx)
+	switch {
+	case err != nil:
+		return "unknown", false
+	case dirty:
+		return "dirty", false
+	case version < c.expected:
+		return "pending", false
+	}
+
+	// A newer schema than the binary knows is fine: it is being rolled out ahead of us
+	c.applied.Store(true)
+	return "applied", true
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/database"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// migrationsDir returns a directory holding up migrations with the given versions
+func migrationsDir(t *testing.T, versions ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, version := range versions {
+		if err := os.WriteFile(filepath.Join(dir, version+"_step.up.sql"), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadinessWaitsForMigrations(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, ""))
+	db, err := database.New(context.Background(), configs.DatabaseConfig{
+		Driver:       "sqlite3",
+		Database:     filepath.Join(t.TempDir(), "crm.db"),
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}, logtest.Discard(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	a.db = db
+	a.migrations = newMigrationCheck(db, migrationsDir(t, "1", "2"), a.logger)
+
+	ctx := context.Background()
+	exec := func(query string) {
+		t.Helper()
+		if _, err := db.Exec(ctx, query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readiness := func(wantCode int, wantStatus string) {
+		t.Helper()
+		rec := get(a.router, "/readiness")
+		if rec.Code != wantCode || !strings.Contains(rec.Body.String(), `"migrations":"`+wantStatus+`"`) {
+			t.Errorf("readiness = %d %s, want %d with migrations %s", rec.Code, rec.Body, wantCode, wantStatus)
+		}
+	}
+
+	exec("CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)")
+	exec("INSERT INTO schema_migrations VALUES (1, FALSE)")
+	readiness(http.StatusServiceUnavailable, "pending")
+
+	exec("UPDATE schema_migrations SET version = 2, dirty = TRUE")
+	readiness(http.StatusServiceUnavailable, "dirty")
+
+	exec("UPDATE schema_migrations SET dirty = FALSE")
+	readiness(http.StatusOK, "applied")
+
+	// Once current the schema is not queried again
+	exec("DROP TABLE schema_migrations")
+	readiness(http.StatusOK, "applied")
+}
+
+func TestMigrationCheckDisabledWithoutMigrations(t *testing.T) {
+	log, logs := logtest.New(t)
+	if c := newMigrationCheck(nil, migrationsDir(t), log); c != nil {
+		t.Error("the check is enabled without any migration")
+	}
+	if c := newMigrationCheck(nil, filepath.Join(t.TempDir(), "missing"), log); c != nil {
+		t.Error("the check is enabled without a migrations directory")
+	}
+	if _, ok := logs.Find("Migration readiness check disabled"); !ok {
+		t.Error("disabling the check for a missing directory was not logged")
+	}
+}
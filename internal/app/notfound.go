@@ -0,0 +1,56 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// routeMethods are the methods probed to build the Allow header
+var routeMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// notFoundHandler answers unknown paths with the JSON error envelope
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	respond.Error(w, http.StatusNotFound, "not_found", "no route for "+r.URL.Path)
+}
+
+// methodNotAllowedHandler answers a known path of routes requested with an unsupported method
+// with a JSON 405 and an Allow header listing the methods the path supports. OPTIONS requests
+// that reach it (CORS preflights are answered earlier) get 204 with the same Allow header.
+func methodNotAllowedHandler(routes chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed := allowedMethods(routes, r)
+		if r.Method == http.MethodOptions {
+			// OPTIONS itself is always answered
+			w.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		respond.Error(w, http.StatusMethodNotAllowed, "method_not_allowed",
+			r.Method+" is not supported for "+r.URL.Path)
+	}
+}
+
+// allowedMethods returns the methods routes has for the request path
+func allowedMethods(routes chi.Routes, r *http.Request) []string {
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+
+	var allowed []string
+	for _, method := range routeMethods {
+		if routes.Match(chi.NewRouteContext(), method, path) {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
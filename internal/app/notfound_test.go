@@ -0,0 +1,91 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// errorCode returns the code of a JSON error envelope, failing if rec does not hold one
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %s is not a JSON error envelope: %v", rec.Body, err)
+	}
+	return body.Error.Code
+}
+
+func TestNotFoundAndMethodNotAllowed(t *testing.T) {
+	r := chi.NewRouter()
+	r.NotFound(notFoundHandler)
+	r.MethodNotAllowed(methodNotAllowedHandler(r))
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/reps/{id}", func(w http.ResponseWriter, r *http.Request) {})
+		r.Put("/reps/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	tests := []struct {
+		method, path string
+		status       int
+		code, allow  string
+	}{
+		{http.MethodDelete, "/api/v1/reps/7", http.StatusMethodNotAllowed, "method_not_allowed", "GET, PUT"},
+		{http.MethodPost, "/api/v1/reps/7", http.StatusMethodNotAllowed, "method_not_allowed", "GET, PUT"},
+		{http.MethodGet, "/api/v1/visits", http.StatusNotFound, "not_found", ""},
+		{http.MethodGet, "/nowhere", http.StatusNotFound, "not_found", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+			continue
+		}
+		if code := errorCode(t, rec); code != tt.code {
+			t.Errorf("%s %s code = %q, want %q", tt.method, tt.path, code, tt.code)
+		}
+		if allow := rec.Header().Get("Allow"); allow != tt.allow {
+			t.Errorf("%s %s Allow = %q, want %q", tt.method, tt.path, allow, tt.allow)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/api/v1/reps/7", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("OPTIONS = %d, want 204", rec.Code)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "GET, PUT, OPTIONS" {
+		t.Errorf("OPTIONS Allow = %q, want GET, PUT, OPTIONS", allow)
+	}
+}
+
+func TestAppMethodNotAllowed(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, ""))
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/version", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("DELETE /version = %d, want 405", rec.Code)
+	}
+	if code := errorCode(t, rec); code != "method_not_allowed" {
+		t.Errorf("code = %q, want method_not_allowed", code)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "GET" {
+		t.Errorf("Allow = %q, want GET", allow)
+	}
+
+	if code := errorCode(t, get(a.router, "/nowhere")); code != "not_found" {
+		t.Errorf("/nowhere code = %q, want not_found", code)
+	}
+}
@@ -0,0 +1,107 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/apperr"
+)
+
+// Params are the pagination query parameters of a list request
+type Params struct {
+	// Limit is the page size, between 1 and the configured maximum
+	Limit int
+	// Cursor is the opaque position after which the page starts, empty for the first page
+	Cursor string
+}
+
+// Parse reads the limit and cursor query parameters. A missing limit uses the configured default
+// and a larger one is capped at the maximum; a limit that is not a positive integer is an
+// apperr.ErrInvalid error.
+func Parse(r *http.Request, cfg configs.PaginationConfig) (Params, error) {
+	query := r.URL.Query()
+	p := Params{Limit: cfg.DefaultLimit, Cursor: query.Get("cursor")}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return Params{}, apperr.New(apperr.ErrInvalid, "limit must be a positive integer")
+		}
+		p.Limit = limit
+	}
+	if cfg.MaxLimit > 0 && p.Limit > cfg.MaxLimit {
+		p.Limit = cfg.MaxLimit
+	}
+	return p, nil
+}
+
+// FetchLimit is how many rows to query: one more than the page size, so NewResponse can tell
+// whether another page exists
+func (p Params) FetchLimit() int {
+	return p.Limit + 1
+}
+
+// After decodes the cursor into key, the sort key of the last row of the previous page.
+// It reports false for the first page.
+func (p Params) After(key any) (bool, error) {
+	if p.Cursor == "" {
+		return false, nil
+	}
+	if err := DecodeCursor(p.Cursor, key); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Response is the envelope of a page of results
+type Response[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// NewResponse builds the page from rows fetched with Params.FetchLimit. When there are more rows
+// than the limit, the extra row is dropped and the cursor points after the last row kept, using
+// the sort key returned by key.
+func NewResponse[T any](rows []T, limit int, key func(T) any) (Response[T], error) {
+	if len(rows) <= limit {
+		if rows == nil {
+			rows = []T{}
+		}
+		return Response[T]{Data: rows}, nil
+	}
+
+	rows = rows[:limit]
+	cursor, err := EncodeCursor(key(rows[len(rows)-1]))
+	if err != nil {
+		return Response[T]{}, err
+	}
+	return Response[T]{Data: rows, NextCursor: cursor, HasMore: true}, nil
+}
+
+// EncodeCursor encodes a sort key, such as a struct of the sort column and ID, as an opaque
+// URL-safe cursor
+func EncodeCursor(key any) (string, error) {
+	b, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor decodes a cursor made by EncodeCursor into key. A cursor that cannot be decoded
+// is an apperr.ErrInvalid error.
+func DecodeCursor(cursor string, key any) error {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(b, key)
+	}
+	if err != nil {
+		return apperr.Wrap(err, apperr.ErrInvalid, "cursor is invalid")
+	}
+	return nil
+}
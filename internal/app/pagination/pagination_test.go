@@ -0,0 +1,119 @@
+package pagination
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/apperr"
+)
+
+var testConfig = configs.PaginationConfig{DefaultLimit: 20, MaxLimit: 100}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query  string
+		limit  int
+		cursor string
+	}{
+		{"", 20, ""},
+		{"?limit=5", 5, ""},
+		{"?limit=100", 100, ""},
+		{"?limit=5000", 100, ""},
+		{"?limit=10&cursor=abc", 10, "abc"},
+	}
+	for _, tt := range tests {
+		p, err := Parse(httptest.NewRequest(http.MethodGet, "/reps"+tt.query, nil), testConfig)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		if p.Limit != tt.limit || p.Cursor != tt.cursor {
+			t.Errorf("Parse(%q) = %+v, want limit %d and cursor %q", tt.query, p, tt.limit, tt.cursor)
+		}
+		if p.FetchLimit() != tt.limit+1 {
+			t.Errorf("FetchLimit = %d, want %d", p.FetchLimit(), tt.limit+1)
+		}
+	}
+
+	for _, query := range []string{"?limit=0", "?limit=-1", "?limit=ten"} {
+		_, err := Parse(httptest.NewRequest(http.MethodGet, "/reps"+query, nil), testConfig)
+		if !errors.Is(err, apperr.ErrInvalid) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalid", query, err)
+		}
+	}
+}
+
+// visitKey is the sort key of a visit list: the visit time, then the ID to break ties
+type visitKey struct {
+	At time.Time `json:"at"`
+	ID int64     `json:"id"`
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := visitKey{At: time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC), ID: 42}
+	cursor, err := EncodeCursor(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded visitKey
+	ok, err := Params{Limit: 10, Cursor: cursor}.After(&decoded)
+	if err != nil || !ok {
+		t.Fatalf("After = %v, %v, want the decoded key", ok, err)
+	}
+	if !decoded.At.Equal(key.At) || decoded.ID != key.ID {
+		t.Errorf("decoded %+v, want %+v", decoded, key)
+	}
+
+	if ok, err := (Params{Limit: 10}).After(&decoded); ok || err != nil {
+		t.Errorf("After on the first page = %v, %v, want false", ok, err)
+	}
+	for _, cursor := range []string{"!!!", "bm90IGpzb24"} {
+		if _, err := (Params{Cursor: cursor}).After(&decoded); !errors.Is(err, apperr.ErrInvalid) {
+			t.Errorf("After(%q) = %v, want ErrInvalid", cursor, err)
+		}
+	}
+}
+
+func TestNewResponse(t *testing.T) {
+	key := func(id int) any { return visitKey{ID: int64(id)} }
+
+	// One more row than the limit was fetched, so another page exists
+	page, err := NewResponse([]int{1, 2, 3, 4}, 3, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !page.HasMore || len(page.Data) != 3 || page.Data[2] != 3 {
+		t.Fatalf("page = %+v, want the first 3 rows and has_more", page)
+	}
+	var next visitKey
+	if err := DecodeCursor(page.NextCursor, &next); err != nil || next.ID != 3 {
+		t.Errorf("next cursor = %+v, %v, want after row 3", next, err)
+	}
+
+	page, err = NewResponse([]int{1, 2, 3}, 3, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.HasMore || page.NextCursor != "" || len(page.Data) != 3 {
+		t.Errorf("last page = %+v, want all rows without a cursor", page)
+	}
+
+	// An empty page encodes as an empty list, not null
+	page, err = NewResponse[int](nil, 3, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"data":[],"has_more":false}` {
+		t.Errorf("empty page = %s, want an empty data list", body)
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errPanicBudgetExceeded is returned by Run after shutting down because of repeated panics
+var errPanicBudgetExceeded = errors.New("shut down after exceeding the panic budget")
+
+// panicBudget counts recovered panics over a sliding window. A handler that panics on every
+// request otherwise degrades the service quietly behind the recovery middleware; once the
+// budget is exceeded the process can exit so the orchestrator restarts it cleanly.
+type panicBudget struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu     sync.Mutex
+	panics []time.Time
+
+	exceeded chan struct{}
+	once     sync.Once
+}
+
+func newPanicBudget(limit int, window time.Duration) *panicBudget {
+	return &panicBudget{
+		limit:    limit,
+		window:   window,
+		now:      time.Now,
+		exceeded: make(chan struct{}),
+	}
+}
+
+// record counts a panic and reports whether it exceeds the budget for the first time
+func (b *panicBudget) record() bool {
+	now := b.now()
+
+	b.mu.Lock()
+	cutoff := now.Add(-b.window)
+	kept := b.panics[:0]
+	for _, t := range b.panics {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	b.panics = append(kept, now)
+	over := len(b.panics) > b.limit
+	b.mu.Unlock()
+
+	first := false
+	if over {
+		b.once.Do(func() {
+			close(b.exceeded)
+			first = true
+		})
+	}
+	return first
+}
+
+// Exceeded is closed once the budget is exceeded; it blocks forever on a nil budget
+func (b *panicBudget) Exceeded() <-chan struct{} {
+	if b == nil {
+		return nil
+	}
+	return b.exceeded
+}
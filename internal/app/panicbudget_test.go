@@ -0,0 +1,96 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// exceeded reports whether the budget's Exceeded channel is closed
+func exceeded(b *panicBudget) bool {
+	select {
+	case <-b.Exceeded():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestPanicBudget(t *testing.T) {
+	b := newPanicBudget(3, time.Minute)
+	now := time.Unix(1_700_000_000, 0)
+	b.now = func() time.Time { return now }
+
+	for range 3 {
+		if b.record() {
+			t.Fatal("the budget was exceeded within the limit")
+		}
+		now = now.Add(10 * time.Second)
+	}
+
+	// Panics older than the window no longer count
+	now = now.Add(time.Minute)
+	if b.record() || exceeded(b) {
+		t.Fatal("the budget was exceeded by panics outside the window")
+	}
+
+	for range 2 {
+		b.record()
+	}
+	if !b.record() || !exceeded(b) {
+		t.Fatal("the budget was not exceeded by 4 panics within the window")
+	}
+	// Exceeding the budget is only reported the first time
+	if b.record() {
+		t.Error("exceeding the budget was reported twice")
+	}
+
+	var disabled *panicBudget
+	if disabled.Exceeded() != nil {
+		t.Error("a nil budget has an Exceeded channel")
+	}
+}
+
+func TestPanicBudgetShutsDownRun(t *testing.T) {
+	for _, shutdown := range []bool{true, false} {
+		cfg := runConfig(t, "app:\n  panic_budget:\n    enabled: true\n    max_panics: 2\n    window: 1m\n")
+		cfg.App.PanicBudget.Shutdown = shutdown
+		a, _ := newRoutedApp(t, cfg)
+		log, logs := logtest.New(t)
+		a.logger = log
+		a.panics = newPanicBudget(cfg.App.PanicBudget.MaxPanics, cfg.App.PanicBudget.Window)
+		a.router.Get("/boom", panicking)
+
+		ln, done := runApp(t, a)
+		// A connection dialed but never used would hold up the shutdown for 5 seconds
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		for range 3 {
+			resp, err := client.Get("http://" + ln.Addr().String() + "/boom")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		}
+
+		entry, ok := logs.Find("Panic budget exceeded, the service is failing repeatedly")
+		if !ok || entry["severity"] != "critical" || entry["max_panics"] != float64(2) {
+			t.Errorf("shutdown %v: critical log = %v, want the budget reported", shutdown, entry)
+		}
+
+		if !shutdown {
+			// Without shutdown the process keeps serving
+			select {
+			case err := <-done:
+				t.Fatalf("Run returned %v with shutdown disabled", err)
+			case <-time.After(100 * time.Millisecond):
+			}
+			a.server.Close()
+		}
+		if err := waitRun(t, done); errors.Is(err, errPanicBudgetExceeded) != shutdown {
+			t.Errorf("shutdown %v: Run returned %v", shutdown, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// dependencyPing is the live result for one dependency
+type dependencyPing struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// pingReport is the body of GET /api/v1/ping
+type pingReport struct {
+	Status       string                    `json:"status"`
+	CheckedAt    time.Time                 `json:"checked_at"`
+	Dependencies map[string]dependencyPing `json:"dependencies"`
+}
+
+// pinger measures dependency latency live and reuses the report for ttl, so bursts of
+// client pings cost one round of pings. Callers arriving during a round wait for it.
+type pinger struct {
+	ttl     time.Duration
+	timeout time.Duration
+	deps    map[string]func(context.Context) error
+
+	mu     sync.Mutex
+	report pingReport
+}
+
+func newPinger(ttl, timeout time.Duration) *pinger {
+	return &pinger{ttl: ttl, timeout: timeout, deps: make(map[string]func(context.Context) error)}
+}
+
+// add registers a dependency ping
+func (p *pinger) add(name string, ping func(context.Context) error) {
+	p.deps[name] = ping
+}
+
+// current returns the cached report, running a new round of pings once it is older than ttl
+func (p *pinger) current(ctx context.Context) pingReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.report.CheckedAt.IsZero() && time.Since(p.report.CheckedAt) < p.ttl {
+		return p.report
+	}
+
+	// Pings are not tied to the request so a disconnecting client cannot poison the cache
+	ctx = context.WithoutCancel(ctx)
+
+	var (
+		wg      sync.WaitGroup
+		resMu   sync.Mutex
+		results = make(map[string]dependencyPing, len(p.deps))
+	)
+	for name, ping := range p.deps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check := checkWithTimeout(name, p.timeout, func(ctx context.Context) (interface{}, error) {
+				return nil, ping(ctx)
+			})
+
+			start := time.Now()
+			_, err := check(ctx)
+			result := dependencyPing{
+				Status:    "up",
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = "down"
+				result.Error = err.Error()
+			}
+
+			resMu.Lock()
+			results[name] = result
+			resMu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	status := "ok"
+	for _, result := range results {
+		if result.Status != "up" {
+			status = "degraded"
+		}
+	}
+
+	p.report = pingReport{Status: status, CheckedAt: time.Now().UTC(), Dependencies: results}
+	return p.report
+}
+
+// pingHandler reports live dependency status and latency so clients can decide whether to
+// work offline. It answers 503 with the same body when any dependency is down.
+func (a *App) pingHandler(w http.ResponseWriter, r *http.Request) {
+	report := a.pinger.current(r.Context())
+
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	respond.JSON(w, status, report)
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// countingPing returns a ping answering err and a counter of its calls
+func countingPing(err error) (func(context.Context) error, *atomic.Int32) {
+	var calls atomic.Int32
+	return func(context.Context) error {
+		calls.Add(1)
+		return err
+	}, &calls
+}
+
+func TestPingEndpoint(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "health:\n  ping_cache_ttl: 1m\n"))
+	dbPing, dbCalls := countingPing(nil)
+	redisPing, redisCalls := countingPing(nil)
+	a.pinger.add("database", dbPing)
+	a.pinger.add("redis", redisPing)
+
+	rec := get(a.router, "/api/v1/ping")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d %s, want 200", rec.Code, rec.Body)
+	}
+	var report pingReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != "ok" || report.CheckedAt.IsZero() || len(report.Dependencies) != 2 {
+		t.Errorf("report = %+v, want ok with both dependencies", report)
+	}
+	for name, dep := range report.Dependencies {
+		if dep.Status != "up" || dep.LatencyMS < 0 || dep.Error != "" {
+			t.Errorf("%s = %+v, want up with a latency", name, dep)
+		}
+	}
+
+	// A second call within the TTL reuses the report
+	if rec := get(a.router, "/api/v1/ping"); rec.Code != http.StatusOK {
+		t.Fatalf("second status = %d", rec.Code)
+	}
+	if dbCalls.Load() != 1 || redisCalls.Load() != 1 {
+		t.Errorf("pings = %d database, %d redis, want one live round", dbCalls.Load(), redisCalls.Load())
+	}
+}
+
+func TestPingNegotiatesMessagePack(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, ""))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	rec := serveAs(a.router, req, "")
+	if ct := rec.Header().Get("Content-Type"); ct != respond.ContentTypeMsgpack {
+		t.Fatalf("Content-Type = %q, want %s", ct, respond.ContentTypeMsgpack)
+	}
+	// The report is a MessagePack fixmap, not JSON
+	if body := rec.Body.Bytes(); len(body) == 0 || body[0]&0xf0 != 0x80 {
+		t.Errorf("body = % x, want a MessagePack map", body)
+	}
+}
+
+func TestPingReportsDownDependency(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, ""))
+	dbPing, _ := countingPing(errors.New("connection refused"))
+	a.pinger.add("database", dbPing)
+	a.pinger.add("redis", a.redis.Ping)
+
+	rec := get(a.router, "/api/v1/ping")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	var report pingReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if db := report.Dependencies["database"]; report.Status != "degraded" || db.Status != "down" || db.Error != "connection refused" {
+		t.Errorf("report = %+v, want the database down", report)
+	}
+	if report.Dependencies["redis"].Status != "up" {
+		t.Errorf("redis = %+v, want up", report.Dependencies["redis"])
+	}
+}
+
+func TestPingerRefreshesAfterTTL(t *testing.T) {
+	p := newPinger(20*time.Millisecond, time.Second)
+	ping, calls := countingPing(nil)
+	p.add("redis", ping)
+
+	first := p.current(context.Background())
+	p.current(context.Background())
+	time.Sleep(30 * time.Millisecond)
+	second := p.current(context.Background())
+
+	if calls.Load() != 2 || !second.CheckedAt.After(first.CheckedAt) {
+		t.Errorf("pinged %d times, want a new round once the report expired", calls.Load())
+	}
+}
+
+func TestPingerTimesOutHangingDependency(t *testing.T) {
+	p := newPinger(time.Minute, 20*time.Millisecond)
+	p.add("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// A cancelled request still gets a full round of pings
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := p.current(ctx)
+	if db := report.Dependencies["database"]; db.Status != "down" || db.Error != "database check timed out after 20ms: context deadline exceeded" {
+		t.Errorf("database = %+v, want a timeout", db)
+	}
+}
@@ -0,0 +1,46 @@
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/app/apperr"
+)
+
+// DefaultSpan is the range TimeRange covers when the request gives no from
+const DefaultSpan = 24 * time.Hour
+
+// TimeRange reads the from and to query parameters as RFC 3339 timestamps. A missing to is now
+// and a missing from is DefaultSpan before to, or maxSpan when that is shorter. An unparsable
+// value, from after to, or a range wider than maxSpan (when positive) is an apperr.ErrInvalid error.
+func TimeRange(r *http.Request, maxSpan time.Duration) (from, to time.Time, err error) {
+	query := r.URL.Query()
+
+	to = time.Now()
+	if raw := query.Get("to"); raw != "" {
+		if to, err = time.Parse(time.RFC3339, raw); err != nil {
+			return time.Time{}, time.Time{}, apperr.New(apperr.ErrInvalid, "to must be an RFC 3339 timestamp")
+		}
+	}
+
+	span := DefaultSpan
+	if maxSpan > 0 && maxSpan < span {
+		span = maxSpan
+	}
+	from = to.Add(-span)
+	if raw := query.Get("from"); raw != "" {
+		if from, err = time.Parse(time.RFC3339, raw); err != nil {
+			return time.Time{}, time.Time{}, apperr.New(apperr.ErrInvalid, "from must be an RFC 3339 timestamp")
+		}
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, apperr.New(apperr.ErrInvalid, "from must not be after to")
+	}
+	if maxSpan > 0 && to.Sub(from) > maxSpan {
+		return time.Time{}, time.Time{}, apperr.New(apperr.ErrInvalid,
+			fmt.Sprintf("the time range must not exceed %s", maxSpan))
+	}
+	return from, to, nil
+}
@@ -0,0 +1,81 @@
+package query
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/app/apperr"
+)
+
+// rangeRequest returns a request with the given query string
+func rangeRequest(query string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/reports/visits?"+query, nil)
+}
+
+func TestTimeRangeDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxSpan time.Duration
+		want    time.Duration
+	}{
+		{"no cap", 0, DefaultSpan},
+		{"cap wider than the default", 7 * DefaultSpan, DefaultSpan},
+		{"cap narrower than the default", time.Hour, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			from, to, err := TimeRange(rangeRequest(""), tt.maxSpan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if to.Before(before) || to.After(time.Now()) {
+				t.Errorf("to = %v, want now", to)
+			}
+			if span := to.Sub(from); span != tt.want {
+				t.Errorf("span = %s, want %s", span, tt.want)
+			}
+		})
+	}
+
+	// A missing from is relative to an explicit to
+	from, _, err := TimeRange(rangeRequest("to=2026-03-02T00:00:00Z"), 0)
+	if err != nil || !from.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("from = %v, %v, want a day before to", from, err)
+	}
+}
+
+func TestTimeRangeExplicitValues(t *testing.T) {
+	from, to, err := TimeRange(rangeRequest("from=2026-03-01T08:00:00%2B02:00&to=2026-03-01T12:00:00Z"), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !from.Equal(time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("range = %v to %v", from, to)
+	}
+
+	// A range exactly at the cap is allowed
+	if _, _, err := TimeRange(rangeRequest("from=2026-03-01T00:00:00Z&to=2026-03-02T00:00:00Z"), 24*time.Hour); err != nil {
+		t.Errorf("range at the cap: %v", err)
+	}
+}
+
+func TestTimeRangeRejectsInvalidRanges(t *testing.T) {
+	tests := map[string]string{
+		"unparsable from": "from=yesterday",
+		"unparsable to":   "to=2026-03-01",
+		"inverted":        "from=2026-03-02T00:00:00Z&to=2026-03-01T00:00:00Z",
+		"over the cap":    "from=2026-03-01T00:00:00Z&to=2026-03-02T00:00:01Z",
+	}
+	for name, query := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := TimeRange(rangeRequest(query), 24*time.Hour)
+			if !errors.Is(err, apperr.ErrInvalid) || apperr.StatusCode(err) != http.StatusBadRequest {
+				t.Errorf("TimeRange(%s) = %v, want an invalid error mapping to 400", query, err)
+			}
+		})
+	}
+}
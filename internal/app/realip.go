@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// trustedProxies is a set of networks whose forwarded headers are believed
+type trustedProxies []netip.Prefix
+
+// parseTrustedProxies parses CIDRs or single addresses
+func parseTrustedProxies(entries []string) (trustedProxies, error) {
+	proxies := make(trustedProxies, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// contains reports whether ip belongs to a trusted network
+func (t trustedProxies) contains(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerKey is the context key under which realIP keeps the immediate peer's address
+type peerKey struct{}
+
+// realIP replaces chi's middleware.RealIP. Forwarded headers are only honored when the
+// immediate peer is a trusted proxy; otherwise RemoteAddr is left untouched so clients
+// cannot spoof their address.
+func (t trustedProxies) realIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer := hostOf(r.RemoteAddr)
+
+		if t.contains(peer) {
+			if ip := t.forwardedIP(r); ip != "" {
+				r = r.WithContext(context.WithValue(r.Context(), peerKey{}, peer))
+				r.RemoteAddr = ip
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// fromTrustedProxy reports whether the request's immediate peer is a trusted proxy, also after
+// realIP has replaced RemoteAddr with the client's address
+func (t trustedProxies) fromTrustedProxy(r *http.Request) bool {
+	peer, ok := r.Context().Value(peerKey{}).(string)
+	if !ok {
+		peer = hostOf(r.RemoteAddr)
+	}
+	return t.contains(peer)
+}
+
+// hostOf strips the port from a RemoteAddr
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// forwardedIP picks the client address from forwarded headers. X-Forwarded-For is walked
+// right to left, skipping trusted hops, so entries prepended by the client are ignored.
+func (t trustedProxies) forwardedIP(r *http.Request) string {
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if _, err := netip.ParseAddr(hop); err != nil {
+				return ""
+			}
+			if !t.contains(hop) || i == 0 {
+				return hop
+			}
+		}
+	}
+
+	for _, header := range []string{"True-Client-IP", "X-Real-IP"} {
+		if ip := strings.TrimSpace(r.Header.Get(header)); ip != "" {
+			if _, err := netip.ParseAddr(ip); err == nil {
+				return ip
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		peer    string
+		headers map[string]string
+		want    string
+	}{
+		{"untrusted peer spoofing", "203.0.113.9:5000", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "203.0.113.9:5000"},
+		{"untrusted peer X-Real-IP", "203.0.113.9:5000", map[string]string{"X-Real-IP": "1.2.3.4"}, "203.0.113.9:5000"},
+		{"trusted proxy", "10.1.2.3:5000", map[string]string{"X-Forwarded-For": "198.51.100.7"}, "198.51.100.7"},
+		{"trusted single address", "192.168.1.1:5000", map[string]string{"X-Real-IP": "198.51.100.7"}, "198.51.100.7"},
+		{"client prepends a hop", "10.1.2.3:5000", map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.7"}, "198.51.100.7"},
+		{"chained trusted proxies", "10.1.2.3:5000", map[string]string{"X-Forwarded-For": "198.51.100.7, 10.9.9.9"}, "198.51.100.7"},
+		{"garbage hop", "10.1.2.3:5000", map[string]string{"X-Forwarded-For": "not-an-ip"}, "10.1.2.3:5000"},
+		{"trusted proxy without headers", "10.1.2.3:5000", nil, "10.1.2.3:5000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := proxies.realIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.peer
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesInvalid(t *testing.T) {
+	for _, entry := range []string{"10.0.0.0/33", "proxy.internal"} {
+		if _, err := parseTrustedProxies([]string{entry}); err == nil {
+			t.Errorf("%q accepted", entry)
+		}
+	}
+}
+
+func TestFromTrustedProxy(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		peer string
+		xff  string
+		want bool
+	}{
+		{"client", "203.0.113.9:5000", "", false},
+		{"client spoofing", "203.0.113.9:5000", "10.1.2.3", false},
+		{"proxy", "10.1.2.3:5000", "", true},
+		{"proxy forwarding a client", "10.1.2.3:5000", "198.51.100.7", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			handler := proxies.realIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = proxies.fromTrustedProxy(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.peer
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("fromTrustedProxy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// recoverer recovers from handler panics, logging the panic value and stack through the
+// application logger and answering with a JSON 500 instead of chi's plain-text stack dump
+func (a *App) recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+			// http.ErrAbortHandler is the sanctioned way to abort a response; let net/http handle it
+			if rvr == http.ErrAbortHandler {
+				panic(rvr)
+			}
+
+			stack := debug.Stack()
+			a.logger.Error("Panic recovered",
+				"panic", rvr,
+				"stack", string(stack),
+				"request_id", requestid.FromContext(r.Context()),
+				"method", r.Method,
+				"path", r.URL.Path,
+			)
+
+			if a.metrics != nil {
+				a.metrics.PanicRecovered()
+			}
+
+			if a.panics != nil && a.panics.record() {
+				budget := a.config.App.PanicBudget
+				a.logger.Error("Panic budget exceeded, the service is failing repeatedly",
+					"severity", "critical",
+					"max_panics", budget.MaxPanics,
+					"window", budget.Window,
+					"shutdown", budget.Shutdown,
+				)
+			}
+
+			// Upgraded connections have no usable response writer left
+			if r.Header.Get("Connection") == "Upgrade" {
+				return
+			}
+
+			// Outside production the panic can be shown to whoever is debugging; never in production
+			if a.config.HTTP.ExposeStackTraces && !a.config.IsProduction() {
+				respond.JSON(w, http.StatusInternalServerError, panicBody{Error: panicDetail{
+					ErrorDetail: respond.ErrorDetail{Code: "internal_error", Message: "internal server error"},
+					Panic:       fmt.Sprint(rvr),
+					Stack:       strings.Split(strings.TrimSpace(string(stack)), "\n"),
+				}})
+				return
+			}
+
+			respond.Error(w, http.StatusInternalServerError, "internal_error", "internal server error")
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// panicBody is the error envelope with the panic details, sent when stack traces are exposed
+type panicBody struct {
+	Error panicDetail `json:"error"`
+}
+
+type panicDetail struct {
+	respond.ErrorDetail
+	Panic string   `json:"panic"`
+	Stack []string `json:"stack"`
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/metrics"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// panicking is a handler that always panics
+var panicking = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+})
+
+func TestRecovererLogsAndAnswersJSON(t *testing.T) {
+	a := newTestApp(t, testConfig(t, "http:\n  expose_stack_traces: false\n"))
+	log, logs := logtest.New(t)
+	a.logger = log
+	a.metrics = metrics.New()
+
+	handler := requestid.Middleware("X-Request-ID", true)(a.recoverer(panicking))
+	req := httptest.NewRequest(http.MethodGet, "/reps", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var body struct {
+		Error struct {
+			Code  string `json:"code"`
+			Stack any    `json:"stack"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body, err)
+	}
+	if body.Error.Code != "internal_error" || body.Error.Stack != nil {
+		t.Errorf("body = %s, want internal_error without a stack", rec.Body)
+	}
+
+	entry, ok := logs.Find("Panic recovered")
+	if !ok {
+		t.Fatal("panic was not logged")
+	}
+	if entry["level"] != "ERROR" || entry["panic"] != "boom" || entry["request_id"] != "req-123" {
+		t.Errorf("log entry = %v, want an error with the panic and request ID", entry)
+	}
+	if stack, _ := entry["stack"].(string); !strings.Contains(stack, "recover_test.go") {
+		t.Error("log entry is missing the stack")
+	}
+
+	scrape := httptest.NewRecorder()
+	a.metrics.Handler().ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(scrape.Body.String(), "panics_total 1") {
+		t.Error("panics_total was not incremented")
+	}
+}
+
+func TestRecovererExposesStackOutsideProduction(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		expose      bool
+		exposed     bool
+	}{
+		{"development with the flag", "development", true, true},
+		{"development without the flag", "development", false, false},
+		{"production with the flag", "production", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t, testConfig(t, ""))
+			a.config.App.Environment = tt.environment
+			a.config.HTTP.ExposeStackTraces = tt.expose
+
+			rec := httptest.NewRecorder()
+			a.recoverer(panicking).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want 500", rec.Code)
+			}
+
+			var body struct {
+				Error struct {
+					Code  string   `json:"code"`
+					Panic string   `json:"panic"`
+					Stack []string `json:"stack"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid body %q: %v", rec.Body, err)
+			}
+			if body.Error.Code != "internal_error" {
+				t.Errorf("code = %q, want internal_error", body.Error.Code)
+			}
+			hasStack := strings.Contains(strings.Join(body.Error.Stack, "\n"), "recover_test.go")
+			if exposed := body.Error.Panic == "boom" && hasStack; exposed != tt.exposed {
+				t.Errorf("body = %s, panic and stack exposed = %v, want %v", rec.Body, exposed, tt.exposed)
+			}
+			if !tt.exposed && (body.Error.Panic != "" || body.Error.Stack != nil) {
+				t.Errorf("body = %s, want no panic details", rec.Body)
+			}
+		})
+	}
+}
+
+func TestRecovererRepanicsAbort(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	handler := a.recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if rvr := recover(); rvr != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler re-panicked", rvr)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
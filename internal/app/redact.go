@@ -0,0 +1,80 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// redacted replaces the value of a sensitive query parameter or header in logs
+const redacted = "***"
+
+// redactor masks secrets in the request details written to the logs
+type redactor struct {
+	params  map[string]bool
+	headers map[string]bool
+	logged  []string
+}
+
+// newRedactor builds a redactor for the configured query parameters and headers,
+// matched case-insensitively
+func newRedactor(cfg configs.AccessLogConfig) *redactor {
+	r := &redactor{
+		params:  make(map[string]bool, len(cfg.Redact.QueryParams)),
+		headers: make(map[string]bool, len(cfg.Redact.Headers)),
+		logged:  cfg.Headers,
+	}
+	for _, name := range cfg.Redact.QueryParams {
+		r.params[strings.ToLower(name)] = true
+	}
+	for _, name := range cfg.Redact.Headers {
+		r.headers[http.CanonicalHeaderKey(name)] = true
+	}
+	return r
+}
+
+// query returns the raw query of u with the values of sensitive parameters masked,
+// keeping the order and encoding of everything else
+func (r *redactor) query(u *url.URL) string {
+	if u.RawQuery == "" || len(r.params) == 0 {
+		return u.RawQuery
+	}
+
+	pairs := strings.Split(u.RawQuery, "&")
+	for i, pair := range pairs {
+		key, _, hasValue := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if hasValue && r.params[strings.ToLower(name)] {
+			pairs[i] = key + "=" + redacted
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// header returns the value of a request header with sensitive values masked
+func (r *redactor) header(h http.Header, name string) string {
+	value := h.Get(name)
+	if value != "" && r.headers[http.CanonicalHeaderKey(name)] {
+		return redacted
+	}
+	return value
+}
+
+// loggedHeaders returns the configured headers present on the request, masked where sensitive
+func (r *redactor) loggedHeaders(h http.Header) map[string]string {
+	var out map[string]string
+	for _, name := range r.logged {
+		if value := r.header(h, name); value != "" {
+			if out == nil {
+				out = make(map[string]string, len(r.logged))
+			}
+			out[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	return out
+}
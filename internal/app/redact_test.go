@@ -0,0 +1,80 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+func TestRedactorQuery(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	r := newRedactor(a.config.HTTP.AccessLog)
+
+	tests := map[string]string{
+		"token=secret":                  "token=***",
+		"page=2&api_key=abc&sort=name":  "page=2&api_key=***&sort=name",
+		"TOKEN=secret&Password=hunter2": "TOKEN=***&Password=***",
+		"api%5Fkey=abc":                 "api%5Fkey=***",
+		"token&q=rep%20name":            "token&q=rep%20name",
+		"q=token%3Dsecret":              "q=token%3Dsecret",
+		"":                              "",
+	}
+	for raw, want := range tests {
+		if got := r.query(&url.URL{RawQuery: raw}); got != want {
+			t.Errorf("query(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestRedactorHeaders(t *testing.T) {
+	a := newTestApp(t, testConfig(t, "http:\n  access_log:\n    headers: [User-Agent, authorization, Cookie, X-Missing]\n"))
+	r := newRedactor(a.config.HTTP.AccessLog)
+
+	h := http.Header{}
+	h.Set("User-Agent", "reps-app/2.1")
+	h.Set("Authorization", "Bearer eyJhbGciOi")
+	h.Set("Cookie", "session=abc")
+	got := r.loggedHeaders(h)
+	want := map[string]string{"User-Agent": "reps-app/2.1", "Authorization": redacted, "Cookie": redacted}
+	if len(got) != len(want) {
+		t.Fatalf("logged headers = %v, want %v", got, want)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %q, want %q", name, got[name], value)
+		}
+	}
+
+	if got := r.loggedHeaders(http.Header{}); got != nil {
+		t.Errorf("logged headers of a bare request = %v, want none", got)
+	}
+}
+
+func TestAccessLogRedactsSecrets(t *testing.T) {
+	a := newTestApp(t, testConfig(t, "http:\n  access_log:\n    headers: [Authorization]\n"))
+	log, logs := logtest.New(t)
+	a.logger = log
+	a.redactor = newRedactor(a.config.HTTP.AccessLog)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reps?token=secret&page=2", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	a.accessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(httptest.NewRecorder(), req)
+
+	entry, ok := logs.Find("Request")
+	if !ok {
+		t.Fatal("the request was not logged")
+	}
+	if entry["query"] != "token=***&page=2" {
+		t.Errorf("query = %v, want token=***&page=2", entry["query"])
+	}
+	if headers, _ := entry["headers"].(map[string]any); headers["Authorization"] != redacted {
+		t.Errorf("headers = %v, want Authorization masked", entry["headers"])
+	}
+	if strings.Contains(logs.String(), "secret") {
+		t.Errorf("the secret reached the log: %s", logs)
+	}
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/go-chi/cors"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/ratelimit"
+)
+
+// reloadableLimiter delegates to a limiter that can be replaced while serving
+type reloadableLimiter struct {
+	current atomic.Pointer[ratelimit.Limiter]
+}
+
+func newReloadableLimiter(limiter ratelimit.Limiter) *reloadableLimiter {
+	l := &reloadableLimiter{}
+	l.current.Store(&limiter)
+	return l
+}
+
+// Allow implements ratelimit.Limiter
+func (l *reloadableLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	return (*l.current.Load()).Allow(ctx, key)
+}
+
+// swap replaces the limiter used for subsequent requests
+func (l *reloadableLimiter) swap(limiter ratelimit.Limiter) {
+	l.current.Store(&limiter)
+}
+
+// setLimits changes the current limiter's rate and burst in place, keeping its per-key
+// state, and reports whether the limiter supports it
+func (l *reloadableLimiter) setLimits(rate float64, burst int) bool {
+	adjustable, ok := (*l.current.Load()).(ratelimit.Adjustable)
+	if ok {
+		adjustable.SetLimits(rate, burst)
+	}
+	return ok
+}
+
+// newCORS builds the CORS handler from config. Origins are matched by the config so
+// that *.domain entries only cover subdomains of that domain
+func newCORS(cfg configs.CORSConfig) *cors.Cors {
+	return cors.New(cors.Options{
+		AllowOriginFunc: func(_ *http.Request, origin string) bool {
+			return cfg.AllowsOrigin(origin)
+		},
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   []string{"Link"},
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           int(cfg.MaxAge.Seconds()),
+	})
+}
+
+// corsMiddleware applies the current CORS policy, which can be replaced on reload
+func (a *App) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.cors.Load().Handler(next).ServeHTTP(w, r)
+	})
+}
+
+// reload re-reads and validates the configuration and applies the values that can change
+// while serving: log level, rate limits, CORS and TLS certificates. Other changes need a restart
+// and are logged as ignored. Applied values are remembered, so the next reload diffs against
+// them rather than the startup config.
+func (a *App) reload() {
+	a.logger.Info("Reloading configuration")
+
+	if err := configs.Load(); err != nil {
+		a.logger.Error("Configuration reload failed, keeping current settings", "error", err)
+		return
+	}
+	next := configs.Get()
+	current := a.config
+	if a.applied != nil {
+		current = a.applied
+	}
+	applied := *current
+	defer func() { a.applied = &applied }()
+
+	if next.Logging.Level != current.Logging.Level {
+		if err := a.logger.SetLevel(next.Logging.Level); err != nil {
+			a.logger.Error("Failed to apply log level", "error", err)
+		} else {
+			applied.Logging.Level = next.Logging.Level
+			a.logger.Info("Applied config change", "key", "logging.level", "from", current.Logging.Level, "to", next.Logging.Level)
+		}
+	}
+
+	if !reflect.DeepEqual(next.HTTP.RateLimit, current.HTTP.RateLimit) {
+		switch {
+		case a.limiter == nil || !next.HTTP.RateLimit.Enabled:
+			a.logger.Warn("Ignored config change, restart required", "key", "http.rate_limit.enabled")
+		case next.HTTP.RateLimit.Store == current.HTTP.RateLimit.Store &&
+			a.limiter.setLimits(next.HTTP.RateLimit.Rate, next.HTTP.RateLimit.Burst):
+			// Same backend: existing keys keep their state and follow the new limits
+			applied.HTTP.RateLimit = next.HTTP.RateLimit
+			a.logger.Info("Applied config change", "key", "http.rate_limit",
+				"rate", next.HTTP.RateLimit.Rate,
+				"burst", next.HTTP.RateLimit.Burst,
+			)
+		default:
+			limiter, err := a.newRateLimiter(next.HTTP.RateLimit)
+			if err != nil {
+				a.logger.Error("Failed to apply rate limits", "error", err)
+				break
+			}
+			a.limiter.swap(limiter)
+			applied.HTTP.RateLimit = next.HTTP.RateLimit
+			a.logger.Info("Applied config change", "key", "http.rate_limit",
+				"rate", next.HTTP.RateLimit.Rate,
+				"burst", next.HTTP.RateLimit.Burst,
+				"store", next.HTTP.RateLimit.Store,
+			)
+		}
+	}
+
+	if !reflect.DeepEqual(next.HTTP.CORS, current.HTTP.CORS) {
+		a.cors.Store(newCORS(next.HTTP.CORS))
+		applied.HTTP.CORS = next.HTTP.CORS
+		a.logger.Info("Applied config change", "key", "http.cors")
+	}
+
+	if a.certs != nil {
+		a.certs.reload("SIGHUP")
+	}
+
+	// Values bound at startup
+	restartOnly := map[string][2]interface{}{
+		"http.host":        {current.HTTP.Host, next.HTTP.Host},
+		"http.port":        {current.HTTP.Port, next.HTTP.Port},
+		"http.tls.enabled": {current.HTTP.TLS.Enabled, next.HTTP.TLS.Enabled},
+		"database":         {current.Database, next.Database},
+		"redis":            {current.Redis, next.Redis},
+		"auth":             {current.Auth, next.Auth},
+	}
+	for key, values := range restartOnly {
+		if !reflect.DeepEqual(values[0], values[1]) {
+			a.logger.Warn("Ignored config change, restart required", "key", key)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// reloadFixture runs the test from a directory holding configs/config.yaml, as reload
+// reads it, and returns the config loaded from it and a function rewriting it
+func reloadFixture(t *testing.T, yaml string) (*configs.Config, func(string)) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "configs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "configs", "config.yaml")
+	write := func(yaml string) {
+		t.Helper()
+		content := "auth:\n  jwt_secret: " + testSecret + "\n" + yaml
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(yaml)
+	t.Chdir(dir)
+
+	cfg, err := configs.LoadFrom(configs.LoadOptions{BaseFile: "configs/config.yaml", Env: map[string]string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg, write
+}
+
+// appliedKeys returns how many times each config key was logged as applied
+func appliedKeys(logs *logtest.Recorder) map[string]int {
+	keys := make(map[string]int)
+	for _, e := range logs.Entries() {
+		if e.Message() == "Applied config change" {
+			key, _ := e["key"].(string)
+			keys[key]++
+		}
+	}
+	return keys
+}
+
+func TestSIGHUPReloadsLogLevel(t *testing.T) {
+	cfg, write := reloadFixture(t, "logging:\n  level: info\n")
+	cfg.App.Shutdown.DrainDelay = 0
+	cfg.HTTP.Host = "127.0.0.1"
+	cfg.HTTP.Port = 0
+	cfg.HTTP.ZeroDowntime = false
+
+	a, _ := newRoutedApp(t, cfg)
+	log, logs := logtest.New(t)
+	a.logger = log
+	if err := a.logger.SetLevel("info"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keep a stray SIGHUP from killing the test binary before Run subscribes to it
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	_, done := runApp(t, a)
+	write("logging:\n  level: debug\n")
+
+	// Run subscribes after it starts listening, so signal until the reload lands
+	deadline := time.Now().Add(5 * time.Second)
+	for a.logger.LevelName() != "debug" {
+		if time.Now().After(deadline) {
+			t.Fatalf("level = %s after SIGHUP, want debug", a.logger.LevelName())
+		}
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	a.server.Close()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("Run returned %v after a reload", err)
+	}
+	if appliedKeys(logs)["logging.level"] == 0 {
+		t.Error("the log level change was not logged")
+	}
+}
+
+func TestReloadDiffsAgainstAppliedConfig(t *testing.T) {
+	cfg, write := reloadFixture(t, "logging:\n  level: info\nhttp:\n  rate_limit:\n    enabled: true\n")
+	a, _ := newRoutedApp(t, cfg)
+	log, logs := logtest.New(t)
+	a.logger = log
+	if err := a.logger.SetLevel("info"); err != nil {
+		t.Fatal(err)
+	}
+
+	write("logging:\n  level: debug\nhttp:\n  rate_limit:\n    enabled: true\n    burst: 7\n  cors:\n    allowed_origins: [\"https://crm.example.com\"]\n")
+	a.reload()
+	if a.logger.LevelName() != "debug" {
+		t.Fatalf("level = %s, want debug", a.logger.LevelName())
+	}
+	if got := appliedKeys(logs); got["logging.level"] != 1 || got["http.rate_limit"] != 1 || got["http.cors"] != 1 {
+		t.Fatalf("applied = %v, want the level, rate limit and CORS changes", got)
+	}
+
+	// Nothing changed since the last reload, so nothing is applied again
+	a.reload()
+	if got := appliedKeys(logs); got["logging.level"] != 1 || got["http.rate_limit"] != 1 || got["http.cors"] != 1 {
+		t.Errorf("applied = %v after an unchanged reload, want no new changes", got)
+	}
+
+	// Reverting to the startup level is a change from what is applied
+	write("logging:\n  level: info\nhttp:\n  rate_limit:\n    enabled: true\n    burst: 7\n  cors:\n    allowed_origins: [\"https://crm.example.com\"]\n")
+	a.reload()
+	if a.logger.LevelName() != "info" {
+		t.Errorf("level = %s, want info restored", a.logger.LevelName())
+	}
+}
+
+func TestReloadIgnoresRestartOnlyChanges(t *testing.T) {
+	cfg, write := reloadFixture(t, "")
+	a, _ := newRoutedApp(t, cfg)
+	log, logs := logtest.New(t)
+	a.logger = log
+
+	write("http:\n  port: 9090\n")
+	a.reload()
+
+	var ignored []any
+	for _, e := range logs.Entries() {
+		if e.Message() == "Ignored config change, restart required" {
+			ignored = append(ignored, e["key"])
+		}
+	}
+	if len(ignored) != 1 || ignored[0] != "http.port" {
+		t.Errorf("ignored = %v, want only http.port", ignored)
+	}
+	if a.config.HTTP.Port != 8080 {
+		t.Errorf("port = %d, want the startup port kept", a.config.HTTP.Port)
+	}
+}
+
+func TestReloadKeepsSettingsOnInvalidConfig(t *testing.T) {
+	cfg, write := reloadFixture(t, "logging:\n  level: info\nhttp:\n  rate_limit:\n    enabled: true\n")
+	a, _ := newRoutedApp(t, cfg)
+	log, logs := logtest.New(t)
+	a.logger = log
+	if err := a.logger.SetLevel("info"); err != nil {
+		t.Fatal(err)
+	}
+
+	write("logging:\n  level: debug\nhttp:\n  rate_limit:\n    enabled: true\n    burst: -1\n")
+	a.reload()
+	if a.logger.LevelName() != "info" {
+		t.Errorf("level = %s, want info kept after an invalid reload", a.logger.LevelName())
+	}
+	if _, ok := logs.Find("Configuration reload failed, keeping current settings"); !ok {
+		t.Error("the failed reload was not logged")
+	}
+}
+
+func TestReloadedRateLimitIsEnforced(t *testing.T) {
+	cfg, write := reloadFixture(t, "http:\n  rate_limit:\n    enabled: true\n    rate: 0.001\n    burst: 10\n")
+	a, _ := newRoutedApp(t, cfg)
+	limiter := *a.limiter.current.Load()
+
+	for i := range 2 {
+		if rec := get(a.router, "/version"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i+1, rec.Code)
+		}
+	}
+
+	write("http:\n  rate_limit:\n    enabled: true\n    rate: 0.001\n    burst: 3\n")
+	a.reload()
+	if *a.limiter.current.Load() != limiter {
+		t.Fatal("the limiter was replaced, want its limits changed in place")
+	}
+
+	// The client's bucket is capped at the new burst rather than reset
+	for i := range 3 {
+		if rec := get(a.router, "/version"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d after reload = %d, want 200", i+1, rec.Code)
+		}
+	}
+	if rec := get(a.router, "/version"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("request over the reloaded burst = %d, want 429", rec.Code)
+	}
+}
+
+func TestCORSFromConfig(t *testing.T) {
+	handler := newCORS(configs.CORSConfig{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowedMethods:   []string{http.MethodGet},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}).Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	preflight := func(origin string) http.Header {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/reps", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Header()
+	}
+
+	h := preflight("https://reps.example.com")
+	if h.Get("Access-Control-Allow-Origin") != "https://reps.example.com" ||
+		h.Get("Access-Control-Allow-Credentials") != "true" || h.Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("subdomain preflight headers = %v", h)
+	}
+	if h := preflight("https://example.com"); h.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("apex domain allowed: %v", h)
+	}
+}
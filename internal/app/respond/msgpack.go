@@ -0,0 +1,137 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// marshalMsgpack encodes payload as MessagePack. The payload goes through encoding/json first,
+// so struct tags, omitempty and json.Marshaler implementations shape both formats the same way.
+func marshalMsgpack(payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := encodeMsgpack(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeMsgpack writes a value decoded by encoding/json with UseNumber. Object keys are sorted
+// so the same payload always produces the same bytes, which keeps ETags stable.
+func encodeMsgpack(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		return encodeMsgpackNumber(buf, v)
+	case string:
+		encodeMsgpackString(buf, v)
+	case []interface{}:
+		writeMsgpackHeader(buf, len(v), 0x90, 0x0f, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := encodeMsgpack(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		writeMsgpackHeader(buf, len(v), 0x80, 0x0f, 0xde, 0xdf)
+		for _, key := range keys {
+			encodeMsgpackString(buf, key)
+			if err := encodeMsgpack(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", value)
+	}
+	return nil
+}
+
+// encodeMsgpackNumber writes integers in the smallest format that holds them and anything else as a float64
+func encodeMsgpackNumber(buf *bytes.Buffer, n json.Number) error {
+	if i, err := n.Int64(); err == nil {
+		switch {
+		case i >= 0 && i <= 0x7f:
+			buf.WriteByte(byte(i))
+		case i < 0 && i >= -32:
+			buf.WriteByte(byte(int8(i)))
+		case i >= math.MinInt8 && i <= math.MaxInt8:
+			buf.Write([]byte{0xd0, byte(int8(i))})
+		case i >= math.MinInt16 && i <= math.MaxInt16:
+			buf.WriteByte(0xd1)
+			buf.Write(binary.BigEndian.AppendUint16(nil, uint16(int16(i))))
+		case i >= math.MinInt32 && i <= math.MaxInt32:
+			buf.WriteByte(0xd2)
+			buf.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(i))))
+		default:
+			buf.WriteByte(0xd3)
+			buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+		}
+		return nil
+	}
+	if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+		buf.WriteByte(0xcf)
+		buf.Write(binary.BigEndian.AppendUint64(nil, u))
+		return nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return fmt.Errorf("msgpack: invalid number %q: %w", n, err)
+	}
+	buf.WriteByte(0xcb)
+	buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	return nil
+}
+
+func encodeMsgpackString(buf *bytes.Buffer, s string) {
+	if len(s) <= 0xff && len(s) > 0x1f {
+		buf.Write([]byte{0xd9, byte(len(s))})
+	} else {
+		writeMsgpackHeader(buf, len(s), 0xa0, 0x1f, 0xda, 0xdb)
+	}
+	buf.WriteString(s)
+}
+
+// writeMsgpackHeader writes the header of a string, array or map of length n: the fixed form
+// when n fits in mask, otherwise the 16 or 32-bit form
+func writeMsgpackHeader(buf *bytes.Buffer, n int, fixed byte, mask int, code16, code32 byte) {
+	switch {
+	case n <= mask:
+		buf.WriteByte(fixed | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(code32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
@@ -0,0 +1,203 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// decodeMsgpack decodes the subset of MessagePack written by marshalMsgpack into the values
+// encoding/json would produce, except that integers decode as int64 (uint64 past MaxInt64)
+func decodeMsgpack(data []byte) (interface{}, error) {
+	r := bytes.NewReader(data)
+	value, err := decodeMsgpackValue(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes", r.Len())
+	}
+	return value, nil
+}
+
+func decodeMsgpackValue(r *bytes.Reader) (interface{}, error) {
+	code, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	// size reads an n-byte big-endian length or integer
+	size := func(n int) (uint64, error) {
+		b := make([]byte, 8)
+		if _, err := r.Read(b[8-n:]); err != nil {
+			return 0, err
+		}
+		return binary.BigEndian.Uint64(b), nil
+	}
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xe0 == 0xa0:
+		return decodeMsgpackString(r, int(code&0x1f))
+	case code&0xf0 == 0x90:
+		return decodeMsgpackArray(r, int(code&0x0f))
+	case code&0xf0 == 0x80:
+		return decodeMsgpackMap(r, int(code&0x0f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xd0:
+		n, err := size(1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := size(2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := size(4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := size(8)
+		return int64(n), err
+	case 0xcf:
+		return size(8)
+	case 0xcb:
+		n, err := size(8)
+		return math.Float64frombits(n), err
+	case 0xd9, 0xda, 0xdb, 0xdc, 0xdd, 0xde, 0xdf:
+		width := map[byte]int{0xd9: 1, 0xda: 2, 0xdb: 4, 0xdc: 2, 0xdd: 4, 0xde: 2, 0xdf: 4}[code]
+		n, err := size(width)
+		if err != nil {
+			return nil, err
+		}
+		switch code {
+		case 0xd9, 0xda, 0xdb:
+			return decodeMsgpackString(r, int(n))
+		case 0xdc, 0xdd:
+			return decodeMsgpackArray(r, int(n))
+		default:
+			return decodeMsgpackMap(r, int(n))
+		}
+	}
+	return nil, fmt.Errorf("unexpected code 0x%x", code)
+}
+
+func decodeMsgpackString(r *bytes.Reader, n int) (interface{}, error) {
+	b := make([]byte, n)
+	if _, err := r.Read(b); err != nil && n > 0 {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func decodeMsgpackArray(r *bytes.Reader, n int) (interface{}, error) {
+	items := make([]interface{}, n)
+	for i := range items {
+		item, err := decodeMsgpackValue(r)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+func decodeMsgpackMap(r *bytes.Reader, n int) (interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for range n {
+		key, err := decodeMsgpackValue(r)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key %v is not a string", key)
+		}
+		if m[s], err = decodeMsgpackValue(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func TestMarshalMsgpack(t *testing.T) {
+	longList := make([]int, 20)
+	wantList := make([]interface{}, 20)
+	bigMap := make(map[string]int, 20)
+	wantMap := make(map[string]interface{}, 20)
+	for i := range 20 {
+		longList[i], wantList[i] = i, int64(i)
+		key := fmt.Sprintf("k%02d", i)
+		bigMap[key], wantMap[key] = i, int64(i)
+	}
+
+	tests := []struct {
+		name    string
+		payload interface{}
+		want    interface{}
+	}{
+		{"nil", nil, nil},
+		{"bools", []bool{true, false}, []interface{}{true, false}},
+		{"fixints", []int{0, 127, -1, -32}, []interface{}{int64(0), int64(127), int64(-1), int64(-32)}},
+		{"int8", -100, int64(-100)},
+		{"int16", []int{300, -300}, []interface{}{int64(300), int64(-300)}},
+		{"int32", []int{70_000, -70_000}, []interface{}{int64(70_000), int64(-70_000)}},
+		{"int64", int64(math.MinInt64), int64(math.MinInt64)},
+		{"uint64", uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{"float", 1.5, 1.5},
+		{"fixstr", "rep-1", "rep-1"},
+		{"str8", strings.Repeat("a", 200), strings.Repeat("a", 200)},
+		{"str16", strings.Repeat("a", 300), strings.Repeat("a", 300)},
+		{"str32", strings.Repeat("a", 70_000), strings.Repeat("a", 70_000)},
+		{"array16", longList, wantList},
+		{"map16", bigMap, wantMap},
+		{
+			"struct with json tags",
+			struct {
+				ID    string `json:"id"`
+				Notes string `json:"notes,omitempty"`
+			}{ID: "rep-1"},
+			map[string]interface{}{"id": "rep-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := marshalMsgpack(tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := decodeMsgpack(data)
+			if err != nil {
+				t.Fatalf("invalid MessagePack % x: %v", data, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalMsgpackIsDeterministic(t *testing.T) {
+	payload := map[string]interface{}{"b": 1, "a": []string{"x"}, "c": map[string]int{"z": 1, "y": 2}}
+	first, err := marshalMsgpack(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		if again, _ := marshalMsgpack(payload); !bytes.Equal(again, first) {
+			t.Fatalf("encoded % x, then % x", first, again)
+		}
+	}
+}
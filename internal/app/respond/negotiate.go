@@ -0,0 +1,83 @@
+package respond
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Response formats chosen by Negotiate
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeMsgpack = "application/msgpack"
+)
+
+// negotiatedWriter carries the format chosen for the request to JSON and Error
+type negotiatedWriter struct {
+	http.ResponseWriter
+	contentType string
+}
+
+func (nw *negotiatedWriter) Unwrap() http.ResponseWriter {
+	return nw.ResponseWriter
+}
+
+// Negotiate picks the response format from the Accept header: JSON unless the client prefers
+// MessagePack. JSON and Error then encode in that format, so handlers do not branch on it.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		if contentType := negotiate(r.Header.Get("Accept")); contentType != ContentTypeJSON {
+			w = &negotiatedWriter{ResponseWriter: w, contentType: contentType}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// negotiate returns the supported format the Accept header ranks highest; on equal quality the
+// one listed first wins. Wildcards and unsupported types fall back to JSON.
+func negotiate(accept string) string {
+	best, bestQ := ContentTypeJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		var contentType string
+		switch mediaType {
+		case "application/msgpack", "application/x-msgpack", "application/vnd.msgpack":
+			contentType = ContentTypeMsgpack
+		case "application/json", "application/*", "*/*":
+			contentType = ContentTypeJSON
+		default:
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = contentType, q
+		}
+	}
+	return best
+}
+
+// contentType returns the format Negotiate chose for the response written through w
+func contentType(w http.ResponseWriter) string {
+	for {
+		if nw, ok := w.(*negotiatedWriter); ok {
+			return nw.contentType
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return ContentTypeJSON
+		}
+		w = u.Unwrap()
+	}
+}
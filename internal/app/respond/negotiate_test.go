@@ -0,0 +1,102 @@
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := map[string]string{
+		"":                                      ContentTypeJSON,
+		"*/*":                                   ContentTypeJSON,
+		"text/html":                             ContentTypeJSON,
+		"application/msgpack":                   ContentTypeMsgpack,
+		"application/x-msgpack":                 ContentTypeMsgpack,
+		"application/vnd.msgpack":               ContentTypeMsgpack,
+		"application/json, application/msgpack": ContentTypeJSON,
+		"application/msgpack, application/json": ContentTypeMsgpack,
+		"application/json;q=0.5, application/msgpack": ContentTypeMsgpack,
+		"application/msgpack;q=0.1, */*;q=0.2":        ContentTypeJSON,
+		"application/msgpack;q=bad, text/html":        ContentTypeJSON,
+	}
+	for accept, want := range tests {
+		if got := negotiate(accept); got != want {
+			t.Errorf("negotiate(%q) = %s, want %s", accept, got, want)
+		}
+	}
+}
+
+// wrappingWriter is a ResponseWriter wrapper like those of the logging and metrics middleware
+type wrappingWriter struct {
+	http.ResponseWriter
+}
+
+func (w *wrappingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestNegotiateEncodesResponses(t *testing.T) {
+	payload := map[string]interface{}{"id": "rep-1", "visits": 3}
+	handler := Negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Middleware running after Negotiate wraps the writer
+		w = &wrappingWriter{ResponseWriter: w}
+		if r.URL.Path == "/error" {
+			Error(w, http.StatusNotFound, "not_found", "rep not found")
+			return
+		}
+		JSON(w, http.StatusOK, payload)
+	}))
+
+	// decode decodes a body of either format into the values encoding/json produces
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) interface{} {
+		t.Helper()
+		var v interface{}
+		var err error
+		if rec.Header().Get("Content-Type") == ContentTypeMsgpack {
+			v, err = decodeMsgpack(rec.Body.Bytes())
+		} else {
+			err = json.Unmarshal(rec.Body.Bytes(), &v)
+		}
+		if err != nil {
+			t.Fatalf("invalid body % x: %v", rec.Body.Bytes(), err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		accept    string
+		path      string
+		wantType  string
+		wantValue interface{}
+	}{
+		{"", "/", ContentTypeJSON, map[string]interface{}{"id": "rep-1", "visits": float64(3)}},
+		{"application/msgpack", "/", ContentTypeMsgpack, map[string]interface{}{"id": "rep-1", "visits": int64(3)}},
+		{"application/json", "/error", ContentTypeJSON, map[string]interface{}{
+			"error": map[string]interface{}{"code": "not_found", "message": "rep not found"},
+		}},
+		{"application/msgpack", "/error", ContentTypeMsgpack, map[string]interface{}{
+			"error": map[string]interface{}{"code": "not_found", "message": "rep not found"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if ct := rec.Header().Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("Content-Type = %q, want %s", ct, tt.wantType)
+			}
+			if vary := rec.Header().Get("Vary"); vary != "Accept" {
+				t.Errorf("Vary = %q, want Accept", vary)
+			}
+			if got := decode(t, rec); !reflect.DeepEqual(got, tt.wantValue) {
+				t.Errorf("body = %v, want %v", got, tt.wantValue)
+			}
+		})
+	}
+}
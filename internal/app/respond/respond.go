@@ -0,0 +1,83 @@
+package respond
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger used to report encoding failures (slog.Default when unset)
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+// Logger returns the logger set with SetLogger, for helpers that report errors alongside a response
+func Logger() *slog.Logger {
+	return getLogger()
+}
+
+func getLogger() *slog.Logger {
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
+// ErrorBody is the error envelope written by Error
+type ErrorBody struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an error with a machine-readable code and a human-readable message
+type ErrorDetail struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// JSON writes payload as JSON with the given status, or as MessagePack when Negotiate chose it.
+// The payload is encoded before any header is written, so an encoding failure can still become a 500.
+func JSON(w http.ResponseWriter, status int, payload interface{}) {
+	contentType := contentType(w)
+	body, err := marshal(contentType, payload)
+	if err != nil {
+		getLogger().Error("Failed to encode response", "status", status, "content_type", contentType, "error", err)
+		status = http.StatusInternalServerError
+		body, _ = marshal(contentType, ErrorBody{Error: ErrorDetail{
+			Code:    "internal_error",
+			Message: http.StatusText(http.StatusInternalServerError),
+		}})
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		getLogger().Debug("Failed to write response", "error", err)
+	}
+}
+
+// marshal encodes payload in the given format; JSON bodies end with a newline
+func marshal(contentType string, payload interface{}) ([]byte, error) {
+	if contentType == ContentTypeMsgpack {
+		return marshalMsgpack(payload)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return append(body, '\n'), nil
+}
+
+// Error writes the standard error envelope: {"error":{"code":...,"message":...}}
+func Error(w http.ResponseWriter, status int, code, message string) {
+	JSON(w, status, ErrorBody{Error: ErrorDetail{Code: code, Message: message}})
+}
@@ -0,0 +1,65 @@
+package respond
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	JSON(rec, http.StatusCreated, map[string]string{"id": "rep-1"})
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want 201", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != ContentTypeJSON {
+		t.Errorf("Content-Type = %q, want %s", ct, ContentTypeJSON)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"id":"rep-1"}` {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Error(rec, http.StatusNotFound, "not_found", "rep not found")
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+	var body map[string]map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body, err)
+	}
+	detail := body["error"]
+	if len(body) != 1 || detail["code"] != "not_found" || detail["message"] != "rep not found" {
+		t.Errorf("body = %s, want the error envelope", rec.Body)
+	}
+	if _, ok := detail["fields"]; ok {
+		t.Error("empty fields were not omitted")
+	}
+}
+
+func TestJSONEncodeFailure(t *testing.T) {
+	SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { SetLogger(nil) })
+
+	rec := httptest.NewRecorder()
+	JSON(rec, http.StatusOK, map[string]any{"callback": func() {}})
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	var body ErrorBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body, err)
+	}
+	if body.Error.Code != "internal_error" {
+		t.Errorf("code = %q, want internal_error", body.Error.Code)
+	}
+}
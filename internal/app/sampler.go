@@ -0,0 +1,39 @@
+package app
+
+import (
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/metrics"
+)
+
+// newSampler creates the metrics sampler over the database and Redis connection pools
+func (a *App) newSampler() *metrics.Sampler {
+	var pools []metrics.PoolSource
+	if a.db != nil {
+		pools = append(pools, metrics.PoolSource{Name: "database", Stats: func() metrics.PoolStats {
+			stats := a.db.Stats()
+			return metrics.PoolStats{
+				Open:      stats.OpenConnections,
+				InUse:     stats.InUse,
+				Idle:      stats.Idle,
+				WaitCount: stats.WaitCount,
+			}
+		}})
+	}
+	if a.redis != nil {
+		pools = append(pools, metrics.PoolSource{Name: "redis", Stats: func() metrics.PoolStats {
+			stats := a.redis.PoolStats()
+			return metrics.PoolStats{
+				Open:      int(stats.TotalConns),
+				InUse:     int(stats.TotalConns) - int(stats.IdleConns),
+				Idle:      int(stats.IdleConns),
+				WaitCount: int64(stats.WaitCount),
+			}
+		}})
+	}
+
+	var log *logger.Logger
+	if a.config.Metrics.Sampler.Log {
+		log = a.logger
+	}
+	return a.metrics.NewSampler(a.config.Metrics.Sampler.Interval, log, pools...)
+}
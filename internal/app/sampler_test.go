@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/database/dbtest"
+)
+
+func TestSamplerRecordsDependencyPools(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "metrics:\n  sampler:\n    interval: 10ms\n"))
+	a.db = dbtest.NewPing(t)
+	if err := a.redis.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s := a.newSampler()
+	s.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+
+	body := get(a.router, a.config.Metrics.Path).Body.String()
+	for _, want := range []string{
+		`pool_connections{pool="database",state="open"}`,
+		`pool_connections{pool="redis",state="open"} 1`,
+		`pool_connections{pool="redis",state="idle"} 1`,
+		`pool_waits{pool="redis"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape is missing %s", want)
+		}
+	}
+}
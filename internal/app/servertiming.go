@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// serverTiming reports how long the server took to start the response, so clients can tell
+// server time from network time. The Server-Timing and X-Response-Time-Ms headers are set
+// just before the headers are sent, whether the handler calls WriteHeader or only Write.
+func serverTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Upgraded connections need the unwrapped writer to hijack
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tw := &timingWriter{ResponseWriter: w, start: time.Now()}
+		next.ServeHTTP(tw, r)
+		// Handlers that write nothing still get a 200 with the headers
+		tw.setHeaders()
+	})
+}
+
+// timingWriter stamps the timing headers on the first write
+type timingWriter struct {
+	http.ResponseWriter
+	start   time.Time
+	stamped bool
+}
+
+func (tw *timingWriter) setHeaders() {
+	if tw.stamped {
+		return
+	}
+	tw.stamped = true
+
+	ms := float64(time.Since(tw.start).Microseconds()) / 1000
+	h := tw.Header()
+	h.Add("Server-Timing", fmt.Sprintf("app;dur=%.1f", ms))
+	h.Set("X-Response-Time-Ms", strconv.FormatFloat(ms, 'f', 1, 64))
+}
+
+func (tw *timingWriter) WriteHeader(status int) {
+	tw.setHeaders()
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *timingWriter) Write(b []byte) (int, error) {
+	tw.setHeaders()
+	return tw.ResponseWriter.Write(b)
+}
+
+func (tw *timingWriter) Flush() {
+	tw.setHeaders()
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (tw *timingWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
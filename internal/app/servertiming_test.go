@@ -0,0 +1,93 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serverTimingPattern matches the app entry of the Server-Timing header
+var serverTimingPattern = regexp.MustCompile(`^app;dur=(\d+\.\d)$`)
+
+func TestServerTiming(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+	}{
+		{"WriteHeader", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(15 * time.Millisecond)
+			w.WriteHeader(http.StatusCreated)
+		}, http.StatusCreated},
+		{"Write only", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(15 * time.Millisecond)
+			w.Write([]byte("ok"))
+		}, http.StatusOK},
+		{"Flush", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(15 * time.Millisecond)
+			http.NewResponseController(w).Flush()
+		}, http.StatusOK},
+		{"nothing written", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(15 * time.Millisecond)
+		}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			serverTiming(tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			match := serverTimingPattern.FindStringSubmatch(rec.Header().Get("Server-Timing"))
+			if match == nil {
+				t.Fatalf("Server-Timing = %q, want app;dur=<ms>", rec.Header().Get("Server-Timing"))
+			}
+			if match[1] != rec.Header().Get("X-Response-Time-Ms") {
+				t.Errorf("X-Response-Time-Ms = %q, want %s", rec.Header().Get("X-Response-Time-Ms"), match[1])
+			}
+			if ms, _ := strconv.ParseFloat(match[1], 64); ms < 15 {
+				t.Errorf("duration = %sms, want at least the 15ms the handler took", match[1])
+			}
+		})
+	}
+}
+
+func TestServerTimingKeepsOtherEntries(t *testing.T) {
+	rec := httptest.NewRecorder()
+	serverTiming(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server-Timing", "db;dur=3.0")
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rec.Header().Values("Server-Timing"); len(got) != 2 || got[0] != "db;dur=3.0" {
+		t.Errorf("Server-Timing = %v, want the handler's entry and app", got)
+	}
+}
+
+func TestServerTimingSkipsUpgrades(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Upgrade", "websocket")
+	serverTiming(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(*timingWriter); ok {
+			t.Error("upgrade request got the wrapped writer")
+		}
+	})).ServeHTTP(rec, req)
+
+	if rec.Header().Get("Server-Timing") != "" {
+		t.Error("upgrade response was timed")
+	}
+}
+
+func TestServerTimingFromConfig(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		a, _ := newRoutedApp(t, testConfig(t, "http:\n  server_timing: "+strconv.FormatBool(enabled)+"\n"))
+		rec := get(a.router, "/liveness")
+		if got := rec.Header().Get("Server-Timing") != ""; got != enabled {
+			t.Errorf("server_timing %v: header present = %v", enabled, got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/metrics"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// slowRequests logs requests that take longer than threshold at warn level, with enough detail
+// to find the outlier; faster requests are left to the access log
+func (a *App) slowRequests(threshold time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			elapsed := time.Since(start)
+			if elapsed < threshold {
+				return
+			}
+
+			route := metrics.RouteLabel(r)
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			a.logger.Warn("Slow request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"route", route,
+				"query", a.redactor.query(r.URL),
+				"status", status,
+				"duration", elapsed,
+				"threshold", threshold,
+				"bytes_written", ww.BytesWritten(),
+				"remote_addr", r.RemoteAddr,
+				"user_agent", r.UserAgent(),
+				"request_id", requestid.FromContext(r.Context()),
+			)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// slowApp returns a router logging requests slower than 20ms, with a route sleeping for the
+// given duration
+func slowApp(t *testing.T, sleep time.Duration) (http.Handler, *logtest.Recorder) {
+	t.Helper()
+	a := newTestApp(t, testConfig(t, ""))
+	log, logs := logtest.New(t)
+	a.logger = log
+	a.redactor = newRedactor(a.config.HTTP.AccessLog)
+
+	r := chi.NewRouter()
+	r.Use(a.slowRequests(20 * time.Millisecond))
+	r.Get("/reps/{id}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(sleep)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return r, logs
+}
+
+func TestSlowRequestIsLogged(t *testing.T) {
+	handler, logs := slowApp(t, 30*time.Millisecond)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reps/7?token=s3cret&page=2", nil))
+
+	entry, ok := logs.Find("Slow request")
+	if !ok {
+		t.Fatal("slow request was not logged")
+	}
+	if entry["level"] != "WARN" || entry["method"] != http.MethodGet || entry["path"] != "/reps/7" || entry["status"] != float64(http.StatusAccepted) {
+		t.Errorf("log entry = %v, want a warning with the method, path and status", entry)
+	}
+	if entry["route"] != "/reps/{id}" {
+		t.Errorf("route = %v, want the route pattern", entry["route"])
+	}
+	if query, _ := entry["query"].(string); strings.Contains(query, "s3cret") || !strings.Contains(query, "page=2") {
+		t.Errorf("query = %q, want the token redacted and the rest kept", query)
+	}
+	if duration, _ := entry["duration"].(float64); time.Duration(duration) < 30*time.Millisecond {
+		t.Errorf("duration = %v, want at least the handler's sleep", entry["duration"])
+	}
+}
+
+func TestFastRequestIsNotLogged(t *testing.T) {
+	handler, logs := slowApp(t, 0)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reps/7", nil))
+
+	if logs.Count("Slow request") != 0 {
+		t.Error("a request under the threshold was logged")
+	}
+}
+
+func TestSlowRequestThresholdZeroDisables(t *testing.T) {
+	for yaml, want := range map[string]bool{
+		"http:\n  slow_request_threshold: 0s\n": false,
+		"http:\n  slow_request_threshold: 1s\n": true,
+	} {
+		a := newTestApp(t, testConfig(t, yaml))
+		m, err := a.middlewares()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := m["slow_requests"]; ok != want {
+			t.Errorf("%q: slow request logging enabled = %v, want %v", yaml, ok, want)
+		}
+	}
+}
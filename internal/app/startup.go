@@ -0,0 +1,55 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+// connectWithRetry calls connect until it succeeds, the configured attempts are used up or
+// ctx expires, doubling the wait between attempts up to the configured maximum. Each attempt
+// gets ctx, so a dial or ping that hangs is cut short by the startup timeout too.
+func connectWithRetry[T any](ctx context.Context, log *logger.Logger, cfg configs.StartupConfig, name string, connect func(context.Context) (T, error)) (T, error) {
+	var zero T
+	backoff := cfg.Backoff
+
+	for attempt := 1; ; attempt++ {
+		conn, err := connect(ctx)
+		if err == nil {
+			if attempt > 1 {
+				log.Info("Connected after retrying", "dependency", name, "attempt", attempt)
+			}
+			return conn, nil
+		}
+
+		if ctx.Err() != nil {
+			return zero, fmt.Errorf("%s unavailable before startup timeout: %w", name, err)
+		}
+
+		if attempt >= cfg.Attempts {
+			return zero, fmt.Errorf("%s unavailable after %d attempts: %w", name, attempt, err)
+		}
+
+		log.Warn("Dependency not ready, retrying",
+			"dependency", name,
+			"attempt", attempt,
+			"max_attempts", cfg.Attempts,
+			"retry_in", backoff,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return zero, fmt.Errorf("%s unavailable before startup timeout: %w", name, err)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > cfg.MaxBackoff {
+			backoff = cfg.MaxBackoff
+		}
+	}
+}
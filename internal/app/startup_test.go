@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// failingConnector fails the first failures calls and then returns "connected"
+func failingConnector(failures int) (func(context.Context) (string, error), *int) {
+	calls := 0
+	return func(context.Context) (string, error) {
+		calls++
+		if calls <= failures {
+			return "", errors.New("connection refused")
+		}
+		return "connected", nil
+	}, &calls
+}
+
+func TestConnectWithRetrySucceeds(t *testing.T) {
+	log, logs := logtest.New(t)
+	connect, calls := failingConnector(2)
+	cfg := configs.StartupConfig{Attempts: 5, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	conn, err := connectWithRetry(context.Background(), log, cfg, "database", connect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn != "connected" || *calls != 3 {
+		t.Fatalf("got %q after %d calls, want connected after 3", conn, *calls)
+	}
+	if n := logs.Count("Dependency not ready, retrying"); n != 2 {
+		t.Errorf("logged %d retries, want 2", n)
+	}
+	if _, ok := logs.Find("Connected after retrying"); !ok {
+		t.Error("the eventual connection was not logged")
+	}
+}
+
+func TestConnectWithRetryGivesUp(t *testing.T) {
+	connect, calls := failingConnector(10)
+	cfg := configs.StartupConfig{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	_, err := connectWithRetry(context.Background(), logtest.Discard(t), cfg, "redis", connect)
+	if err == nil || !strings.Contains(err.Error(), "redis unavailable after 3 attempts") {
+		t.Fatalf("err = %v, want the attempts exhausted", err)
+	}
+	if *calls != 3 {
+		t.Errorf("connector called %d times, want 3", *calls)
+	}
+}
+
+func TestConnectWithRetryRespectsTimeout(t *testing.T) {
+	connect, _ := failingConnector(10)
+	cfg := configs.StartupConfig{Attempts: 10, Backoff: time.Hour, MaxBackoff: time.Hour}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := connectWithRetry(ctx, logtest.Discard(t), cfg, "database", connect)
+	if err == nil || !strings.Contains(err.Error(), "before startup timeout") {
+		t.Fatalf("err = %v, want the startup timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %s, want the startup timeout to cut the backoff short", elapsed)
+	}
+}
+
+func TestConnectWithRetryBoundsHangingAttempt(t *testing.T) {
+	// The attempt only returns once its context is done, like a dial to an unreachable host
+	connect := func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	cfg := configs.StartupConfig{Attempts: 10, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := connectWithRetry(ctx, logtest.Discard(t), cfg, "database", connect)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "before startup timeout") || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want the startup timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a hanging attempt was not cut short by the startup timeout")
+	}
+}
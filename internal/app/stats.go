@@ -0,0 +1,127 @@
+package app
+
+import (
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// serverStats tracks live process, connection and request statistics for the debug metrics endpoint
+type serverStats struct {
+	startedAt     time.Time
+	requests      atomic.Uint64
+	active        atomic.Int64
+	connsAccepted atomic.Uint64
+	connsActive   atomic.Int64
+	connStates    sync.Map // net.Conn -> http.ConnState
+	stateCounts   [http.StateIdle + 1]atomic.Int64
+}
+
+// newServerStats starts the uptime clock
+func newServerStats() *serverStats {
+	return &serverStats{startedAt: time.Now()}
+}
+
+// Middleware counts every request handled and tracks how many are still running
+func (s *serverStats) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.active.Add(1)
+		defer s.active.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Active returns the number of requests currently being handled
+func (s *serverStats) Active() int64 {
+	return s.active.Load()
+}
+
+// Connections returns the number of open TCP connections accepted by a counted listener
+func (s *serverStats) Connections() int64 {
+	return s.connsActive.Load()
+}
+
+// countConnections wraps ln so accepted connections are counted until they are closed
+func (s *serverStats) countConnections(ln net.Listener) net.Listener {
+	return &countingListener{Listener: ln, stats: s}
+}
+
+// countingListener counts connections accepted from the wrapped listener
+type countingListener struct {
+	net.Listener
+	stats *serverStats
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.stats.connsAccepted.Add(1)
+	l.stats.connsActive.Add(1)
+	return &countingConn{Conn: conn, stats: l.stats}, nil
+}
+
+// countingConn decrements the active count once, however often it is closed.
+// Hijacked connections keep this wrapper, so they are counted until the hijacker closes them.
+type countingConn struct {
+	net.Conn
+	stats *serverStats
+	once  sync.Once
+}
+
+func (c *countingConn) Close() error {
+	c.once.Do(func() { c.stats.connsActive.Add(-1) })
+	return c.Conn.Close()
+}
+
+// ConnState is an http.Server ConnState hook counting connections that are new, serving a
+// request or idle in keep-alive. Hijacked and closed connections are no longer tracked
+func (s *serverStats) ConnState(conn net.Conn, state http.ConnState) {
+	if prev, ok := s.connStates.Load(conn); ok {
+		s.stateCounts[prev.(http.ConnState)].Add(-1)
+	}
+	if state == http.StateHijacked || state == http.StateClosed {
+		s.connStates.Delete(conn)
+		return
+	}
+	s.connStates.Store(conn, state)
+	s.stateCounts[state].Add(1)
+}
+
+// Snapshot returns the current uptime, request and connection counts, goroutine count and memory statistics
+func (s *serverStats) Snapshot() map[string]interface{} {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	uptime := time.Since(s.startedAt)
+
+	return map[string]interface{}{
+		"started_at":     s.startedAt.UTC().Format(time.RFC3339),
+		"uptime":         uptime.String(),
+		"uptime_seconds": uptime.Seconds(),
+		"requests_total": s.requests.Load(),
+		"goroutines":     runtime.NumGoroutine(),
+		"connections": map[string]interface{}{
+			"active":         s.connsActive.Load(),
+			"accepted_total": s.connsAccepted.Load(),
+			"new":            s.stateCounts[http.StateNew].Load(),
+			"serving":        s.stateCounts[http.StateActive].Load(),
+			"idle":           s.stateCounts[http.StateIdle].Load(),
+		},
+		"memory": map[string]interface{}{
+			"alloc_bytes":       mem.Alloc,
+			"total_alloc_bytes": mem.TotalAlloc,
+			"sys_bytes":         mem.Sys,
+			"heap_alloc_bytes":  mem.HeapAlloc,
+			"heap_inuse_bytes":  mem.HeapInuse,
+			"heap_objects":      mem.HeapObjects,
+			"num_gc":            mem.NumGC,
+			"pause_total_ns":    mem.PauseTotalNs,
+		},
+	}
+}
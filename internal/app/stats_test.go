@@ -0,0 +1,212 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerStatsSnapshot(t *testing.T) {
+	stats := newServerStats()
+	handler := stats.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if stats.Active() != 1 {
+			t.Errorf("active = %d while serving, want 1", stats.Active())
+		}
+	}))
+
+	first := stats.Snapshot()
+	for range 3 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	time.Sleep(10 * time.Millisecond)
+	second := stats.Snapshot()
+
+	if second["uptime_seconds"].(float64) <= first["uptime_seconds"].(float64) {
+		t.Errorf("uptime did not increase: %v then %v", first["uptime_seconds"], second["uptime_seconds"])
+	}
+	if got := second["requests_total"].(uint64); got != 3 {
+		t.Errorf("requests_total = %d, want 3", got)
+	}
+	if stats.Active() != 0 {
+		t.Errorf("active = %d after serving, want 0", stats.Active())
+	}
+	if second["goroutines"].(int) < 1 {
+		t.Error("no goroutines reported")
+	}
+}
+
+func TestDebugMetricsEndpoint(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, "app:\n  debug: true\n"))
+	token := bearer(t, a)
+
+	serveAs(a.router, httptest.NewRequest(http.MethodGet, "/liveness", nil), "")
+	rec := serveAs(a.router, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil), token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var body struct {
+		RequestsTotal uint64         `json:"requests_total"`
+		Memory        map[string]any `json:"memory"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body, err)
+	}
+	// The liveness probe and this request
+	if body.RequestsTotal != 2 {
+		t.Errorf("requests_total = %d, want 2", body.RequestsTotal)
+	}
+	if body.Memory["heap_alloc_bytes"] == nil {
+		t.Errorf("memory = %v, want heap statistics", body.Memory)
+	}
+
+	if rec := serveAs(a.router, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil), ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status without a token = %d, want 401", rec.Code)
+	}
+}
+
+// waitConnections waits for the open connection count to reach want
+func waitConnections(t *testing.T, stats *serverStats, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for stats.Connections() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("open connections = %d, want %d", stats.Connections(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCountingListener(t *testing.T) {
+	stats := newServerStats()
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := stats.countConnections(raw)
+	defer ln.Close()
+
+	// Close each server side once its client hangs up, closing twice to check it counts once
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(io.Discard, conn)
+				conn.Close()
+				conn.Close()
+			}()
+		}
+	}()
+
+	const n = 5
+	clients := make([]net.Conn, n)
+	for i := range clients {
+		if clients[i], err = net.Dial("tcp", raw.Addr().String()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitConnections(t, stats, n)
+
+	for i, c := range clients {
+		c.Close()
+		waitConnections(t, stats, int64(n-i-1))
+	}
+
+	conns := stats.Snapshot()["connections"].(map[string]interface{})
+	if conns["accepted_total"].(uint64) != n || conns["active"].(int64) != 0 {
+		t.Errorf("connections = %v, want %d accepted and none active", conns, n)
+	}
+}
+
+func TestRunCountsConnections(t *testing.T) {
+	a, _ := newRoutedApp(t, runConfig(t, ""))
+	ln, done := runApp(t, a)
+
+	var clients []net.Conn
+	for range 3 {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, c)
+	}
+	waitConnections(t, a.stats, 3)
+
+	for _, c := range clients {
+		c.Close()
+	}
+	a.server.Close()
+	if err := waitRun(t, done); err != nil {
+		t.Fatal(err)
+	}
+	waitConnections(t, a.stats, 0)
+}
+
+func TestConnStateCounts(t *testing.T) {
+	stats := newServerStats()
+	a, b := &net.TCPConn{}, &net.TCPConn{}
+
+	stats.ConnState(a, http.StateNew)
+	stats.ConnState(b, http.StateNew)
+	stats.ConnState(a, http.StateActive)
+	stats.ConnState(a, http.StateIdle)
+	stats.ConnState(b, http.StateActive)
+
+	conns := stats.Snapshot()["connections"].(map[string]interface{})
+	if conns["new"] != int64(0) || conns["serving"] != int64(1) || conns["idle"] != int64(1) {
+		t.Errorf("connections = %v, want one serving and one idle", conns)
+	}
+
+	stats.ConnState(a, http.StateClosed)
+	stats.ConnState(b, http.StateHijacked)
+	conns = stats.Snapshot()["connections"].(map[string]interface{})
+	if conns["serving"] != int64(0) || conns["idle"] != int64(0) {
+		t.Errorf("connections = %v after close and hijack, want none tracked", conns)
+	}
+}
+
+func TestReadHeaderTimeoutCutsOffSlowClients(t *testing.T) {
+	a, _ := newRoutedApp(t, runConfig(t, "http:\n  read_header_timeout: 100ms\n"))
+	ln, done := runApp(t, a)
+	defer func() {
+		a.server.Close()
+		waitRun(t, done)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Dribble the headers one line at a time, as a Slowloris client does
+	start := time.Now()
+	closed := false
+	for _, line := range []string{"GET /liveness HTTP/1.1\r\n", "Host: crm\r\n", "X-A: 1\r\n", "X-B: 2\r\n", "X-C: 3\r\n"} {
+		if _, err := conn.Write([]byte(line)); err != nil {
+			closed = true
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reply, err := io.ReadAll(conn)
+	if err != nil && !closed {
+		t.Fatalf("connection was not closed by the server: %v", err)
+	}
+	if len(reply) > 0 && !strings.HasPrefix(string(reply), "HTTP/1.1 408") {
+		t.Errorf("slow client was answered with %q", reply)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("slow client was cut off after %s, want about the 100ms header timeout", elapsed)
+	}
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// requestTimeout cancels the request context after timeout. If the handler returns because of
+// the deadline without having written a response, a JSON 503 is sent and the timeout is logged.
+func (a *App) requestTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return
+			}
+
+			route := r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			a.logger.Warn("Request timed out",
+				"method", r.Method,
+				"route", route,
+				"elapsed", time.Since(start),
+				"timeout", timeout,
+				"request_id", requestid.FromContext(r.Context()),
+			)
+
+			if a.metrics != nil {
+				a.metrics.RequestTimedOut()
+			}
+
+			if ww.Status() == 0 {
+				respond.Error(w, http.StatusServiceUnavailable, "timeout", "request timed out")
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/metrics"
+)
+
+// slow is a handler that waits for its request to be cancelled
+var slow = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	<-r.Context().Done()
+})
+
+func TestRequestTimeoutAnswersJSON(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	log, logs := logtest.New(t)
+	a.logger = log
+	a.metrics = metrics.New()
+
+	r := chi.NewRouter()
+	r.Use(a.requestTimeout(10 * time.Millisecond))
+	r.Get("/reps/{id}", slow)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reps/42", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Code != "timeout" {
+		t.Fatalf("body = %s, want a timeout error", rec.Body)
+	}
+
+	entry, ok := logs.Find("Request timed out")
+	if !ok {
+		t.Fatal("timeout was not logged")
+	}
+	if entry["route"] != "/reps/{id}" || entry["method"] != http.MethodGet || entry["elapsed"] == nil {
+		t.Errorf("log entry = %v, want the route pattern, method and elapsed time", entry)
+	}
+
+	scrape := httptest.NewRecorder()
+	a.metrics.Handler().ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(scrape.Body.String(), "http_request_timeouts_total 1") {
+		t.Error("http_request_timeouts_total was not incremented")
+	}
+}
+
+func TestRequestTimeoutKeepsWrittenResponse(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	handler := a.requestTimeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		<-r.Context().Done()
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusAccepted || rec.Body.Len() != 0 {
+		t.Errorf("got %d %q, want the handler's 202 left alone", rec.Code, rec.Body)
+	}
+}
+
+func TestRequestTimeoutIgnoresFastRequests(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	log, logs := logtest.New(t)
+	a.logger = log
+
+	handler := a.requestTimeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", rec.Code)
+	}
+	if _, ok := logs.Find("Request timed out"); ok {
+		t.Error("a fast request was logged as timed out")
+	}
+}
+
+func TestRequestTimeoutComesFromConfig(t *testing.T) {
+	cfg := testConfig(t, "http:\n  request_timeout: 10ms\n")
+	a := newTestApp(t, cfg)
+	m, err := a.middlewares()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	m["timeout"](slow).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want the configured timeout to fire", rec.Code)
+	}
+}
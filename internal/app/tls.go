@@ -0,0 +1,65 @@
+package app
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// defaultCipherSuites is used when tls.cipher_suites is not configured
+var defaultCipherSuites = []uint16{
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+}
+
+// newTLSConfig builds the hardened protocol, cipher and client certificate settings shared by
+// every TLS listener. Server certificates are left to the caller.
+func newTLSConfig(cfg configs.TLSConfig) (*tls.Config, error) {
+	minVersion, err := cfg.ParseMinVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	// Cipher suites only apply up to TLS 1.2; TLS 1.3 suites are not configurable
+	cipherSuites, err := cfg.ParseCipherSuites()
+	if err != nil {
+		return nil, err
+	}
+
+	clientAuth, err := cfg.ParseClientAuth()
+	if err != nil {
+		return nil, err
+	}
+
+	if cipherSuites == nil {
+		cipherSuites = defaultCipherSuites
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:               minVersion,
+		CipherSuites:             cipherSuites,
+		PreferServerCipherSuites: true,
+		CurvePreferences:         []tls.CurveID{tls.CurveP256, tls.X25519},
+		ClientAuth:               clientAuth,
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificates found in client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+	}
+
+	return tlsConfig, nil
+}
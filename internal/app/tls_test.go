@@ -0,0 +1,213 @@
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// testCert is a certificate with its key, signed by a testCA or self-signed
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newTestCert issues a certificate for name, signed by parent or self-signed when parent is nil
+func newTestCert(t *testing.T, name string, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// newTestCA creates a self-signed certificate authority
+func newTestCA(t *testing.T) *testCert {
+	t.Helper()
+	return newTestCert(t, "Test CA", nil, true)
+}
+
+// discardLog returns a standard logger that drops everything, for servers whose
+// handshake errors are expected
+func discardLog() *log.Logger {
+	return log.New(io.Discard, "", 0)
+}
+
+// tlsCertificate returns the certificate as used by crypto/tls
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+// write stores the certificate and key as PEM files in dir and returns their paths
+func (c *testCert) write(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	writePEM(t, certFile, "CERTIFICATE", c.der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+// writePEM writes one PEM block to path
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewTLSConfigDefaults(t *testing.T) {
+	cfg, err := newTLSConfig(configs.TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x, want TLS 1.2", cfg.MinVersion)
+	}
+	if !slices.Equal(cfg.CipherSuites, defaultCipherSuites) {
+		t.Errorf("CipherSuites = %v, want the defaults", cfg.CipherSuites)
+	}
+	if len(cfg.CurvePreferences) == 0 {
+		t.Error("no curve preferences set")
+	}
+	if cfg.ClientAuth != tls.NoClientCert {
+		t.Errorf("ClientAuth = %v, want none without a client CA", cfg.ClientAuth)
+	}
+}
+
+func TestNewTLSConfigOverrides(t *testing.T) {
+	cfg, err := newTLSConfig(configs.TLSConfig{
+		MinVersion:   "1.3",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("MinVersion = %x, want TLS 1.3", cfg.MinVersion)
+	}
+	if !slices.Equal(cfg.CipherSuites, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}) {
+		t.Errorf("CipherSuites = %v, want only the configured suite", cfg.CipherSuites)
+	}
+
+	if _, err := newTLSConfig(configs.TLSConfig{MinVersion: "1.1"}); err == nil {
+		t.Error("TLS 1.1 accepted")
+	}
+}
+
+func TestNewTLSConfigRequiresClientCert(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTestCert(t, "localhost", ca, false)
+	client := newTestCert(t, "partner", ca, false)
+	stranger := newTestCert(t, "stranger", nil, false)
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	writePEM(t, caFile, "CERTIFICATE", ca.der)
+
+	tlsConfig, err := newTLSConfig(configs.TLSConfig{ClientCAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("ClientAuth = %v, want RequireAndVerifyClientCert with a client CA", tlsConfig.ClientAuth)
+	}
+	tlsConfig.Certificates = []tls.Certificate{server.tlsCertificate()}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = tlsConfig
+	srv.Config.ErrorLog = discardLog()
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(certs ...tls.Certificate) error {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+		}}}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	if err := get(); err == nil {
+		t.Error("a client without a certificate was accepted")
+	}
+	if err := get(stranger.tlsCertificate()); err == nil {
+		t.Error("a client certificate from another CA was accepted")
+	}
+	if err := get(client.tlsCertificate()); err != nil {
+		t.Errorf("a client certificate from the CA was refused: %v", err)
+	}
+}
+
+func TestNewTLSConfigInvalidClientCA(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newTLSConfig(configs.TLSConfig{ClientCAFile: caFile}); err == nil {
+		t.Error("a client CA file without certificates was accepted")
+	}
+}
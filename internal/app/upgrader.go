@@ -0,0 +1,107 @@
+package app
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/cloudflare/tableflip"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+var (
+	// errUpgradesDisabled is returned when an upgrade is requested without tableflip
+	errUpgradesDisabled = errors.New("zero-downtime upgrades are disabled")
+	// errUpgradeInProgress is returned when an upgrade is requested while another one runs
+	errUpgradeInProgress = errors.New("an upgrade is already in progress")
+)
+
+// Upgrader provides the server's listener and coordinates zero-downtime upgrades.
+// *tableflip.Upgrader implements it; plainUpgrader is the fallback without upgrades.
+type Upgrader interface {
+	Listen(network, addr string) (net.Listener, error)
+	Ready() error
+	Upgrade() error
+	Exit() <-chan struct{}
+	Stop()
+}
+
+// newUpgrader returns a tableflip upgrader, or a plain listener when zero-downtime upgrades
+// are disabled in configuration or tableflip cannot run here (e.g. on non-Unix platforms)
+func newUpgrader(cfg configs.HTTPConfig, log *logger.Logger) Upgrader {
+	if !cfg.ZeroDowntime {
+		log.Info("Zero-downtime upgrades disabled by configuration")
+		return newPlainUpgrader()
+	}
+
+	upg, err := tableflip.New(tableflip.Options{})
+	if err != nil {
+		log.Warn("Zero-downtime upgrades unavailable, using a plain listener", "error", err)
+		return newPlainUpgrader()
+	}
+	return upg
+}
+
+// plainUpgrader listens with net.Listen and never upgrades
+type plainUpgrader struct {
+	exit chan struct{}
+}
+
+func newPlainUpgrader() *plainUpgrader {
+	return &plainUpgrader{exit: make(chan struct{})}
+}
+
+func (p *plainUpgrader) Listen(network, addr string) (net.Listener, error) {
+	return net.Listen(network, addr)
+}
+
+func (p *plainUpgrader) Ready() error { return nil }
+
+func (p *plainUpgrader) Upgrade() error { return errUpgradesDisabled }
+
+// Exit never fires: without upgrades no new process takes over
+func (p *plainUpgrader) Exit() <-chan struct{} { return p.exit }
+
+func (p *plainUpgrader) Stop() {}
+
+// upgrade runs a zero-downtime upgrade: tableflip launches a new process from the current
+// binary and returns once it is ready, after which this process shuts down. Readiness fails
+// from the start so the load balancer favors the new process; if the upgrade fails this
+// process keeps serving and reports ready again.
+func (a *App) upgrade() error {
+	if !a.upgrading.CompareAndSwap(false, true) {
+		return errUpgradeInProgress
+	}
+	defer a.upgrading.Store(false)
+
+	if err := a.upgrader.Upgrade(); err != nil {
+		return err
+	}
+
+	// The new process is serving; stay not-ready until the exit path shuts us down
+	a.startDraining()
+	return nil
+}
+
+// upgradeHandler starts a zero-downtime upgrade and answers once the new process is ready
+func (a *App) upgradeHandler(w http.ResponseWriter, r *http.Request) {
+	a.logger.Info("Upgrade requested", "remote_addr", r.RemoteAddr)
+	if err := a.upgrade(); err != nil {
+		if errors.Is(err, errUpgradeInProgress) {
+			respond.Error(w, http.StatusConflict, "upgrade_in_progress", err.Error())
+			return
+		}
+		if errors.Is(err, errUpgradesDisabled) {
+			respond.Error(w, http.StatusNotImplemented, "upgrades_disabled", err.Error())
+			return
+		}
+		a.logger.Error("Upgrade failed", "error", err)
+		respond.Error(w, http.StatusInternalServerError, "upgrade_failed", err.Error())
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"status": "upgraded"})
+}
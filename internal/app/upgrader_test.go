@@ -0,0 +1,290 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/tableflip"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// tableflip allows one upgrader per process, so both paths are exercised in a single test
+func TestNewUpgrader(t *testing.T) {
+	log, logs := logtest.New(t)
+
+	if _, ok := newUpgrader(configs.HTTPConfig{ZeroDowntime: false}, log).(*plainUpgrader); !ok {
+		t.Fatal("disabled zero-downtime upgrades did not use a plain listener")
+	}
+	if _, ok := logs.Find("Zero-downtime upgrades disabled by configuration"); !ok {
+		t.Error("disabling upgrades was not logged")
+	}
+
+	upg := newUpgrader(configs.HTTPConfig{ZeroDowntime: true}, log)
+	flip, ok := upg.(*tableflip.Upgrader)
+	if !ok {
+		t.Fatalf("got %T, want a tableflip upgrader", upg)
+	}
+	defer flip.Stop()
+
+	ln, err := upg.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if err := upg.Ready(); err != nil {
+		t.Fatalf("Ready() = %v without a parent process", err)
+	}
+
+	// A second tableflip upgrader cannot be created, which stands in for an unsupported platform
+	if _, ok := newUpgrader(configs.HTTPConfig{ZeroDowntime: true}, log).(*plainUpgrader); !ok {
+		t.Fatal("a failing tableflip did not fall back to a plain listener")
+	}
+	if entry, ok := logs.Find("Zero-downtime upgrades unavailable, using a plain listener"); !ok || entry["error"] == nil {
+		t.Errorf("fallback logged as %v, want a warning with the tableflip error", entry)
+	}
+}
+
+func TestPlainUpgrader(t *testing.T) {
+	p := newPlainUpgrader()
+
+	ln, err := p.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+
+	if err := p.Ready(); err != nil {
+		t.Errorf("Ready() = %v", err)
+	}
+	if err := p.Upgrade(); !errors.Is(err, errUpgradesDisabled) {
+		t.Errorf("Upgrade() = %v, want errUpgradesDisabled", err)
+	}
+	select {
+	case <-p.Exit():
+		t.Error("Exit fired without an upgrade")
+	default:
+	}
+}
+
+func TestUpgradeHandlerWithoutUpgrades(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	a.upgrader = newPlainUpgrader()
+
+	rec := httptest.NewRecorder()
+	a.upgradeHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/upgrade", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rec.Code)
+	}
+	if a.draining.Load() || a.upgrading.Load() {
+		t.Error("a refused upgrade left the app draining or upgrading")
+	}
+}
+
+func TestUpgradeHandlerRejectsConcurrentUpgrade(t *testing.T) {
+	a := newTestApp(t, testConfig(t, ""))
+	a.upgrader = newPlainUpgrader()
+	a.upgrading.Store(true)
+
+	rec := httptest.NewRecorder()
+	a.upgradeHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/upgrade", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want 409", rec.Code)
+	}
+	if !a.upgrading.Load() {
+		t.Error("a rejected upgrade cleared the running upgrade's flag")
+	}
+}
+
+// mockUpgrader is a plainUpgrader whose Upgrade returns err and counts its calls
+type mockUpgrader struct {
+	*plainUpgrader
+	err   error
+	calls int
+}
+
+func (u *mockUpgrader) Upgrade() error {
+	u.calls++
+	return u.err
+}
+
+func TestUpgradeEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		code     string
+		draining bool
+	}{
+		{"upgraded", nil, http.StatusOK, "", true},
+		{"failed", errors.New("new process exited before ready"), http.StatusInternalServerError, "upgrade_failed", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newRoutedApp(t, testConfig(t, ""))
+			log, logs := logtest.New(t)
+			a.logger = log
+			upgrader := &mockUpgrader{plainUpgrader: newPlainUpgrader(), err: tt.err}
+			a.upgrader = upgrader
+
+			rec := serveAs(a.router, httptest.NewRequest(http.MethodPost, "/admin/upgrade", nil), bearer(t, a, "admin"))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if upgrader.calls != 1 {
+				t.Errorf("Upgrade called %d times, want once", upgrader.calls)
+			}
+			if tt.err != nil {
+				if code := errorCode(t, rec); code != tt.code {
+					t.Errorf("code = %q, want %q", code, tt.code)
+				}
+				if !strings.Contains(rec.Body.String(), tt.err.Error()) {
+					t.Errorf("body = %s, want the upgrade error surfaced", rec.Body)
+				}
+				if _, ok := logs.Find("Upgrade failed"); !ok {
+					t.Error("the failed upgrade was not logged")
+				}
+			}
+			if a.draining.Load() != tt.draining || a.upgrading.Load() {
+				t.Errorf("draining = %v, upgrading = %v; want draining %v once finished", a.draining.Load(), a.upgrading.Load(), tt.draining)
+			}
+		})
+	}
+}
+
+func TestUpgradeEndpointRequiresAdmin(t *testing.T) {
+	a, _ := newRoutedApp(t, testConfig(t, ""))
+	upgrader := &mockUpgrader{plainUpgrader: newPlainUpgrader()}
+	a.upgrader = upgrader
+
+	for token, want := range map[string]int{"": http.StatusUnauthorized, bearer(t, a, "rep"): http.StatusForbidden} {
+		if rec := serveAs(a.router, httptest.NewRequest(http.MethodPost, "/admin/upgrade", nil), token); rec.Code != want {
+			t.Errorf("status = %d, want %d", rec.Code, want)
+		}
+	}
+	if upgrader.calls != 0 {
+		t.Errorf("Upgrade called %d times without an admin", upgrader.calls)
+	}
+}
+
+// blockingUpgrader is a plainUpgrader whose Upgrade signals started and returns err once released
+type blockingUpgrader struct {
+	*plainUpgrader
+	err              error
+	started, release chan struct{}
+}
+
+func (u *blockingUpgrader) Upgrade() error {
+	close(u.started)
+	<-u.release
+	return u.err
+}
+
+func TestReadinessFailsDuringUpgrade(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantAfter int
+	}{
+		// The old process stays not-ready until the exit path shuts it down
+		{"upgraded", nil, http.StatusServiceUnavailable},
+		// A failed upgrade leaves this process serving, so it reports ready again
+		{"failed", errors.New("new process exited before ready"), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newRoutedApp(t, testConfig(t, ""))
+			upgrader := &blockingUpgrader{plainUpgrader: newPlainUpgrader(), err: tt.err, started: make(chan struct{}), release: make(chan struct{})}
+			a.upgrader = upgrader
+			readiness := http.HandlerFunc(a.readinessHandler)
+
+			if rec := get(readiness, "/readiness"); rec.Code != http.StatusOK {
+				t.Fatalf("readiness before the upgrade = %d, want 200", rec.Code)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- a.upgrade() }()
+			<-upgrader.started
+
+			rec := get(readiness, "/readiness")
+			if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"upgrading":true`) {
+				t.Errorf("readiness during the upgrade = %d %s, want 503 upgrading", rec.Code, rec.Body)
+			}
+			if rec := get(http.HandlerFunc(a.livenessHandler), "/liveness"); rec.Code != http.StatusOK {
+				t.Errorf("liveness during the upgrade = %d, want 200", rec.Code)
+			}
+
+			close(upgrader.release)
+			if err := <-done; !errors.Is(err, tt.err) {
+				t.Fatalf("upgrade() = %v, want %v", err, tt.err)
+			}
+			if rec := get(readiness, "/readiness"); rec.Code != tt.wantAfter {
+				t.Errorf("readiness after the upgrade = %d, want %d", rec.Code, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestUpgradeExitUsesUpgradeTimeout(t *testing.T) {
+	cfg := runConfig(t, "app:\n  shutdown:\n    timeout: 50ms\n    upgrade_timeout: 5s\n")
+	a, _ := newRoutedApp(t, cfg)
+	log, logs := logtest.New(t)
+	a.logger = log
+
+	started := make(chan struct{})
+	a.router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+	})
+	ln, done := runApp(t, a)
+
+	// A request outlasting the signal shutdown timeout is in flight when the new process takes over
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+	close(a.upgrader.(*testUpgrader).exit)
+
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("Run = %v after an upgrade exit", err)
+	}
+	if code := <-status; code != http.StatusOK {
+		t.Errorf("in-flight request = %d, want 200 within the upgrade timeout", code)
+	}
+	entry, ok := logs.Find("Shutting down application...")
+	if !ok {
+		t.Fatal("the shutdown was not logged")
+	}
+	if entry["timeout"] != float64(5*time.Second) {
+		t.Errorf("shutdown timeout = %v, want the 5s upgrade timeout", entry["timeout"])
+	}
+}
+
+func TestShutdownUsesSignalTimeout(t *testing.T) {
+	a, _ := newRoutedApp(t, runConfig(t, "app:\n  shutdown:\n    timeout: 50ms\n    upgrade_timeout: 5s\n"))
+	log, logs := logtest.New(t)
+	a.logger = log
+	if err := a.setupServer(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if entry, _ := logs.Find("Shutting down application..."); entry["timeout"] != float64(50*time.Millisecond) {
+		t.Errorf("shutdown timeout = %v, want the 50ms signal timeout", entry["timeout"])
+	}
+}
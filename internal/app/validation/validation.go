@@ -0,0 +1,215 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// Error codes written by Decode
+const (
+	CodeEmptyBody        = "empty_body"
+	CodeMalformedJSON    = "malformed_json"
+	CodeUnknownField     = "unknown_field"
+	CodeBodyTooLarge     = "body_too_large"
+	CodeValidationFailed = "validation_failed"
+)
+
+// validate is shared because validator caches struct metadata per type
+var validate = newValidator()
+
+// Error is a rejected request body, carrying the response it should produce
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []respond.FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Bind decodes the JSON request body into dst, a pointer to a struct, and validates it using
+// its `validate` struct tags. Unknown fields and trailing data after the JSON value are rejected.
+// Failures are returned as *Error.
+func Bind(r *http.Request, dst interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return &Error{
+			Status:  http.StatusBadRequest,
+			Code:    CodeMalformedJSON,
+			Message: "request body must contain a single JSON value",
+		}
+	}
+
+	return Struct(dst)
+}
+
+// Struct validates an already decoded value using its `validate` struct tags
+func Struct(v interface{}) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return fmt.Errorf("failed to validate request: %w", err)
+	}
+
+	fields := make([]respond.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, respond.FieldError{Field: fieldPath(fe), Message: message(fe)})
+	}
+	return &Error{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    CodeValidationFailed,
+		Message: "request validation failed",
+		Fields:  fields,
+	}
+}
+
+// Decode binds the request body into dst and writes the error response on failure.
+// It reports whether the handler should continue.
+func Decode(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	err := Bind(r, dst)
+	if err == nil {
+		return true
+	}
+
+	var verr *Error
+	if !errors.As(err, &verr) {
+		respond.Error(w, http.StatusInternalServerError, "internal_error", http.StatusText(http.StatusInternalServerError))
+		return false
+	}
+
+	respond.JSON(w, verr.Status, respond.ErrorBody{Error: respond.ErrorDetail{
+		Code:    verr.Code,
+		Message: verr.Message,
+		Fields:  verr.Fields,
+	}})
+	return false
+}
+
+// decodeError maps a json.Decoder error to a client error
+func decodeError(err error) *Error {
+	var (
+		syntaxErr  *json.SyntaxError
+		typeErr    *json.UnmarshalTypeError
+		maxBodyErr *http.MaxBytesError
+	)
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return &Error{Status: http.StatusBadRequest, Code: CodeEmptyBody, Message: "request body must not be empty"}
+	case errors.As(err, &maxBodyErr):
+		return &Error{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    CodeBodyTooLarge,
+			Message: fmt.Sprintf("request body must not exceed %d bytes", maxBodyErr.Limit),
+		}
+	case errors.As(err, &syntaxErr):
+		return &Error{
+			Status:  http.StatusBadRequest,
+			Code:    CodeMalformedJSON,
+			Message: fmt.Sprintf("request body contains malformed JSON at position %d", syntaxErr.Offset),
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &Error{Status: http.StatusBadRequest, Code: CodeMalformedJSON, Message: "request body contains malformed JSON"}
+	case errors.As(err, &typeErr):
+		return &Error{
+			Status:  http.StatusBadRequest,
+			Code:    CodeMalformedJSON,
+			Message: "request body has the wrong type for a field",
+			Fields:  []respond.FieldError{{Field: typeErr.Field, Message: "must be " + typeErr.Type.String()}},
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for DisallowUnknownFields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &Error{
+			Status:  http.StatusBadRequest,
+			Code:    CodeUnknownField,
+			Message: "request body contains an unknown field",
+			Fields:  []respond.FieldError{{Field: field, Message: "is not allowed"}},
+		}
+	default:
+		return &Error{Status: http.StatusBadRequest, Code: CodeMalformedJSON, Message: "request body could not be decoded"}
+	}
+}
+
+// newValidator creates a validator that reports fields by their JSON names
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return f.Name
+		}
+		return name
+	})
+	return v
+}
+
+// fieldPath returns the JSON path of the field without the top-level struct name
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if _, rest, ok := strings.Cut(ns, "."); ok {
+		return rest
+	}
+	return ns
+}
+
+// message returns a human-readable description of a failed validation tag
+func message(fe validator.FieldError) string {
+	unit := ""
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s%s", fe.Param(), unit)
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s%s", fe.Param(), unit)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s%s", fe.Param(), unit)
+	case "lt":
+		return fmt.Sprintf("must be less than %s%s", fe.Param(), unit)
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s", fe.Param(), unit)
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "datetime":
+		return "must be a date/time in the format " + fe.Param()
+	default:
+		return fmt.Sprintf("failed %s validation", fe.Tag())
+	}
+}
@@ -0,0 +1,131 @@
+package validation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+)
+
+// visitRequest is a representative API request body
+type visitRequest struct {
+	DoctorID string   `json:"doctor_id" validate:"required"`
+	Notes    string   `json:"notes" validate:"max=10"`
+	Samples  int      `json:"samples" validate:"gte=0"`
+	Outcome  string   `json:"outcome" validate:"omitempty,oneof=positive neutral negative"`
+	Products []string `json:"products" validate:"omitempty,min=1,dive,required"`
+	Location *struct {
+		City string `json:"city" validate:"required"`
+	} `json:"location"`
+}
+
+// decode runs Decode on body and returns whether the handler may continue and the response
+func decode(body string) (bool, *httptest.ResponseRecorder, visitRequest) {
+	var dst visitRequest
+	rec := httptest.NewRecorder()
+	ok := Decode(rec, httptest.NewRequest(http.MethodPost, "/visits", strings.NewReader(body)), &dst)
+	return ok, rec, dst
+}
+
+// errorDetail decodes the error envelope of rec
+func errorDetail(t *testing.T, rec *httptest.ResponseRecorder) respond.ErrorDetail {
+	t.Helper()
+	var body respond.ErrorBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid error body %q: %v", rec.Body, err)
+	}
+	return body.Error
+}
+
+func TestDecodeValidBody(t *testing.T) {
+	ok, rec, dst := decode(`{"doctor_id":"d-1","samples":2,"outcome":"positive","location":{"city":"Cairo"}}`)
+	if !ok {
+		t.Fatalf("Decode rejected a valid body: %d %s", rec.Code, rec.Body)
+	}
+	if dst.DoctorID != "d-1" || dst.Samples != 2 || dst.Location.City != "Cairo" {
+		t.Errorf("decoded %+v", dst)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("Decode wrote %q for a valid body", rec.Body)
+	}
+}
+
+func TestDecodeReportsFieldErrors(t *testing.T) {
+	ok, rec, _ := decode(`{"notes":"far too long for the limit","samples":-1,"outcome":"great","products":[""],"location":{}}`)
+	if ok {
+		t.Fatal("Decode accepted an invalid body")
+	}
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", rec.Code)
+	}
+
+	detail := errorDetail(t, rec)
+	if detail.Code != CodeValidationFailed {
+		t.Errorf("code = %q, want %q", detail.Code, CodeValidationFailed)
+	}
+	want := []respond.FieldError{
+		{Field: "doctor_id", Message: "is required"},
+		{Field: "notes", Message: "must be at most 10 characters"},
+		{Field: "samples", Message: "must be at least 0"},
+		{Field: "outcome", Message: "must be one of: positive, neutral, negative"},
+		{Field: "products[0]", Message: "is required"},
+		{Field: "location.city", Message: "is required"},
+	}
+	if !reflect.DeepEqual(detail.Fields, want) {
+		t.Errorf("fields = %+v, want %+v", detail.Fields, want)
+	}
+}
+
+func TestDecodeRejectsBadJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+		field  string
+	}{
+		{"empty", ``, http.StatusBadRequest, CodeEmptyBody, ""},
+		{"syntax error", `{"doctor_id":}`, http.StatusBadRequest, CodeMalformedJSON, ""},
+		{"truncated", `{"doctor_id":"d-1"`, http.StatusBadRequest, CodeMalformedJSON, ""},
+		{"trailing garbage", `{"doctor_id":"d-1"} garbage`, http.StatusBadRequest, CodeMalformedJSON, ""},
+		{"second value", `{"doctor_id":"d-1"}{}`, http.StatusBadRequest, CodeMalformedJSON, ""},
+		{"wrong type", `{"doctor_id":"d-1","samples":"two"}`, http.StatusBadRequest, CodeMalformedJSON, "samples"},
+		{"unknown field", `{"doctor_id":"d-1","doctor":"x"}`, http.StatusBadRequest, CodeUnknownField, "doctor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rec, _ := decode(tt.body)
+			if ok {
+				t.Fatal("Decode accepted the body")
+			}
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			detail := errorDetail(t, rec)
+			if detail.Code != tt.code {
+				t.Errorf("code = %q, want %q", detail.Code, tt.code)
+			}
+			if tt.field != "" && (len(detail.Fields) != 1 || detail.Fields[0].Field != tt.field) {
+				t.Errorf("fields = %+v, want %s", detail.Fields, tt.field)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsOversizedBody(t *testing.T) {
+	var dst visitRequest
+	req := httptest.NewRequest(http.MethodPost, "/visits", strings.NewReader(`{"doctor_id":"`+strings.Repeat("d", 100)+`"}`))
+	rec := httptest.NewRecorder()
+	req.Body = http.MaxBytesReader(rec, req.Body, 16)
+
+	if Decode(rec, req, &dst) {
+		t.Fatal("Decode accepted an oversized body")
+	}
+	if rec.Code != http.StatusRequestEntityTooLarge || errorDetail(t, rec).Code != CodeBodyTooLarge {
+		t.Errorf("got %d %s, want 413 body_too_large", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/apiversion"
+)
+
+// mountVersion mounts an API version's routes under /<version>, storing the version in the
+// request context and attaching deprecation headers when it is marked deprecated in config
+func (a *App) mountVersion(r chi.Router, version string, routes func(r chi.Router)) {
+	r.Route("/"+version, func(r chi.Router) {
+		r.Use(apiversion.Middleware(version), a.versionHeaders(version))
+		routes(r)
+	})
+}
+
+// versionHeaders returns middleware setting the Deprecation (RFC 9745) and Sunset (RFC 8594)
+// headers for a deprecated version, or a passthrough for an active one
+func (a *App) versionHeaders(version string) func(http.Handler) http.Handler {
+	var deprecation, sunset string
+	for _, v := range a.config.Versions {
+		if v.Name != version || !v.Deprecated() {
+			continue
+		}
+		// Dates are checked in configs.validate
+		if t, err := v.DeprecationTime(); err == nil {
+			deprecation = fmt.Sprintf("@%d", t.Unix())
+		}
+		if t, err := v.SunsetTime(); err == nil {
+			sunset = t.UTC().Format(http.TimeFormat)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		if deprecation == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			if sunset != "" {
+				w.Header().Set("Sunset", sunset)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/apiversion"
+)
+
+func TestVersionHeaders(t *testing.T) {
+	a := newTestApp(t, testConfig(t, `versions:
+  - name: v1
+    deprecation: "2026-01-01"
+    sunset: "2026-07-01"
+  - name: v2
+`))
+
+	var version string
+	r := chi.NewRouter()
+	for _, v := range []string{"v1", "v2"} {
+		a.mountVersion(r, v, func(r chi.Router) {
+			r.Get("/reps", func(w http.ResponseWriter, r *http.Request) {
+				version = apiversion.FromContext(r.Context())
+			})
+		})
+	}
+
+	rec := get(r, "/v1/reps")
+	if version != "v1" {
+		t.Errorf("version in context = %q, want v1", version)
+	}
+	if got := rec.Header().Get("Deprecation"); got != "@1767225600" {
+		t.Errorf("Deprecation = %q, want @1767225600", got)
+	}
+	if got := rec.Header().Get("Sunset"); got != "Wed, 01 Jul 2026 00:00:00 GMT" {
+		t.Errorf("Sunset = %q, want the sunset date", got)
+	}
+
+	rec = get(r, "/v2/reps")
+	if version != "v2" {
+		t.Errorf("version in context = %q, want v2", version)
+	}
+	if rec.Header().Get("Deprecation") != "" || rec.Header().Get("Sunset") != "" {
+		t.Errorf("active version has deprecation headers: %v", rec.Header())
+	}
+}
@@ -0,0 +1,47 @@
+package apiversion
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey struct{}
+
+// slotKey holds the *string that Middleware fills in for Track
+type slotKey struct{}
+
+// NewContext returns a copy of ctx carrying the API version
+func NewContext(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, contextKey{}, version)
+}
+
+// FromContext returns the API version of the matched route group, or "" outside versioned routes
+func FromContext(ctx context.Context) string {
+	version, _ := ctx.Value(contextKey{}).(string)
+	return version
+}
+
+// Middleware stores version in the request context for the routes of a version group
+func Middleware(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slot, ok := r.Context().Value(slotKey{}).(*string); ok {
+				*slot = version
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), version)))
+		})
+	}
+}
+
+// Track is for middleware that runs before routing, such as access logs and metrics, which
+// cannot see the context of the version group. It returns r prepared to record the version
+// and a function returning it once the request has been served ("" when no version matched).
+// Nested calls share one record.
+func Track(r *http.Request) (*http.Request, func() string) {
+	slot, ok := r.Context().Value(slotKey{}).(*string)
+	if !ok {
+		slot = new(string)
+		r = r.WithContext(context.WithValue(r.Context(), slotKey{}, slot))
+	}
+	return r, func() string { return *slot }
+}
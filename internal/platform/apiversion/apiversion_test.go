@@ -0,0 +1,54 @@
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMiddlewareAndTrack(t *testing.T) {
+	var inContext, tracked, nested string
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, version := Track(r)
+			r, inner := Track(r)
+			next.ServeHTTP(w, r)
+			tracked, nested = version(), inner()
+		})
+	})
+	for _, v := range []string{"v1", "v2"} {
+		r.Route("/"+v, func(r chi.Router) {
+			r.Use(Middleware(v))
+			r.Get("/reps", func(w http.ResponseWriter, r *http.Request) {
+				inContext = FromContext(r.Context())
+			})
+		})
+	}
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		inContext = FromContext(r.Context())
+	})
+
+	for path, want := range map[string]string{"/v1/reps": "v1", "/v2/reps": "v2", "/health": "", "/v3/reps": ""} {
+		inContext, tracked, nested = "unset", "unset", "unset"
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if path != "/v3/reps" && inContext != want {
+			t.Errorf("%s: version in context = %q, want %q", path, inContext, want)
+		}
+		if tracked != want || nested != want {
+			t.Errorf("%s: tracked %q and %q, want %q", path, tracked, nested, want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if v := FromContext(context.Background()); v != "" {
+		t.Errorf("FromContext(empty) = %q, want empty", v)
+	}
+	if v := FromContext(NewContext(context.Background(), "v2")); v != "v2" {
+		t.Errorf("FromContext = %q, want v2", v)
+	}
+}
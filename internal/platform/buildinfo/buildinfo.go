@@ -0,0 +1,32 @@
+package buildinfo
+
+import "runtime"
+
+// Build metadata, injected at build time:
+//
+//	go build -ldflags "-X github.com/rixtrayker/medical-rep/internal/platform/buildinfo.GitCommit=$(git rev-parse HEAD) \
+//	  -X github.com/rixtrayker/medical-rep/internal/platform/buildinfo.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	GitCommit = "unknown"
+	BuildDate = "unknown"
+)
+
+// Info describes the running build
+type Info struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build metadata for the named application at the given version
+func Get(name, version string) Info {
+	return Info{
+		Name:      name,
+		Version:   version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
@@ -0,0 +1,22 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	commit, date := GitCommit, BuildDate
+	t.Cleanup(func() { GitCommit, BuildDate = commit, date })
+
+	want := Info{Name: "crm", Version: "2.1.0", GitCommit: "unknown", BuildDate: "unknown", GoVersion: runtime.Version()}
+	if got := Get("crm", "2.1.0"); got != want {
+		t.Errorf("Get() = %+v, want %+v without injected metadata", got, want)
+	}
+
+	GitCommit, BuildDate = "abc1234", "2026-01-02T03:04:05Z"
+	got := Get("crm", "2.1.0")
+	if got.GitCommit != "abc1234" || got.BuildDate != "2026-01-02T03:04:05Z" {
+		t.Errorf("Get() = %+v, want the injected commit and date", got)
+	}
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+	"github.com/rixtrayker/medical-rep/internal/platform/tenant"
+)
+
+const keyPrefix = "cache:"
+
+// Cache is a JSON read-through cache in Redis. Concurrent misses for the same key
+// share a single loader call so an expired entry does not stampede the database
+type Cache struct {
+	client *redis.Client
+	ttl    time.Duration
+	log    *logger.Logger
+	flight group
+}
+
+// New creates a cache storing entries for cfg.TTL
+func New(cfg configs.CacheConfig, client *redis.Client, log *logger.Logger) *Cache {
+	return &Cache{
+		client: client,
+		ttl:    cfg.TTL,
+		log:    log,
+	}
+}
+
+var defaultCache atomic.Pointer[Cache]
+
+// SetDefault sets the cache used by the package-level GetOrLoad and Delete
+func SetDefault(c *Cache) {
+	defaultCache.Store(c)
+}
+
+// GetOrLoad is Cache.GetOrLoad on the default cache. Before SetDefault is called, or when
+// Redis is not configured, it calls loader directly.
+func GetOrLoad(ctx context.Context, key string, loader func() (any, error)) (any, error) {
+	c := defaultCache.Load()
+	if c == nil {
+		return loader()
+	}
+	return c.GetOrLoad(ctx, key, loader)
+}
+
+// Delete is Cache.Delete on the default cache; it does nothing before SetDefault is called
+func Delete(ctx context.Context, keys ...string) error {
+	c := defaultCache.Load()
+	if c == nil {
+		return nil
+	}
+	return c.Delete(ctx, keys...)
+}
+
+// GetOrLoad returns the value cached at key (scoped to the tenant in ctx, if any), or runs loader, stores its result and
+// returns it. Cached values come back as decoded JSON (maps, slices, float64...);
+// use Load to decode into a concrete type. Redis errors fail open to the loader
+func (c *Cache) GetOrLoad(ctx context.Context, key string, loader func() (any, error)) (any, error) {
+	return c.load(ctx, key, reflect.TypeFor[any](), loader, func(data []byte) (any, error) {
+		var v any
+		err := json.Unmarshal(data, &v)
+		return v, err
+	})
+}
+
+// Load is the typed form of GetOrLoad
+func Load[T any](ctx context.Context, c *Cache, key string, loader func() (T, error)) (T, error) {
+	var zero T
+	v, err := c.load(ctx, key, reflect.TypeFor[T](), func() (any, error) {
+		return loader()
+	}, func(data []byte) (any, error) {
+		var v T
+		err := json.Unmarshal(data, &v)
+		return v, err
+	})
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := v.(T)
+	if !ok {
+		return zero, fmt.Errorf("cache entry %s is %T, not %T", key, v, zero)
+	}
+	return typed, nil
+}
+
+// Delete removes the cached values for keys
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	storageKeys := make([]string, len(keys))
+	for i, key := range keys {
+		storageKeys[i] = keyPrefix + tenant.Key(ctx, key)
+	}
+	_, err := c.client.Del(ctx, storageKeys...)
+	return err
+}
+
+// load reads or loads the value at key, decoding cached entries with decode. Concurrent loads
+// share a flight only when they decode to the same type, so a typed Load never receives the
+// untyped result of a GetOrLoad for the same key, or the other way round.
+func (c *Cache) load(ctx context.Context, key string, typ reflect.Type, loader func() (any, error), decode func([]byte) (any, error)) (any, error) {
+	// The load is shared, so one caller giving up must not fail the others
+	ctx = context.WithoutCancel(ctx)
+	storageKey := keyPrefix + tenant.Key(ctx, key)
+
+	return c.flight.do(flightKey{storageKey, typ}, func() (any, error) {
+		data, err := c.client.Get(ctx, storageKey)
+		switch {
+		case err == nil:
+			if v, err := decode([]byte(data)); err == nil {
+				return v, nil
+			}
+			c.log.Warn("Discarding undecodable cache entry", "key", key)
+		case !errors.Is(err, redis.ErrNotFound):
+			c.log.Warn("Cache read failed, loading from source", "key", key, "error", err)
+		}
+
+		v, err := loader()
+		if err != nil {
+			return nil, err
+		}
+
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			c.log.Warn("Failed to encode cache entry", "key", key, "error", err)
+			return v, nil
+		}
+		if err := c.client.Set(ctx, storageKey, encoded, c.ttl); err != nil {
+			c.log.Warn("Cache write failed", "key", key, "error", err)
+		}
+		return v, nil
+	})
+}
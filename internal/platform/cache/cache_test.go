@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+	"github.com/rixtrayker/medical-rep/internal/platform/tenant"
+)
+
+// newTestCache returns a cache on miniredis with a one minute TTL
+func newTestCache(t *testing.T) (*Cache, *miniredis.Miniredis) {
+	t.Helper()
+	client, server := redistest.New(t)
+	return New(configs.CacheConfig{TTL: time.Minute}, client, logtest.Discard(t)), server
+}
+
+type rep struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGetOrLoadCoalescesConcurrentMisses(t *testing.T) {
+	c, server := newTestCache(t)
+	release := make(chan struct{})
+	var loads atomic.Int32
+	loader := func() (any, error) {
+		loads.Add(1)
+		<-release
+		return []string{"alice", "bob"}, nil
+	}
+
+	// Callers arriving after the load finished read the stored entry instead
+	var wg sync.WaitGroup
+	results := make(chan any, 100)
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), "reps", loader)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- v
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loader ran %d times, want once", n)
+	}
+	for v := range results {
+		if names, ok := v.([]string); ok && len(names) == 2 {
+			continue
+		}
+		if names, ok := v.([]any); !ok || len(names) != 2 || names[0] != "alice" {
+			t.Errorf("result = %#v, want the loaded names", v)
+		}
+	}
+	if got, err := server.Get("cache:reps"); err != nil || got != `["alice","bob"]` {
+		t.Errorf("stored entry = %q, %v", got, err)
+	}
+	if ttl := server.TTL("cache:reps"); ttl != time.Minute {
+		t.Errorf("TTL = %s, want 1m", ttl)
+	}
+}
+
+func TestLoadDoesNotJoinUntypedLoad(t *testing.T) {
+	c, _ := newTestCache(t)
+	ctx := context.Background()
+
+	// An untyped load of the key is in flight
+	started, release := make(chan struct{}), make(chan struct{})
+	untyped := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(ctx, "rep:1", func() (any, error) {
+			close(started)
+			<-release
+			return map[string]any{"id": 1, "name": "alice"}, nil
+		})
+		untyped <- err
+	}()
+	<-started
+	defer func() {
+		close(release)
+		if err := <-untyped; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// A typed load of the same key runs its own loader instead of receiving the untyped result
+	typed := make(chan rep, 1)
+	go func() {
+		v, err := Load(ctx, c, "rep:1", func() (rep, error) { return rep{ID: 1, Name: "alice"}, nil })
+		if err != nil {
+			t.Error(err)
+		}
+		typed <- v
+	}()
+	select {
+	case v := <-typed:
+		if v != (rep{ID: 1, Name: "alice"}) {
+			t.Errorf("Load() = %+v, want the typed rep", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Load joined the untyped load of the same key")
+	}
+}
+
+func TestGetOrLoadReadsThroughRedis(t *testing.T) {
+	c, server := newTestCache(t)
+	ctx := context.Background()
+
+	first, err := Load(ctx, c, "rep:1", func() (rep, error) { return rep{ID: 1, Name: "Alice"}, nil })
+	if err != nil || first.Name != "Alice" {
+		t.Fatalf("Load() = %+v, %v", first, err)
+	}
+	cached, err := Load(ctx, c, "rep:1", func() (rep, error) {
+		t.Error("loader ran for a cached entry")
+		return rep{}, nil
+	})
+	if err != nil || cached != first {
+		t.Errorf("cached = %+v, %v, want %+v", cached, err, first)
+	}
+
+	// Expired and deleted entries are loaded again
+	server.FastForward(time.Minute)
+	if err := c.Delete(ctx, "rep:1"); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, _ := Load(ctx, c, "rep:1", func() (rep, error) { return rep{ID: 1, Name: "Alicia"}, nil })
+	if reloaded.Name != "Alicia" {
+		t.Errorf("reloaded = %+v, want a fresh load", reloaded)
+	}
+}
+
+func TestGetOrLoadDoesNotCacheErrors(t *testing.T) {
+	c, server := newTestCache(t)
+	errDB := errors.New("database unavailable")
+
+	if _, err := c.GetOrLoad(context.Background(), "reps", func() (any, error) { return nil, errDB }); err != errDB {
+		t.Fatalf("err = %v, want the loader error", err)
+	}
+	if server.Exists("cache:reps") {
+		t.Error("a failed load was cached")
+	}
+}
+
+func TestGetOrLoadFailsOpenWithoutRedis(t *testing.T) {
+	c, server := newTestCache(t)
+	server.Close()
+
+	v, err := c.GetOrLoad(context.Background(), "reps", func() (any, error) { return "loaded", nil })
+	if err != nil || v != "loaded" {
+		t.Errorf("GetOrLoad() = %v, %v, want the loader result", v, err)
+	}
+}
+
+func TestDefaultCache(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+	SetDefault(nil)
+
+	loads := 0
+	loader := func() (any, error) { loads++; return "loaded", nil }
+	GetOrLoad(context.Background(), "reps", loader)
+	GetOrLoad(context.Background(), "reps", loader)
+	if loads != 2 {
+		t.Errorf("loader ran %d times without a default cache, want every call", loads)
+	}
+
+	c, _ := newTestCache(t)
+	SetDefault(c)
+	GetOrLoad(context.Background(), "reps", loader)
+	GetOrLoad(context.Background(), "reps", loader)
+	if loads != 3 {
+		t.Errorf("loader ran %d times, want the default cache to serve the second call", loads)
+	}
+}
+
+func TestGetOrLoadScopesKeysToTenant(t *testing.T) {
+	c, server := newTestCache(t)
+	pfizer := tenant.NewContext(context.Background(), "pfizer")
+	novartis := tenant.NewContext(context.Background(), "novartis")
+
+	c.GetOrLoad(pfizer, "reps", func() (any, error) { return "pfizer reps", nil })
+	v, _ := c.GetOrLoad(novartis, "reps", func() (any, error) { return "novartis reps", nil })
+	if v != "novartis reps" {
+		t.Errorf("novartis read %v, want its own entry", v)
+	}
+	if !server.Exists("cache:tenant:pfizer:reps") || !server.Exists("cache:tenant:novartis:reps") {
+		t.Errorf("keys = %v, want one entry per tenant", server.Keys())
+	}
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// errLoadPanicked is handed to waiters when the load they joined panicked
+var errLoadPanicked = errors.New("cache: loader panicked")
+
+// call is an in-flight or completed load for a key
+type call struct {
+	wg  sync.WaitGroup
+	val any
+	err error
+}
+
+// flightKey identifies a load: the storage key and the type its result is decoded to
+type flightKey struct {
+	key string
+	typ reflect.Type
+}
+
+// group coalesces concurrent loads of the same key into one execution
+type group struct {
+	mu    sync.Mutex
+	calls map[flightKey]*call
+}
+
+// do runs fn once for all callers that ask for key while it is running and hands each
+// of them the same result
+func (g *group) do(key flightKey, fn func() (any, error)) (any, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[flightKey]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err
+	}
+	c := &call{err: errLoadPanicked}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxBindParams is the bind parameter limit per statement shared by PostgreSQL and MySQL
+const maxBindParams = 65535
+
+// BulkInsert inserts rows into table using multi-row INSERT statements, batched to stay under
+// the driver's bind parameter limit. All batches run in one transaction, so either every row is
+// inserted or none is. It returns the number of rows inserted.
+func (db *DB) BulkInsert(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("bulk insert into %s: no columns", table)
+	}
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return 0, fmt.Errorf("bulk insert into %s: row %d has %d values, want %d", table, i, len(row), len(columns))
+		}
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	ctx, span := db.startSpan(ctx, "bulk_insert", "INSERT INTO "+table)
+	defer span.End()
+
+	n, err := db.bulkInsert(ctx, table, columns, rows, maxBindParams/len(columns))
+	return n, db.end(ctx, span, "bulk_insert", err)
+}
+
+// bulkInsert runs the batches of at most batchSize rows in a transaction
+func (db *DB) bulkInsert(ctx context.Context, table string, columns []string, rows [][]any, batchSize int) (int64, error) {
+	if err := db.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.pool.Load().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin bulk insert: %w", err)
+	}
+	defer tx.Rollback()
+
+	prefix := db.insertPrefix(table, columns)
+	var total int64
+	for start := 0; start < len(rows); start += batchSize {
+		batch := rows[start:min(start+batchSize, len(rows))]
+
+		query, args := db.buildInsert(prefix, len(columns), batch)
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert rows %d-%d into %s: %w", start, start+len(batch)-1, table, err)
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to count inserted rows: %w", err)
+		}
+		total += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit bulk insert: %w", err)
+	}
+	return total, nil
+}
+
+// insertPrefix returns "INSERT INTO <table> (<columns>) VALUES " with quoted identifiers
+func (db *DB) insertPrefix(table string, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = db.quoteIdent(c)
+	}
+	return "INSERT INTO " + db.quoteIdent(table) + " (" + strings.Join(quoted, ", ") + ") VALUES "
+}
+
+// buildInsert appends one placeholder tuple per row to prefix and flattens the arguments
+func (db *DB) buildInsert(prefix string, width int, rows [][]any) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	args := make([]any, 0, len(rows)*width)
+
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for j := range row {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(db.placeholder(len(args) + 1))
+			args = append(args, row[j])
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String(), args
+}
+
+// placeholder returns the driver's bind parameter syntax for the n-th argument
+func (db *DB) placeholder(n int) string {
+	if db.driver == "postgres" {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// quoteIdent quotes a possibly schema-qualified identifier for the driver
+func (db *DB) quoteIdent(name string) string {
+	quote := `"`
+	if db.driver == "mysql" {
+		quote = "`"
+	}
+
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = quote + strings.ReplaceAll(p, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
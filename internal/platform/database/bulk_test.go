@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// newSQLite opens a file-backed SQLite database with a visits table, closed when the test ends
+func newSQLite(t *testing.T) *DB {
+	t.Helper()
+	db, err := New(context.Background(), configs.DatabaseConfig{
+		Driver:       "sqlite3",
+		Database:     filepath.Join(t.TempDir(), "crm.db"),
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}, logtest.Discard(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(context.Background(),
+		"CREATE TABLE visits (id INTEGER PRIMARY KEY, rep TEXT NOT NULL, doctor TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// visitRows returns n rows of (id, rep, doctor)
+func visitRows(n int) [][]any {
+	rows := make([][]any, n)
+	for i := range rows {
+		rows[i] = []any{i + 1, "rep", nil}
+	}
+	return rows
+}
+
+// countVisits returns the number of rows in the visits table
+func countVisits(t *testing.T, db *DB) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(context.Background(), "SELECT COUNT(*) FROM visits").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestBulkInsertLargeBatch(t *testing.T) {
+	db := newSQLite(t)
+
+	// 3 columns keep 10,000 rows in one statement under SQLite's own 32,766 parameter limit
+	n, err := db.BulkInsert(context.Background(), "visits", []string{"id", "rep", "doctor"}, visitRows(10000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10000 || countVisits(t, db) != 10000 {
+		t.Errorf("inserted %d rows, table holds %d, want 10000", n, countVisits(t, db))
+	}
+
+	var rep string
+	var doctor *string
+	if err := db.QueryRow(context.Background(), "SELECT rep, doctor FROM visits WHERE id = 10000").Scan(&rep, &doctor); err != nil {
+		t.Fatal(err)
+	}
+	if rep != "rep" || doctor != nil {
+		t.Errorf("last row = %q, %v", rep, doctor)
+	}
+}
+
+func TestBulkInsertSplitsBatches(t *testing.T) {
+	db := newSQLite(t)
+
+	n, err := db.bulkInsert(context.Background(), "visits", []string{"id", "rep", "doctor"}, visitRows(10), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 || countVisits(t, db) != 10 {
+		t.Errorf("inserted %d rows, want 10 across three batches", n)
+	}
+}
+
+func TestBulkInsertRollsBackEveryBatch(t *testing.T) {
+	db := newSQLite(t)
+
+	rows := visitRows(10)
+	rows[9][1] = nil // violates NOT NULL in the third batch
+	_, err := db.bulkInsert(context.Background(), "visits", []string{"id", "rep", "doctor"}, rows, 4)
+	if err == nil || !strings.Contains(err.Error(), "failed to insert rows 8-9 into visits") {
+		t.Fatalf("err = %v, want the third batch to fail", err)
+	}
+	if n := countVisits(t, db); n != 0 {
+		t.Errorf("table holds %d rows after a failed insert, want 0", n)
+	}
+}
+
+func TestBulkInsertRejectsBadInput(t *testing.T) {
+	db := newSQLite(t)
+	ctx := context.Background()
+
+	if _, err := db.BulkInsert(ctx, "visits", nil, visitRows(1)); err == nil {
+		t.Error("no columns accepted")
+	}
+	if _, err := db.BulkInsert(ctx, "visits", []string{"id", "rep"}, visitRows(1)); err == nil ||
+		err.Error() != "bulk insert into visits: row 0 has 3 values, want 2" {
+		t.Errorf("err = %v, want a row width error", err)
+	}
+	if n, err := db.BulkInsert(ctx, "visits", []string{"id"}, nil); n != 0 || err != nil {
+		t.Errorf("empty insert = %d, %v", n, err)
+	}
+}
+
+func TestBuildInsert(t *testing.T) {
+	tests := []struct {
+		driver string
+		want   string
+	}{
+		{"postgres", `INSERT INTO "crm"."visits" ("id", "rep") VALUES ($1, $2), ($3, $4)`},
+		{"mysql", "INSERT INTO `crm`.`visits` (`id`, `rep`) VALUES (?, ?), (?, ?)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			db := &DB{driver: tt.driver}
+			query, args := db.buildInsert(db.insertPrefix("crm.visits", []string{"id", "rep"}), 2,
+				[][]any{{1, "a"}, {2, "b"}})
+			if query != tt.want {
+				t.Errorf("query = %s, want %s", query, tt.want)
+			}
+			if len(args) != 4 || args[2] != 2 || args[3] != "b" {
+				t.Errorf("args = %v", args)
+			}
+		})
+	}
+
+	if got := (&DB{driver: "postgres"}).quoteIdent(`we"ird`); got != `"we""ird"` {
+		t.Errorf("quoteIdent = %s", got)
+	}
+}
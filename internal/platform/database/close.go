@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// closePollInterval is how often CloseWithTimeout checks for connections still in use
+const closePollInterval = 50 * time.Millisecond
+
+// ErrClosing is returned for queries started after CloseWithTimeout was called
+var ErrClosing = errors.New("database is closing")
+
+// CloseWithTimeout stops new queries and waits for in-flight ones to return their connections
+// before closing the pool. If ctx ends first the pool is closed anyway, aborting the remaining
+// queries, and how many connections were still in use is logged.
+func (db *DB) CloseWithTimeout(ctx context.Context) error {
+	db.closing.Store(true)
+	db.stop()
+
+	ticker := time.NewTicker(closePollInterval)
+	defer ticker.Stop()
+
+	for {
+		inUse := db.pool.Load().Stats().InUse
+		if inUse == 0 {
+			return db.pool.Load().Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			if db.log != nil {
+				db.log.Warn("Closing database with queries still in flight", "in_use", inUse, "error", ctx.Err())
+			}
+			return db.pool.Load().Close()
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkOpen returns ErrClosing once CloseWithTimeout has been called
+func (db *DB) checkOpen() error {
+	if db.closing.Load() {
+		return ErrClosing
+	}
+	return nil
+}
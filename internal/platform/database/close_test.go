@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// startQuery runs a query sleeping for d in the background and waits until it holds a connection
+func startQuery(t *testing.T, db *DB, d time.Duration) <-chan error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		_, err := db.Exec(context.Background(), "SELECT pg_sleep($1)", int64(d))
+		done <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for db.Stats().InUse == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the query never borrowed a connection")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return done
+}
+
+func TestCloseWithTimeoutWaitsForQueries(t *testing.T) {
+	db := newSleeping(t, logtest.Discard(t), 0, 0)
+	query := startQuery(t, db, 200*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.CloseWithTimeout(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The in-flight query completed before the pool was closed
+	select {
+	case err := <-query:
+		if err != nil {
+			t.Errorf("in-flight query = %v, want it to finish", err)
+		}
+	default:
+		t.Fatal("Close returned before the in-flight query finished")
+	}
+
+	if _, err := db.Exec(context.Background(), "SELECT 1"); !errors.Is(err, ErrClosing) {
+		t.Errorf("Exec after close = %v, want ErrClosing", err)
+	}
+	if _, err := db.Query(context.Background(), "SELECT 1"); !errors.Is(err, ErrClosing) {
+		t.Errorf("Query after close = %v, want ErrClosing", err)
+	}
+	if _, err := db.BeginTx(context.Background(), nil); !errors.Is(err, ErrClosing) {
+		t.Errorf("BeginTx after close = %v, want ErrClosing", err)
+	}
+}
+
+func TestCloseWithTimeoutGivesUpAtDeadline(t *testing.T) {
+	log, logs := logtest.New(t)
+	db := newSleeping(t, log, 0, 0)
+	query := startQuery(t, db, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := db.CloseWithTimeout(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Close took %s, want it bounded by the context", elapsed)
+	}
+
+	entry, ok := logs.Find("Closing database with queries still in flight")
+	if !ok {
+		t.Fatal("the queries still in flight were not logged")
+	}
+	if entry["in_use"] != float64(1) {
+		t.Errorf("in_use = %v, want 1", entry["in_use"])
+	}
+
+	<-query
+}
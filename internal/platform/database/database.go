@@ -0,0 +1,302 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+	"github.com/rixtrayker/medical-rep/internal/platform/tenant"
+)
+
+const instrumentationName = "github.com/rixtrayker/medical-rep/internal/platform/database"
+
+// DB wraps the SQL connection pool, tracing each call as a child span of the request
+// and logging failures with the request ID carried by the context
+type DB struct {
+	// pool is replaced when the supervisor reopens the connection pool
+	pool               atomic.Pointer[sql.DB]
+	driver             string
+	tracer             trace.Tracer
+	log                *logger.Logger
+	queryTimeout       time.Duration
+	slowQueryThreshold time.Duration
+	maxIdleConns       int
+	closing            atomic.Bool
+
+	// open creates a new pool for the supervisor
+	open           func() (*sql.DB, error)
+	healthy        atomic.Bool
+	stopSupervisor chan struct{}
+	stopOnce       sync.Once
+}
+
+// New opens the connection pool and verifies the database is reachable within ctx
+func New(ctx context.Context, cfg configs.DatabaseConfig, log *logger.Logger) (*DB, error) {
+	if err := checkDriver(cfg.Driver); err != nil {
+		return nil, err
+	}
+
+	open := func() (*sql.DB, error) { return openPool(cfg) }
+	sqlDB, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{
+		open:               open,
+		stopSupervisor:     make(chan struct{}),
+		driver:             cfg.Driver,
+		tracer:             otel.Tracer(instrumentationName),
+		log:                log,
+		queryTimeout:       cfg.QueryTimeout,
+		slowQueryThreshold: cfg.SlowQueryThreshold,
+		maxIdleConns:       cfg.MaxIdleConns,
+	}
+
+	db.pool.Store(sqlDB)
+
+	if err := db.Ping(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	db.healthy.Store(true)
+
+	return db, nil
+}
+
+// openPool opens a connection pool with the configured limits
+func openPool(cfg configs.DatabaseConfig) (*sql.DB, error) {
+	sqlDB, err := sql.Open(cfg.Driver, cfg.ConnectionString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	return sqlDB, nil
+}
+
+// checkDriver returns an error naming the registered drivers when driver is not one of them.
+// sql.Open reports an unknown driver too, but without saying which ones are compiled in.
+func checkDriver(driver string) error {
+	drivers := sql.Drivers()
+	if slices.Contains(drivers, driver) {
+		return nil
+	}
+	return fmt.Errorf("database driver %q is not registered (available: %s)", driver, strings.Join(drivers, ", "))
+}
+
+// Ping verifies the connection to the database
+func (db *DB) Ping(ctx context.Context) error {
+	ctx, span := db.startSpan(ctx, "ping", "")
+	defer span.End()
+
+	// Not logged: startup retries and health checks report ping failures themselves
+	return endSpan(span, db.pool.Load().PingContext(ctx))
+}
+
+// Query executes a query that returns rows. The query timeout also bounds reading the rows,
+// so callers must finish iterating within it and Close the rows to release it.
+func (db *DB) Query(ctx context.Context, query string, args ...any) (*Rows, error) {
+	if err := db.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	ctx, span := db.startSpan(ctx, "query", query)
+	defer span.End()
+
+	ctx, cancel := db.withQueryTimeout(ctx)
+	start := time.Now()
+	rows, err := db.pool.Load().QueryContext(ctx, query, args...)
+	db.logSlow(ctx, "query", query, time.Since(start))
+	if err != nil {
+		cancel()
+		return nil, db.end(ctx, span, "query", db.timeoutError(ctx, err))
+	}
+
+	// The deadline must outlive this call for rows to be read; Close releases it
+	return &Rows{Rows: rows, cancel: cancel}, nil
+}
+
+// Rows is *sql.Rows whose Close also releases the query timeout
+type Rows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+// Close closes the rows and releases the query timeout
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	r.cancel()
+	return err
+}
+
+// Row is *sql.Row whose Scan also releases the query timeout
+type Row struct {
+	row    *sql.Row
+	cancel context.CancelFunc
+}
+
+// Scan copies the columns of the row into dest and releases the query timeout
+func (r *Row) Scan(dest ...any) error {
+	defer r.cancel()
+	return r.row.Scan(dest...)
+}
+
+// Err returns the error, if any, that was encountered while running the query
+func (r *Row) Err() error {
+	return r.row.Err()
+}
+
+// QueryRow executes a query that is expected to return at most one row. The query timeout
+// lasts until Scan is called, which releases it.
+func (db *DB) QueryRow(ctx context.Context, query string, args ...any) *Row {
+	if db.checkOpen() != nil {
+		// A Row cannot carry ErrClosing; a cancelled context fails it without borrowing a connection
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		return &Row{row: db.pool.Load().QueryRowContext(ctx, query, args...), cancel: cancel}
+	}
+
+	ctx, span := db.startSpan(ctx, "query_row", query)
+	defer span.End()
+
+	ctx, cancel := db.withQueryTimeout(ctx)
+	start := time.Now()
+	row := db.pool.Load().QueryRowContext(ctx, query, args...)
+	db.logSlow(ctx, "query_row", query, time.Since(start))
+	db.end(ctx, span, "query_row", db.timeoutError(ctx, row.Err()))
+
+	// Scan reads after this call returns, so it releases the deadline
+	return &Row{row: row, cancel: cancel}
+}
+
+// Exec executes a query without returning any rows
+func (db *DB) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if err := db.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	ctx, span := db.startSpan(ctx, "exec", query)
+	defer span.End()
+
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	result, err := db.pool.Load().ExecContext(ctx, query, args...)
+	db.logSlow(ctx, "exec", query, time.Since(start))
+	return result, db.end(ctx, span, "exec", db.timeoutError(ctx, err))
+}
+
+// BeginTx starts a transaction
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if err := db.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	ctx, span := db.startSpan(ctx, "begin", "")
+	defer span.End()
+
+	tx, err := db.pool.Load().BeginTx(ctx, opts)
+	return tx, db.end(ctx, span, "begin", err)
+}
+
+// Stats returns connection pool statistics
+func (db *DB) Stats() sql.DBStats {
+	return db.pool.Load().Stats()
+}
+
+// Driver returns the configured driver name
+func (db *DB) Driver() string {
+	return db.driver
+}
+
+// Close closes the connection pool immediately, aborting in-flight queries
+func (db *DB) Close() error {
+	db.stop()
+	return db.pool.Load().Close()
+}
+
+// withQueryTimeout applies the configured query timeout unless the context already ends sooner
+func (db *DB) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, db.queryTimeout)
+}
+
+// timeoutError makes a query cut off by its deadline report the timeout
+func (db *DB) timeoutError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("query exceeded its deadline: %w (%w)", err, context.DeadlineExceeded)
+	}
+	return err
+}
+
+// logSlow warns about statements that took longer than the slow query threshold.
+// Only the SQL text is logged; bound arguments are never included.
+func (db *DB) logSlow(ctx context.Context, operation, query string, elapsed time.Duration) {
+	if db.slowQueryThreshold <= 0 || elapsed < db.slowQueryThreshold || db.log == nil {
+		return
+	}
+	db.log.Warn("Slow query",
+		"operation", operation,
+		"query", strings.Join(strings.Fields(query), " "),
+		"duration", elapsed,
+		"threshold", db.slowQueryThreshold,
+		"request_id", requestid.FromContext(ctx),
+		"tenant", tenant.FromContext(ctx),
+	)
+}
+
+// startSpan starts a client span for a database operation
+func (db *DB) startSpan(ctx context.Context, operation, query string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", db.driver),
+		attribute.String("db.operation", operation),
+	}
+	if query != "" {
+		attrs = append(attrs, attribute.String("db.statement", query))
+	}
+
+	return db.tracer.Start(ctx, "db."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// end records err on the span, logs it with the request ID and returns it unchanged
+func (db *DB) end(ctx context.Context, span trace.Span, operation string, err error) error {
+	if endSpan(span, err) != nil && err != sql.ErrNoRows && db.log != nil {
+		db.log.Error("Database operation failed",
+			"operation", operation, "request_id", requestid.FromContext(ctx), "tenant", tenant.FromContext(ctx), "error", err)
+	}
+	return err
+}
+
+// endSpan records err on the span and returns it unchanged
+func endSpan(span trace.Span, err error) error {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
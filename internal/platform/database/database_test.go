@@ -0,0 +1,280 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+	"github.com/rixtrayker/medical-rep/internal/platform/tenant"
+)
+
+// errQuery is returned by every statement on failingDriver connections
+var errQuery = errors.New("relation \"visits\" does not exist")
+
+// failingDriver is a database/sql driver whose connections answer pings and fail everything else
+type failingDriver struct{}
+
+func (failingDriver) Open(string) (driver.Conn, error) { return failingConn{}, nil }
+
+type failingConn struct{}
+
+func (failingConn) Prepare(string) (driver.Stmt, error) { return nil, errQuery }
+func (failingConn) Close() error                        { return nil }
+func (failingConn) Begin() (driver.Tx, error)           { return nil, errQuery }
+func (failingConn) Ping(context.Context) error          { return nil }
+
+func init() {
+	sql.Register("database-failing", failingDriver{})
+}
+
+func TestNewRequiresRegisteredDriver(t *testing.T) {
+	_, err := New(context.Background(), configs.DatabaseConfig{Driver: "oracle"}, logtest.Discard(t))
+	if err == nil {
+		t.Fatal("New succeeded with an unregistered driver")
+	}
+	for _, want := range []string{`"oracle" is not registered`, "database-failing", "database-sleeping"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+	if err := MigrateUp(configs.DatabaseConfig{Driver: "oracle"}); err == nil || !strings.Contains(err.Error(), "available:") {
+		t.Errorf("MigrateUp() = %v, want the available drivers listed", err)
+	}
+
+	db, err := New(context.Background(), configs.DatabaseConfig{Driver: "database-failing", MaxOpenConns: 1}, logtest.Discard(t))
+	if err != nil {
+		t.Fatalf("New with a registered driver: %v", err)
+	}
+	db.Close()
+}
+
+func TestFailuresAreLoggedWithRequestID(t *testing.T) {
+	log, logs := logtest.New(t)
+	db, err := New(context.Background(), configs.DatabaseConfig{Driver: "database-failing", MaxOpenConns: 1}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var id string
+	handler := requestid.Middleware("", false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = requestid.FromContext(r.Context())
+		if _, err := db.Exec(r.Context(), "DELETE FROM visits"); !errors.Is(err, errQuery) {
+			t.Errorf("Exec() = %v, want the driver error", err)
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/visits", nil))
+
+	entry, ok := logs.Find("Database operation failed")
+	if !ok {
+		t.Fatal("the failed statement was not logged")
+	}
+	if entry["request_id"] != id || entry["operation"] != "exec" || entry["error"] != errQuery.Error() {
+		t.Errorf("log entry = %v, want the exec failure with request ID %s", entry, id)
+	}
+}
+
+func TestFailuresAreLoggedWithTenant(t *testing.T) {
+	log, logs := logtest.New(t)
+	db, err := New(context.Background(), configs.DatabaseConfig{Driver: "database-failing", MaxOpenConns: 1}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	db.Exec(tenant.NewContext(context.Background(), "pfizer"), "DELETE FROM visits")
+	if entry, ok := logs.Find("Database operation failed"); !ok || entry["tenant"] != "pfizer" {
+		t.Errorf("log entry = %v, want the tenant", entry)
+	}
+}
+
+// sleepingDriver is a database/sql driver whose statements sleep for the duration given as
+// their first argument, or until the context ends
+type sleepingDriver struct{}
+
+func (sleepingDriver) Open(string) (driver.Conn, error) { return sleepingConn{}, nil }
+
+type sleepingConn struct{}
+
+func (sleepingConn) Prepare(string) (driver.Stmt, error) { return nil, errQuery }
+func (sleepingConn) Close() error                        { return nil }
+func (sleepingConn) Begin() (driver.Tx, error)           { return nil, errQuery }
+func (sleepingConn) Ping(context.Context) error          { return nil }
+
+func (sleepingConn) ExecContext(ctx context.Context, _ string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), sleep(ctx, args)
+}
+
+func (sleepingConn) QueryContext(ctx context.Context, _ string, args []driver.NamedValue) (driver.Rows, error) {
+	return noRows{}, sleep(ctx, args)
+}
+
+func sleep(ctx context.Context, args []driver.NamedValue) error {
+	select {
+	case <-time.After(time.Duration(args[0].Value.(int64))):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type noRows struct{}
+
+func (noRows) Columns() []string         { return nil }
+func (noRows) Close() error              { return nil }
+func (noRows) Next([]driver.Value) error { return io.EOF }
+
+func init() {
+	sql.Register("database-sleeping", sleepingDriver{})
+}
+
+// newSleeping opens a database on the sleeping driver with the given limits
+func newSleeping(t *testing.T, log *logger.Logger, queryTimeout, slowQueryThreshold time.Duration) *DB {
+	t.Helper()
+	db, err := New(context.Background(), configs.DatabaseConfig{
+		Driver:             "database-sleeping",
+		MaxOpenConns:       2,
+		QueryTimeout:       queryTimeout,
+		SlowQueryThreshold: slowQueryThreshold,
+	}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestQueryTimeout(t *testing.T) {
+	db := newSleeping(t, logtest.Discard(t), 20*time.Millisecond, 0)
+	ctx := context.Background()
+	hang := int64(time.Minute)
+
+	start := time.Now()
+	if _, err := db.Exec(ctx, "SELECT pg_sleep($1)", hang); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Exec() = %v, want a deadline error", err)
+	}
+	if _, err := db.Query(ctx, "SELECT pg_sleep($1)", hang); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Query() = %v, want a deadline error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("hanging statements took %s, want them cut off at the query timeout", elapsed)
+	}
+
+	// A shorter caller deadline wins over the configured timeout
+	ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	if _, err := db.Exec(ctx, "SELECT pg_sleep($1)", int64(10*time.Millisecond)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Exec() = %v with an expired caller deadline", err)
+	}
+}
+
+// recordingDriver is a database/sql driver whose queries return one row holding 1 and record
+// the context they ran with in queryContexts
+type recordingDriver struct{}
+
+var queryContexts = make(chan context.Context, 1)
+
+func (recordingDriver) Open(string) (driver.Conn, error) { return recordingConn{}, nil }
+
+type recordingConn struct{}
+
+func (recordingConn) Prepare(string) (driver.Stmt, error) { return nil, errQuery }
+func (recordingConn) Close() error                        { return nil }
+func (recordingConn) Begin() (driver.Tx, error)           { return nil, errQuery }
+func (recordingConn) Ping(context.Context) error          { return nil }
+
+func (recordingConn) QueryContext(ctx context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+	queryContexts <- ctx
+	return &oneRow{}, nil
+}
+
+type oneRow struct{ done bool }
+
+func (*oneRow) Columns() []string { return []string{"n"} }
+func (*oneRow) Close() error      { return nil }
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func init() {
+	sql.Register("database-recording", recordingDriver{})
+}
+
+func TestReadingRowsReleasesQueryTimeout(t *testing.T) {
+	db, err := New(context.Background(), configs.DatabaseConfig{Driver: "database-recording", MaxOpenConns: 1, QueryTimeout: time.Hour}, logtest.Discard(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+
+	rows, err := db.Query(ctx, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	queryCtx := <-queryContexts
+	for rows.Next() {
+	}
+	if queryCtx.Err() != nil {
+		t.Fatal("the query timeout was released before the rows were closed")
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(queryCtx.Err(), context.Canceled) {
+		t.Errorf("query context err = %v after Close, want it released", queryCtx.Err())
+	}
+
+	var n int
+	row := db.QueryRow(ctx, "SELECT 1")
+	queryCtx = <-queryContexts
+	if queryCtx.Err() != nil {
+		t.Fatal("the query timeout was released before Scan")
+	}
+	if err := row.Scan(&n); err != nil || n != 1 {
+		t.Fatalf("Scan() = %d, %v, want 1", n, err)
+	}
+	if !errors.Is(queryCtx.Err(), context.Canceled) {
+		t.Errorf("query context err = %v after Scan, want it released", queryCtx.Err())
+	}
+}
+
+func TestSlowQueriesAreLogged(t *testing.T) {
+	log, logs := logtest.New(t)
+	db := newSleeping(t, log, time.Second, 20*time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := db.Exec(ctx, "SELECT  pg_sleep($1)\n\tFROM visits", int64(50*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, "SELECT 1 WHERE $1 > 0", int64(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := logs.Count("Slow query"); n != 1 {
+		t.Fatalf("%d slow queries logged, want only the sleeping one", n)
+	}
+	entry, _ := logs.Find("Slow query")
+	if entry["level"] != "WARN" || entry["operation"] != "exec" || entry["query"] != "SELECT pg_sleep($1) FROM visits" {
+		t.Errorf("log entry = %v, want the normalized SQL without its arguments", entry)
+	}
+	if entry["duration"] == nil || entry["threshold"] == nil {
+		t.Errorf("log entry = %v, want the duration and threshold", entry)
+	}
+}
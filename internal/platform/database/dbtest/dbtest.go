@@ -0,0 +1,58 @@
+// Package dbtest opens databases for tests that need a *database.DB
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/database"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// PingDriver is the name of a driver whose connections only answer pings
+const PingDriver = "dbtest-ping"
+
+func init() {
+	sql.Register(PingDriver, pingDriver{})
+}
+
+// NewPing opens a database on PingDriver, closed when the test ends. It suits tests that only
+// need a live or closed handle.
+func NewPing(t testing.TB) *database.DB {
+	t.Helper()
+	return open(t, configs.DatabaseConfig{Driver: PingDriver})
+}
+
+// open opens the database described by cfg with test pool settings
+func open(t testing.TB, cfg configs.DatabaseConfig) *database.DB {
+	t.Helper()
+	cfg.MaxOpenConns = 4
+	cfg.MaxIdleConns = 4
+	cfg.QueryTimeout = 5 * time.Second
+
+	db, err := database.New(context.Background(), cfg, logtest.Discard(t))
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// errUnsupported is returned for anything but pings on PingDriver connections
+var errUnsupported = errors.New("dbtest: the ping driver only supports pings")
+
+type pingDriver struct{}
+
+func (pingDriver) Open(string) (driver.Conn, error) { return pingConn{}, nil }
+
+type pingConn struct{}
+
+func (pingConn) Prepare(string) (driver.Stmt, error) { return nil, errUnsupported }
+func (pingConn) Close() error                        { return nil }
+func (pingConn) Begin() (driver.Tx, error)           { return nil, errUnsupported }
+func (pingConn) Ping(context.Context) error          { return nil }
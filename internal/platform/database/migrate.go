@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// MigrateUp applies all pending migrations from cfg.MigrationsPath
+func MigrateUp(cfg configs.DatabaseConfig) error {
+	m, err := newMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// MigrateDown rolls back the given number of applied migrations
+func MigrateDown(cfg configs.DatabaseConfig, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive")
+	}
+
+	m, err := newMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to roll back migrations: %w", err)
+	}
+	return nil
+}
+
+// LatestMigration returns the highest migration version in dir, or 0 when it holds none
+func LatestMigration(dir string) (uint, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations from %s: %w", dir, err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		// Files that are not migrations, such as a README, are skipped like golang-migrate does
+		m, err := source.Parse(entry.Name())
+		if err != nil {
+			continue
+		}
+		latest = max(latest, m.Version)
+	}
+	return latest, nil
+}
+
+// MigrationVersion returns the schema version recorded by golang-migrate and whether a failed
+// migration left it dirty. A database without the schema_migrations table is at version 0.
+func (db *DB) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	if err := db.checkOpen(); err != nil {
+		return 0, false, err
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err := db.pool.Load().QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		if exists, existsErr := db.migrationsTableExists(ctx); existsErr == nil && !exists {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
+
+// migrationsTableExists reports whether golang-migrate has created its version table
+func (db *DB) migrationsTableExists(ctx context.Context) (bool, error) {
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_name = 'schema_migrations' AND table_schema = "
+	if db.driver == "mysql" {
+		query += "DATABASE()"
+	} else {
+		query += "current_schema()"
+	}
+
+	var count int
+	if err := db.pool.Load().QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// newMigrator opens a dedicated connection for migrations; closing the migrator closes it
+func newMigrator(cfg configs.DatabaseConfig) (*migrate.Migrate, error) {
+	dsn := cfg.ConnectionString()
+	if cfg.Driver == "mysql" {
+		// Migration files usually hold several statements
+		dsn += "&multiStatements=true"
+	}
+
+	if err := checkDriver(cfg.Driver); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(cfg.Driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	var driver database.Driver
+	switch cfg.Driver {
+	case "postgres":
+		driver, err = postgres.WithInstance(db, &postgres.Config{})
+	case "mysql":
+		driver, err = mysql.WithInstance(db, &mysql.Config{})
+	default:
+		err = fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance("file://"+cfg.MigrationsPath, cfg.Driver, driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("failed to load migrations from %s: %w", cfg.MigrationsPath, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLatestMigration(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"1_init.up.sql", "1_init.down.sql", "20_add_visits.up.sql", "3_add_reps.up.sql", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Directories are skipped even when named like a migration
+	if err := os.Mkdir(filepath.Join(dir, "99_old.up.sql"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if latest, err := LatestMigration(dir); err != nil || latest != 20 {
+		t.Errorf("LatestMigration() = %d, %v, want 20", latest, err)
+	}
+	if latest, err := LatestMigration(t.TempDir()); err != nil || latest != 0 {
+		t.Errorf("LatestMigration(empty) = %d, %v, want 0", latest, err)
+	}
+	if _, err := LatestMigration(filepath.Join(dir, "missing")); err == nil {
+		t.Error("LatestMigration(missing) succeeded")
+	}
+}
+
+func TestMigrationVersion(t *testing.T) {
+	db := newSQLite(t)
+	ctx := context.Background()
+
+	if _, err := db.Exec(ctx, "CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	if version, dirty, err := db.MigrationVersion(ctx); err != nil || version != 0 || dirty {
+		t.Errorf("MigrationVersion() = %d, %v, %v with no migration applied, want 0", version, dirty, err)
+	}
+
+	if _, err := db.Exec(ctx, "INSERT INTO schema_migrations VALUES (20, TRUE)"); err != nil {
+		t.Fatal(err)
+	}
+	if version, dirty, err := db.MigrationVersion(ctx); err != nil || version != 20 || !dirty {
+		t.Errorf("MigrationVersion() = %d, %v, %v, want 20 dirty", version, dirty, err)
+	}
+
+	db.Close()
+	if _, _, err := db.MigrationVersion(ctx); err == nil {
+		t.Error("MigrationVersion() succeeded on a closed database")
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// IsHealthy reports whether the database is reachable as far as the supervisor knows. It is
+// true after New succeeds and only changes while Supervise runs.
+func (db *DB) IsHealthy() bool {
+	return db.healthy.Load()
+}
+
+// Supervise pings the database every cfg.Interval in the background until the DB is closed.
+// After cfg.FailureThreshold consecutive failures, such as after a primary failover, the DB is
+// marked unhealthy and a new pool is opened with backoff until it answers a ping; it then
+// replaces the old pool, which is closed, and the DB is marked healthy again.
+func (db *DB) Supervise(cfg configs.DatabaseReconnectConfig) {
+	go db.supervise(cfg)
+}
+
+func (db *DB) supervise(cfg configs.DatabaseReconnectConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-db.stopSupervisor:
+			return
+		case <-ticker.C:
+		}
+
+		err := db.ping(db.pool.Load(), cfg.Interval)
+		if err == nil {
+			failures = 0
+			continue
+		}
+
+		failures++
+		db.logWarn("Database ping failed", "consecutive_failures", failures, "error", err)
+		if failures < cfg.FailureThreshold {
+			continue
+		}
+
+		db.healthy.Store(false)
+		db.logError("Database connection lost, reopening the pool", "consecutive_failures", failures, "error", err)
+		if !db.reconnect(cfg) {
+			return
+		}
+		failures = 0
+		ticker.Reset(cfg.Interval)
+	}
+}
+
+// reconnect opens new pools with backoff until one answers a ping and swaps it in. It returns
+// false when the DB was closed first.
+func (db *DB) reconnect(cfg configs.DatabaseReconnectConfig) bool {
+	backoff := cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-db.stopSupervisor:
+			return false
+		case <-time.After(backoff):
+		}
+
+		pool, err := db.open()
+		if err == nil {
+			if err = db.ping(pool, cfg.Interval); err != nil {
+				pool.Close()
+			}
+		}
+		if err == nil {
+			// Close waits for queries still running on the old pool, so it must not hold up recovery
+			old := db.pool.Swap(pool)
+			go old.Close()
+			db.healthy.Store(true)
+			if db.log != nil {
+				db.log.Info("Database connection restored", "attempt", attempt)
+			}
+			return true
+		}
+
+		db.logWarn("Database reconnect failed", "attempt", attempt, "retry_in", backoff, "error", err)
+		backoff = min(backoff*2, cfg.MaxBackoff)
+	}
+}
+
+// ping checks pool within timeout, without tracing: it runs in the background every interval
+func (db *DB) ping(pool *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return pool.PingContext(ctx)
+}
+
+// stop ends the supervisor, if running
+func (db *DB) stop() {
+	db.stopOnce.Do(func() { close(db.stopSupervisor) })
+}
+
+func (db *DB) logWarn(msg string, args ...any) {
+	if db.log != nil {
+		db.log.Warn(msg, args...)
+	}
+}
+
+func (db *DB) logError(msg string, args ...any) {
+	if db.log != nil {
+		db.log.Error(msg, args...)
+	}
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// flakyDown makes every connection of the flaky driver fail its pings, like a primary that
+// went away during a failover
+var flakyDown atomic.Bool
+
+var errConnectionLost = errors.New("connection refused")
+
+// flakyDriver opens connections whose pings fail while flakyDown is set
+type flakyDriver struct{}
+
+func (flakyDriver) Open(string) (driver.Conn, error) { return flakyConn{}, nil }
+
+type flakyConn struct{}
+
+func (flakyConn) Prepare(string) (driver.Stmt, error) { return nil, errQuery }
+func (flakyConn) Close() error                        { return nil }
+func (flakyConn) Begin() (driver.Tx, error)           { return nil, errQuery }
+
+func (flakyConn) Ping(context.Context) error {
+	if flakyDown.Load() {
+		return errConnectionLost
+	}
+	return nil
+}
+
+func init() {
+	sql.Register("database-flaky", flakyDriver{})
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSupervisorReopensPoolAfterConnectionLoss(t *testing.T) {
+	t.Cleanup(func() { flakyDown.Store(false) })
+	log, logs := logtest.New(t)
+	db, err := New(context.Background(), configs.DatabaseConfig{Driver: "database-flaky", MaxOpenConns: 2}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if !db.IsHealthy() {
+		t.Fatal("not healthy after connecting")
+	}
+
+	original := db.pool.Load()
+	db.Supervise(configs.DatabaseReconnectConfig{
+		Interval:         10 * time.Millisecond,
+		FailureThreshold: 3,
+		Backoff:          10 * time.Millisecond,
+		MaxBackoff:       20 * time.Millisecond,
+	})
+
+	flakyDown.Store(true)
+	waitFor(t, "the database to be marked unhealthy", func() bool { return !db.IsHealthy() })
+	waitFor(t, "a failed reconnect", func() bool { return logs.Count("Database reconnect failed") > 0 })
+	if n := logs.Count("Database ping failed"); n < 3 {
+		t.Errorf("%d failed pings logged before the pool was reopened, want the threshold of 3", n)
+	}
+	entry, ok := logs.Find("Database connection lost, reopening the pool")
+	if !ok || entry["consecutive_failures"] != float64(3) {
+		t.Errorf("connection loss = %v, want it logged after 3 failures", entry)
+	}
+	if err := db.Ping(context.Background()); !errors.Is(err, errConnectionLost) {
+		t.Errorf("Ping while down = %v, want the connection error", err)
+	}
+
+	flakyDown.Store(false)
+	waitFor(t, "the database to recover", db.IsHealthy)
+	if _, ok := logs.Find("Database connection restored"); !ok {
+		t.Error("the recovery was not logged")
+	}
+	if db.pool.Load() == original {
+		t.Error("the pool was not replaced")
+	}
+	if err := db.Ping(context.Background()); err != nil {
+		t.Errorf("Ping after recovery = %v", err)
+	}
+}
+
+func TestSupervisorToleratesFailuresBelowThreshold(t *testing.T) {
+	t.Cleanup(func() { flakyDown.Store(false) })
+	log, logs := logtest.New(t)
+	db, err := New(context.Background(), configs.DatabaseConfig{Driver: "database-flaky", MaxOpenConns: 1}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	original := db.pool.Load()
+	db.Supervise(configs.DatabaseReconnectConfig{
+		Interval:         10 * time.Millisecond,
+		FailureThreshold: 1000,
+		Backoff:          10 * time.Millisecond,
+		MaxBackoff:       10 * time.Millisecond,
+	})
+
+	flakyDown.Store(true)
+	waitFor(t, "failed pings", func() bool { return logs.Count("Database ping failed") >= 3 })
+	if !db.IsHealthy() || db.pool.Load() != original {
+		t.Error("the pool was reopened before reaching the failure threshold")
+	}
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// Warmup opens and pings up to n connections at once, then returns them to the pool so the
+// first requests after a cold start do not each pay for a new connection. n is capped by the
+// pool's max open and max idle connections, since idle connections beyond the idle limit
+// would be closed again. It returns how many connections were warmed; on error or
+// cancellation the connections opened so far are still returned to the pool.
+func (db *DB) Warmup(ctx context.Context, n int) (int, error) {
+	if maxOpen := db.pool.Load().Stats().MaxOpenConnections; maxOpen > 0 && n > maxOpen {
+		n = maxOpen
+	}
+	if db.maxIdleConns > 0 && n > db.maxIdleConns {
+		n = db.maxIdleConns
+	}
+	if n <= 0 {
+		return 0, nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		conns    []*sql.Conn
+		warmed   int
+		firstErr error
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := db.pool.Load().Conn(ctx)
+			if err == nil {
+				err = conn.PingContext(ctx)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if conn != nil {
+				// Held until every goroutine is done so each one opens its own connection
+				conns = append(conns, conn)
+			}
+			if err == nil {
+				warmed++
+			} else if firstErr == nil {
+				firstErr = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	return warmed, firstErr
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+func TestWarmup(t *testing.T) {
+	tests := []struct {
+		name             string
+		maxOpen, maxIdle int
+		warmup, want     int
+	}{
+		{"requested level", 10, 10, 4, 4},
+		{"capped at max open", 3, 10, 8, 3},
+		{"capped at max idle", 10, 2, 8, 2},
+		{"none", 10, 10, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := New(context.Background(), configs.DatabaseConfig{Driver: "database-failing", MaxOpenConns: tt.maxOpen, MaxIdleConns: tt.maxIdle}, logtest.Discard(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			warmed, err := db.Warmup(context.Background(), tt.warmup)
+			if err != nil || warmed != tt.want {
+				t.Fatalf("Warmup(%d) = %d, %v, want %d", tt.warmup, warmed, err, tt.want)
+			}
+			// New already opened one connection to ping
+			stats := db.Stats()
+			if want := max(tt.want, 1); stats.OpenConnections != want || stats.Idle != want {
+				t.Errorf("open = %d, idle = %d, want %d connections kept in the pool", stats.OpenConnections, stats.Idle, want)
+			}
+		})
+	}
+}
+
+func TestWarmupRespectsCancellation(t *testing.T) {
+	db, err := New(context.Background(), configs.DatabaseConfig{Driver: "database-failing", MaxOpenConns: 10, MaxIdleConns: 10}, logtest.Discard(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	warmed, err := db.Warmup(ctx, 5)
+	if warmed != 0 || !errors.Is(err, context.Canceled) {
+		t.Errorf("Warmup() = %d, %v, want nothing warmed and the cancellation", warmed, err)
+	}
+	if open := db.Stats().OpenConnections; open > 1 {
+		t.Errorf("%d connections open after a cancelled warmup", open)
+	}
+}
@@ -0,0 +1,222 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+const (
+	// keyPrefix namespaces the Redis overrides, one key per flag
+	keyPrefix = "flags:"
+
+	// refresh bounds how stale an instance's view of an override can be
+	refresh = 5 * time.Second
+)
+
+// State is a flag's configuration. An enabled flag applies to Percentage percent of subjects;
+// 0 and 100 both mean everyone.
+type State struct {
+	Enabled    bool `json:"enabled"`
+	Percentage int  `json:"percentage,omitempty"`
+}
+
+// Flag is a flag's effective state and where it came from
+type Flag struct {
+	Name   string `json:"name"`
+	State  State  `json:"state"`
+	Source string `json:"source"`
+}
+
+// SubjectFunc returns the ID that percentage rollouts are keyed on, or "" if the request has none
+type SubjectFunc func(ctx context.Context) string
+
+// cached is an override read from Redis; found is false when no override is stored
+type cached struct {
+	state     State
+	found     bool
+	fetchedAt time.Time
+}
+
+// Store evaluates flags from config defaults and Redis overrides
+type Store struct {
+	defaults map[string]configs.FeatureConfig
+	client   *redis.Client
+	subject  SubjectFunc
+	log      *logger.Logger
+
+	mu        sync.Mutex
+	overrides map[string]cached
+}
+
+// New creates a store. A nil client disables overrides; subject may be nil when flags
+// are never rolled out by percentage.
+func New(defaults map[string]configs.FeatureConfig, client *redis.Client, subject SubjectFunc, log *logger.Logger) *Store {
+	return &Store{
+		defaults:  defaults,
+		client:    client,
+		subject:   subject,
+		log:       log,
+		overrides: make(map[string]cached),
+	}
+}
+
+var defaultStore atomic.Pointer[Store]
+
+// SetDefault sets the store used by the package-level Enabled
+func SetDefault(s *Store) {
+	defaultStore.Store(s)
+}
+
+// Enabled reports whether the flag is on for the request in ctx using the default store.
+// Unknown flags, and every flag before SetDefault is called, are off.
+func Enabled(ctx context.Context, name string) bool {
+	s := defaultStore.Load()
+	if s == nil {
+		return false
+	}
+	return s.Enabled(ctx, name)
+}
+
+// Enabled reports whether the flag is on for the request in ctx
+func (s *Store) Enabled(ctx context.Context, name string) bool {
+	state, _ := s.state(ctx, name)
+	if !state.Enabled {
+		return false
+	}
+	if state.Percentage <= 0 || state.Percentage >= 100 {
+		return true
+	}
+
+	var subject string
+	if s.subject != nil {
+		subject = s.subject(ctx)
+	}
+	if subject == "" {
+		return false
+	}
+	return Bucket(name, subject) < state.Percentage
+}
+
+// Bucket places subject in one of 100 buckets for the flag. The hash includes the flag name
+// so each flag rolls out to a different slice of subjects, and a subject stays in its bucket
+// as the percentage grows.
+func Bucket(name, subject string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(subject))
+	return int(h.Sum32() % 100)
+}
+
+// List returns the effective state of every configured flag and of the extra names
+func (s *Store) List(ctx context.Context, extra ...string) []Flag {
+	names := make(map[string]struct{}, len(s.defaults)+len(extra))
+	for name := range s.defaults {
+		names[name] = struct{}{}
+	}
+	for _, name := range extra {
+		names[name] = struct{}{}
+	}
+
+	list := make([]Flag, 0, len(names))
+	for name := range names {
+		state, source := s.state(ctx, name)
+		list = append(list, Flag{Name: name, State: state, Source: source})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get returns the flag's effective state
+func (s *Store) Get(ctx context.Context, name string) Flag {
+	state, source := s.state(ctx, name)
+	return Flag{Name: name, State: state, Source: source}
+}
+
+// SetOverride stores an override for every instance
+func (s *Store) SetOverride(ctx context.Context, name string, state State) error {
+	if s.client == nil {
+		return errors.New("flag overrides require redis")
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Set(ctx, keyPrefix+name, data, 0); err != nil {
+		return err
+	}
+	s.remember(name, cached{state: state, found: true, fetchedAt: time.Now()})
+	return nil
+}
+
+// DeleteOverride removes the override so the config default applies again
+func (s *Store) DeleteOverride(ctx context.Context, name string) error {
+	if s.client == nil {
+		return errors.New("flag overrides require redis")
+	}
+	if _, err := s.client.Del(ctx, keyPrefix+name); err != nil {
+		return err
+	}
+	s.remember(name, cached{fetchedAt: time.Now()})
+	return nil
+}
+
+// state returns the override if one is stored, else the config default, and its source
+func (s *Store) state(ctx context.Context, name string) (State, string) {
+	if override, ok := s.override(ctx, name); ok {
+		return override, "override"
+	}
+	if def, ok := s.defaults[name]; ok {
+		return State{Enabled: def.Enabled, Percentage: def.Percentage}, "config"
+	}
+	return State{}, "unknown"
+}
+
+// override returns the Redis override for name, cached for refresh. On Redis errors the last
+// known override is kept.
+func (s *Store) override(ctx context.Context, name string) (State, bool) {
+	if s.client == nil {
+		return State{}, false
+	}
+
+	s.mu.Lock()
+	c, ok := s.overrides[name]
+	s.mu.Unlock()
+	if ok && time.Since(c.fetchedAt) < refresh {
+		return c.state, c.found
+	}
+
+	next := cached{fetchedAt: time.Now()}
+	data, err := s.client.Get(ctx, keyPrefix+name)
+	switch {
+	case errors.Is(err, redis.ErrNotFound):
+	case err != nil:
+		s.log.Warn("Failed to read feature flag override, keeping last known state", "flag", name, "error", err)
+		next.state, next.found = c.state, c.found
+	default:
+		if err := json.Unmarshal([]byte(data), &next.state); err != nil {
+			s.log.Warn("Ignoring malformed feature flag override", "flag", name, "error", err)
+			break
+		}
+		next.found = true
+	}
+
+	s.remember(name, next)
+	return next.state, next.found
+}
+
+func (s *Store) remember(name string, c cached) {
+	s.mu.Lock()
+	s.overrides[name] = c
+	s.mu.Unlock()
+}
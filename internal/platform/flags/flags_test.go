@@ -0,0 +1,189 @@
+package flags
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+type subjectKey struct{}
+
+// subjectFromContext keys rollouts on the subject stored with withSubject
+func subjectFromContext(ctx context.Context) string {
+	s, _ := ctx.Value(subjectKey{}).(string)
+	return s
+}
+
+func withSubject(subject string) context.Context {
+	return context.WithValue(context.Background(), subjectKey{}, subject)
+}
+
+var testDefaults = map[string]configs.FeatureConfig{
+	"new_reports": {Enabled: true},
+	"visit_map":   {Enabled: false},
+	"bulk_import": {Enabled: true, Percentage: 30},
+}
+
+func TestDefaults(t *testing.T) {
+	s := New(testDefaults, nil, subjectFromContext, logtest.Discard(t))
+	ctx := context.Background()
+
+	if !s.Enabled(ctx, "new_reports") || s.Enabled(ctx, "visit_map") || s.Enabled(ctx, "missing") {
+		t.Error("flags do not follow their config defaults")
+	}
+	if f := s.Get(ctx, "missing"); f.Source != "unknown" || f.State.Enabled {
+		t.Errorf("unknown flag = %+v", f)
+	}
+	if err := s.SetOverride(ctx, "visit_map", State{Enabled: true}); err == nil {
+		t.Error("override accepted without redis")
+	}
+
+	list := s.List(ctx, "extra")
+	if len(list) != 4 || list[0].Name != "bulk_import" || list[1].Name != "extra" || list[1].Source != "unknown" {
+		t.Errorf("List() = %+v, want the configured flags and extra sorted by name", list)
+	}
+}
+
+func TestOverrideWinsOverDefault(t *testing.T) {
+	client, server := redistest.New(t)
+	admin := New(testDefaults, client, subjectFromContext, logtest.Discard(t))
+	other := New(testDefaults, redistest.Connect(t, server), subjectFromContext, logtest.Discard(t))
+	ctx := context.Background()
+
+	if !other.Enabled(ctx, "new_reports") {
+		t.Fatal("default not applied before any override")
+	}
+	if err := admin.SetOverride(ctx, "new_reports", State{Enabled: false}); err != nil {
+		t.Fatal(err)
+	}
+	if admin.Enabled(ctx, "new_reports") {
+		t.Error("the instance setting the override did not apply it at once")
+	}
+	if f := admin.Get(ctx, "new_reports"); f.Source != "override" {
+		t.Errorf("source = %s, want override", f.Source)
+	}
+
+	// Other instances serve their cached view until it is refreshed
+	if !other.Enabled(ctx, "new_reports") {
+		t.Error("override read from redis on every call")
+	}
+	expire(other, "new_reports")
+	if other.Enabled(ctx, "new_reports") {
+		t.Error("other instance did not pick up the override after the refresh interval")
+	}
+
+	if err := admin.DeleteOverride(ctx, "new_reports"); err != nil {
+		t.Fatal(err)
+	}
+	expire(other, "new_reports")
+	if !other.Enabled(ctx, "new_reports") || other.Get(ctx, "new_reports").Source != "config" {
+		t.Error("default not restored after deleting the override")
+	}
+}
+
+func TestOverrideSurvivesRedisErrors(t *testing.T) {
+	client, server := redistest.New(t)
+	log, logs := logtest.New(t)
+	s := New(testDefaults, client, subjectFromContext, log)
+	ctx := context.Background()
+
+	server.Set(keyPrefix+"visit_map", `{"enabled":true}`)
+	if !s.Enabled(ctx, "visit_map") {
+		t.Fatal("override not applied")
+	}
+
+	server.SetError("connection lost")
+	expire(s, "visit_map")
+	if !s.Enabled(ctx, "visit_map") {
+		t.Error("last known override dropped on a redis error")
+	}
+	if _, ok := logs.Find("Failed to read feature flag override, keeping last known state"); !ok {
+		t.Error("redis error was not logged")
+	}
+
+	server.SetError("")
+	server.Set(keyPrefix+"visit_map", "not json")
+	expire(s, "visit_map")
+	if s.Enabled(ctx, "visit_map") {
+		t.Error("malformed override applied")
+	}
+}
+
+// expire makes the store's cached override for name stale
+func expire(s *Store, name string) {
+	s.mu.Lock()
+	c := s.overrides[name]
+	c.fetchedAt = time.Now().Add(-refresh)
+	s.overrides[name] = c
+	s.mu.Unlock()
+}
+
+func TestPercentageRollout(t *testing.T) {
+	s := New(testDefaults, nil, subjectFromContext, logtest.Discard(t))
+
+	enabled := 0
+	for i := range 1000 {
+		subject := "user:" + strconv.Itoa(i)
+		on := s.Enabled(withSubject(subject), "bulk_import")
+		if on != s.Enabled(withSubject(subject), "bulk_import") {
+			t.Fatalf("%s flipped between calls", subject)
+		}
+		if on != (Bucket("bulk_import", subject) < 30) {
+			t.Fatalf("%s does not follow its bucket", subject)
+		}
+		if on {
+			enabled++
+		}
+	}
+	if enabled < 240 || enabled > 360 {
+		t.Errorf("%d of 1000 subjects enabled, want about 30%%", enabled)
+	}
+
+	if s.Enabled(context.Background(), "bulk_import") {
+		t.Error("partial rollout enabled for a request without a subject")
+	}
+}
+
+func TestBucket(t *testing.T) {
+	if Bucket("bulk_import", "user:42") != Bucket("bulk_import", "user:42") {
+		t.Error("Bucket is not deterministic")
+	}
+
+	// Every subject lands in one of the 100 buckets
+	for i := range 200 {
+		subject := "tenant:" + strconv.Itoa(i)
+		if b := Bucket("bulk_import", subject); b < 0 || b > 99 {
+			t.Fatalf("Bucket(%s) = %d, want 0-99", subject, b)
+		}
+	}
+
+	// The flag name is part of the hash, so flags roll out to different subjects
+	same := 0
+	for i := range 200 {
+		subject := "user:" + strconv.Itoa(i)
+		if Bucket("bulk_import", subject) == Bucket("visit_map", subject) {
+			same++
+		}
+	}
+	if same > 20 {
+		t.Errorf("%d of 200 subjects share a bucket across flags", same)
+	}
+}
+
+func TestPackageEnabled(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+
+	SetDefault(nil)
+	if Enabled(context.Background(), "new_reports") {
+		t.Error("flag enabled before SetDefault")
+	}
+	SetDefault(New(testDefaults, nil, nil, logtest.Discard(t)))
+	if !Enabled(context.Background(), "new_reports") {
+		t.Error("default store not used")
+	}
+}
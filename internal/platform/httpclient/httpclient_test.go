@@ -0,0 +1,152 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// testConfig allows three retries with millisecond backoff
+func testConfig() configs.HTTPClientConfig {
+	return configs.HTTPClientConfig{
+		Timeout:             5 * time.Second,
+		DialTimeout:         time.Second,
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     time.Minute,
+		MaxRetries:          3,
+		RetryBackoff:        time.Millisecond,
+		RetryMaxBackoff:     5 * time.Millisecond,
+	}
+}
+
+// flaky returns a server answering the first failures requests with status, then handler
+func flaky(t *testing.T, failures int, status int, handler http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestGetJSONRetriesUnavailable(t *testing.T) {
+	server, calls := flaky(t, 2, http.StatusServiceUnavailable, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"name":"rep"}`)
+	})
+
+	var out struct {
+		Name string `json:"name"`
+	}
+	if err := New(testConfig()).GetJSON(context.Background(), server.URL, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "rep" || calls.Load() != 3 {
+		t.Errorf("got %q after %d calls, want rep after 3", out.Name, calls.Load())
+	}
+}
+
+func TestPostJSONResendsBody(t *testing.T) {
+	var bodies []string
+	server, _ := flaky(t, 1, http.StatusBadGateway, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := New(testConfig()).PostJSON(context.Background(), server.URL, map[string]int{"visits": 3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 1 || bodies[0] != `{"visits":3}` {
+		t.Errorf("bodies = %q, want the body resent on the retry", bodies)
+	}
+}
+
+func TestDoGivesUpAfterMaxRetries(t *testing.T) {
+	server, calls := flaky(t, 10, http.StatusGatewayTimeout, nil)
+
+	err := New(testConfig()).GetJSON(context.Background(), server.URL, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("err = %v, want a 504 StatusError", err)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("calls = %d, want the first attempt and 3 retries", calls.Load())
+	}
+}
+
+func TestDoDoesNotRetryOtherStatuses(t *testing.T) {
+	server, calls := flaky(t, 10, http.StatusInternalServerError, nil)
+
+	err := New(testConfig()).GetJSON(context.Background(), server.URL, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("err = %v, want a 500 StatusError", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want no retries for a 500", calls.Load())
+	}
+}
+
+func TestDoTimeoutCoversAllAttempts(t *testing.T) {
+	server, _ := flaky(t, 10, http.StatusServiceUnavailable, nil)
+	cfg := testConfig()
+	cfg.Timeout = 50 * time.Millisecond
+	cfg.MaxRetries = 100
+	cfg.RetryBackoff = 20 * time.Millisecond
+	cfg.RetryMaxBackoff = 20 * time.Millisecond
+
+	start := time.Now()
+	err := New(cfg).GetJSON(context.Background(), server.URL, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the total timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retried for %s, want the timeout to stop it", elapsed)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	c := New(configs.HTTPClientConfig{RetryBackoff: 100 * time.Millisecond, RetryMaxBackoff: time.Second})
+
+	for attempt, want := range map[int]time.Duration{
+		0: 100 * time.Millisecond,
+		1: 200 * time.Millisecond,
+		3: 800 * time.Millisecond,
+		4: time.Second,
+	} {
+		if got := c.retryDelay(attempt, nil); got != want {
+			t.Errorf("retryDelay(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"5"}}}
+	if got := c.retryDelay(0, resp); got != time.Second {
+		t.Errorf("retryDelay with Retry-After 5 = %s, want it capped at 1s", got)
+	}
+}
+
+func TestStatusErrorKeepsBody(t *testing.T) {
+	server, _ := flaky(t, 0, 0, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "no such rep\n")
+	})
+
+	err := New(testConfig()).GetJSON(context.Background(), server.URL, nil)
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 404: no such rep") {
+		t.Errorf("err = %v, want the status and body", err)
+	}
+}
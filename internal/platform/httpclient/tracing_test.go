@@ -0,0 +1,97 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// recordSpans installs a recording tracer provider and W3C propagation for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+func TestClientSpansPropagate(t *testing.T) {
+	recorder := recordSpans(t)
+
+	// The first attempt is refused so that the retry gets its own span
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		if len(headers) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	ctx = requestid.NewContext(ctx, "req-123")
+	if err := New(testConfig()).GetJSON(ctx, server.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	var clientSpans []sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.SpanKind() == trace.SpanKindClient {
+			clientSpans = append(clientSpans, s)
+		}
+	}
+	if len(clientSpans) != 2 {
+		t.Fatalf("recorded %d client spans, want one per attempt", len(clientSpans))
+	}
+	if clientSpans[0].Status().Code != codes.Error || clientSpans[1].Status().Code == codes.Error {
+		t.Errorf("span statuses = %v, %v, want the 503 attempt marked as an error", clientSpans[0].Status(), clientSpans[1].Status())
+	}
+
+	for i, span := range clientSpans {
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("attempt %d is not a child of the caller's span", i+1)
+		}
+		want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+		if got := headers[i].Get("Traceparent"); got != want {
+			t.Errorf("attempt %d traceparent = %q, want %q", i+1, got, want)
+		}
+		if got := headers[i].Get(requestid.Header); got != "req-123" {
+			t.Errorf("attempt %d request ID = %q, want req-123", i+1, got)
+		}
+	}
+}
+
+func TestStandardClientIsTraced(t *testing.T) {
+	recorder := recordSpans(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	resp, err := New(testConfig()).StandardClient().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(recorder.Ended()) != 1 {
+		t.Errorf("recorded %d spans, want 1", len(recorder.Ended()))
+	}
+}
@@ -0,0 +1,174 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+	"github.com/rixtrayker/medical-rep/internal/platform/tenant"
+)
+
+// HeaderKey is the request header carrying the client's idempotency key
+const HeaderKey = "Idempotency-Key"
+
+const (
+	keyPrefix = "idempotency:"
+
+	// inFlightTTL bounds how long a crashed request can block retries of the same key
+	inFlightTTL = time.Minute
+)
+
+// record is the stored state for an idempotency key
+type record struct {
+	InFlight bool        `json:"in_flight,omitempty"`
+	Status   int         `json:"status,omitempty"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     []byte      `json:"body,omitempty"`
+}
+
+// Middleware makes POST, PUT and PATCH requests carrying an Idempotency-Key safe to retry.
+// The first response for a (key, method, path, body) combination is stored for ttl and replayed
+// to retries; a retry arriving while the first request is still running gets 409.
+// Server errors are not stored so the client can retry them. Store errors fail open.
+func Middleware(client *redis.Client, ttl time.Duration, log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idemKey := r.Header.Get(HeaderKey)
+			if idemKey == "" || !unsafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "bad_request", "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			key := storageKey(tenant.FromContext(ctx), idemKey, r.Method, r.URL.Path, body)
+
+			existing, err := load(ctx, client, key)
+			if err != nil {
+				log.Warn("Idempotency store unavailable, executing request", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if existing != nil {
+				replay(w, existing)
+				return
+			}
+
+			marker, _ := json.Marshal(record{InFlight: true})
+			acquired, err := client.SetNX(ctx, key, marker, inFlightTTL)
+			if err != nil {
+				log.Warn("Idempotency store unavailable, executing request", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !acquired {
+				writeError(w, http.StatusConflict, "idempotency_conflict", "a request with this idempotency key is in progress")
+				return
+			}
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+			next.ServeHTTP(ww, r)
+
+			// Use a fresh context so a client disconnect doesn't leave the key locked
+			storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				if _, err := client.Del(storeCtx, key); err != nil {
+					log.Warn("Failed to release idempotency key", "error", err)
+				}
+				return
+			}
+
+			data, err := json.Marshal(record{Status: status, Header: w.Header().Clone(), Body: buf.Bytes()})
+			if err == nil {
+				err = client.Set(storeCtx, key, data, ttl)
+			}
+			if err != nil {
+				log.Warn("Failed to store idempotent response", "error", err)
+			}
+		})
+	}
+}
+
+// load returns the stored record for key, or nil if there is none
+func load(ctx context.Context, client *redis.Client, key string) (*record, error) {
+	data, err := client.Get(ctx, key)
+	if errors.Is(err, redis.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rec record
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// replay writes a stored response, or 409 if the original request is still running
+func replay(w http.ResponseWriter, rec *record) {
+	if rec.InFlight {
+		writeError(w, http.StatusConflict, "idempotency_conflict", "a request with this idempotency key is in progress")
+		return
+	}
+
+	for name, values := range rec.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(rec.Status)
+	w.Write(rec.Body)
+}
+
+// storageKey scopes the client key to the tenant, route and request body, so tenants cannot
+// replay each other's responses
+func storageKey(tenantID, idemKey, method, path string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	h := sha256.New()
+	h.Write([]byte(tenantID))
+	h.Write([]byte{0})
+	h.Write([]byte(idemKey))
+	h.Write([]byte{0})
+	h.Write([]byte(method + " " + path))
+	h.Write([]byte{0})
+	h.Write(bodyHash[:])
+	return keyPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// unsafeMethod reports whether the method is one idempotency keys apply to
+func unsafeMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
@@ -0,0 +1,165 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+	"github.com/rixtrayker/medical-rep/internal/platform/tenant"
+)
+
+// post sends a POST with the idempotency key and body through handler
+func post(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/visits", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// assertConflict fails unless rec is a 409 carrying the idempotency_conflict envelope
+func assertConflict(t *testing.T, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", rec.Code)
+	}
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body, err)
+	}
+	if body.Error.Code != "idempotency_conflict" || body.Error.Message == "" {
+		t.Errorf("body = %s, want the idempotency_conflict envelope", rec.Body)
+	}
+}
+
+func TestReplaysCompletedRequest(t *testing.T) {
+	client, _ := redistest.New(t)
+	var calls atomic.Int32
+	handler := Middleware(client, time.Hour, logtest.Discard(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Location", "/api/v1/visits/1")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":1,"call":%d}`, n)
+	}))
+
+	first := post(handler, "key-1", `{"rep":1}`)
+	retry := post(handler, "key-1", `{"rep":1}`)
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want once", calls.Load())
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %s, want the first response %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get("Location") != "/api/v1/visits/1" || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry headers = %v, want the stored headers marked as replayed", retry.Header())
+	}
+
+	// Another body or key is a different request
+	post(handler, "key-1", `{"rep":2}`)
+	post(handler, "key-2", `{"rep":1}`)
+	post(handler, "", `{"rep":1}`)
+	if calls.Load() != 4 {
+		t.Errorf("handler ran %d times, want 4", calls.Load())
+	}
+}
+
+func TestTenantsDoNotShareKeys(t *testing.T) {
+	client, _ := redistest.New(t)
+	var calls atomic.Int32
+	handler := Middleware(client, time.Hour, logtest.Discard(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"tenant":%q}`, tenant.FromContext(r.Context()))
+	}))
+
+	postAs := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/visits", strings.NewReader(`{"rep":1}`))
+		req.Header.Set(HeaderKey, "key-1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req.WithContext(tenant.NewContext(req.Context(), id)))
+		return rec
+	}
+
+	if rec := postAs("pfizer"); rec.Body.String() != `{"tenant":"pfizer"}` {
+		t.Fatalf("pfizer got %s", rec.Body)
+	}
+	// The same key, route and body from another tenant runs the handler instead of replaying
+	if rec := postAs("novartis"); rec.Body.String() != `{"tenant":"novartis"}` {
+		t.Errorf("novartis got %s, want its own response", rec.Body)
+	}
+	if rec := postAs("pfizer"); rec.Body.String() != `{"tenant":"pfizer"}` {
+		t.Errorf("pfizer retry got %s, want its stored response", rec.Body)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("handler ran %d times, want once per tenant", n)
+	}
+}
+
+func TestConflictWhileInFlight(t *testing.T) {
+	client, _ := redistest.New(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := Middleware(client, time.Hour, logtest.Discard(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post(handler, "key-1", `{}`) }()
+	<-started
+
+	assertConflict(t, post(handler, "key-1", `{}`))
+
+	close(release)
+	if rec := <-done; rec.Code != http.StatusCreated {
+		t.Fatalf("first request status = %d, want 201", rec.Code)
+	}
+}
+
+func TestServerErrorsAreNotStored(t *testing.T) {
+	client, _ := redistest.New(t)
+	var calls atomic.Int32
+	handler := Middleware(client, time.Hour, logtest.Discard(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	post(handler, "key-1", `{}`)
+	if rec := post(handler, "key-1", `{}`); rec.Code != http.StatusCreated {
+		t.Fatalf("retry after a server error = %d, want the handler to run again", rec.Code)
+	}
+}
+
+func TestStoreUnavailableFailsOpen(t *testing.T) {
+	client, server := redistest.New(t)
+	server.Close()
+
+	var calls atomic.Int32
+	handler := Middleware(client, time.Hour, logtest.Discard(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+
+	post(handler, "key-1", `{}`)
+	post(handler, "key-1", `{}`)
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want every request executed without the store", calls.Load())
+	}
+}
@@ -0,0 +1,88 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+var (
+	// ErrNotAcquired is returned when the lock is held by someone else
+	ErrNotAcquired = errors.New("lock: not acquired")
+
+	// ErrNotHeld is returned when refreshing or releasing a lock that has expired or been taken over
+	ErrNotHeld = errors.New("lock: not held")
+)
+
+const keyPrefix = "lock:"
+
+// refreshScript extends the TTL only if the lock still carries our token
+var refreshScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lock only if it still carries our token
+var releaseScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Lock is a distributed lock held in Redis until it is released or its TTL lapses
+type Lock struct {
+	client *redis.Client
+	key    string
+	token  string
+	ttl    time.Duration
+}
+
+// Acquire takes the named lock for ttl, or returns ErrNotAcquired if another holder has it
+func Acquire(ctx context.Context, client *redis.Client, name string, ttl time.Duration) (*Lock, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+
+	l := &Lock{client: client, key: keyPrefix + name, token: hex.EncodeToString(b), ttl: ttl}
+	ok, err := client.SetNX(ctx, l.key, l.token, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if !ok {
+		return nil, ErrNotAcquired
+	}
+	return l, nil
+}
+
+// Refresh extends the lock by its TTL, or returns ErrNotHeld if it was lost
+func (l *Lock) Refresh(ctx context.Context) error {
+	return l.run(ctx, refreshScript, l.ttl.Milliseconds())
+}
+
+// Release gives up the lock, or returns ErrNotHeld if it was already lost
+func (l *Lock) Release(ctx context.Context) error {
+	return l.run(ctx, releaseScript)
+}
+
+// run executes a token-guarded script, mapping a zero result to ErrNotHeld
+func (l *Lock) run(ctx context.Context, script *goredis.Script, args ...interface{}) error {
+	res, err := l.client.RunScript(ctx, script, []string{l.key}, append([]interface{}{l.token}, args...)...)
+	if err != nil {
+		return fmt.Errorf("lock script failed: %w", err)
+	}
+	if n, ok := res.(int64); !ok || n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
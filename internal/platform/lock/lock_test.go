@@ -0,0 +1,73 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+func TestLockIsExclusive(t *testing.T) {
+	client, server := redistest.New(t)
+	ctx := context.Background()
+
+	l, err := Acquire(ctx, client, "cleanup", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Acquire(ctx, redistest.Connect(t, server), "cleanup", time.Minute); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("second Acquire() = %v, want ErrNotAcquired", err)
+	}
+
+	if err := l.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Acquire(ctx, client, "cleanup", time.Minute); err != nil {
+		t.Errorf("Acquire() after Release = %v, want the lock free", err)
+	}
+}
+
+func TestLockRefreshExtendsTTL(t *testing.T) {
+	client, server := redistest.New(t)
+	ctx := context.Background()
+
+	l, err := Acquire(ctx, client, "cleanup", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(45 * time.Second)
+	if err := l.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL(keyPrefix + "cleanup"); ttl != time.Minute {
+		t.Errorf("TTL after Refresh = %s, want 1m", ttl)
+	}
+}
+
+func TestExpiredLockIsNotHeld(t *testing.T) {
+	client, server := redistest.New(t)
+	ctx := context.Background()
+
+	l, err := Acquire(ctx, client, "cleanup", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(2 * time.Minute)
+
+	// Another holder takes over; the stale holder must not extend or delete its lock
+	other, err := Acquire(ctx, client, "cleanup", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Refresh(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Refresh() = %v, want ErrNotHeld", err)
+	}
+	if err := l.Release(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Release() = %v, want ErrNotHeld", err)
+	}
+	if err := other.Refresh(ctx); err != nil {
+		t.Errorf("the new holder lost the lock: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+// Logger wraps slog.Logger with application specific helpers
+type Logger struct {
+	*slog.Logger
+	level *slog.LevelVar
+}
+
+// New creates a new logger from the logging configuration
+func New(cfg configs.LoggingConfig) (*Logger, error) {
+	out, err := newWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithWriter(cfg, out)
+}
+
+// NewWithWriter creates a logger writing to out instead of the configured output, for callers
+// that capture logs
+func NewWithWriter(cfg configs.LoggingConfig, out io.Writer) (*Logger, error) {
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level)
+
+	opts := &slog.HandlerOptions{Level: levelVar}
+
+	var handler slog.Handler
+	switch cfg.Format {
+	case "console", "text":
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		handler = slog.NewJSONHandler(out, opts)
+	}
+
+	return &Logger{Logger: slog.New(handler), level: levelVar}, nil
+}
+
+// Level returns the current minimum level
+func (l *Logger) Level() slog.Level {
+	return l.level.Level()
+}
+
+// LevelName returns the current minimum level in the form accepted by SetLevel
+func (l *Logger) LevelName() string {
+	return strings.ToLower(l.level.Level().String())
+}
+
+// SetLevel changes the minimum level of the running logger; it is safe for concurrent use
+func (l *Logger) SetLevel(name string) error {
+	level, err := parseLevel(name)
+	if err != nil {
+		return err
+	}
+	l.level.Set(level)
+	return nil
+}
+
+// StdLogger returns a standard library logger that writes through this logger at error level
+func (l *Logger) StdLogger() *log.Logger {
+	return slog.NewLogLogger(l.Handler(), slog.LevelError)
+}
+
+// parseLevel converts a configured level name to a slog level
+func parseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// newWriter resolves the configured output to a writer, rotating files with lumberjack
+func newWriter(cfg configs.LoggingConfig) (io.Writer, error) {
+	switch cfg.Output {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		return &lumberjack.Logger{
+			Filename:   cfg.Output,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			Compress:   cfg.Compress,
+		}, nil
+	}
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/configs"
+)
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := NewWithWriter(configs.LoggingConfig{Level: "info", Format: "json"}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.Debug("suppressed")
+	if buf.Len() != 0 {
+		t.Fatalf("debug entry logged at info level: %s", buf.String())
+	}
+
+	if err := log.SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	if log.LevelName() != "debug" {
+		t.Errorf("LevelName() = %q, want debug", log.LevelName())
+	}
+	log.Debug("emitted")
+	if !strings.Contains(buf.String(), `"msg":"emitted"`) {
+		t.Fatalf("debug entry missing after raising verbosity: %s", buf.String())
+	}
+
+	// Loggers derived before the change follow it too
+	derived := log.With("component", "test")
+	if err := log.SetLevel("warn"); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	derived.Info("suppressed")
+	if buf.Len() != 0 {
+		t.Errorf("derived logger ignored the new level: %s", buf.String())
+	}
+}
+
+func TestSetLevelRejectsUnknownLevel(t *testing.T) {
+	log, err := NewWithWriter(configs.LoggingConfig{Level: "info"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.SetLevel("verbose"); err == nil {
+		t.Fatal("SetLevel accepted an unknown level")
+	}
+	if log.LevelName() != "info" {
+		t.Errorf("LevelName() = %q, want info kept", log.LevelName())
+	}
+}
+
+func TestSetLevelIsRaceFree(t *testing.T) {
+	log, err := NewWithWriter(configs.LoggingConfig{Level: "info"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = log.SetLevel([]string{"debug", "info"}[j%2])
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				log.Debug("concurrent", "n", j)
+				_ = log.LevelName()
+			}
+		}()
+	}
+	wg.Wait()
+}
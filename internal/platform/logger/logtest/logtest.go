@@ -0,0 +1,99 @@
+// Package logtest captures application logs in tests
+package logtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+// Entry is one decoded log line
+type Entry map[string]any
+
+// Message returns the entry's log message
+func (e Entry) Message() string {
+	msg, _ := e["msg"].(string)
+	return msg
+}
+
+// Recorder holds the JSON lines written by a logger from New
+type Recorder struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write implements io.Writer
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+// String returns everything logged so far
+func (r *Recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.String()
+}
+
+// Entries returns the entries logged so far
+func (r *Recorder) Entries() []Entry {
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader([]byte(r.String())))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Find returns the first entry with the given message
+func (r *Recorder) Find(msg string) (Entry, bool) {
+	for _, e := range r.Entries() {
+		if e.Message() == msg {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Count returns how many entries have the given message
+func (r *Recorder) Count(msg string) int {
+	n := 0
+	for _, e := range r.Entries() {
+		if e.Message() == msg {
+			n++
+		}
+	}
+	return n
+}
+
+// New returns a debug level JSON logger recording into the returned Recorder
+func New(t testing.TB) (*logger.Logger, *Recorder) {
+	t.Helper()
+	rec := &Recorder{}
+	log, err := logger.NewWithWriter(configs.LoggingConfig{Level: "debug", Format: "json"}, rec)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	return log, rec
+}
+
+// Discard returns a logger that drops everything
+func Discard(t testing.TB) *logger.Logger {
+	t.Helper()
+	log, err := logger.NewWithWriter(configs.LoggingConfig{Level: "error", Format: "json"}, io.Discard)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	return log
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/apiversion"
+)
+
+// Metrics holds the Prometheus registry and HTTP instrumentation collectors
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+	panics   prometheus.Counter
+	timeouts prometheus.Counter
+	conns    *prometheus.GaugeVec
+	states   sync.Map // net.Conn -> http.ConnState
+}
+
+// New creates a registry with Go runtime, process and HTTP request collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests by method, route, status and API version.",
+		}, []string{"method", "path", "status", "version"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route and API version.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "path", "version"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served.",
+		}),
+		panics: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "panics_total",
+			Help: "Total number of panics recovered while serving HTTP requests.",
+		}),
+		timeouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "http_request_timeouts_total",
+			Help: "Total number of HTTP requests that exceeded the request timeout.",
+		}),
+		conns: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_connections",
+			Help: "Number of open HTTP connections by state (new, active, idle).",
+		}, []string{"state"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.inFlight,
+		m.panics,
+		m.timeouts,
+		m.conns,
+	)
+
+	return m
+}
+
+// Registry returns the registry so other components can add their own collectors
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// PanicRecovered counts a panic recovered by the HTTP recovery middleware
+func (m *Metrics) PanicRecovered() {
+	m.panics.Inc()
+}
+
+// RequestTimedOut counts a request cancelled by the request timeout middleware
+func (m *Metrics) RequestTimedOut() {
+	m.timeouts.Inc()
+}
+
+// ConnState is an http.Server ConnState hook tracking open connections by state
+func (m *Metrics) ConnState(conn net.Conn, state http.ConnState) {
+	if prev, ok := m.states.Load(conn); ok {
+		m.conns.WithLabelValues(prev.(http.ConnState).String()).Dec()
+	}
+	if state == http.StateHijacked || state == http.StateClosed {
+		m.states.Delete(conn)
+		return
+	}
+	m.states.Store(conn, state)
+	m.conns.WithLabelValues(state.String()).Inc()
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RouteLabel returns the chi route pattern that matched r, such as /api/v1/reps/{id}, or
+// "unmatched" when no route did. Call it after the request has been routed.
+func RouteLabel(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
+
+// versionLabel returns the API version, or "none" for unversioned routes
+func versionLabel(version string) string {
+	if version == "" {
+		return "none"
+	}
+	return version
+}
+
+// Middleware records request count, latency and in-flight requests.
+// The path label is the chi route pattern so concrete IDs don't explode label cardinality.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		start := time.Now()
+		r, version := apiversion.Track(r)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		path, v := RouteLabel(r), versionLabel(version())
+		m.requests.WithLabelValues(r.Method, path, strconv.Itoa(status), v).Inc()
+		m.duration.WithLabelValues(r.Method, path, v).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// scrape returns the exposition text served by m
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scrape status = %d, want 200", rec.Code)
+	}
+	return rec.Body.String()
+}
+
+func TestMiddlewareCountsRequests(t *testing.T) {
+	m := New()
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/reps/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/missing", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) })
+
+	for _, path := range []string{"/reps/1", "/reps/2", "/missing", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`http_requests_total{method="GET",path="/reps/{id}",status="200",version="none"} 2`,
+		`http_requests_total{method="GET",path="/missing",status="404",version="none"} 1`,
+		`http_requests_total{method="GET",path="unmatched",status="404",version="none"} 1`,
+		`http_request_duration_seconds_count{method="GET",path="/reps/{id}",version="none"} 2`,
+		`http_requests_in_flight 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape is missing %s", want)
+		}
+	}
+	if strings.Contains(body, `path="/reps/1"`) {
+		t.Error("raw URL used as the path label")
+	}
+}
+
+func TestCounters(t *testing.T) {
+	m := New()
+	m.PanicRecovered()
+	m.RequestTimedOut()
+	m.RequestTimedOut()
+
+	body := scrape(t, m)
+	for _, want := range []string{"panics_total 1", "http_request_timeouts_total 2"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape is missing %s", want)
+		}
+	}
+}
+
+func TestConnState(t *testing.T) {
+	m := New()
+	a, b := &net.TCPConn{}, &net.TCPConn{}
+
+	m.ConnState(a, http.StateNew)
+	m.ConnState(b, http.StateNew)
+	m.ConnState(a, http.StateActive)
+	m.ConnState(b, http.StateActive)
+	m.ConnState(b, http.StateIdle)
+
+	body := scrape(t, m)
+	for _, want := range []string{`http_connections{state="new"} 0`, `http_connections{state="active"} 1`, `http_connections{state="idle"} 1`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape is missing %s", want)
+		}
+	}
+
+	m.ConnState(a, http.StateClosed)
+	m.ConnState(b, http.StateClosed)
+	body = scrape(t, m)
+	if !strings.Contains(body, `http_connections{state="active"} 0`) || !strings.Contains(body, `http_connections{state="idle"} 0`) {
+		t.Errorf("closed connections still counted:\n%s", body)
+	}
+}
+
+func TestRouteLabel(t *testing.T) {
+	var got string
+	r := chi.NewRouter()
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/reps/{id}", func(w http.ResponseWriter, r *http.Request) { got = RouteLabel(r) })
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/reps/42", nil))
+	if got != "/api/v1/reps/{id}" {
+		t.Errorf("RouteLabel() = %q, want the nested pattern", got)
+	}
+
+	if got := RouteLabel(httptest.NewRequest(http.MethodGet, "/api/v1/reps/42", nil)); got != "unmatched" {
+		t.Errorf("RouteLabel() before routing = %q, want unmatched", got)
+	}
+}
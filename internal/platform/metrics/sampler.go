@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+// PoolStats is a snapshot of a connection pool
+type PoolStats struct {
+	Open      int
+	InUse     int
+	Idle      int
+	WaitCount int64
+}
+
+// PoolSource returns the current stats of a named connection pool
+type PoolSource struct {
+	Name  string
+	Stats func() PoolStats
+}
+
+// Sampler records runtime and connection pool stats into gauges on a fixed interval, so
+// short-lived spikes between scrapes still move a gauge that a scrape can catch close to
+// its peak, and optionally logs each sample at debug level
+type Sampler struct {
+	interval time.Duration
+	pools    []PoolSource
+	log      *logger.Logger
+
+	heapAlloc   prometheus.Gauge
+	heapObjects prometheus.Gauge
+	goroutines  prometheus.Gauge
+	gcCycles    prometheus.Gauge
+	gcPause     prometheus.Gauge
+	poolConns   *prometheus.GaugeVec
+	poolWaits   *prometheus.GaugeVec
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSampler registers the sampled gauges with m. log is nil unless samples should be logged.
+func (m *Metrics) NewSampler(interval time.Duration, log *logger.Logger, pools ...PoolSource) *Sampler {
+	s := &Sampler{
+		interval: interval,
+		pools:    pools,
+		log:      log,
+		heapAlloc: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "runtime_sampled_heap_alloc_bytes",
+			Help: "Bytes of allocated heap objects at the last sample.",
+		}),
+		heapObjects: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "runtime_sampled_heap_objects",
+			Help: "Number of allocated heap objects at the last sample.",
+		}),
+		goroutines: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "runtime_sampled_goroutines",
+			Help: "Number of goroutines at the last sample.",
+		}),
+		gcCycles: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "runtime_sampled_gc_cycles",
+			Help: "Number of completed GC cycles at the last sample.",
+		}),
+		gcPause: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "runtime_sampled_gc_last_pause_seconds",
+			Help: "Duration of the most recent GC stop-the-world pause at the last sample.",
+		}),
+		poolConns: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "pool_connections",
+			Help: "Connections of the database and Redis pools by state (open, in_use, idle) at the last sample.",
+		}, []string{"pool", "state"}),
+		poolWaits: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "pool_waits",
+			Help: "Total number of times a caller waited for a pool connection, at the last sample.",
+		}, []string{"pool"}),
+	}
+
+	m.registry.MustRegister(s.heapAlloc, s.heapObjects, s.goroutines, s.gcCycles, s.gcPause, s.poolConns, s.poolWaits)
+	return s
+}
+
+// Start samples immediately and then every interval until Stop
+func (s *Sampler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.sample()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop ends sampling, waiting for an in-progress sample until ctx is done
+func (s *Sampler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Sampler) sample() {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	goroutines := runtime.NumGoroutine()
+	lastPause := time.Duration(mem.PauseNs[(mem.NumGC+255)%256])
+
+	s.heapAlloc.Set(float64(mem.HeapAlloc))
+	s.heapObjects.Set(float64(mem.HeapObjects))
+	s.goroutines.Set(float64(goroutines))
+	s.gcCycles.Set(float64(mem.NumGC))
+	s.gcPause.Set(lastPause.Seconds())
+
+	pools := make(map[string]PoolStats, len(s.pools))
+	for _, pool := range s.pools {
+		stats := pool.Stats()
+		pools[pool.Name] = stats
+		s.poolConns.WithLabelValues(pool.Name, "open").Set(float64(stats.Open))
+		s.poolConns.WithLabelValues(pool.Name, "in_use").Set(float64(stats.InUse))
+		s.poolConns.WithLabelValues(pool.Name, "idle").Set(float64(stats.Idle))
+		s.poolWaits.WithLabelValues(pool.Name).Set(float64(stats.WaitCount))
+	}
+
+	if s.log != nil {
+		s.log.Debug("Runtime stats",
+			"heap_alloc_bytes", mem.HeapAlloc,
+			"heap_objects", mem.HeapObjects,
+			"goroutines", goroutines,
+			"gc_cycles", mem.NumGC,
+			"gc_last_pause", lastPause,
+			"pools", pools,
+		)
+	}
+}
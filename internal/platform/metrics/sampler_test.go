@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+func TestSamplerUpdatesGauges(t *testing.T) {
+	m := New()
+	log, logs := logtest.New(t)
+
+	var samples atomic.Int32
+	pool := PoolSource{Name: "database", Stats: func() PoolStats {
+		n := int(samples.Add(1))
+		return PoolStats{Open: 3, InUse: n, Idle: 3 - n, WaitCount: 7}
+	}}
+	s := m.NewSampler(10*time.Millisecond, log, pool)
+	s.Start()
+
+	// The first sample is taken right away and the next ones on the interval
+	deadline := time.Now().Add(5 * time.Second)
+	for samples.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("sampled %d times in 5s, want at least 3", samples.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+	stopped := samples.Load()
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`pool_connections{pool="database",state="open"} 3`,
+		`pool_waits{pool="database"} 7`,
+		"runtime_sampled_heap_alloc_bytes ",
+		"runtime_sampled_gc_cycles ",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape is missing %s", want)
+		}
+	}
+	if strings.Contains(body, "runtime_sampled_goroutines 0\n") {
+		t.Error("the goroutine gauge was not updated")
+	}
+	if entry, ok := logs.Find("Runtime stats"); !ok || entry["goroutines"] == float64(0) {
+		t.Errorf("sample log = %v, want the goroutine count logged at debug", entry)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if n := samples.Load(); n != stopped {
+		t.Errorf("sampled %d more times after Stop", n-stopped)
+	}
+}
+
+func TestSamplerWithoutLog(t *testing.T) {
+	s := New().NewSampler(time.Hour, nil)
+
+	// Stopping a sampler that never started is a no-op
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() before Start = %v", err)
+	}
+
+	s.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sweepInterval controls how often idle buckets are pruned
+const sweepInterval = time.Minute
+
+// MemoryLimiter is a per-process token bucket limiter
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	limits    atomic.Pointer[limits]
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemoryLimiter creates a limiter refilling rate tokens per second up to burst
+func NewMemoryLimiter(rate float64, burst int) *MemoryLimiter {
+	l := &MemoryLimiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+	l.SetLimits(rate, burst)
+	return l
+}
+
+// SetLimits changes the refill rate and burst. Buckets refill at the new rate from their
+// next request and are capped at the new burst.
+func (l *MemoryLimiter) SetLimits(rate float64, burst int) {
+	l.limits.Store(&limits{rate: rate, burst: burst})
+}
+
+// Allow takes a token from the key's bucket if one is available
+func (l *MemoryLimiter) Allow(_ context.Context, key string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	lim := l.limits.Load()
+	l.sweep(now, lim)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(lim.burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(lim.burst), b.tokens+now.Sub(b.last).Seconds()*lim.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, nil
+	}
+
+	b.tokens--
+	return true, nil
+}
+
+// sweep drops buckets that would be full again, keeping memory bounded
+func (l *MemoryLimiter) sweep(now time.Time, lim *limits) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*lim.rate >= float64(lim.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// allowN returns how many of n requests for key the limiter allows
+func allowN(t *testing.T, l Limiter, key string, n int) int {
+	t.Helper()
+	allowed := 0
+	for range n {
+		ok, err := l.Allow(context.Background(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestMemoryLimiterBurstAndRefill(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewMemoryLimiter(2, 4)
+	limiter.now = func() time.Time { return now }
+
+	if got := allowN(t, limiter, "key", 6); got != 4 {
+		t.Fatalf("allowed %d of 6, want the burst of 4", got)
+	}
+	if got := allowN(t, limiter, "other", 1); got != 1 {
+		t.Fatal("another key was limited by the first one's requests")
+	}
+
+	now = now.Add(time.Second)
+	if got := allowN(t, limiter, "key", 4); got != 2 {
+		t.Errorf("allowed %d after 1s at 2/s, want 2", got)
+	}
+}
+
+func TestMemoryLimiterSetLimits(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewMemoryLimiter(1, 10)
+	limiter.now = func() time.Time { return now }
+
+	if got := allowN(t, limiter, "key", 3); got != 3 {
+		t.Fatalf("allowed %d of 3, want all", got)
+	}
+
+	// Lowering the burst caps the existing bucket instead of resetting it
+	limiter.SetLimits(1, 2)
+	if got := allowN(t, limiter, "key", 5); got != 2 {
+		t.Fatalf("allowed %d after lowering the burst to 2, want 2", got)
+	}
+
+	// Raising the rate refills at the new rate from the next request
+	limiter.SetLimits(5, 20)
+	now = now.Add(time.Second)
+	if got := allowN(t, limiter, "key", 10); got != 5 {
+		t.Errorf("allowed %d after 1s at 5/s, want 5", got)
+	}
+
+	// New keys start from the new burst
+	if got := allowN(t, limiter, "new", 25); got != 20 {
+		t.Errorf("allowed %d for a new key, want the new burst of 20", got)
+	}
+}
+
+func TestMemoryLimiterSetLimitsConcurrently(t *testing.T) {
+	limiter := NewMemoryLimiter(1000, 1000)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 100 {
+			limiter.SetLimits(float64(100+i), 100+i)
+		}
+	}()
+	allowN(t, limiter, "key", 200)
+	<-done
+}
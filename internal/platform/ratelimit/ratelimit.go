@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+// Limiter decides whether a request identified by key may proceed
+type Limiter interface {
+	Allow(ctx context.Context, key string) (bool, error)
+}
+
+// Adjustable is a limiter whose rate and burst can be changed while serving. Existing
+// keys keep their state and follow the new limits from their next request.
+type Adjustable interface {
+	Limiter
+	SetLimits(rate float64, burst int)
+}
+
+// limits is the rate (tokens per second) and burst a limiter enforces
+type limits struct {
+	rate  float64
+	burst int
+}
+
+// failureLogInterval is how often a limiter that keeps failing is logged again
+const failureLogInterval = time.Minute
+
+// Middleware rejects requests over the limit with 429, keyed by client IP and route pattern,
+// so /users/1 and /users/2 share a bucket. Limiter errors fail open so an unavailable store
+// never blocks traffic; they are logged once, then at most once per failureLogInterval.
+// The 429 body matches the respond.Error envelope.
+func Middleware(limiter Limiter, log *logger.Logger) func(http.Handler) http.Handler {
+	failures := &failureLog{log: log, interval: failureLogInterval}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := clientIP(r) + ":" + r.Method + ":" + routePattern(r)
+
+			allowed, err := limiter.Allow(r.Context(), key)
+			failures.observe(time.Now(), err)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !allowed {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"error": map[string]string{"code": "rate_limited", "message": "rate limit exceeded"},
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// failureLog logs limiter failures without flooding the log while the store stays down: the
+// first failure, a reminder with the number of requests allowed unchecked at most once per
+// interval, and the recovery
+type failureLog struct {
+	log      *logger.Logger
+	interval time.Duration
+
+	failing     atomic.Bool
+	mu          sync.Mutex
+	failures    int
+	failingFrom time.Time
+	lastLogged  time.Time
+}
+
+func (f *failureLog) observe(now time.Time, err error) {
+	// Keep the common case, a healthy limiter, off the mutex
+	if err == nil && !f.failing.Load() {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		if f.failures > 0 {
+			f.log.Info("Rate limiter recovered",
+				"unchecked_requests", f.failures,
+				"failing_for", now.Sub(f.failingFrom),
+			)
+		}
+		f.failures = 0
+		f.failing.Store(false)
+		return
+	}
+
+	f.failures++
+	switch {
+	case f.failures == 1:
+		f.failing.Store(true)
+		f.failingFrom, f.lastLogged = now, now
+		f.log.Warn("Rate limiter unavailable, allowing requests", "error", err)
+	case now.Sub(f.lastLogged) >= f.interval:
+		f.lastLogged = now
+		f.log.Warn("Rate limiter still unavailable, allowing requests",
+			"unchecked_requests", f.failures,
+			"failing_for", now.Sub(f.failingFrom),
+			"error", err,
+		)
+	}
+}
+
+// clientIP returns the host part of the request's remote address
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// routePattern returns the chi route pattern matching r, such as /users/{id}. Mounted as
+// router middleware it runs before routing, so the pattern is looked up in the routing tree.
+// Requests matching no route share the "unmatched" bucket, which keeps the key space bounded.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return "unmatched"
+	}
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern
+	}
+	if rctx.Routes != nil {
+		path := r.URL.RawPath
+		if path == "" {
+			path = r.URL.Path
+		}
+		if pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, path); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
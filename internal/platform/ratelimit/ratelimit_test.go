@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rixtrayker/medical-rep/internal/app/respond"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// recordingLimiter allows every request and records the keys it was asked about
+type recordingLimiter struct {
+	keys []string
+	err  error
+}
+
+func (l *recordingLimiter) Allow(_ context.Context, key string) (bool, error) {
+	l.keys = append(l.keys, key)
+	return l.err == nil, l.err
+}
+
+func TestMiddlewareKeysByRoutePattern(t *testing.T) {
+	limiter := &recordingLimiter{}
+	r := chi.NewRouter()
+	r.Use(Middleware(limiter, logtest.Discard(t)))
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	for _, path := range []string{"/api/users/1", "/api/users/2", "/nowhere/1", "/nowhere/2"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := []string{
+		"10.0.0.1:GET:/api/users/{id}",
+		"10.0.0.1:GET:/api/users/{id}",
+		"10.0.0.1:GET:unmatched",
+		"10.0.0.1:GET:unmatched",
+	}
+	if len(limiter.keys) != len(want) {
+		t.Fatalf("keys = %v, want %v", limiter.keys, want)
+	}
+	for i := range want {
+		if limiter.keys[i] != want[i] {
+			t.Errorf("key %d = %q, want %q", i, limiter.keys[i], want[i])
+		}
+	}
+}
+
+func TestMiddlewareRejectsOverLimit(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware(NewMemoryLimiter(1, 1), logtest.Discard(t)))
+	r.Get("/reps/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	first := httptest.NewRecorder()
+	r.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/reps/1", nil))
+	if first.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", first.Code)
+	}
+
+	// Another ID of the same route shares the bucket
+	second := httptest.NewRecorder()
+	r.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/reps/2", nil))
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", second.Code)
+	}
+	if got := second.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(second.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", second.Body.String(), err)
+	}
+	if body.Error.Code != "rate_limited" || body.Error.Message == "" {
+		t.Errorf("body = %s, want the rate_limited error envelope", second.Body.String())
+	}
+}
+
+func TestRejectionMatchesErrorEnvelope(t *testing.T) {
+	handler := Middleware(NewMemoryLimiter(1, 1), logtest.Discard(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	limited := httptest.NewRecorder()
+	handler.ServeHTTP(limited, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := httptest.NewRecorder()
+	respond.Error(want, http.StatusTooManyRequests, "rate_limited", "rate limit exceeded")
+	if limited.Code != want.Code || limited.Body.String() != want.Body.String() {
+		t.Errorf("429 = %d %q, want respond.Error's %d %q", limited.Code, limited.Body, want.Code, want.Body)
+	}
+	if got, want := limited.Header().Get("Content-Type"), want.Header().Get("Content-Type"); got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+}
+
+func TestMiddlewareFailsOpen(t *testing.T) {
+	limiter := &recordingLimiter{err: errors.New("redis down")}
+	log, logs := logtest.New(t)
+	handler := Middleware(limiter, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for range 5 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 when the limiter fails", rec.Code)
+		}
+	}
+	if n := logs.Count("Rate limiter unavailable, allowing requests"); n != 1 {
+		t.Errorf("logged the failure %d times for 5 requests, want once", n)
+	}
+
+	limiter.err = nil
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if entry, ok := logs.Find("Rate limiter recovered"); !ok || entry["unchecked_requests"] != float64(5) {
+		t.Errorf("recovery log = %v, want 5 unchecked requests", entry)
+	}
+}
+
+func TestFailureLogInterval(t *testing.T) {
+	log, logs := logtest.New(t)
+	f := &failureLog{log: log, interval: time.Minute}
+	start := time.Unix(1_700_000_000, 0)
+	errDown := errors.New("redis down")
+
+	for i := range 10 {
+		f.observe(start.Add(time.Duration(i)*10*time.Second), errDown)
+	}
+	if n := logs.Count("Rate limiter unavailable, allowing requests"); n != 1 {
+		t.Errorf("first failure logged %d times, want once", n)
+	}
+	// 90s in: one reminder after the first minute
+	if n := logs.Count("Rate limiter still unavailable, allowing requests"); n != 1 {
+		t.Errorf("reminders = %d after 90s, want 1", n)
+	}
+}
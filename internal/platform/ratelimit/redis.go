@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+// slidingWindowScript counts requests in a sorted set scored by timestamp (microseconds).
+// Entries older than the window are trimmed before counting, so the window slides with time.
+var slidingWindowScript = goredis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+if redis.call('ZCARD', key) >= limit then
+	return 0
+end
+
+redis.call('ZADD', key, now, ARGV[4])
+redis.call('PEXPIRE', key, math.ceil(window / 1000))
+return 1
+`)
+
+// RedisLimiter is a sliding window limiter shared by every instance using the same Redis
+type RedisLimiter struct {
+	client *redis.Client
+	window atomic.Pointer[window]
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisLimiter creates a limiter allowing burst requests per burst/rate seconds,
+// matching the long-run rate of the in-memory token bucket
+func NewRedisLimiter(client *redis.Client, rate float64, burst int) *RedisLimiter {
+	l := &RedisLimiter{
+		client: client,
+		prefix: "ratelimit:",
+		now:    time.Now,
+	}
+	l.SetLimits(rate, burst)
+	return l
+}
+
+// window is the sliding window limit derived from a rate and burst
+type window struct {
+	limit  int
+	length time.Duration
+}
+
+// SetLimits changes the window for subsequent requests. Requests already recorded count
+// against the new window, so keys adapt without being reset.
+func (l *RedisLimiter) SetLimits(rate float64, burst int) {
+	l.window.Store(&window{
+		limit:  burst,
+		length: time.Duration(float64(burst) / rate * float64(time.Second)),
+	})
+}
+
+// Allow records the request and reports whether the key is still within its window limit
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	now := l.now().UnixMicro()
+	w := l.window.Load()
+	member := fmt.Sprintf("%d-%d", now, rand.Uint64())
+
+	res, err := l.client.RunScript(ctx, slidingWindowScript, []string{l.prefix + key},
+		now, w.length.Microseconds(), w.limit, member)
+	if err != nil {
+		return false, fmt.Errorf("rate limit script failed: %w", err)
+	}
+
+	allowed, ok := res.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected rate limit script result %T", res)
+	}
+
+	return allowed == 1, nil
+}
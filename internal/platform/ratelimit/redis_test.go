@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+func TestRedisLimiterSharedAcrossInstances(t *testing.T) {
+	client, server := redistest.New(t)
+	now := time.Unix(1_700_000_000, 0)
+	clock := func() time.Time { return now }
+
+	// Two instances of the service, each with its own client, sharing one Redis
+	first := NewRedisLimiter(client, 3, 3)
+	second := NewRedisLimiter(redistest.Connect(t, server), 3, 3)
+	first.now, second.now = clock, clock
+
+	ctx := context.Background()
+	for i, limiter := range []*RedisLimiter{first, second, first} {
+		allowed, err := limiter.Allow(ctx, "10.0.0.1:GET:/api/v1/reps")
+		if err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+		if !allowed {
+			t.Fatalf("request %d was rejected within the limit", i+1)
+		}
+	}
+
+	for _, limiter := range []*RedisLimiter{first, second} {
+		allowed, err := limiter.Allow(ctx, "10.0.0.1:GET:/api/v1/reps")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed {
+			t.Fatal("request over the shared limit was allowed")
+		}
+	}
+
+	allowed, err := second.Allow(ctx, "10.0.0.2:GET:/api/v1/reps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !allowed {
+		t.Fatal("another client was limited by the first one's requests")
+	}
+}
+
+func TestRedisLimiterWindowSlides(t *testing.T) {
+	client, _ := redistest.New(t)
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewRedisLimiter(client, 2, 2)
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	allow := func() bool {
+		t.Helper()
+		allowed, err := limiter.Allow(ctx, "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return allowed
+	}
+
+	if !allow() || !allow() {
+		t.Fatal("requests within the limit were rejected")
+	}
+	if allow() {
+		t.Fatal("request over the limit was allowed")
+	}
+
+	// The window is burst/rate = 1s long
+	now = now.Add(1001 * time.Millisecond)
+	if !allow() {
+		t.Fatal("request was rejected after the window passed")
+	}
+}
+
+func TestRedisLimiterSetLimits(t *testing.T) {
+	client, _ := redistest.New(t)
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewRedisLimiter(client, 5, 5)
+	limiter.now = func() time.Time { return now }
+
+	if got := allowN(t, limiter, "key", 3); got != 3 {
+		t.Fatalf("allowed %d of 3, want all", got)
+	}
+
+	// Requests already recorded count against the lowered limit
+	limiter.SetLimits(4, 4)
+	if got := allowN(t, limiter, "key", 3); got != 1 {
+		t.Fatalf("allowed %d after lowering the limit to 4, want 1", got)
+	}
+
+	limiter.SetLimits(8, 8)
+	if got := allowN(t, limiter, "key", 6); got != 4 {
+		t.Errorf("allowed %d after raising the limit to 8, want 4", got)
+	}
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+// loggingHook logs failed commands with the request ID so they can be matched to the request
+type loggingHook struct {
+	log *logger.Logger
+}
+
+func (h loggingHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h loggingHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		err := next(ctx, cmd)
+		h.logError(ctx, cmd.Name(), err)
+		return err
+	}
+}
+
+func (h loggingHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		err := next(ctx, cmds)
+		if err != nil {
+			for _, cmd := range cmds {
+				h.logError(ctx, cmd.Name(), cmd.Err())
+			}
+		}
+		return err
+	}
+}
+
+// logError logs err unless it is a cache miss; ping failures are left to health checks, and
+// CLIENT commands are the connection handshake, whose failures on servers before Redis 7.2
+// go-redis ignores
+func (h loggingHook) logError(ctx context.Context, command string, err error) {
+	if err == nil || errors.Is(err, goredis.Nil) || command == "ping" || command == "client" {
+		return
+	}
+	h.log.Error("Redis command failed", "command", command, "request_id", requestid.FromContext(ctx), "error", err)
+}
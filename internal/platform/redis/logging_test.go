@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/requestid"
+)
+
+func TestFailedCommandsAreLoggedWithRequestID(t *testing.T) {
+	server := miniredis.RunT(t)
+	port, _ := strconv.Atoi(server.Port())
+	log, logs := logtest.New(t)
+	client, err := New(context.Background(), configs.RedisConfig{
+		Host:         server.Host(),
+		Port:         port,
+		DialTimeout:  time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := requestid.NewContext(context.Background(), "req-123")
+
+	// A cache miss is not a failure
+	if _, err := client.Get(ctx, "missing"); err == nil {
+		t.Fatal("Get() of a missing key succeeded")
+	}
+	if logs.Count("Redis command failed") != 0 {
+		t.Fatalf("a cache miss was logged as a failure: %v", logs.Entries())
+	}
+
+	server.Set("rep:1", "not a set")
+	if _, err := client.SIsMember(ctx, "rep:1", "x"); err == nil {
+		t.Fatal("SIsMember() on a string succeeded")
+	}
+	entry, ok := logs.Find("Redis command failed")
+	if !ok {
+		t.Fatal("the failed command was not logged")
+	}
+	if entry["request_id"] != "req-123" || entry["command"] != "sismember" {
+		t.Errorf("log entry = %v, want sismember with request ID req-123", entry)
+	}
+}
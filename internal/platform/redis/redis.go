@@ -0,0 +1,255 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+// ErrNotFound is returned when a key does not exist
+var ErrNotFound = errors.New("redis: key not found")
+
+// Client wraps the go-redis client used across the application. Every method returns the
+// context's error without a round trip once it is done, and bounds contexts without a deadline
+// by the configured read or write timeout.
+type Client struct {
+	client       *goredis.Client
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+// New creates a new Redis client and verifies the connection within ctx and the dial timeout.
+// Command failures are logged with the request ID carried by the context.
+func New(ctx context.Context, cfg configs.RedisConfig, log *logger.Logger) (*Client, error) {
+	opts, err := Options(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client := goredis.NewClient(opts)
+	client.AddHook(newTracingHook())
+	if log != nil {
+		client.AddHook(loggingHook{log: log})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.DialTimeout)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &Client{client: client, readTimeout: cfg.ReadTimeout, writeTimeout: cfg.WriteTimeout}, nil
+}
+
+// Options builds the go-redis client options from cfg, including the ACL username and TLS
+func Options(cfg configs.RedisConfig) (*goredis.Options, error) {
+	opts := &goredis.Options{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		DB:           cfg.Database,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := newTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	return opts, nil
+}
+
+// newTLSConfig verifies the server against CAFile when set, or the system roots otherwise
+func newTLSConfig(cfg configs.RedisConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.TLS.ServerName,
+		InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = cfg.Host
+	}
+
+	if cfg.TLS.CAFile != "" {
+		pem, err := os.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificates found in redis CA file %s", cfg.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// Ping checks the connection to Redis
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel, err := c.withTimeout(ctx, c.readTimeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return c.client.Ping(ctx).Err()
+}
+
+// Get returns the value stored at key, or ErrNotFound
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	ctx, cancel, err := c.withTimeout(ctx, c.readTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+
+	value, err := c.client.Get(ctx, key).Result()
+	if errors.Is(err, goredis.Nil) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+// GetEx returns the value stored at key and resets its TTL, or ErrNotFound
+func (c *Client) GetEx(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	ctx, cancel, err := c.withTimeout(ctx, c.writeTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+
+	value, err := c.client.GetEx(ctx, key, ttl).Result()
+	if errors.Is(err, goredis.Nil) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+// Set stores value at key with the given TTL (zero means no expiry)
+func (c *Client) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	ctx, cancel, err := c.withTimeout(ctx, c.writeTimeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// SetNX stores value at key only if it does not exist yet and reports whether it was set
+func (c *Client) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	ctx, cancel, err := c.withTimeout(ctx, c.writeTimeout)
+	if err != nil {
+		return false, err
+	}
+	defer cancel()
+
+	return c.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// Del removes the keys and returns how many existed
+func (c *Client) Del(ctx context.Context, keys ...string) (int64, error) {
+	ctx, cancel, err := c.withTimeout(ctx, c.writeTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer cancel()
+
+	return c.client.Del(ctx, keys...).Result()
+}
+
+// Exists returns how many of the keys exist
+func (c *Client) Exists(ctx context.Context, keys ...string) (int64, error) {
+	ctx, cancel, err := c.withTimeout(ctx, c.readTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer cancel()
+
+	return c.client.Exists(ctx, keys...).Result()
+}
+
+// SIsMember reports whether member is in the set at key
+func (c *Client) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	ctx, cancel, err := c.withTimeout(ctx, c.readTimeout)
+	if err != nil {
+		return false, err
+	}
+	defer cancel()
+
+	return c.client.SIsMember(ctx, key, member).Result()
+}
+
+// ZAdd adds member to the sorted set at key with the given score
+func (c *Client) ZAdd(ctx context.Context, key string, score float64, member string) error {
+	ctx, cancel, err := c.withTimeout(ctx, c.writeTimeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return c.client.ZAdd(ctx, key, goredis.Z{Score: score, Member: member}).Err()
+}
+
+// ZRem removes members from the sorted set at key
+func (c *Client) ZRem(ctx context.Context, key string, members ...interface{}) error {
+	ctx, cancel, err := c.withTimeout(ctx, c.writeTimeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return c.client.ZRem(ctx, key, members...).Err()
+}
+
+// RunScript executes a Lua script, loading it into the script cache when needed
+func (c *Client) RunScript(ctx context.Context, script *goredis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	ctx, cancel, err := c.withTimeout(ctx, c.writeTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	return script.Run(ctx, c.client, keys, args...).Result()
+}
+
+// withTimeout returns ctx's error when it is already done, sparing a round trip, and otherwise
+// bounds a context without a deadline by timeout
+func (c *Client) withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
+
+// PoolStats returns connection pool statistics
+func (c *Client) PoolStats() *goredis.PoolStats {
+	return c.client.PoolStats()
+}
+
+// Close closes the Redis connection pool
+func (c *Client) Close() error {
+	return c.client.Close()
+}
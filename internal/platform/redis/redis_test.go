@@ -0,0 +1,248 @@
+package redis
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// selfSigned returns a self-signed certificate for 127.0.0.1 and the path of its PEM file
+func selfSigned(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "redis.internal"},
+		DNSNames:              []string{"redis.internal"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, path
+}
+
+func TestOptions(t *testing.T) {
+	_, caFile := selfSigned(t)
+	cfg := configs.RedisConfig{Host: "redis.internal", Port: 6380, Username: "svc-reps", Password: "secret"}
+
+	opts, err := Options(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Addr != "redis.internal:6380" || opts.Username != "svc-reps" || opts.Password != "secret" {
+		t.Errorf("options = %s as %s/%s, want redis.internal:6380 as svc-reps/secret", opts.Addr, opts.Username, opts.Password)
+	}
+	if opts.TLSConfig != nil {
+		t.Error("TLS configured without redis.tls.enabled")
+	}
+
+	cfg.TLS = configs.RedisTLSConfig{Enabled: true, CAFile: caFile}
+	opts, err = Options(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig := opts.TLSConfig
+	if tlsConfig == nil {
+		t.Fatal("TLS not configured with redis.tls.enabled")
+	}
+	if tlsConfig.ServerName != "redis.internal" || tlsConfig.RootCAs == nil || tlsConfig.InsecureSkipVerify {
+		t.Errorf("TLS config = server %q, roots set %v, insecure %v; want the host verified against the CA",
+			tlsConfig.ServerName, tlsConfig.RootCAs != nil, tlsConfig.InsecureSkipVerify)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x, want TLS 1.2", tlsConfig.MinVersion)
+	}
+
+	cfg.TLS = configs.RedisTLSConfig{Enabled: true, ServerName: "cache.example.com", InsecureSkipVerify: true}
+	opts, err = Options(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.TLSConfig.ServerName != "cache.example.com" || !opts.TLSConfig.InsecureSkipVerify || opts.TLSConfig.RootCAs != nil {
+		t.Errorf("TLS config = %+v, want the overrides and the system roots", opts.TLSConfig)
+	}
+}
+
+func TestOptionsRejectsBadCAFile(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(invalid, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		filepath.Join(t.TempDir(), "missing.pem"): "failed to read redis CA file",
+		invalid: "no valid certificates",
+	}
+	for file, want := range tests {
+		_, err := Options(configs.RedisConfig{TLS: configs.RedisTLSConfig{Enabled: true, CAFile: file}})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Options(%s) = %v, want %q", filepath.Base(file), err, want)
+		}
+	}
+}
+
+func TestNewConnectsWithTLSAndUsername(t *testing.T) {
+	cert, caFile := selfSigned(t)
+	server := miniredis.NewMiniRedis()
+	server.RequireUserAuth("svc-reps", "secret")
+	if err := server.StartTLS(&tls.Config{Certificates: []tls.Certificate{cert}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	port, _ := strconv.Atoi(server.Port())
+
+	cfg := configs.RedisConfig{
+		Host:        server.Host(),
+		Port:        port,
+		Username:    "svc-reps",
+		Password:    "secret",
+		DialTimeout: time.Second,
+		TLS:         configs.RedisTLSConfig{Enabled: true, CAFile: caFile},
+	}
+	client, err := New(context.Background(), cfg, logtest.Discard(t))
+	if err != nil {
+		t.Fatalf("New over TLS with an ACL user: %v", err)
+	}
+	client.Close()
+
+	cfg.Username = "default"
+	if _, err := New(context.Background(), cfg, logtest.Discard(t)); err == nil {
+		t.Error("New succeeded with the wrong ACL user")
+	}
+
+	cfg.Username = "svc-reps"
+	cfg.TLS.CAFile = ""
+	if _, err := New(context.Background(), cfg, logtest.Discard(t)); err == nil {
+		t.Error("New trusted a certificate from an unknown CA")
+	}
+}
+
+// newClient connects to server with the given read and write timeouts
+func newClient(t *testing.T, server *miniredis.Miniredis, read, write time.Duration) *Client {
+	t.Helper()
+	port, _ := strconv.Atoi(server.Port())
+	client, err := New(context.Background(), configs.RedisConfig{
+		Host:         server.Host(),
+		Port:         port,
+		DialTimeout:  time.Second,
+		ReadTimeout:  read,
+		WriteTimeout: write,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestDoneContextSkipsRoundTrip(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := newClient(t, server, time.Second, time.Second)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	for ctx, want := range map[context.Context]error{cancelled: context.Canceled, expired: context.DeadlineExceeded} {
+		before := server.CommandCount()
+		calls := map[string]error{
+			"Ping":      client.Ping(ctx),
+			"Set":       client.Set(ctx, "k", "v", time.Minute),
+			"ZAdd":      client.ZAdd(ctx, "z", 1, "m"),
+			"ZRem":      client.ZRem(ctx, "z", "m"),
+			"Get":       second(client.Get(ctx, "k")),
+			"GetEx":     second(client.GetEx(ctx, "k", time.Minute)),
+			"SetNX":     second(client.SetNX(ctx, "k", "v", time.Minute)),
+			"Del":       second(client.Del(ctx, "k")),
+			"Exists":    second(client.Exists(ctx, "k")),
+			"SIsMember": second(client.SIsMember(ctx, "s", "m")),
+		}
+		for name, err := range calls {
+			if !errors.Is(err, want) {
+				t.Errorf("%s() = %v, want %v", name, err, want)
+			}
+		}
+		if n := server.CommandCount() - before; n != 0 {
+			t.Errorf("%d commands reached Redis with a done context", n)
+		}
+	}
+}
+
+// second returns the error of a two-valued call
+func second[T any](_ T, err error) error {
+	return err
+}
+
+func TestWithTimeout(t *testing.T) {
+	client := &Client{}
+
+	start := time.Now()
+	ctx, cancel, err := client.withTimeout(context.Background(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || deadline.Before(start.Add(time.Second)) || deadline.After(time.Now().Add(time.Second)) {
+		t.Errorf("deadline = %v (set %v), want the default timeout from now", deadline, ok)
+	}
+
+	// A caller's deadline wins, even when it is later than the default
+	callerDeadline := time.Now().Add(time.Hour)
+	caller, cancelCaller := context.WithDeadline(context.Background(), callerDeadline)
+	defer cancelCaller()
+	ctx, cancel, err = client.withTimeout(caller, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(callerDeadline) {
+		t.Errorf("deadline = %v, want the caller's %v", deadline, callerDeadline)
+	}
+
+	// No default timeout leaves the context unbounded
+	ctx, cancel, err = client.withTimeout(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("a zero timeout set a deadline")
+	}
+}
@@ -0,0 +1,50 @@
+// Package redistest runs an in-memory Redis server for tests
+package redistest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+// New starts an in-memory Redis server for the test and returns a client connected to it.
+// Both are closed when the test ends; use the server to inspect keys or fast-forward TTLs.
+func New(t testing.TB) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	return Connect(t, server), server
+}
+
+// Connect returns another client of server, as a second instance of the service would have
+func Connect(t testing.TB, server *miniredis.Miniredis) *redis.Client {
+	t.Helper()
+	port, err := strconv.Atoi(server.Port())
+	if err != nil {
+		t.Fatalf("invalid miniredis port %q: %v", server.Port(), err)
+	}
+
+	client, err := redis.New(context.Background(), Config(server.Host(), port), nil)
+	if err != nil {
+		t.Fatalf("failed to connect to miniredis: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// Config returns a Redis configuration for host and port with test-friendly timeouts
+func Config(host string, port int) configs.RedisConfig {
+	return configs.RedisConfig{
+		Host:         host,
+		Port:         port,
+		PoolSize:     10,
+		DialTimeout:  time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	}
+}
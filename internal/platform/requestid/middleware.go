@@ -0,0 +1,53 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"net/http"
+)
+
+// maxLength bounds adopted request IDs so a client cannot inflate every log line
+const maxLength = 128
+
+// Middleware stores a request ID in the request context and echoes it in the header
+// response header. When trust is set, a valid ID arriving in header (typically assigned by
+// an edge proxy) is adopted; otherwise, or when it is missing or malformed, a new one is generated
+func Middleware(header string, trust bool) func(http.Handler) http.Handler {
+	if header == "" {
+		header = Header
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(header)
+			if !trust || !Valid(id) {
+				id = New()
+			}
+
+			w.Header().Set(header, id)
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+		})
+	}
+}
+
+// New returns a random request ID
+func New() string {
+	return rand.Text()
+}
+
+// Valid reports whether id is safe to adopt: 1 to 128 letters, digits or the
+// punctuation . _ - : / + = used by common ID formats, and nothing that could break
+// log lines or headers
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == '-', c == ':', c == '/', c == '+', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,20 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Header is the default header carrying the request ID on inbound and outbound requests
+const Header = "X-Request-ID"
+
+// NewContext returns a copy of ctx carrying id, for work that does not start from an HTTP request
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, middleware.RequestIDKey, id)
+}
+
+// FromContext returns the request ID set by Middleware or NewContext, or "" if none
+func FromContext(ctx context.Context) string {
+	return middleware.GetReqID(ctx)
+}
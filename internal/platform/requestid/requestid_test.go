@@ -0,0 +1,109 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serve runs the middleware on a request with the given inbound ID and returns the ID the
+// handler saw and the one echoed in the response
+func serve(trust bool, inbound string) (seen, echoed string) {
+	handler := Middleware("", trust)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if inbound != "" {
+		req.Header.Set(Header, inbound)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return seen, rec.Header().Get(Header)
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		trust   bool
+		inbound string
+		adopted bool
+	}{
+		{"generated when missing", true, "", false},
+		{"adopted from a trusted proxy", true, "edge-7f3a:42", true},
+		{"replaced when untrusted", false, "edge-7f3a:42", false},
+		{"replaced when malformed", true, "bad id\r\nX-Injected: 1", false},
+		{"replaced when too long", true, strings.Repeat("a", 129), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen, echoed := serve(tt.trust, tt.inbound)
+			if seen == "" || seen != echoed {
+				t.Fatalf("handler saw %q, response echoed %q; want the same non-empty ID", seen, echoed)
+			}
+			if adopted := seen == tt.inbound; adopted != tt.adopted {
+				t.Errorf("ID %q adopted = %v, want %v", seen, adopted, tt.adopted)
+			}
+		})
+	}
+}
+
+func TestNewIsUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := New()
+		if !Valid(id) || seen[id] {
+			t.Fatalf("New() = %q, want a valid unused ID", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if id := FromContext(context.Background()); id != "" {
+		t.Errorf("FromContext(empty) = %q, want empty", id)
+	}
+	if id := FromContext(NewContext(context.Background(), "job-1")); id != "job-1" {
+		t.Errorf("FromContext = %q, want job-1", id)
+	}
+}
+
+func TestMiddlewareCustomHeader(t *testing.T) {
+	var seen string
+	handler := Middleware("X-Edge-Trace", true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Edge-Trace", "edge-1")
+	req.Header.Set(Header, "ignored")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if seen != "edge-1" || rec.Header().Get("X-Edge-Trace") != "edge-1" {
+		t.Errorf("saw %q, echoed %q; want edge-1 from the configured header", seen, rec.Header().Get("X-Edge-Trace"))
+	}
+	if got := rec.Header().Get(Header); got != "" {
+		t.Errorf("%s = %q, want only the configured header echoed", Header, got)
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := map[string]bool{
+		"0f8fad5b-d9cb-469f-a165-70867728950e":     true,
+		"Root=1-5759e988-bd862e3fe1be46a994272793": true,
+		"edge/7f3a+AB==":         true,
+		"":                       false,
+		"has space":              false,
+		"quote\"":                false,
+		"tab\t":                  false,
+		strings.Repeat("a", 128): true,
+		strings.Repeat("a", 129): false,
+	}
+	for id, want := range tests {
+		if got := Valid(id); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
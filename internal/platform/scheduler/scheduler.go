@@ -0,0 +1,249 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/lock"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+// ErrNotConfigured is returned by Register before SetDefault is called, e.g. when the
+// scheduler is disabled
+var ErrNotConfigured = errors.New("scheduler: no scheduler configured")
+
+// leaderLock is the lock name the instances compete for
+const leaderLock = "scheduler:leader"
+
+// job is a registered periodic task
+type job struct {
+	name     string
+	schedule cron.Schedule
+	fn       func(ctx context.Context) error
+	next     time.Time
+	running  bool
+}
+
+// Scheduler runs periodic jobs on whichever instance holds the leader lock, so each job
+// runs once per schedule across the deployment rather than once per instance
+type Scheduler struct {
+	client   *redis.Client
+	log      *logger.Logger
+	lockTTL  time.Duration
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	jobs    []*job
+	leader  *lock.Lock
+	renewAt time.Time
+
+	cancel     context.CancelFunc
+	cancelJobs context.CancelFunc
+	done       chan struct{}
+	runs       sync.WaitGroup
+}
+
+// New creates a scheduler; cfg is expected to have passed configs validation
+func New(cfg configs.SchedulerConfig, client *redis.Client, log *logger.Logger) *Scheduler {
+	return &Scheduler{
+		client:   client,
+		log:      log,
+		lockTTL:  cfg.LockTTL,
+		interval: time.Second,
+		now:      time.Now,
+	}
+}
+
+// Register adds a job run on a standard five-field cron schedule or a descriptor such as
+// "@hourly" or "@every 5m". Jobs registered after Start take effect from their next run.
+func (s *Scheduler) Register(name, spec string, fn func(ctx context.Context) error) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for job %s: %w", spec, name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.name == name {
+			return fmt.Errorf("job %s is already registered", name)
+		}
+	}
+	s.jobs = append(s.jobs, &job{name: name, schedule: schedule, fn: fn, next: schedule.Next(s.now())})
+	return nil
+}
+
+var defaultScheduler atomic.Pointer[Scheduler]
+
+// SetDefault sets the scheduler used by the package-level Register
+func SetDefault(s *Scheduler) {
+	defaultScheduler.Store(s)
+}
+
+// Register adds a job to the default scheduler; see Scheduler.Register
+func Register(name, spec string, fn func(ctx context.Context) error) error {
+	s := defaultScheduler.Load()
+	if s == nil {
+		return ErrNotConfigured
+	}
+	return s.Register(name, spec, fn)
+}
+
+// Start runs the scheduling loop until Stop is called. Jobs get their own context, which
+// Stop only cancels once they have had until its deadline to finish.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.cancelJobs = cancelJobs
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.tick(ctx, jobCtx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops scheduling, lets running jobs finish until ctx is done, then cancels the ones
+// still running and gives up leadership
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	<-s.done
+
+	finished := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(finished)
+	}()
+
+	var err error
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		err = fmt.Errorf("jobs still running: %w", ctx.Err())
+	}
+	s.cancelJobs()
+
+	s.mu.Lock()
+	leader := s.leader
+	s.leader = nil
+	s.mu.Unlock()
+	if leader != nil {
+		// Release even when ctx has expired, so the next leader need not wait out the TTL;
+		// the Redis client's own timeout bounds the call
+		if releaseErr := leader.Release(context.WithoutCancel(ctx)); releaseErr != nil && !errors.Is(releaseErr, lock.ErrNotHeld) {
+			err = errors.Join(err, releaseErr)
+		}
+	}
+	return err
+}
+
+// tick renews leadership and starts every due job with jobCtx if this instance is the leader.
+// Due jobs are rescheduled either way, so a follower does not run a backlog on promotion.
+func (s *Scheduler) tick(ctx, jobCtx context.Context) {
+	now := s.now()
+	leader := s.ensureLeadership(ctx, now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if now.Before(j.next) {
+			continue
+		}
+		j.next = j.schedule.Next(now)
+
+		switch {
+		case !leader:
+			s.log.Debug("Skipping job, not the leader", "job", j.name)
+		case j.running:
+			s.log.Warn("Skipping job, previous run still in progress", "job", j.name)
+		default:
+			j.running = true
+			s.runs.Add(1)
+			go s.run(jobCtx, j)
+		}
+	}
+}
+
+// run executes one job and records its completion
+func (s *Scheduler) run(ctx context.Context, j *job) {
+	defer s.runs.Done()
+	defer func() {
+		if rec := recover(); rec != nil {
+			s.log.Error("Job panicked", "job", j.name, "panic", rec)
+		}
+		s.mu.Lock()
+		j.running = false
+		s.mu.Unlock()
+	}()
+
+	start := s.now()
+	if err := j.fn(ctx); err != nil {
+		s.log.Error("Job failed", "job", j.name, "duration", s.now().Sub(start), "error", err)
+		return
+	}
+	s.log.Debug("Job completed", "job", j.name, "duration", s.now().Sub(start))
+}
+
+// ensureLeadership acquires or renews the leader lock and reports whether this instance holds it.
+// The lock is renewed at a third of its TTL so one missed renewal does not lose it.
+func (s *Scheduler) ensureLeadership(ctx context.Context, now time.Time) bool {
+	s.mu.Lock()
+	held, renewAt := s.leader, s.renewAt
+	s.mu.Unlock()
+
+	if held != nil && now.Before(renewAt) {
+		return true
+	}
+
+	var err error
+	if held != nil {
+		err = held.Refresh(ctx)
+		if err != nil {
+			s.log.Warn("Lost scheduler leadership", "error", err)
+			held = nil
+		}
+	} else {
+		held, err = lock.Acquire(ctx, s.client, leaderLock, s.lockTTL)
+		switch {
+		case err == nil:
+			s.log.Info("Acquired scheduler leadership")
+		case !errors.Is(err, lock.ErrNotAcquired):
+			s.log.Warn("Failed to acquire scheduler leadership", "error", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.leader = nil
+		return false
+	}
+	s.leader = held
+	s.renewAt = now.Add(s.lockTTL / 3)
+	return true
+}
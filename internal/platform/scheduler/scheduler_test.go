@@ -0,0 +1,269 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/lock"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+// fakeClock is a time source the test moves by hand
+type fakeClock struct {
+	now atomic.Pointer[time.Time]
+}
+
+func newFakeClock(t time.Time) *fakeClock {
+	c := &fakeClock{}
+	c.set(t)
+	return c
+}
+
+func (c *fakeClock) Now() time.Time  { return *c.now.Load() }
+func (c *fakeClock) set(t time.Time) { c.now.Store(&t) }
+
+// newTestScheduler returns a scheduler on a fake clock starting at 12:00:30
+func newTestScheduler(t *testing.T) (*Scheduler, *fakeClock, *miniredis.Miniredis) {
+	t.Helper()
+	client, server := redistest.New(t)
+	s := New(configs.SchedulerConfig{Enabled: true, LockTTL: 30 * time.Second}, client, logtest.Discard(t))
+	clock := newFakeClock(time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC))
+	s.now = clock.Now
+	return s, clock, server
+}
+
+// counting returns a job counting its runs
+func counting() (func(context.Context) error, *atomic.Int32) {
+	var runs atomic.Int32
+	return func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}, &runs
+}
+
+// tickAt runs one scheduling pass at t and waits for the jobs it started
+func tickAt(s *Scheduler, clock *fakeClock, t time.Time) {
+	clock.set(t)
+	ctx := context.Background()
+	s.tick(ctx, ctx)
+	s.runs.Wait()
+}
+
+func TestJobRunsOnSchedule(t *testing.T) {
+	s, clock, _ := newTestScheduler(t)
+	fn, runs := counting()
+	if err := s.Register("warm-cache", "*/5 * * * *", fn); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, step := range []struct {
+		at   time.Duration
+		want int32
+	}{
+		{12*time.Hour + time.Minute, 0},
+		{12*time.Hour + 5*time.Minute, 1},
+		{12*time.Hour + 6*time.Minute, 1},
+		{12*time.Hour + 10*time.Minute, 2},
+		// A late tick runs the job once, not once per missed slot
+		{12*time.Hour + 31*time.Minute, 3},
+	} {
+		tickAt(s, clock, day.Add(step.at))
+		if got := runs.Load(); got != step.want {
+			t.Fatalf("after tick at %s: %d runs, want %d", day.Add(step.at).Format("15:04"), got, step.want)
+		}
+	}
+}
+
+func TestJobSkippedWithoutLeadership(t *testing.T) {
+	s, clock, server := newTestScheduler(t)
+	log, logs := logtest.New(t)
+	s.log = log
+	fn, runs := counting()
+	if err := s.Register("cleanup", "@every 1m", fn); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another instance leads
+	other, err := lock.Acquire(context.Background(), redistest.Connect(t, server), leaderLock, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tickAt(s, clock, clock.Now().Add(time.Minute))
+	if runs.Load() != 0 {
+		t.Fatal("job ran without holding the leader lock")
+	}
+	if _, ok := logs.Find("Skipping job, not the leader"); !ok {
+		t.Error("the skipped run was not logged")
+	}
+
+	if err := other.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tickAt(s, clock, clock.Now().Add(time.Minute))
+	if runs.Load() != 1 {
+		t.Errorf("runs = %d, want 1 once leadership is free", runs.Load())
+	}
+}
+
+func TestLeadershipIsRenewedAndLost(t *testing.T) {
+	s, clock, server := newTestScheduler(t)
+	ctx := context.Background()
+
+	if !s.ensureLeadership(ctx, clock.Now()) {
+		t.Fatal("did not acquire free leadership")
+	}
+
+	// Renewal at a third of the TTL keeps the lock alive
+	server.FastForward(15 * time.Second)
+	if !s.ensureLeadership(ctx, clock.Now().Add(15*time.Second)) {
+		t.Fatal("lost leadership on renewal")
+	}
+	if ttl := server.TTL("lock:" + leaderLock); ttl != 30*time.Second {
+		t.Errorf("leader lock TTL = %s, want it refreshed to 30s", ttl)
+	}
+
+	// A missed renewal lets the lock lapse and another instance take it
+	server.FastForward(time.Minute)
+	if _, err := lock.Acquire(ctx, redistest.Connect(t, server), leaderLock, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if s.ensureLeadership(ctx, clock.Now().Add(2*time.Minute)) {
+		t.Error("kept leadership after the lock was taken over")
+	}
+}
+
+func TestOverlappingRunIsSkipped(t *testing.T) {
+	s, clock, _ := newTestScheduler(t)
+	release := make(chan struct{})
+	var runs atomic.Int32
+	if err := s.Register("slow", "@every 1m", func(context.Context) error {
+		runs.Add(1)
+		<-release
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	clock.set(clock.Now().Add(time.Minute))
+	s.tick(ctx, ctx)
+	clock.set(clock.Now().Add(time.Minute))
+	s.tick(ctx, ctx)
+	close(release)
+	s.runs.Wait()
+
+	if runs.Load() != 1 {
+		t.Errorf("runs = %d, want the overlapping run skipped", runs.Load())
+	}
+}
+
+func TestRegisterRejectsInvalidJobs(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	fn, _ := counting()
+	if err := s.Register("bad", "every minute", fn); err == nil {
+		t.Error("Register accepted an invalid schedule")
+	}
+	if err := s.Register("twice", "@hourly", fn); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("twice", "@daily", fn); err == nil {
+		t.Error("Register accepted a duplicate name")
+	}
+}
+
+func TestRegisterOnDefault(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+	fn, runs := counting()
+
+	SetDefault(nil)
+	if err := Register("report", "@hourly", fn); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("Register() without a default = %v, want ErrNotConfigured", err)
+	}
+
+	s, clock, _ := newTestScheduler(t)
+	SetDefault(s)
+	if err := Register("report", "@hourly", fn); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register("report", "@daily", fn); err == nil {
+		t.Error("Register accepted a duplicate name on the default scheduler")
+	}
+
+	tickAt(s, clock, time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC))
+	if runs.Load() != 1 {
+		t.Errorf("runs = %d, want the job registered through the package to run", runs.Load())
+	}
+}
+
+func TestStopDrainsThenCancelsJobs(t *testing.T) {
+	s, clock, server := newTestScheduler(t)
+	s.interval = time.Millisecond
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	if err := s.Register("stuck", "@every 1m", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	clock.set(clock.Now().Add(time.Minute))
+
+	s.Start()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not start")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() = %v, want the deadline reported", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not cancelled after the stop deadline")
+	}
+	if server.Exists("lock:" + leaderLock) {
+		t.Error("leader lock was not released")
+	}
+}
+
+func TestStopWaitsForRunningJobs(t *testing.T) {
+	s, clock, _ := newTestScheduler(t)
+	s.interval = time.Millisecond
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	if err := s.Register("flush", "@every 1m", func(ctx context.Context) error {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		finished.Store(ctx.Err() == nil)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	clock.set(clock.Now().Add(time.Minute))
+
+	s.Start()
+	<-started
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !finished.Load() {
+		t.Error("Stop cancelled a job that would have finished in time")
+	}
+}
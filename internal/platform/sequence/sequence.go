@@ -0,0 +1,82 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+// ErrNotConfigured is returned by Next before SetDefault is called
+var ErrNotConfigured = errors.New("sequence: no generator configured")
+
+const keyPrefix = "sequence:"
+
+// retention keeps a day's counter past midnight so late writers in another time zone, or a
+// clock slightly behind, still continue that day's sequence instead of restarting it
+const retention = 48 * time.Hour
+
+// incrScript increments the counter and sets its expiry when the increment created it, in one
+// round trip so a counter can never be left without a TTL
+var incrScript = goredis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// Generator hands out per-key sequence numbers that restart at 1 every day. Numbers come from a
+// Redis INCR, so they are unique and increasing across every instance sharing the Redis server.
+//
+// Counters live only in Redis: if Redis restarts without persistence (RDB or AOF) the day's
+// counters restart at 1 and numbers handed out earlier that day are issued again. Keep
+// persistence on for the Redis used here, and back reference numbers with a unique
+// constraint so a reissued number fails loudly instead of duplicating a reference.
+type Generator struct {
+	client   *redis.Client
+	location *time.Location
+	now      func() time.Time
+}
+
+// New creates a generator whose days start at midnight in loc
+func New(client *redis.Client, loc *time.Location) *Generator {
+	return &Generator{client: client, location: loc, now: time.Now}
+}
+
+// Next returns the next number of today's sequence for key, starting at 1. Keys are
+// independent, so include the tenant in key for per-tenant numbering, e.g. "visit:acme".
+func (g *Generator) Next(ctx context.Context, key string) (int64, error) {
+	day := g.now().In(g.location).Format("2006-01-02")
+	result, err := g.client.RunScript(ctx, incrScript, []string{keyPrefix + key + ":" + day}, retention.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment sequence %s: %w", key, err)
+	}
+
+	n, ok := result.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sequence reply %T", result)
+	}
+	return n, nil
+}
+
+var defaultGenerator atomic.Pointer[Generator]
+
+// SetDefault sets the generator used by the package-level Next
+func SetDefault(g *Generator) {
+	defaultGenerator.Store(g)
+}
+
+// Next returns the next number of today's sequence for key using the default generator
+func Next(ctx context.Context, key string) (int64, error) {
+	g := defaultGenerator.Load()
+	if g == nil {
+		return 0, ErrNotConfigured
+	}
+	return g.Next(ctx, key)
+}
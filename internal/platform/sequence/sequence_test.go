@@ -0,0 +1,151 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+// newTestGenerator returns a UTC generator on miniredis whose clock reads *now
+func newTestGenerator(t *testing.T, now *time.Time) (*Generator, *miniredis.Miniredis) {
+	t.Helper()
+	client, server := redistest.New(t)
+	g := New(client, time.UTC)
+	g.now = func() time.Time { return *now }
+	return g, server
+}
+
+// next returns the next number of key, failing the test on error
+func next(t *testing.T, g *Generator, key string) int64 {
+	t.Helper()
+	n, err := g.Next(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestNextIncrementsPerKey(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	g, server := newTestGenerator(t, &now)
+
+	for want := int64(1); want <= 3; want++ {
+		if n := next(t, g, "visit:acme"); n != want {
+			t.Fatalf("Next(visit:acme) = %d, want %d", n, want)
+		}
+	}
+	if n := next(t, g, "visit:globex"); n != 1 {
+		t.Errorf("Next(visit:globex) = %d, want its own sequence starting at 1", n)
+	}
+
+	// Counters expire once their day is well over
+	if ttl := server.TTL(keyPrefix + "visit:acme:2026-03-01"); ttl != retention {
+		t.Errorf("TTL = %s, want %s", ttl, retention)
+	}
+}
+
+func TestNextRollsOverDaily(t *testing.T) {
+	now := time.Date(2026, 3, 1, 21, 30, 0, 0, time.UTC)
+	g, _ := newTestGenerator(t, &now)
+
+	next(t, g, "visit:acme")
+	if n := next(t, g, "visit:acme"); n != 2 {
+		t.Fatalf("Next() = %d, want 2", n)
+	}
+
+	now = now.Add(3 * time.Hour)
+	if n := next(t, g, "visit:acme"); n != 1 {
+		t.Errorf("Next() = %d on the next day, want 1", n)
+	}
+
+	// Days start at midnight in the generator's time zone: 21:30 UTC is past midnight in Cairo
+	cairo, err := time.LoadLocation("Africa/Cairo")
+	if err != nil {
+		t.Skip(err)
+	}
+	g.location = cairo
+	now = time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC)
+	if n := next(t, g, "visit:acme"); n != 1 {
+		t.Errorf("Next() = %d at 01:30 in Cairo, want a new day starting at 1", n)
+	}
+}
+
+func TestNextIsSafeAcrossInstances(t *testing.T) {
+	client, server := redistest.New(t)
+	other := redistest.Connect(t, server)
+	generators := []*Generator{New(client, time.UTC), New(other, time.UTC)}
+
+	const perInstance = 50
+	var (
+		mu   sync.Mutex
+		seen = make(map[int64]bool)
+		wg   sync.WaitGroup
+	)
+	for _, g := range generators {
+		for range perInstance {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				n, err := g.Next(context.Background(), "visit:acme")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				seen[n] = true
+				mu.Unlock()
+			}()
+		}
+	}
+	wg.Wait()
+
+	// Every number from 1 to the total was handed out exactly once
+	for n := int64(1); n <= 2*perInstance; n++ {
+		if !seen[n] {
+			t.Errorf("%d was not handed out", n)
+		}
+	}
+	if len(seen) != 2*perInstance {
+		t.Errorf("handed out %d distinct numbers, want %d", len(seen), 2*perInstance)
+	}
+}
+
+func TestNextAfterRedisDataLoss(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	g, server := newTestGenerator(t, &now)
+
+	next(t, g, "visit:acme")
+	next(t, g, "visit:acme")
+
+	// Without persistence the day's sequence restarts, as documented on Generator
+	server.FlushAll()
+	if n := next(t, g, "visit:acme"); n != 1 {
+		t.Errorf("Next() = %d after Redis lost its data, want 1", n)
+	}
+
+	server.Close()
+	if _, err := g.Next(context.Background(), "visit:acme"); err == nil {
+		t.Error("Next() succeeded with Redis down")
+	}
+}
+
+func TestPackageNext(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+
+	SetDefault(nil)
+	if _, err := Next(context.Background(), "visit:acme"); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("Next() = %v without a generator, want ErrNotConfigured", err)
+	}
+
+	client, _ := redistest.New(t)
+	SetDefault(New(client, time.UTC))
+	if n, err := Next(context.Background(), "visit:acme"); err != nil || n != 1 {
+		t.Errorf("Next() = %d, %v, want 1", n, err)
+	}
+}
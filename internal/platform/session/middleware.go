@@ -0,0 +1,133 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+)
+
+type contextKey struct{}
+
+// Middleware loads the session named by the request cookie, or starts a new one, and stores it
+// in the request context. Modified sessions are saved and the cookie is (re)written just before
+// the response headers are sent; new sessions that were never modified set no cookie.
+func (m *Manager) Middleware(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			s, err := m.load(ctx, r)
+			if err != nil {
+				log.Warn("Failed to load session, starting a new one", "error", err)
+				s, err = m.New(ctx)
+			}
+			if err != nil {
+				log.Error("Failed to create session", "error", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			sw := &sessionWriter{ResponseWriter: w, ctx: ctx, manager: m, session: s, log: log}
+			next.ServeHTTP(sw, r.WithContext(NewContext(ctx, s)))
+			sw.finish()
+		})
+	}
+}
+
+// load returns the session named by the request cookie, or a new session when there is none
+func (m *Manager) load(ctx context.Context, r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(m.name)
+	if err != nil || cookie.Value == "" {
+		return m.New(ctx)
+	}
+
+	s, err := m.Get(ctx, cookie.Value)
+	if errors.Is(err, ErrNotFound) {
+		return m.New(ctx)
+	}
+	return s, err
+}
+
+// NewContext returns a copy of ctx carrying the session
+func NewContext(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the session stored by the middleware, if any
+func FromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(contextKey{}).(*Session)
+	return s, ok
+}
+
+// sessionWriter saves the session and sets its cookie before the first byte of the response
+type sessionWriter struct {
+	http.ResponseWriter
+	ctx       context.Context
+	manager   *Manager
+	session   *Session
+	log       *logger.Logger
+	committed bool
+	cookieSet bool
+}
+
+func (sw *sessionWriter) WriteHeader(status int) {
+	sw.commit()
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *sessionWriter) Write(p []byte) (int, error) {
+	sw.commit()
+	return sw.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (sw *sessionWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// commit persists a modified session and writes the cookie, once
+func (sw *sessionWriter) commit() {
+	if sw.committed {
+		return
+	}
+	sw.committed = true
+
+	s, m := sw.session, sw.manager
+	switch {
+	case s.destroyed:
+		http.SetCookie(sw.ResponseWriter, m.cookie("", -1))
+		return
+	case s.dirty:
+		if err := m.Save(sw.ctx, s); err != nil {
+			sw.log.Error("Failed to save session", "error", err)
+			return
+		}
+	case s.isNew:
+		return
+	}
+
+	http.SetCookie(sw.ResponseWriter, m.cookie(s.ID, int(m.ttl.Seconds())))
+	sw.cookieSet = true
+}
+
+// finish commits if the handler wrote nothing and saves changes made after the headers were sent
+func (sw *sessionWriter) finish() {
+	if !sw.committed {
+		sw.commit()
+		return
+	}
+
+	s := sw.session
+	if !s.dirty || s.destroyed {
+		return
+	}
+	if !sw.cookieSet {
+		sw.log.Warn("Session modified after the response was written; changes discarded")
+		return
+	}
+	if err := sw.manager.Save(sw.ctx, s); err != nil {
+		sw.log.Error("Failed to save session", "error", err)
+	}
+}
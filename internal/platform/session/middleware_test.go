@@ -0,0 +1,112 @@
+package session
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// sessionCookie returns the session cookie set by rec, if any
+func sessionCookie(rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "sid" {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestMiddlewareSavesModifiedSessions(t *testing.T) {
+	m, _ := newTestManager(t)
+	var seen string
+	handler := m.Middleware(logtest.Discard(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, ok := FromContext(r.Context())
+		if !ok {
+			t.Fatal("no session in the request context")
+		}
+		if r.URL.Path == "/login" {
+			s.Set("user_id", "user-1")
+		}
+		seen, _ = s.Get("user_id")
+		io.WriteString(w, "ok")
+	}))
+
+	// A new session that is never modified sets no cookie
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if sessionCookie(rec) != nil {
+		t.Fatal("an unmodified new session set a cookie")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+	cookie := sessionCookie(rec)
+	if cookie == nil || cookie.Value == "" || !cookie.HttpOnly || cookie.MaxAge != 1800 {
+		t.Fatalf("cookie = %+v, want an HttpOnly session cookie lasting the idle TTL", cookie)
+	}
+
+	// The cookie loads the stored session and is refreshed with the sliding TTL
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if seen != "user-1" {
+		t.Errorf("user_id = %q, want the session loaded from the cookie", seen)
+	}
+	if refreshed := sessionCookie(rec); refreshed == nil || refreshed.Value != cookie.Value {
+		t.Errorf("cookie = %+v, want the same session refreshed", refreshed)
+	}
+}
+
+func TestMiddlewareStartsOverForUnknownCookie(t *testing.T) {
+	m, _ := newTestManager(t)
+	var isNew bool
+	handler := m.Middleware(logtest.Discard(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, _ := FromContext(r.Context())
+		isNew = s.IsNew()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "expired"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if !isNew {
+		t.Error("an unknown session cookie did not start a new session")
+	}
+}
+
+func TestMiddlewareExpiresDestroyedSessionCookie(t *testing.T) {
+	m, _ := newTestManager(t)
+	handler := m.Middleware(logtest.Discard(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, _ := FromContext(r.Context())
+		if err := m.Destroy(r.Context(), s); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logout", nil))
+	if cookie := sessionCookie(rec); cookie == nil || cookie.MaxAge >= 0 {
+		t.Errorf("cookie = %+v, want it expired", cookie)
+	}
+}
+
+func TestMiddlewareDiscardsChangesAfterNewSessionResponse(t *testing.T) {
+	m, server := newTestManager(t)
+	log, logs := logtest.New(t)
+	handler := m.Middleware(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+		s, _ := FromContext(r.Context())
+		s.Set("user_id", "user-1")
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(server.Keys()) != 0 {
+		t.Errorf("keys = %v, want nothing saved without a cookie to find it", server.Keys())
+	}
+	if _, ok := logs.Find("Session modified after the response was written; changes discarded"); !ok {
+		t.Error("the discarded change was not logged")
+	}
+}
@@ -0,0 +1,185 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+// ErrNotFound is returned when a session does not exist or has expired
+var ErrNotFound = errors.New("session not found")
+
+const keyPrefix = "session:"
+
+// Session is a server-side session. Values are only persisted by Save.
+type Session struct {
+	ID        string
+	CreatedAt time.Time
+
+	values    map[string]string
+	isNew     bool
+	dirty     bool
+	destroyed bool
+}
+
+// record is the stored form of a session
+type record struct {
+	Values    map[string]string `json:"values"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Get returns the value stored under key
+func (s *Session) Get(key string) (string, bool) {
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Set stores value under key
+func (s *Session) Set(key, value string) {
+	s.values[key] = value
+	s.dirty = true
+}
+
+// Delete removes key from the session
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.dirty = true
+	}
+}
+
+// IsNew reports whether the session was created during this request
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// Manager creates, loads and stores sessions in Redis with a sliding idle TTL
+type Manager struct {
+	client   *redis.Client
+	ttl      time.Duration
+	name     string
+	secure   bool
+	sameSite http.SameSite
+}
+
+// NewManager creates a session manager; cfg is expected to have passed configs validation
+func NewManager(cfg configs.SessionConfig, client *redis.Client) (*Manager, error) {
+	sameSite, err := cfg.ParseSameSite()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		client:   client,
+		ttl:      cfg.IdleTTL,
+		name:     cfg.CookieName,
+		secure:   cfg.Secure,
+		sameSite: sameSite,
+	}, nil
+}
+
+// New returns a fresh, unsaved session with a random ID
+func (m *Manager) New(ctx context.Context) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		ID:        id,
+		CreatedAt: time.Now().UTC(),
+		values:    make(map[string]string),
+		isNew:     true,
+	}, nil
+}
+
+// Get loads a session and extends its idle TTL, or returns ErrNotFound
+func (m *Manager) Get(ctx context.Context, id string) (*Session, error) {
+	data, err := m.client.GetEx(ctx, keyPrefix+id, m.ttl)
+	if errors.Is(err, redis.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	var rec record
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	if rec.Values == nil {
+		rec.Values = make(map[string]string)
+	}
+
+	return &Session{ID: id, CreatedAt: rec.CreatedAt, values: rec.Values}, nil
+}
+
+// Save stores the session and resets its idle TTL
+func (m *Manager) Save(ctx context.Context, s *Session) error {
+	data, err := json.Marshal(record{Values: s.values, CreatedAt: s.CreatedAt})
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	if err := m.client.Set(ctx, keyPrefix+s.ID, data, m.ttl); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// Destroy deletes the session; the middleware also expires its cookie
+func (m *Manager) Destroy(ctx context.Context, s *Session) error {
+	if _, err := m.client.Del(ctx, keyPrefix+s.ID); err != nil {
+		return fmt.Errorf("failed to destroy session: %w", err)
+	}
+	s.destroyed = true
+	return nil
+}
+
+// Renew moves the session to a new ID, keeping its values. Call it on login to prevent session fixation.
+func (m *Manager) Renew(ctx context.Context, s *Session) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	if !s.isNew {
+		if _, err := m.client.Del(ctx, keyPrefix+s.ID); err != nil {
+			return fmt.Errorf("failed to renew session: %w", err)
+		}
+	}
+	s.ID = id
+	s.dirty = true
+	return nil
+}
+
+// cookie builds the session cookie; a negative maxAge expires it
+func (m *Manager) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   m.secure,
+		SameSite: m.sameSite,
+	}
+}
+
+// newID returns a random URL-safe session ID
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
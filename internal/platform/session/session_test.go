@@ -0,0 +1,130 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+// newTestManager returns a manager with a 30 minute idle TTL backed by miniredis
+func newTestManager(t *testing.T) (*Manager, *miniredis.Miniredis) {
+	t.Helper()
+	client, server := redistest.New(t)
+	m, err := NewManager(configs.SessionConfig{CookieName: "sid", IdleTTL: 30 * time.Minute}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, server
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+
+	s, err := m.New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsNew() || s.ID == "" {
+		t.Fatalf("New() = %+v, want a new session with an ID", s)
+	}
+	if _, err := m.Get(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() before Save = %v, want ErrNotFound", err)
+	}
+
+	s.Set("user_id", "user-1")
+	if err := m.Save(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := m.Get(ctx, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := loaded.Get("user_id"); !ok || v != "user-1" {
+		t.Errorf("user_id = %q, %v, want user-1", v, ok)
+	}
+	if loaded.IsNew() || !loaded.CreatedAt.Equal(s.CreatedAt) {
+		t.Errorf("loaded = %+v, want a stored session created at %s", loaded, s.CreatedAt)
+	}
+}
+
+func TestSessionExpiresWhenIdle(t *testing.T) {
+	m, server := newTestManager(t)
+	ctx := context.Background()
+
+	s, _ := m.New(ctx)
+	s.Set("user_id", "user-1")
+	if err := m.Save(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+
+	server.FastForward(31 * time.Minute)
+	if _, err := m.Get(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after the idle TTL = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSessionTTLSlidesOnAccess(t *testing.T) {
+	m, server := newTestManager(t)
+	ctx := context.Background()
+
+	s, _ := m.New(ctx)
+	if err := m.Save(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each access within the idle TTL pushes expiry back, past the original deadline
+	for i := 0; i < 3; i++ {
+		server.FastForward(20 * time.Minute)
+		if _, err := m.Get(ctx, s.ID); err != nil {
+			t.Fatalf("Get() after %d idle periods = %v", i+1, err)
+		}
+		if ttl := server.TTL(keyPrefix + s.ID); ttl != 30*time.Minute {
+			t.Fatalf("TTL after access = %s, want it reset to 30m", ttl)
+		}
+	}
+}
+
+func TestSessionDestroyAndRenew(t *testing.T) {
+	m, server := newTestManager(t)
+	ctx := context.Background()
+
+	s, _ := m.New(ctx)
+	s.Set("user_id", "user-1")
+	if err := m.Save(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+
+	// Renewal happens on login, to a session loaded from its cookie
+	s, err := m.Get(ctx, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldID := s.ID
+	if err := m.Renew(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if s.ID == oldID || server.Exists(keyPrefix+oldID) {
+		t.Fatal("Renew kept the old session ID")
+	}
+	if err := m.Save(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := s.Get("user_id"); v != "user-1" {
+		t.Errorf("user_id = %q after renewal, want it kept", v)
+	}
+
+	if err := m.Destroy(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Destroy = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,108 @@
+package tenant
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+// Resolver identifies the tenant of each request
+type Resolver struct {
+	header      string
+	trustHeader func(*http.Request) bool
+	baseDomain  string
+	registry    Registry
+	log         *logger.Logger
+}
+
+// NewResolver creates a resolver checking tenants against the configured registry.
+// The tenant header is only read from requests for which trustHeader reports true, i.e. that
+// came through a proxy which sets it; a nil trustHeader leaves only the subdomain.
+func NewResolver(cfg configs.TenancyConfig, client *redis.Client, log *logger.Logger, trustHeader func(*http.Request) bool) (*Resolver, error) {
+	var registry Registry
+	switch cfg.Registry {
+	case "static":
+		registry = newStaticRegistry(cfg.Tenants)
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("redis tenant registry requires a redis client")
+		}
+		registry = redisRegistry{client: client}
+	default:
+		return nil, fmt.Errorf("unsupported tenant registry %q", cfg.Registry)
+	}
+
+	return &Resolver{
+		header:      cfg.Header,
+		trustHeader: trustHeader,
+		baseDomain:  strings.ToLower(strings.TrimPrefix(cfg.BaseDomain, ".")),
+		registry:    registry,
+		log:         log,
+	}, nil
+}
+
+// Middleware stores the request's tenant in its context. A trusted tenant header wins over the
+// subdomain of the base domain; requests naming no tenant or an unknown one get 400.
+func (t *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := t.resolve(r)
+		if id == "" {
+			writeError(w, http.StatusBadRequest, "tenant_required", "request does not identify a tenant")
+			return
+		}
+
+		ctx := r.Context()
+		exists, err := t.registry.Exists(ctx, id)
+		if err != nil {
+			t.log.Error("Tenant lookup failed", "tenant", id, "error", err)
+			writeError(w, http.StatusServiceUnavailable, "tenant_lookup_failed", "tenant registry unavailable")
+			return
+		}
+		if !exists {
+			writeError(w, http.StatusBadRequest, "unknown_tenant", "unknown tenant")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(ctx, id)))
+	})
+}
+
+// resolve returns the tenant named by the header or subdomain, or "" if none is well-formed.
+// The header of an untrusted request is ignored, so clients cannot pick another tenant with it.
+func (t *Resolver) resolve(r *http.Request) string {
+	if t.trustHeader != nil && t.trustHeader(r) {
+		if id := strings.ToLower(strings.TrimSpace(r.Header.Get(t.header))); id != "" {
+			if ValidID(id) {
+				return id
+			}
+			return ""
+		}
+	}
+
+	if t.baseDomain == "" {
+		return ""
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+t.baseDomain)
+	if !ok || !ValidID(label) {
+		return ""
+	}
+	return label
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
@@ -0,0 +1,133 @@
+package tenant
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+// serveTenant runs req through the resolver and returns the response and the tenant the
+// handler saw
+func serveTenant(t *testing.T, resolver *Resolver, req *http.Request) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	var seen string
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, seen
+}
+
+// proxyAddr is the address of the proxy the tests trust to set the tenant header
+const proxyAddr = "10.0.0.1:443"
+
+// fromProxy trusts the tenant header of requests from proxyAddr
+func fromProxy(r *http.Request) bool {
+	return r.RemoteAddr == proxyAddr
+}
+
+// trustAll trusts the tenant header of every request
+func trustAll(*http.Request) bool {
+	return true
+}
+
+func TestMiddlewareResolvesTenant(t *testing.T) {
+	resolver, err := NewResolver(configs.TenancyConfig{
+		Header:     Header,
+		BaseDomain: ".crm.example.com",
+		Registry:   "static",
+		Tenants:    []string{"pfizer", "novartis"},
+	}, nil, logtest.Discard(t), fromProxy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		host       string
+		header     string
+		proxied    bool
+		wantStatus int
+		wantTenant string
+		wantCode   string
+	}{
+		{"subdomain", "pfizer.crm.example.com", "", false, http.StatusOK, "pfizer", ""},
+		{"subdomain with port", "Pfizer.CRM.example.com:8443", "", false, http.StatusOK, "pfizer", ""},
+		{"header", "api.internal", "novartis", true, http.StatusOK, "novartis", ""},
+		{"header wins over subdomain", "pfizer.crm.example.com", "novartis", true, http.StatusOK, "novartis", ""},
+		{"untrusted header is ignored", "pfizer.crm.example.com", "novartis", false, http.StatusOK, "pfizer", ""},
+		{"untrusted header alone", "api.internal", "novartis", false, http.StatusBadRequest, "", "tenant_required"},
+		{"unknown subdomain", "acme.crm.example.com", "", false, http.StatusBadRequest, "", "unknown_tenant"},
+		{"unknown header", "api.internal", "acme", true, http.StatusBadRequest, "", "unknown_tenant"},
+		{"malformed header", "pfizer.crm.example.com", "pfizer:admin", true, http.StatusBadRequest, "", "tenant_required"},
+		{"nested subdomain", "eu.pfizer.crm.example.com", "", false, http.StatusBadRequest, "", "tenant_required"},
+		{"other domain", "pfizer.example.org", "", false, http.StatusBadRequest, "", "tenant_required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/reps", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+			if tt.proxied {
+				req.RemoteAddr = proxyAddr
+			}
+
+			rec, seen := serveTenant(t, resolver, req)
+			if rec.Code != tt.wantStatus || seen != tt.wantTenant {
+				t.Errorf("status = %d, tenant = %q, want %d, %q", rec.Code, seen, tt.wantStatus, tt.wantTenant)
+			}
+			if tt.wantCode != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %s", rec.Body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRedisRegistry(t *testing.T) {
+	client, server := redistest.New(t)
+	log, logs := logtest.New(t)
+	resolver, err := NewResolver(configs.TenancyConfig{Header: Header, Registry: "redis"}, client, log, trustAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(id string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(Header, id)
+		return req
+	}
+
+	if rec, _ := serveTenant(t, resolver, request("pfizer")); rec.Code != http.StatusBadRequest {
+		t.Errorf("unregistered tenant = %d, want 400", rec.Code)
+	}
+
+	// Tenants onboarded in Redis are accepted without a restart
+	server.SAdd(registryKey, "pfizer")
+	if rec, seen := serveTenant(t, resolver, request("pfizer")); rec.Code != http.StatusOK || seen != "pfizer" {
+		t.Errorf("registered tenant = %d, %q", rec.Code, seen)
+	}
+
+	server.SetError("connection lost")
+	if rec, _ := serveTenant(t, resolver, request("pfizer")); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("registry failure = %d, want 503", rec.Code)
+	}
+	if _, ok := logs.Find("Tenant lookup failed"); !ok {
+		t.Error("registry failure was not logged")
+	}
+}
+
+func TestNewResolverRejectsBadRegistry(t *testing.T) {
+	if _, err := NewResolver(configs.TenancyConfig{Registry: "redis"}, nil, logtest.Discard(t), nil); err == nil {
+		t.Error("redis registry accepted without a client")
+	}
+	if _, err := NewResolver(configs.TenancyConfig{Registry: "ldap"}, nil, logtest.Discard(t), nil); err == nil {
+		t.Error("unknown registry accepted")
+	}
+}
@@ -0,0 +1,41 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+// registryKey is the Redis set holding the registered tenant IDs
+const registryKey = "tenants"
+
+// Registry reports whether a tenant is known
+type Registry interface {
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+// staticRegistry is the allowlist from config
+type staticRegistry map[string]struct{}
+
+func newStaticRegistry(ids []string) staticRegistry {
+	r := make(staticRegistry, len(ids))
+	for _, id := range ids {
+		r[id] = struct{}{}
+	}
+	return r
+}
+
+func (r staticRegistry) Exists(_ context.Context, id string) (bool, error) {
+	_, ok := r[id]
+	return ok, nil
+}
+
+// redisRegistry looks tenants up in a Redis set shared by every instance, so tenants
+// can be onboarded without a deploy
+type redisRegistry struct {
+	client *redis.Client
+}
+
+func (r redisRegistry) Exists(ctx context.Context, id string) (bool, error) {
+	return r.client.SIsMember(ctx, registryKey, id)
+}
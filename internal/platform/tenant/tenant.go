@@ -0,0 +1,39 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Header is the request header naming the tenant when it is not taken from the subdomain
+const Header = "X-Tenant-ID"
+
+type contextKey struct{}
+
+// idPattern restricts tenant IDs to a single DNS label so they are safe in hosts and keys
+var idPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidID reports whether id is a well-formed tenant ID
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// NewContext returns a copy of ctx carrying the tenant ID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant ID stored by the middleware or NewContext, or "" if none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Key scopes key to the tenant in ctx, so tenants sharing a store cannot read each other's
+// entries. Without a tenant the key is returned unchanged.
+func Key(ctx context.Context, key string) string {
+	if id := FromContext(ctx); id != "" {
+		return "tenant:" + id + ":" + key
+	}
+	return key
+}
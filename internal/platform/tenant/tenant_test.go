@@ -0,0 +1,32 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidID(t *testing.T) {
+	for _, id := range []string{"pfizer", "a", "novartis-eu", "tenant42"} {
+		if !ValidID(id) {
+			t.Errorf("ValidID(%q) = false", id)
+		}
+	}
+	for _, id := range []string{"", "-pfizer", "pfizer-", "Pfizer", "pfizer.eu", "pfizer:admin", strings.Repeat("a", 64)} {
+		if ValidID(id) {
+			t.Errorf("ValidID(%q) = true", id)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	ctx := context.Background()
+	if got := Key(ctx, "reps"); got != "reps" || FromContext(ctx) != "" {
+		t.Errorf("Key() without a tenant = %q", got)
+	}
+
+	ctx = NewContext(ctx, "pfizer")
+	if got := Key(ctx, "reps"); got != "tenant:pfizer:reps" || FromContext(ctx) != "pfizer" {
+		t.Errorf("Key() = %q, want the tenant prefix", got)
+	}
+}
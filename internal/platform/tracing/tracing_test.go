@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/database"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+)
+
+// fakeDriver is a database/sql driver whose connections accept pings and nothing else
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+func (fakeConn) Ping(context.Context) error          { return nil }
+
+func init() {
+	sql.Register("tracing-fake", fakeDriver{})
+}
+
+// recordSpans installs a tracer provider recording every span until the test ends
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+func TestMiddlewareParentsDatabaseSpans(t *testing.T) {
+	recorder := recordSpans(t)
+
+	db, err := database.New(context.Background(), configs.DatabaseConfig{Driver: "tracing-fake", MaxOpenConns: 1}, logtest.Discard(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	recorder.Reset()
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/reps/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := db.Ping(r.Context()); err != nil {
+			t.Error(err)
+		}
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reps/1", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want the server span and a database span", len(spans))
+	}
+	child, parent := spans[0], spans[1]
+
+	if parent.Name() != "GET /reps/{id}" || parent.SpanKind() != trace.SpanKindServer {
+		t.Errorf("parent span = %q (%s), want the server span named by route", parent.Name(), parent.SpanKind())
+	}
+	if child.Name() != "db.ping" || child.SpanKind() != trace.SpanKindClient {
+		t.Errorf("child span = %q (%s), want db.ping", child.Name(), child.SpanKind())
+	}
+	if child.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("database span is not a child of the server span")
+	}
+}
+
+func TestMiddlewareContinuesTraceparent(t *testing.T) {
+	recorder := recordSpans(t)
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the incoming one", got)
+	}
+	if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("parent span ID = %s, want the incoming one", got)
+	}
+	if span.Status().Code.String() != "Error" {
+		t.Errorf("status = %s, want Error for a 500", span.Status().Code)
+	}
+}
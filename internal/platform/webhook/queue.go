@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// queueKey is the sorted set of pending retries, scored by due time in milliseconds
+const queueKey = "webhook:retries"
+
+// batchSize bounds how many due retries one poll claims and delivers in sequence
+const batchSize = 10
+
+// claimScript returns up to ARGV[3] entries due at ARGV[1] and pushes their score to ARGV[2],
+// so another instance does not pick them up while they are being delivered. An instance that
+// dies mid-delivery leaves the entry to be claimed again once the lease expires.
+var claimScript = goredis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[3]))
+for _, member in ipairs(due) do
+	redis.call('ZADD', KEYS[1], tonumber(ARGV[2]), member)
+end
+return due
+`)
+
+// delivery is a queued attempt to send one event to one endpoint
+type delivery struct {
+	EventID   string          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Endpoint  string          `json:"endpoint"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempt   int             `json:"attempt"`
+}
+
+// enqueue schedules the delivery at the given time
+func (d *Dispatcher) enqueue(ctx context.Context, del delivery, at time.Time) error {
+	member, err := json.Marshal(del)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook delivery: %w", err)
+	}
+	return d.redis.ZAdd(ctx, queueKey, float64(at.UnixMilli()), string(member))
+}
+
+// Start runs the retry worker until Stop is called
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+				d.processDue()
+			}
+		}
+	}()
+}
+
+// Stop stops the retry worker and waits for the current batch, or until ctx is done
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	close(d.stop)
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// processDue claims due retries and attempts each of them
+func (d *Dispatcher) processDue() {
+	ctx, cancel := context.WithTimeout(context.Background(), d.lease)
+	defer cancel()
+
+	now := d.now()
+	res, err := d.redis.RunScript(ctx, claimScript, []string{queueKey},
+		now.UnixMilli(), now.Add(d.lease).UnixMilli(), batchSize)
+	if err != nil {
+		d.log.Warn("Failed to claim webhook retries", "error", err)
+		return
+	}
+
+	members, _ := res.([]interface{})
+	for _, m := range members {
+		member, ok := m.(string)
+		if !ok {
+			continue
+		}
+
+		var del delivery
+		if err := json.Unmarshal([]byte(member), &del); err != nil {
+			d.log.Error("Dropping malformed webhook retry", "error", err)
+			d.redis.ZRem(ctx, queueKey, member)
+			continue
+		}
+
+		// attempt re-queues retryable failures as a new entry, so the claimed one is removed unless
+		// that failed; it is then claimed again once its lease expires
+		var qerr *queueError
+		if err := d.attempt(ctx, del); errors.As(err, &qerr) {
+			d.log.Warn("Keeping webhook retry after failing to re-queue it", "event_id", del.EventID, "error", err)
+			continue
+		}
+		if err := d.redis.ZRem(ctx, queueKey, member); err != nil {
+			d.log.Warn("Failed to remove webhook retry", "event_id", del.EventID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+)
+
+// ErrNotConfigured is returned by Send before SetDefault is called, e.g. when webhooks are
+// disabled
+var ErrNotConfigured = errors.New("webhook: no dispatcher configured")
+
+// Headers set on every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderID        = "X-Webhook-ID"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Event is a notification delivered to every configured endpoint
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Dispatcher signs and POSTs events to the configured endpoints. Failed deliveries are queued
+// in Redis and retried with exponential backoff by the worker started with Start.
+type Dispatcher struct {
+	client      *http.Client
+	redis       *redis.Client
+	log         *logger.Logger
+	endpoints   []string
+	secret      []byte
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	interval    time.Duration
+	lease       time.Duration
+	now         func() time.Time
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a dispatcher; cfg is expected to have passed configs validation
+func New(cfg configs.WebhookConfig, client *redis.Client, log *logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		client:      &http.Client{Timeout: cfg.Timeout},
+		redis:       client,
+		log:         log,
+		endpoints:   cfg.Endpoints,
+		secret:      []byte(cfg.Secret),
+		maxAttempts: cfg.MaxAttempts,
+		backoff:     cfg.Backoff,
+		maxBackoff:  cfg.MaxBackoff,
+		interval:    cfg.PollInterval,
+		lease:       (batchSize + 1) * cfg.Timeout,
+		now:         time.Now,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Send delivers the event to every endpoint. Deliveries that fail with a retryable error are
+// queued for retry and do not cause an error; Send only fails when a delivery can be neither
+// completed nor queued.
+func (d *Dispatcher) Send(ctx context.Context, event Event) error {
+	if event.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		event.ID = id
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = d.now().UTC()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	var errs []error
+	for _, endpoint := range d.endpoints {
+		del := delivery{
+			EventID:   event.ID,
+			EventType: event.Type,
+			Endpoint:  endpoint,
+			Payload:   payload,
+			Attempt:   1,
+		}
+		if err := d.attempt(ctx, del); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// attempt delivers once and schedules a retry on retryable failures
+func (d *Dispatcher) attempt(ctx context.Context, del delivery) error {
+	err := d.deliver(ctx, del)
+	if err == nil {
+		return nil
+	}
+
+	var perm *permanentError
+	if errors.As(err, &perm) || del.Attempt >= d.maxAttempts {
+		d.log.Error("Webhook delivery failed",
+			"event_id", del.EventID, "endpoint", del.Endpoint, "attempt", del.Attempt, "error", err)
+		return fmt.Errorf("webhook delivery to %s failed: %w", del.Endpoint, err)
+	}
+
+	d.log.Warn("Webhook delivery failed, retrying later",
+		"event_id", del.EventID, "endpoint", del.Endpoint, "attempt", del.Attempt, "error", err)
+
+	next := del
+	next.Attempt++
+	if err := d.enqueue(ctx, next, d.now().Add(d.retryDelay(del.Attempt))); err != nil {
+		return &queueError{err: fmt.Errorf("failed to queue webhook retry for %s: %w", del.Endpoint, err)}
+	}
+	return nil
+}
+
+// deliver POSTs the signed payload; 5xx, 408, 429 and transport errors are retryable
+func (d *Dispatcher) deliver(ctx context.Context, del delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, del.Endpoint, bytes.NewReader(del.Payload))
+	if err != nil {
+		return &permanentError{err: err}
+	}
+
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, del.EventType)
+	req.Header.Set(HeaderID, del.EventID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(d.secret, timestamp, del.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	default:
+		return &permanentError{err: fmt.Errorf("endpoint returned %d", resp.StatusCode)}
+	}
+}
+
+var defaultDispatcher atomic.Pointer[Dispatcher]
+
+// SetDefault sets the dispatcher used by the package-level Send
+func SetDefault(d *Dispatcher) {
+	defaultDispatcher.Store(d)
+}
+
+// Send delivers the event with the default dispatcher; see Dispatcher.Send
+func Send(ctx context.Context, event Event) error {
+	d := defaultDispatcher.Load()
+	if d == nil {
+		return ErrNotConfigured
+	}
+	return d.Send(ctx, event)
+}
+
+// retryDelay returns the backoff after the given attempt, doubling up to maxBackoff
+func (d *Dispatcher) retryDelay(attempt int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempt && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.maxBackoff)
+}
+
+// Sign returns the signature header value for a payload: "sha256=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<payload>". Receivers should recompute it and compare in
+// constant time, and reject stale timestamps to prevent replays.
+func Sign(secret []byte, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// permanentError marks a delivery failure that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// queueError marks a retryable failure whose retry could not be queued
+type queueError struct {
+	err error
+}
+
+func (e *queueError) Error() string { return e.err.Error() }
+func (e *queueError) Unwrap() error { return e.err }
+
+// newID returns a random event ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate event ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
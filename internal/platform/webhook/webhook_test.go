@@ -0,0 +1,272 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	miniserver "github.com/alicebob/miniredis/v2/server"
+
+	"github.com/rixtrayker/medical-rep/configs"
+	"github.com/rixtrayker/medical-rep/internal/platform/logger/logtest"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis"
+	"github.com/rixtrayker/medical-rep/internal/platform/redis/redistest"
+)
+
+const testSecret = "webhook-secret"
+
+// receiver is an endpoint answering with the queued status codes, then 200
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []received
+}
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func (rv *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+	rv.requests = append(rv.requests, received{header: r.Header.Clone(), body: body})
+	status := http.StatusOK
+	if len(rv.statuses) > 0 {
+		status, rv.statuses = rv.statuses[0], rv.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (rv *receiver) received() []received {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+	return append([]received(nil), rv.requests...)
+}
+
+// newTestDispatcher returns a dispatcher posting to a receiver answering statuses, with a
+// clock the test moves
+func newTestDispatcher(t *testing.T, statuses ...int) (*Dispatcher, *receiver, *time.Time, *miniredis.Miniredis) {
+	t.Helper()
+	rv := &receiver{statuses: statuses}
+	server := httptest.NewServer(rv)
+	t.Cleanup(server.Close)
+
+	client, redisServer := redistest.New(t)
+	d, now := newDispatcher(t, server.URL, client)
+	return d, rv, now, redisServer
+}
+
+// newDispatcher returns a dispatcher for endpoint using client, with a clock the test moves
+func newDispatcher(t *testing.T, endpoint string, client *redis.Client) (*Dispatcher, *time.Time) {
+	t.Helper()
+	d := New(configs.WebhookConfig{
+		Enabled:      true,
+		Endpoints:    []string{endpoint},
+		Secret:       testSecret,
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		Backoff:      time.Minute,
+		MaxBackoff:   10 * time.Minute,
+		PollInterval: time.Hour,
+	}, client, logtest.Discard(t))
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+func TestSendSignsPayload(t *testing.T) {
+	d, rv, now, _ := newTestDispatcher(t)
+
+	event := Event{Type: "visit.logged", Data: map[string]string{"visit_id": "v-1"}}
+	if err := d.Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := rv.received()
+	if len(reqs) != 1 {
+		t.Fatalf("received %d requests, want 1", len(reqs))
+	}
+	req := reqs[0]
+
+	timestamp := req.header.Get(HeaderTimestamp)
+	if timestamp != "1704110400" || req.header.Get(HeaderEvent) != "visit.logged" || req.header.Get(HeaderID) == "" {
+		t.Errorf("headers = %v, want the event type, ID and timestamp %d", req.header, now.Unix())
+	}
+
+	// Recompute the signature the way a receiver would
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(timestamp + "." + string(req.body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.header.Get(HeaderSignature); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+
+	var sent Event
+	if err := json.Unmarshal(req.body, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.ID != req.header.Get(HeaderID) || !sent.CreatedAt.Equal(*now) {
+		t.Errorf("payload = %+v, want the header ID and the current time", sent)
+	}
+}
+
+func TestTransientFailureIsRetried(t *testing.T) {
+	d, rv, now, server := newTestDispatcher(t, http.StatusInternalServerError)
+
+	if err := d.Send(context.Background(), Event{Type: "visit.logged"}); err != nil {
+		t.Fatalf("Send() = %v, want the failure queued for retry", err)
+	}
+	if members, _ := server.ZMembers(queueKey); len(members) != 1 {
+		t.Fatalf("queued %d retries, want 1", len(members))
+	}
+
+	// Not due before the backoff elapses
+	d.processDue()
+	if len(rv.received()) != 1 {
+		t.Fatal("retry was delivered before its backoff")
+	}
+
+	*now = now.Add(time.Minute)
+	d.processDue()
+
+	reqs := rv.received()
+	if len(reqs) != 2 {
+		t.Fatalf("received %d requests, want the retry delivered", len(reqs))
+	}
+	if string(reqs[1].body) != string(reqs[0].body) || reqs[1].header.Get(HeaderID) != reqs[0].header.Get(HeaderID) {
+		t.Error("the retry did not resend the same event")
+	}
+	if members, _ := server.ZMembers(queueKey); len(members) != 0 {
+		t.Errorf("queue = %v, want it empty after the retry succeeded", members)
+	}
+}
+
+func TestRetriesSurviveRestart(t *testing.T) {
+	rv := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	endpoint := httptest.NewServer(rv)
+	t.Cleanup(endpoint.Close)
+	_, server := redistest.New(t)
+
+	first, now := newDispatcher(t, endpoint.URL, redistest.Connect(t, server))
+	if err := first.Send(context.Background(), Event{Type: "visit.logged"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process picks up the queued retry
+	second, later := newDispatcher(t, endpoint.URL, redistest.Connect(t, server))
+	*later = now.Add(time.Minute)
+	second.processDue()
+
+	if len(rv.received()) != 2 {
+		t.Errorf("received %d requests, want the retry delivered by the second dispatcher", len(rv.received()))
+	}
+}
+
+func TestPermanentFailureIsNotRetried(t *testing.T) {
+	d, _, _, server := newTestDispatcher(t, http.StatusBadRequest)
+
+	if err := d.Send(context.Background(), Event{Type: "visit.logged"}); err == nil {
+		t.Fatal("Send() succeeded on a 400")
+	}
+	if server.Exists(queueKey) {
+		t.Error("a permanent failure was queued for retry")
+	}
+}
+
+func TestRetriesStopAfterMaxAttempts(t *testing.T) {
+	d, rv, now, server := newTestDispatcher(t, 500, 500, 500, 500)
+
+	if err := d.Send(context.Background(), Event{Type: "visit.logged"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		*now = now.Add(10 * time.Minute)
+		d.processDue()
+	}
+
+	if len(rv.received()) != 3 {
+		t.Errorf("received %d requests, want max_attempts of 3", len(rv.received()))
+	}
+	if server.Exists(queueKey) {
+		t.Error("retries remain queued after the last attempt")
+	}
+}
+
+func TestFailedRequeueKeepsRetry(t *testing.T) {
+	d, rv, now, server := newTestDispatcher(t, 500, 500)
+
+	if err := d.Send(context.Background(), Event{Type: "visit.logged"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The retry fails again, and so does queueing the third attempt, while removal still works
+	server.Server().SetPreHook(func(peer *miniserver.Peer, cmd string, args ...string) bool {
+		if strings.EqualFold(cmd, "ZADD") && strings.Contains(args[len(args)-1], `"attempt":3`) {
+			peer.WriteError("ERR zadd unavailable")
+			return true
+		}
+		return false
+	})
+	*now = now.Add(time.Minute)
+	d.processDue()
+	if members, _ := server.ZMembers(queueKey); len(members) != 1 {
+		t.Fatalf("queue = %v, want the claimed retry kept", members)
+	}
+
+	// Once the lease expires the kept entry is claimed and delivered again
+	server.Server().SetPreHook(nil)
+	*now = now.Add(d.lease + time.Second)
+	d.processDue()
+	if len(rv.received()) != 3 {
+		t.Errorf("received %d requests, want the kept retry delivered", len(rv.received()))
+	}
+}
+
+func TestSendOnDefault(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+
+	SetDefault(nil)
+	if err := Send(context.Background(), Event{Type: "visit.logged"}); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("Send() without a default = %v, want ErrNotConfigured", err)
+	}
+
+	d, rv, _, _ := newTestDispatcher(t)
+	SetDefault(d)
+	if err := Send(context.Background(), Event{Type: "visit.logged"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rv.received()) != 1 {
+		t.Errorf("received %d requests, want the event sent through the default dispatcher", len(rv.received()))
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	d, _, _, _ := newTestDispatcher(t)
+	for attempt, want := range map[int]time.Duration{
+		1: time.Minute,
+		2: 2 * time.Minute,
+		3: 4 * time.Minute,
+		4: 8 * time.Minute,
+		5: 10 * time.Minute,
+		9: 10 * time.Minute,
+	} {
+		if got := d.retryDelay(attempt); got != want {
+			t.Errorf("retryDelay(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}